@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -16,7 +19,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/formatter"
-	"github.com/boshu2/agentops/cli/internal/parser"
 	"github.com/boshu2/agentops/cli/internal/storage"
 )
 
@@ -28,19 +30,29 @@ var forgeBatchCmd = &cobra.Command{
 Scans standard Claude Code transcript locations, processes each through
 the forge extraction pipeline, and deduplicates similar learnings.
 
+Transcripts are forged in parallel by --jobs workers, while a single writer
+records sessions, the search index, provenance and the forged index. The
+combined size of transcripts in flight is kept under --max-memory. Ctrl-C
+stops starting new transcripts, checkpoints the ones in progress and exits
+with the forged index covering exactly the transcripts written; the next run
+resumes the rest. With -o json each file's status and timing is reported.
+
 Examples:
   ao forge batch                    # Process all pending transcripts
   ao forge batch --dry-run          # List what would be processed
   ao forge batch --dir ~/.claude/projects/my-project
   ao forge batch --max 10           # Process up to 10 transcripts
-  ao forge batch --extract          # Trigger extraction after forging`,
+  ao forge batch --extract          # Trigger extraction after forging
+  ao forge batch --jobs 8 -o json   # 8 workers, per-file timing`,
 	RunE: runForgeBatch,
 }
 
 var (
-	batchDir     string
-	batchExtract bool
-	batchMax     int
+	batchDir         string
+	batchExtract     bool
+	batchMax         int
+	batchJobs        int
+	batchMaxMemoryMB int
 )
 
 func init() {
@@ -48,6 +60,8 @@ func init() {
 	forgeBatchCmd.Flags().StringVar(&batchDir, "dir", "", "Specific directory to scan (default: all Claude project dirs)")
 	forgeBatchCmd.Flags().BoolVar(&batchExtract, "extract", false, "Trigger extraction after forging")
 	forgeBatchCmd.Flags().IntVar(&batchMax, "max", 0, "Maximum transcripts to process (0 = all)")
+	forgeBatchCmd.Flags().IntVar(&batchJobs, "jobs", 0, "Transcripts to forge in parallel (0 = one per CPU)")
+	forgeBatchCmd.Flags().IntVar(&batchMaxMemoryMB, "max-memory", 2048, "Memory ceiling in MiB: bounds transcript bytes in flight and sets the GC limit (0 = none)")
 }
 
 func runForgeBatch(cmd *cobra.Command, args []string) error {
@@ -73,11 +87,13 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("load forged index: %w", err)
 	}
 
-	// Filter out already-forged transcripts
+	// Filter out already-forged transcripts, unless they have grown past
+	// their forge checkpoint
+	checkpointDir := forgeCheckpointDir(cwd)
 	var unforgedTranscripts []transcriptCandidate
 	var skippedCount int
 	for _, t := range transcripts {
-		if forgedSet[t.path] {
+		if forgedSet[t.path] && !forgeCheckpointBehind(checkpointDir, t.path, t.size) {
 			skippedCount++
 			VerbosePrintf("Skipping already-forged: %s\n", t.path)
 		} else {
@@ -98,7 +114,12 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("Found %d transcript(s) to process (skipped %d already forged).\n", len(unforgedTranscripts), skippedCount)
+	// Progress goes to stderr when stdout carries JSON
+	progressOut := io.Writer(os.Stdout)
+	if GetOutput() == "json" {
+		progressOut = os.Stderr
+	}
+	fmt.Fprintf(progressOut, "Found %d transcript(s) to process (skipped %d already forged).\n", len(unforgedTranscripts), skippedCount)
 
 	// Initialize storage
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
@@ -114,93 +135,58 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("initialize storage: %w", err)
 	}
 
-	p := parser.NewParser()
-	p.MaxContentLength = 0
-	extractor := parser.NewExtractor()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	started := time.Now()
+	run := forgeBatchParallel(ctx, fs, unforgedTranscripts, batchForgeConfig{
+		Jobs:            batchJobs,
+		MaxMemory:       int64(batchMaxMemoryMB) << 20,
+		CheckpointDir:   checkpointDir,
+		ForgedIndexPath: forgedIndexPath,
+		ForgedSet:       forgedSet,
+	}, progressOut)
+	interrupted := ctx.Err() != nil
 
 	var (
-		totalProcessed  int
-		totalFailed     int
-		totalDecisions  int
-		totalKnowledge  int
+		totalProcessed   int
+		totalFailed      int
+		totalUnchanged   int
+		totalInterrupted int
+		totalDecisions   int
+		totalKnowledge   int
 		totalDupsRemoved int
-		allKnowledge    []string
-		allDecisions    []string
-		processedPaths  []string
+		processedPaths   []string
 	)
-
-	for i, t := range unforgedTranscripts {
-		fmt.Printf("[%d/%d] Processing %s...\n", i+1, len(unforgedTranscripts), filepath.Base(t.path))
-
-		session, err := processTranscript(t.path, p, extractor, false, os.Stdout)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: skipping %s: %v\n", t.path, err)
+	for _, f := range run.Files {
+		switch f.Status {
+		case batchStatusForged:
+			totalProcessed++
+			totalDecisions += f.Decisions
+			totalKnowledge += f.Learnings
+			processedPaths = append(processedPaths, f.Path)
+		case batchStatusUnchanged:
+			totalUnchanged++
+		case batchStatusInterrupted:
+			totalInterrupted++
+		default:
 			totalFailed++
-			continue
-		}
-
-		// Write session
-		sessionPath, err := fs.WriteSession(session)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to write session for %s: %v\n", t.path, err)
-			continue
-		}
-
-		// Write index entry
-		indexEntry := &storage.IndexEntry{
-			SessionID:   session.ID,
-			Date:        session.Date,
-			SessionPath: sessionPath,
-			Summary:     session.Summary,
 		}
-		if err := fs.WriteIndex(indexEntry); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to index session: %v\n", err)
-		}
-
-		// Write provenance
-		provRecord := &storage.ProvenanceRecord{
-			ID:           fmt.Sprintf("prov-%s", session.ID[:7]),
-			ArtifactPath: sessionPath,
-			ArtifactType: "session",
-			SourcePath:   t.path,
-			SourceType:   "transcript",
-			SessionID:    session.ID,
-			CreatedAt:    time.Now(),
-		}
-		if err := fs.WriteProvenance(provRecord); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to write provenance: %v\n", err)
-		}
-
-		totalProcessed++
-		totalDecisions += len(session.Decisions)
-		totalKnowledge += len(session.Knowledge)
-		allKnowledge = append(allKnowledge, session.Knowledge...)
-		allDecisions = append(allDecisions, session.Decisions...)
-		processedPaths = append(processedPaths, t.path)
-
-		// Record in forged index
-		forgedRecord := ForgedRecord{
-			Path:     t.path,
-			ForgedAt: time.Now(),
-			Session:  session.ID,
-		}
-		if err := appendForgedRecord(forgedIndexPath, forgedRecord); err != nil {
-			VerbosePrintf("  Warning: failed to record forged transcript: %v\n", err)
-		}
-
-		VerbosePrintf("  -> %d decisions, %d learnings\n", len(session.Decisions), len(session.Knowledge))
 	}
-
 	// Deduplicate across all sessions
-	dedupedKnowledge := dedupSimilar(allKnowledge)
-	dedupedDecisions := dedupSimilar(allDecisions)
-	knowledgeDups := len(allKnowledge) - len(dedupedKnowledge)
-	decisionDups := len(allDecisions) - len(dedupedDecisions)
+	dedupedKnowledge := dedupSimilar(run.Knowledge)
+	dedupedDecisions := dedupSimilar(run.Decisions)
+	knowledgeDups := len(run.Knowledge) - len(dedupedKnowledge)
+	decisionDups := len(run.Decisions) - len(dedupedDecisions)
 	totalDupsRemoved = knowledgeDups + decisionDups
 
+	if interrupted {
+		fmt.Fprintf(progressOut, "\nInterrupted: %d transcript(s) left for the next run.\n", totalInterrupted)
+	}
+
 	// Run extraction if --extract flag is set
 	totalExtracted := 0
-	if batchExtract && totalProcessed > 0 {
+	if batchExtract && totalProcessed > 0 && !interrupted {
 		fmt.Printf("\nTriggering extraction for %d session(s)...\n", totalProcessed)
 		extractedCount, extractErr := triggerExtraction(cwd)
 		if extractErr != nil {
@@ -213,11 +199,16 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 	// Output results
 	if GetOutput() == "json" {
 		result := BatchForgeResult{
-			Forged:    totalProcessed,
-			Skipped:   skippedCount,
-			Failed:    totalFailed,
-			Extracted: totalExtracted,
-			Paths:     processedPaths,
+			Forged:      totalProcessed,
+			Skipped:     skippedCount,
+			Unchanged:   totalUnchanged,
+			Failed:      totalFailed,
+			Interrupted: totalInterrupted,
+			Extracted:   totalExtracted,
+			Jobs:        run.Jobs,
+			DurationMs:  time.Since(started).Milliseconds(),
+			Paths:       processedPaths,
+			Files:       run.Files,
 		}
 		data, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Println(string(data))
@@ -225,7 +216,13 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\n--- Batch Forge Summary ---\n")
 		fmt.Printf("Transcripts processed: %d\n", totalProcessed)
 		fmt.Printf("Skipped (already):     %d\n", skippedCount)
+		if totalUnchanged > 0 {
+			fmt.Printf("Unchanged:             %d\n", totalUnchanged)
+		}
 		fmt.Printf("Failed:                %d\n", totalFailed)
+		if totalInterrupted > 0 {
+			fmt.Printf("Interrupted:           %d\n", totalInterrupted)
+		}
 		fmt.Printf("Decisions extracted:   %d\n", totalDecisions)
 		fmt.Printf("Learnings extracted:   %d\n", totalKnowledge)
 		fmt.Printf("Duplicates removed:    %d\n", totalDupsRemoved)
@@ -234,6 +231,8 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		if totalExtracted > 0 {
 			fmt.Printf("Extractions processed: %d\n", totalExtracted)
 		}
+		fmt.Printf("Workers:               %d\n", run.Jobs)
+		fmt.Printf("Elapsed:               %s\n", time.Since(started).Round(time.Millisecond))
 		fmt.Printf("Output:                %s\n", baseDir)
 	}
 
@@ -256,11 +255,16 @@ type ForgedRecord struct {
 
 // BatchForgeResult holds results from batch forge operation.
 type BatchForgeResult struct {
-	Forged    int      `json:"forged"`
-	Skipped   int      `json:"skipped"`
-	Failed    int      `json:"failed"`
-	Extracted int      `json:"extracted,omitempty"`
-	Paths     []string `json:"paths"`
+	Forged      int              `json:"forged"`
+	Skipped     int              `json:"skipped"`
+	Unchanged   int              `json:"unchanged,omitempty"`
+	Failed      int              `json:"failed"`
+	Interrupted int              `json:"interrupted,omitempty"`
+	Extracted   int              `json:"extracted,omitempty"`
+	Jobs        int              `json:"jobs,omitempty"`
+	DurationMs  int64            `json:"duration_ms,omitempty"`
+	Paths       []string         `json:"paths"`
+	Files       []BatchForgeFile `json:"files,omitempty"`
 }
 
 // findPendingTranscripts discovers JSONL transcript files in Claude project directories.
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/worker"
+)
+
+// Per-file outcomes of a batch forge.
+const (
+	batchStatusForged      = "forged"
+	batchStatusUnchanged   = "unchanged"
+	batchStatusFailed      = "failed"
+	batchStatusInterrupted = "interrupted"
+)
+
+// BatchForgeFile reports how one transcript fared in a batch forge.
+type BatchForgeFile struct {
+	Path       string `json:"path"`
+	Status     string `json:"status"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"duration_ms"`
+	Decisions  int    `json:"decisions,omitempty"`
+	Learnings  int    `json:"learnings,omitempty"`
+	Session    string `json:"session,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// batchForgeConfig configures forgeBatchParallel.
+type batchForgeConfig struct {
+	// Jobs is the number of workers; <= 0 means one per CPU.
+	Jobs int
+
+	// MaxMemory bounds the bytes of transcript in flight and is applied as
+	// the runtime's soft memory limit; <= 0 means no ceiling.
+	MaxMemory int64
+
+	CheckpointDir   string
+	ForgedIndexPath string
+
+	// ForgedSet holds paths already in the forged index; they are not
+	// recorded again when resumed.
+	ForgedSet map[string]bool
+}
+
+// batchForgeRun is the outcome of forgeBatchParallel. Files is in input
+// order.
+type batchForgeRun struct {
+	Jobs      int
+	Files     []BatchForgeFile
+	Knowledge []string
+	Decisions []string
+}
+
+// forgedTranscript is what a worker hands to the writer.
+type forgedTranscript struct {
+	session  *storage.Session
+	progress *forgeProgress
+	duration time.Duration
+}
+
+// forgeBatchParallel forges transcripts on a worker pool and streams the
+// results to a single writer (this goroutine), which alone touches
+// storage and the forged index. A transcript is added to the forged index
+// only after its session, index entry and provenance are written, so
+// cancelling ctx leaves the index consistent: in-flight transcripts stop
+// at a checkpoint and, like those never started, are reported as
+// interrupted and picked up by the next run.
+func forgeBatchParallel(ctx context.Context, fs *storage.FileStorage, transcripts []transcriptCandidate, cfg batchForgeConfig, w io.Writer) *batchForgeRun {
+	jobs := cfg.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	run := &batchForgeRun{Jobs: jobs, Files: make([]BatchForgeFile, len(transcripts))}
+	if len(transcripts) == 0 {
+		return run
+	}
+
+	if cfg.MaxMemory > 0 {
+		prev := debug.SetMemoryLimit(cfg.MaxMemory)
+		defer debug.SetMemoryLimit(prev)
+	}
+	budget := worker.NewBudget(cfg.MaxMemory)
+
+	paths := make([]string, len(transcripts))
+	sizes := make(map[string]int64, len(transcripts))
+	for i, t := range transcripts {
+		paths[i] = t.path
+		sizes[t.path] = t.size
+	}
+
+	opts := forgeOptions{CheckpointDir: cfg.CheckpointDir, Quiet: true}
+	forge := func(ctx context.Context, path string) (forgedTranscript, error) {
+		held, err := budget.Acquire(ctx, sizes[path])
+		if err != nil {
+			return forgedTranscript{}, err
+		}
+		defer budget.Release(held)
+
+		// Parsers and extractors are cheap; one per file keeps workers
+		// independent
+		p := parser.NewParser()
+		p.MaxContentLength = 0
+		start := time.Now()
+		session, progress, err := processTranscript(ctx, path, p, parser.NewExtractor(), opts, io.Discard)
+		return forgedTranscript{session: session, progress: progress, duration: time.Since(start)}, err
+	}
+
+	done := 0
+	for r := range worker.NewPool[forgedTranscript](jobs).Stream(ctx, paths, forge) {
+		t := transcripts[r.Index]
+		file := BatchForgeFile{Path: t.path, Bytes: t.size, DurationMs: r.Value.duration.Milliseconds()}
+
+		switch {
+		case r.Err != nil && (errors.Is(r.Err, context.Canceled) || errors.Is(r.Err, context.DeadlineExceeded)):
+			file.Status = batchStatusInterrupted
+		case r.Err != nil:
+			file.Status = batchStatusFailed
+			file.Error = r.Err.Error()
+		case r.Value.progress.UpToDate:
+			file.Status = batchStatusUnchanged
+		default:
+			if err := writeBatchSession(fs, r.Value.session, t.path, cfg); err != nil {
+				file.Status = batchStatusFailed
+				file.Error = err.Error()
+				break
+			}
+			session := r.Value.session
+			file.Status = batchStatusForged
+			file.Session = session.ID
+			file.Decisions = len(session.Decisions)
+			file.Learnings = len(session.Knowledge)
+			run.Knowledge = append(run.Knowledge, session.Knowledge...)
+			run.Decisions = append(run.Decisions, session.Decisions...)
+		}
+		run.Files[r.Index] = file
+
+		done++
+		if file.Status != batchStatusInterrupted {
+			fmt.Fprintf(w, "[%d/%d] %s: %s (%s, %s)\n", done, len(transcripts), filepath.Base(t.path), file.Status, humanSize(t.size), r.Value.duration.Round(time.Millisecond))
+		}
+		if file.Error != "" {
+			fmt.Fprintf(os.Stderr, "  Warning: skipping %s: %s\n", t.path, file.Error)
+		}
+		if file.Status == batchStatusForged {
+			VerbosePrintf("  -> %d decisions, %d learnings\n", file.Decisions, file.Learnings)
+		}
+	}
+	return run
+}
+
+// writeBatchSession writes a forged session with its index entry and
+// provenance, then records the transcript in the forged index.
+func writeBatchSession(fs *storage.FileStorage, session *storage.Session, transcriptPath string, cfg batchForgeConfig) error {
+	sessionPath, err := fs.WriteSession(session)
+	if err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+
+	indexEntry := &storage.IndexEntry{
+		SessionID:   session.ID,
+		Date:        session.Date,
+		SessionPath: sessionPath,
+		Summary:     session.Summary,
+	}
+	if err := fs.WriteIndex(indexEntry); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: failed to index session: %v\n", err)
+	}
+
+	provRecord := &storage.ProvenanceRecord{
+		ID:           fmt.Sprintf("prov-%s", session.ID[:7]),
+		ArtifactPath: sessionPath,
+		ArtifactType: "session",
+		SourcePath:   transcriptPath,
+		SourceType:   "transcript",
+		SessionID:    session.ID,
+		CreatedAt:    time.Now(),
+	}
+	if err := fs.WriteProvenance(provRecord); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: failed to write provenance: %v\n", err)
+	}
+
+	// A resumed transcript is already in the forged index
+	if cfg.ForgedSet[transcriptPath] {
+		return nil
+	}
+	forgedRecord := ForgedRecord{
+		Path:     transcriptPath,
+		ForgedAt: time.Now(),
+		Session:  session.ID,
+	}
+	if err := appendForgedRecord(cfg.ForgedIndexPath, forgedRecord); err != nil {
+		VerbosePrintf("  Warning: failed to record forged transcript: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+func batchForgeTestSetup(t *testing.T, n int) (*storage.FileStorage, []transcriptCandidate, batchForgeConfig) {
+	t.Helper()
+	dir := t.TempDir()
+	baseDir := filepath.Join(dir, storage.DefaultBaseDir)
+	fs := storage.NewFileStorage(storage.WithBaseDir(baseDir))
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	var transcripts []transcriptCandidate
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("t%d.jsonl", i))
+		line := fmt.Sprintf(`{"type":"assistant","sessionId":"session-%04d","timestamp":"2026-01-02T10:00:00Z","message":{"role":"assistant","content":"I learned that transcript %d needs care."}}`+"\n", i, i)
+		if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+			t.Fatal(err)
+		}
+		transcripts = append(transcripts, transcriptCandidate{path: path, size: int64(len(line))})
+	}
+
+	cfg := batchForgeConfig{
+		Jobs:            3,
+		MaxMemory:       1 << 20,
+		CheckpointDir:   filepath.Join(baseDir, forgeCheckpointDirName),
+		ForgedIndexPath: filepath.Join(baseDir, "forged.jsonl"),
+		ForgedSet:       map[string]bool{},
+	}
+	return fs, transcripts, cfg
+}
+
+func TestForgeBatchParallel(t *testing.T) {
+	fs, transcripts, cfg := batchForgeTestSetup(t, 8)
+
+	run := forgeBatchParallel(context.Background(), fs, transcripts, cfg, io.Discard)
+	if run.Jobs != 3 {
+		t.Errorf("Jobs = %d, want 3", run.Jobs)
+	}
+	for i, f := range run.Files {
+		if f.Path != transcripts[i].path || f.Status != batchStatusForged || f.Learnings != 1 {
+			t.Errorf("file %d = %+v, want forged in input order with 1 learning", i, f)
+		}
+	}
+	if len(run.Knowledge) != 8 {
+		t.Errorf("knowledge = %d, want 8", len(run.Knowledge))
+	}
+
+	forged, err := loadForgedIndex(cfg.ForgedIndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forged) != 8 {
+		t.Errorf("forged index has %d paths, want 8", len(forged))
+	}
+
+	// A second pass over the same files finds nothing new
+	cfg.ForgedSet = forged
+	run = forgeBatchParallel(context.Background(), fs, transcripts, cfg, io.Discard)
+	for i, f := range run.Files {
+		if f.Status != batchStatusUnchanged {
+			t.Errorf("file %d status = %s, want unchanged", i, f.Status)
+		}
+	}
+}
+
+func TestForgeBatchParallelCancelled(t *testing.T) {
+	fs, transcripts, cfg := batchForgeTestSetup(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run := forgeBatchParallel(ctx, fs, transcripts, cfg, io.Discard)
+	for i, f := range run.Files {
+		if f.Status != batchStatusInterrupted {
+			t.Errorf("file %d status = %s, want interrupted", i, f.Status)
+		}
+	}
+
+	// Nothing was written, so nothing may be recorded as forged
+	forged, err := loadForgedIndex(cfg.ForgedIndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forged) != 0 {
+		t.Errorf("forged index has %d paths after cancellation, want 0", len(forged))
+	}
+}
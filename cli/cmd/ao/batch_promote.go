@@ -13,7 +13,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/pool"
-	"github.com/boshu2/agentops/cli/internal/ratchet"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -42,14 +41,15 @@ var poolBatchPromoteCmd = &cobra.Command{
 	Long: `Promote pending pool candidates that meet promotion criteria.
 
 Criteria (unless --force):
-  - Age > 24h (candidate has had time to settle)
-  - Has been cited at least once
+  - The promotion policy allows it (.agents/policy/promotion.yaml; see
+    'ao pool policy'). The default policy requires 24h in the pool and
+    silver/gold tier or at least one citation.
   - Not a duplicate of already-promoted content
 
 Flags:
   --dry-run   Show what would be promoted without executing
   --force     Promote all pending candidates regardless of criteria
-  --min-age   Minimum age threshold (default: 24h)
+  --min-age   Additional minimum age on top of the policy
 
 Examples:
   ao pool batch-promote
@@ -63,13 +63,17 @@ func init() {
 	poolCmd.AddCommand(poolBatchPromoteCmd)
 
 	poolBatchPromoteCmd.Flags().BoolVar(&batchPromoteForce, "force", false, "Promote all pending regardless of criteria")
-	poolBatchPromoteCmd.Flags().StringVar(&batchPromoteMinAge, "min-age", "24h", "Minimum age for promotion eligibility")
+	poolBatchPromoteCmd.Flags().StringVar(&batchPromoteMinAge, "min-age", "", "Additional minimum age for promotion eligibility (default: policy decides)")
 }
 
 func runBatchPromote(cmd *cobra.Command, args []string) error {
-	minAge, err := time.ParseDuration(batchPromoteMinAge)
-	if err != nil {
-		return fmt.Errorf("invalid --min-age: %w", err)
+	var minAge time.Duration
+	if batchPromoteMinAge != "" {
+		var err error
+		minAge, err = time.ParseDuration(batchPromoteMinAge)
+		if err != nil {
+			return fmt.Errorf("invalid --min-age: %w", err)
+		}
 	}
 
 	cwd, err := os.Getwd()
@@ -92,15 +96,12 @@ func runBatchPromote(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Load citations for checking
-	citations, err := ratchet.LoadCitations(cwd)
+	// Load the promotion policy with the citations and outcomes it checks
+	pctx, err := loadPromotionPolicyContext(cwd)
 	if err != nil {
-		VerbosePrintf("Warning: could not load citations: %v\n", err)
+		return err
 	}
 
-	// Build citation count map keyed by candidate ID
-	citationCounts := buildCitationCounts(citations, cwd)
-
 	// Load existing promoted content for duplicate detection
 	promotedContent := loadPromotedContent(cwd)
 
@@ -122,7 +123,7 @@ func runBatchPromote(cmd *cobra.Command, args []string) error {
 		}
 
 		// Check criteria
-		if reason := checkPromotionCriteria(entry, minAge, citationCounts, promotedContent); reason != "" {
+		if reason := checkPromotionCriteria(entry, pctx, minAge, promotedContent); reason != "" {
 			result.Skipped++
 			result.Reasons = append(result.Reasons, skipReason{
 				CandidateID: entry.Candidate.ID,
@@ -145,18 +146,16 @@ func runBatchPromote(cmd *cobra.Command, args []string) error {
 }
 
 // checkPromotionCriteria returns a skip reason if the candidate does not qualify, or "" if it qualifies.
-func checkPromotionCriteria(entry pool.PoolEntry, minAge time.Duration, citationCounts map[string]int, promotedContent map[string]bool) string {
+// minAge, if set, is a floor on top of the promotion policy.
+func checkPromotionCriteria(entry pool.PoolEntry, pctx *promotionPolicyContext, minAge time.Duration, promotedContent map[string]bool) string {
 	// Check age
 	if entry.Age < minAge {
 		return fmt.Sprintf("too young (%s < %s)", entry.AgeString, minAge)
 	}
 
-	// Check citations
-	if citationCounts[entry.Candidate.ID] < 1 {
-		// Also check by file path in case citations reference the pool file
-		if entry.FilePath == "" || citationCounts[entry.FilePath] < 1 {
-			return "no citations"
-		}
+	// Check the promotion policy
+	if d := pctx.decide(entry); !d.Promote() {
+		return "policy: " + d.Reason()
 	}
 
 	// Check for duplicate content
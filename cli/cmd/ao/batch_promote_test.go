@@ -62,8 +62,6 @@ func TestNormalizeContent(t *testing.T) {
 }
 
 func TestCheckPromotionCriteria(t *testing.T) {
-	minAge := 24 * time.Hour
-
 	tests := []struct {
 		name       string
 		entry      pool.PoolEntry
@@ -101,7 +99,7 @@ func TestCheckPromotionCriteria(t *testing.T) {
 			},
 			citations:  map[string]int{"cand-young": 1},
 			promoted:   map[string]bool{},
-			wantReason: "too young",
+			wantReason: "age 12h (min 24h)",
 		},
 		{
 			name: "no citations",
@@ -117,7 +115,7 @@ func TestCheckPromotionCriteria(t *testing.T) {
 			},
 			citations:  map[string]int{},
 			promoted:   map[string]bool{},
-			wantReason: "no citations",
+			wantReason: "citations 0 (min 1)",
 		},
 		{
 			name: "cited by file path",
@@ -152,11 +150,47 @@ func TestCheckPromotionCriteria(t *testing.T) {
 			promoted:   map[string]bool{normalizeContent("already promoted learning"): true},
 			wantReason: "duplicate of already-promoted content",
 		},
+		{
+			name: "silver settles without citations",
+			entry: pool.PoolEntry{
+				PoolEntry: types.PoolEntry{
+					Candidate: types.Candidate{
+						ID:      "cand-silver",
+						Tier:    types.TierSilver,
+						Content: "silver learning",
+					},
+				},
+				Age:       30 * time.Hour,
+				AgeString: "30h",
+			},
+			citations:  map[string]int{},
+			promoted:   map[string]bool{},
+			wantReason: "",
+		},
+		{
+			name: "held for human review",
+			entry: pool.PoolEntry{
+				PoolEntry: types.PoolEntry{
+					Candidate: types.Candidate{
+						ID:      "cand-gated",
+						Tier:    types.TierBronze,
+						Content: "gated learning",
+					},
+					ScoringResult: types.Scoring{GateRequired: true},
+				},
+				Age:       48 * time.Hour,
+				AgeString: "48h",
+			},
+			citations:  map[string]int{"cand-gated": 3},
+			promoted:   map[string]bool{},
+			wantReason: `rule "human-gate": hold`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reason := checkPromotionCriteria(tt.entry, minAge, tt.citations, tt.promoted)
+			pctx := &promotionPolicyContext{policy: pool.DefaultPromotionPolicy(), citationCounts: tt.citations}
+			reason := checkPromotionCriteria(tt.entry, pctx, 0, tt.promoted)
 			if tt.wantReason == "" {
 				if reason != "" {
 					t.Errorf("expected qualification, got skip reason: %q", reason)
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const (
+	// citationShingleWords is the length of the word sequences compared
+	// between a learning's summary and what the session wrote.
+	citationShingleWords = 4
+
+	// citationOverlapThreshold is the share of a summary's shingles that one
+	// assistant message must repeat for the learning to count as applied.
+	citationOverlapThreshold = 0.3
+
+	// citationMinOverlap is the fewest shared shingles that count, so a
+	// short summary is not matched by one common phrase.
+	citationMinOverlap = 2
+
+	// citationMinIDLength keeps short IDs like "L2" from matching prose.
+	citationMinIDLength = 4
+)
+
+// citationWeights scale a session's reward by how a learning was used, so
+// utility follows learnings the session actually read or applied rather
+// than everything that was injected. Manual and unknown citation types
+// carry the full reward.
+var citationWeights = map[string]float64{
+	types.CitationTypeInjected:  0.2,
+	types.CitationTypeRetrieved: 0.6,
+	types.CitationTypeApplied:   1.0,
+}
+
+// citationRanks orders citation types by the strength of evidence of use.
+var citationRanks = map[string]int{
+	types.CitationTypeInjected:  1,
+	types.CitationTypeRetrieved: 2,
+	types.CitationTypeApplied:   3,
+}
+
+// citationWeight returns the reward weight for a citation type.
+func citationWeight(citationType string) float64 {
+	if w, ok := citationWeights[citationType]; ok {
+		return w
+	}
+	return 1
+}
+
+// citationSearchTools are the tools whose results list matching files.
+var citationSearchTools = map[string]bool{
+	"Grep": true,
+	"Glob": true,
+	"Bash": true,
+}
+
+// citationCandidate is a learning the detector looks for in a transcript.
+type citationCandidate struct {
+	path     string
+	ref      string // how tools name the file: learnings/<file>
+	idRe     *regexp.Regexp
+	shingles map[string]bool
+}
+
+// loadCitationCandidates returns every learning under cwd's learnings
+// directory.
+func loadCitationCandidates(cwd string) ([]citationCandidate, error) {
+	learningsDir := findLearningsDir(cwd)
+	if learningsDir == "" {
+		return nil, nil
+	}
+	files, err := learningFiles(learningsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []citationCandidate
+	for _, file := range files {
+		l, err := parseLearningFile(file)
+		if err != nil {
+			continue
+		}
+		c := citationCandidate{
+			path:     file,
+			ref:      "learnings/" + filepath.Base(file),
+			shingles: wordShingles(l.Summary),
+		}
+		if len(l.ID) >= citationMinIDLength {
+			c.idRe = regexp.MustCompile(`(^|[^\w-])` + regexp.QuoteMeta(l.ID) + `($|[^\w-])`)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// citationUse is how and when a session used a learning.
+type citationUse struct {
+	citationType string
+	first, last  int // indices of the messages that used it
+}
+
+// detectCitations returns, for each candidate learning the session used,
+// the strongest citation type the transcript supports and where it was used:
+//   - retrieved: a tool call names the learning file (Read, cat, a Grep
+//     path), or a search tool's result lists it
+//   - applied: an assistant message or written file quotes the learning's
+//     ID, or repeats enough of its summary
+func detectCitations(messages []types.TranscriptMessage, candidates []citationCandidate) map[string]citationUse {
+	found := make(map[string]citationUse)
+	upgrade := func(path, citationType string, at int) {
+		use, ok := found[path]
+		if !ok {
+			use.first = at
+		}
+		use.last = at
+		if citationRanks[citationType] > citationRanks[use.citationType] {
+			use.citationType = citationType
+		}
+		found[path] = use
+	}
+
+	searching := false
+	for i, msg := range messages {
+		assistant := msg.Role == "assistant" || msg.Type == "assistant"
+		if assistant {
+			searching = false
+		}
+
+		written := []string{}
+		if assistant {
+			written = append(written, msg.Content)
+		}
+		for _, tool := range msg.Tools {
+			if tool.Name == "tool_result" {
+				if searching {
+					for _, c := range candidates {
+						if strings.Contains(tool.Output, c.ref) {
+							upgrade(c.path, types.CitationTypeRetrieved, i)
+						}
+					}
+				}
+				continue
+			}
+			searching = searching || citationSearchTools[tool.Name]
+
+			input := toolInputText(tool.Input)
+			for _, c := range candidates {
+				if strings.Contains(input, c.ref) {
+					upgrade(c.path, types.CitationTypeRetrieved, i)
+				}
+			}
+			for _, key := range []string{"content", "new_string"} {
+				if text, ok := tool.Input[key].(string); ok {
+					written = append(written, text)
+				}
+			}
+		}
+
+		for _, text := range written {
+			if text == "" {
+				continue
+			}
+			shingles := wordShingles(text)
+			for _, c := range candidates {
+				if c.idRe != nil && c.idRe.MatchString(text) || summaryOverlaps(c.shingles, shingles) {
+					upgrade(c.path, types.CitationTypeApplied, i)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// toolInputText joins a tool call's string inputs.
+func toolInputText(input map[string]interface{}) string {
+	var parts []string
+	for _, v := range input {
+		if s, ok := v.(string); ok {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// wordShingles returns the lower-cased citationShingleWords-word sequences
+// of text.
+func wordShingles(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	shingles := make(map[string]bool)
+	for i := 0; i+citationShingleWords <= len(words); i++ {
+		shingles[strings.Join(words[i:i+citationShingleWords], " ")] = true
+	}
+	return shingles
+}
+
+// summaryOverlaps reports whether text repeats enough of a summary.
+func summaryOverlaps(summary, text map[string]bool) bool {
+	if len(summary) == 0 {
+		return false
+	}
+	shared := 0
+	for s := range summary {
+		if text[s] {
+			shared++
+		}
+	}
+	return shared >= citationMinOverlap && float64(shared)/float64(len(summary)) >= citationOverlapThreshold
+}
+
+// detectSessionCitations parses a transcript and returns citation events
+// for the learnings it shows the session using, with the session's timeline
+// for credit assignment.
+func detectSessionCitations(cwd, transcriptPath, sessionID string) ([]types.CitationEvent, *creditTimeline, error) {
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	result, _, err := parser.ParseTranscript(transcriptPath, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse transcript: %w", err)
+	}
+	candidates, err := loadCitationCandidates(cwd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load learnings: %w", err)
+	}
+
+	found := detectCitations(result.Messages, candidates)
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	now := time.Now()
+	events := make([]types.CitationEvent, 0, len(paths))
+	for _, path := range paths {
+		events = append(events, types.CitationEvent{
+			ArtifactPath: path,
+			SessionID:    sessionID,
+			CitedAt:      now,
+			CitationType: found[path].citationType,
+			Source:       types.CitationSourceTranscript,
+		})
+	}
+	return events, buildCreditTimeline(result.Messages, found), nil
+}
+
+// recordDetectedCitations records the detected citations that are stronger
+// than what the transcript already showed for the same learning, and
+// returns them. Running the detector twice records nothing new.
+func recordDetectedCitations(cwd string, existing, detected []types.CitationEvent) ([]types.CitationEvent, error) {
+	best := make(map[string]int)
+	for _, c := range existing {
+		if c.Source != types.CitationSourceTranscript {
+			continue
+		}
+		best[c.ArtifactPath] = max(best[c.ArtifactPath], citationRanks[c.CitationType])
+	}
+
+	var recorded []types.CitationEvent
+	for _, c := range detected {
+		if citationRanks[c.CitationType] <= best[c.ArtifactPath] {
+			continue
+		}
+		if err := ratchet.RecordCitation(cwd, c); err != nil {
+			return recorded, fmt.Errorf("record citation for %s: %w", c.ArtifactPath, err)
+		}
+		recorded = append(recorded, c)
+	}
+	return recorded, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// citationLearnings writes learnings the session uses in different ways
+// and returns their paths by name.
+func citationLearnings(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	learningsDir := filepath.Join(dir, ".agents", "learnings")
+	if err := os.MkdirAll(learningsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"read.md":        "# Read\n\nRetry flaky network calls with jittered backoff.\n",
+		"grepped.md":     "# Grepped\n\nPrefer table tests for parsers.\n",
+		"summary.md":     "# Summary\n\nAlways wrap context cancellation errors with the operation name before returning them.\n",
+		"quoted.jsonl":   `{"id":"L-quoted-42","title":"Quoted","summary":"Pin tool versions in CI.","utility":0.5}`,
+		"injected.md":    "# Injected\n\nKeep migrations backwards compatible for one release.\n",
+		"untouched.md":   "# Untouched\n\nNothing references this one.\n",
+		"short-id.jsonl": `{"id":"L2","title":"Short","summary":"Cache DNS lookups.","utility":0.5}`,
+	}
+	paths := make(map[string]string, len(files))
+	for name, content := range files {
+		path := filepath.Join(learningsDir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths[name] = path
+	}
+	return paths
+}
+
+// citationTranscript is a Claude transcript that reads, greps, quotes and
+// paraphrases learnings. The injected learning's text only appears in the
+// user turn, which does not count as use.
+const citationTranscript = `{"type":"user","sessionId":"s1","message":{"role":"user","content":"Prior knowledge: keep migrations backwards compatible for one release (injected.md). L2 applies too."}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"tool_use","name":"Read","input":{"file_path":"/repo/.agents/learnings/read.md"}},{"type":"tool_use","name":"Grep","input":{"pattern":"table","path":".agents"}}]}}
+{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","content":"Retry flaky network calls"},{"type":"tool_result","content":".agents/learnings/grepped.md:3:Prefer table tests for parsers."}]}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"text","text":"Following L-quoted-42, I pinned the linter version. L2 cache misses are fine."},{"type":"tool_use","name":"Edit","input":{"file_path":"db.go","new_string":"// Wrap context cancellation errors with the operation name.\nreturn fmt.Errorf(\"query: %w\", err)"}}]}}
+`
+
+func TestDetectSessionCitations(t *testing.T) {
+	tmp := t.TempDir()
+	paths := citationLearnings(t, tmp)
+	transcript := filepath.Join(tmp, "session.jsonl")
+	if err := os.WriteFile(transcript, []byte(citationTranscript), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, _, err := detectSessionCitations(tmp, transcript, "session-1")
+	if err != nil {
+		t.Fatalf("detectSessionCitations: %v", err)
+	}
+	got := make(map[string]string)
+	for _, e := range events {
+		got[filepath.Base(e.ArtifactPath)] = e.CitationType
+		if e.SessionID != "session-1" {
+			t.Errorf("%s: session %q", e.ArtifactPath, e.SessionID)
+		}
+	}
+	want := map[string]string{
+		"read.md":      types.CitationTypeRetrieved,
+		"grepped.md":   types.CitationTypeRetrieved,
+		"quoted.jsonl": types.CitationTypeApplied,
+		"summary.md":   types.CitationTypeApplied,
+	}
+	if len(got) != len(want) {
+		t.Errorf("detected %v, want %v", got, want)
+	}
+	for name, typ := range want {
+		if got[name] != typ {
+			t.Errorf("%s: citation type %q, want %q", name, got[name], typ)
+		}
+	}
+
+	for _, e := range events {
+		if e.Source != types.CitationSourceTranscript {
+			t.Errorf("%s: source %q, want %q", filepath.Base(e.ArtifactPath), e.Source, types.CitationSourceTranscript)
+		}
+	}
+
+	// Recording skips what the transcript already showed at the same
+	// strength; inject-time citations don't count
+	existing := []types.CitationEvent{
+		{ArtifactPath: paths["read.md"], CitationType: types.CitationTypeRetrieved, Source: types.CitationSourceTranscript},
+		{ArtifactPath: paths["grepped.md"], CitationType: types.CitationTypeRetrieved},
+		{ArtifactPath: paths["summary.md"], CitationType: types.CitationTypeInjected},
+	}
+	recorded, err := recordDetectedCitations(tmp, existing, events)
+	if err != nil {
+		t.Fatalf("recordDetectedCitations: %v", err)
+	}
+	if len(recorded) != 3 {
+		t.Errorf("recorded %d citations, want 3 (read.md already retrieved)", len(recorded))
+	}
+	if again, _ := recordDetectedCitations(tmp, append(existing, recorded...), events); len(again) != 0 {
+		t.Errorf("second run recorded %d citations, want 0", len(again))
+	}
+}
+
+func TestDeduplicateCitationsKeepsStrongestType(t *testing.T) {
+	got := deduplicateCitations([]types.CitationEvent{
+		{ArtifactPath: "/a.md", CitationType: types.CitationTypeInjected},
+		{ArtifactPath: "/b.md", CitationType: types.CitationTypeInjected},
+		{ArtifactPath: "/a.md", CitationType: types.CitationTypeApplied},
+		{ArtifactPath: "/a.md", CitationType: types.CitationTypeRetrieved},
+	})
+	if len(got) != 2 || got[0].CitationType != types.CitationTypeApplied || got[1].CitationType != types.CitationTypeInjected {
+		t.Errorf("deduplicateCitations() = %+v", got)
+	}
+}
+
+func TestFeedbackLoopWeightsRewardsByUse(t *testing.T) {
+	tmp := chdirTemp(t)
+	paths := citationLearnings(t, tmp)
+	transcript := filepath.Join(tmp, "session.jsonl")
+	if err := os.WriteFile(transcript, []byte(citationTranscript), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// ao inject put two learnings in context
+	sessionID := "session-20260301-090000"
+	injected := []learning{
+		{ID: "injected.md", Source: paths["injected.md"]},
+		{ID: "summary.md", Source: paths["summary.md"]},
+	}
+	if err := recordCitations(tmp, injected, sessionID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := []interface{}{feedbackLoopSessionID, feedbackLoopReward, feedbackLoopTranscript, feedbackLoopAlpha, feedbackLoopCitationType}
+	t.Cleanup(func() {
+		feedbackLoopSessionID = prev[0].(string)
+		feedbackLoopReward = prev[1].(float64)
+		feedbackLoopTranscript = prev[2].(string)
+		feedbackLoopAlpha = prev[3].(float64)
+		feedbackLoopCitationType = prev[4].(string)
+	})
+	feedbackLoopSessionID = sessionID
+	feedbackLoopReward = 1
+	feedbackLoopTranscript = transcript
+	feedbackLoopAlpha = 0.5
+	feedbackLoopCitationType = "all"
+
+	if err := runFeedbackLoop(feedbackLoopCmd, nil); err != nil {
+		t.Fatalf("runFeedbackLoop: %v", err)
+	}
+
+	events, err := loadFeedbackEvents(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := make(map[string]float64)
+	for _, e := range events {
+		name := filepath.Base(e.ArtifactPath)
+		if e.Credit == nil {
+			t.Fatalf("%s: no credit breakdown", name)
+		}
+		weights[name] = e.Credit.TypeWeight
+		if math.Abs(e.Reward-e.Credit.Reward()) > 1e-9 || e.Reward > e.Credit.TypeWeight {
+			t.Errorf("%s: reward %.2f, breakdown %s", name, e.Reward, e.Credit)
+		}
+	}
+	want := map[string]float64{
+		"injected.md":  citationWeight(types.CitationTypeInjected),
+		"summary.md":   citationWeight(types.CitationTypeApplied),
+		"read.md":      citationWeight(types.CitationTypeRetrieved),
+		"grepped.md":   citationWeight(types.CitationTypeRetrieved),
+		"quoted.jsonl": citationWeight(types.CitationTypeApplied),
+	}
+	if len(weights) != len(want) {
+		t.Errorf("feedback for %v, want %v", weights, want)
+	}
+	for name, w := range want {
+		if weights[name] != w {
+			t.Errorf("%s: type weight %.2f, want %.2f", name, weights[name], w)
+		}
+	}
+
+	// The applied JSONL learning, used in the last message, moved halfway to
+	// the full reward
+	data, err := os.ReadFile(paths["quoted.jsonl"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.SplitN(string(data), "\n", 2)[0]), &stored); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := stored["utility"].(float64); math.Abs(u-0.75) > 1e-9 {
+		t.Errorf("quoted.jsonl utility = %v, want 0.75", stored["utility"])
+	}
+
+	// Detected citations were recorded for the session
+	citations, err := ratchet.LoadCitations(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(citations) != 2+4 {
+		t.Errorf("citations.jsonl has %d events, want 2 injected + 4 detected", len(citations))
+	}
+	for _, c := range citations {
+		if c.SessionID != sessionID {
+			t.Errorf("unexpected citation %+v", c)
+		}
+	}
+}
@@ -88,7 +88,7 @@ func runConfig(cmd *cobra.Command, args []string) error {
 
 	fmt.Println()
 	fmt.Println("Environment variables (if set):")
-	envVars := []string{"AGENTOPS_OUTPUT", "AGENTOPS_BASE_DIR", "AGENTOPS_VERBOSE", "AGENTOPS_NO_SC"}
+	envVars := []string{"AGENTOPS_OUTPUT", "AGENTOPS_BASE_DIR", "AGENTOPS_VERBOSE", "AGENTOPS_NO_SC", "AGENTOPS_SAMPLE_SEED", "AGENTOPS_EXPLORE_SEED"}
 	anySet := false
 	for _, env := range envVars {
 		if v := os.Getenv(env); v != "" {
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+var (
+	contextSessionID   string
+	contextTranscript  string
+	contextMaxTokens   int
+	contextHook        bool
+	contextDescription string
+	contextTestStatus  string
+	contextNote        string
+	contextTarget      float64
+)
+
+// contextCmd is the parent command for context budget operations.
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Track context window usage and survive compaction",
+	Long: `Track how much of the model's context window a session uses, and carry
+its working state across compaction.
+
+The session transcript is read directly: usage comes from the API usage
+recorded on the latest assistant message, and state (files changed, test
+status, current and completed tasks) is derived from tool calls.
+
+Commands:
+  status      Show usage against the context window
+  checkpoint  Record a checkpoint and save resumption state
+  summarize   Progressively summarize the session to a target usage
+  resume      Print the resumption block for a saved session
+
+Hooks:
+  PreCompact:   ao context checkpoint --hook
+  SessionStart: ao context resume --hook
+
+With --hook, the session ID and transcript path are read from the hook
+payload on stdin.
+
+Examples:
+  ao context status
+  ao context status --session abc123 -o json
+  ao context checkpoint --description "auth refactor done"
+  ao context summarize --target 0.4
+  ao context resume`,
+}
+
+var contextStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show context window usage for a session",
+	RunE:  runContextStatus,
+}
+
+var contextCheckpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Record a checkpoint and save resumption state",
+	Long: `Record a checkpoint of the session's usage and save its working state
+(files changed, test status, tasks) for resumption after compaction.
+
+Examples:
+  ao context checkpoint
+  ao context checkpoint --description "parser done" --test-status passing
+  ao context checkpoint --hook            # From the PreCompact hook`,
+	RunE: runContextCheckpoint,
+}
+
+var contextSummarizeCmd = &cobra.Command{
+	Use:   "summarize",
+	Short: "Progressively summarize the session to a target usage",
+	Long: `Classify the session's content by priority and summarize it down to a
+target fraction of the context window. File changes, failing tests and
+critical findings are always kept; exploration is dropped first.
+
+The summary is printed and the summarization is recorded in the session's
+budget.
+
+Examples:
+  ao context summarize
+  ao context summarize --target 0.4 -o json`,
+	RunE: runContextSummarize,
+}
+
+var contextResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Print the resumption block for a saved session",
+	Long: `Print the resumption block saved by the last checkpoint.
+
+Without --session, the most recently saved state is used. With --hook, the
+block is printed only after a compaction or resume of the same session, so
+fresh sessions start clean. Prints nothing when no state exists.
+
+Examples:
+  ao context resume
+  ao context resume --session abc123
+  ao context resume --hook                # From the SessionStart hook`,
+	RunE: runContextResume,
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextStatusCmd)
+	contextCmd.AddCommand(contextCheckpointCmd)
+	contextCmd.AddCommand(contextSummarizeCmd)
+	contextCmd.AddCommand(contextResumeCmd)
+
+	contextCmd.PersistentFlags().StringVar(&contextSessionID, "session", "", "Session ID (default: most recent transcript)")
+	contextCmd.PersistentFlags().StringVar(&contextTranscript, "transcript", "", "Transcript path (overrides --session lookup)")
+	contextCmd.PersistentFlags().IntVar(&contextMaxTokens, "max-tokens", aocontext.DefaultMaxTokens, "Model context window size in tokens")
+	contextCmd.PersistentFlags().BoolVar(&contextHook, "hook", false, "Read the Claude Code hook payload from stdin")
+
+	contextCheckpointCmd.Flags().StringVar(&contextDescription, "description", "", "What was completed (default: current task)")
+	contextCheckpointCmd.Flags().StringVar(&contextTestStatus, "test-status", "", "Override test status (passing, failing, none)")
+	contextCheckpointCmd.Flags().StringVar(&contextNote, "note", "", "Free-form notes to include on resume")
+
+	contextSummarizeCmd.Flags().Float64Var(&contextTarget, "target", aocontext.DefaultSummaryConfig().TargetUsage, "Target usage after summarization (0-1)")
+}
+
+// contextHookInput is the subset of the Claude Code hook payload used here.
+type contextHookInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Source         string `json:"source"`
+}
+
+// contextSession is a resolved session: where its transcript is and where
+// its budget and state are stored.
+type contextSession struct {
+	ID         string
+	Transcript string
+	BaseDir    string
+	Source     string
+}
+
+// readContextHookInput decodes the hook payload; an empty payload is not an
+// error.
+func readContextHookInput(r io.Reader) (contextHookInput, error) {
+	var in contextHookInput
+	if err := json.NewDecoder(r).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		return in, fmt.Errorf("decode hook input: %w", err)
+	}
+	return in, nil
+}
+
+// resolveContextSession finds the session from flags, the hook payload, or
+// the most recent transcript, in that order. The transcript is only
+// required when needTranscript is set.
+func resolveContextSession(stdin io.Reader, needTranscript bool) (*contextSession, error) {
+	s := &contextSession{ID: contextSessionID, Transcript: contextTranscript}
+
+	if contextHook {
+		in, err := readContextHookInput(stdin)
+		if err != nil {
+			return nil, err
+		}
+		if s.ID == "" {
+			s.ID = in.SessionID
+		}
+		if s.Transcript == "" {
+			s.Transcript = in.TranscriptPath
+		}
+		s.BaseDir = in.Cwd
+		s.Source = in.Source
+	}
+
+	if s.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get working directory: %w", err)
+		}
+		s.BaseDir = cwd
+	}
+
+	if s.Transcript == "" && needTranscript {
+		path, _, err := resolveTranscript(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.Transcript = path
+	}
+	if s.ID == "" && s.Transcript != "" {
+		s.ID = strings.TrimSuffix(filepath.Base(s.Transcript), filepath.Ext(s.Transcript))
+	}
+	return s, nil
+}
+
+// loadContextTracker loads the session's budget or starts a new one, and
+// applies --max-tokens.
+func loadContextTracker(cmd *cobra.Command, s *contextSession) *aocontext.BudgetTracker {
+	tracker, err := aocontext.Load(s.BaseDir, s.ID)
+	if err != nil {
+		tracker = aocontext.NewBudgetTracker(s.ID)
+	}
+	if cmd.Flags().Changed("max-tokens") || tracker.MaxTokens <= 0 {
+		tracker.MaxTokens = contextMaxTokens
+	}
+	return tracker
+}
+
+// parseContextTranscript parses the full transcript, untruncated, so test
+// output and prompts are complete.
+func parseContextTranscript(path string) ([]types.TranscriptMessage, error) {
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	result, err := p.ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse transcript: %w", err)
+	}
+	return result.Messages, nil
+}
+
+func runContextStatus(cmd *cobra.Command, args []string) error {
+	s, err := resolveContextSession(cmd.InOrStdin(), true)
+	if err != nil {
+		return err
+	}
+	usage, err := aocontext.TranscriptUsage(s.Transcript)
+	if err != nil {
+		return err
+	}
+
+	tracker := loadContextTracker(cmd, s)
+	tracker.UpdateUsage(usage)
+	if !GetDryRun() {
+		if err := tracker.Save(s.BaseDir); err != nil {
+			return fmt.Errorf("save budget: %w", err)
+		}
+	}
+
+	report := tracker.GetReport()
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	fmt.Fprintf(w, "Session:     %s\n", report.SessionID)
+	fmt.Fprintf(w, "Status:      %s\n", report.Status)
+	fmt.Fprintf(w, "Usage:       %d / %d tokens (%.1f%%)\n", report.TokensUsed, tracker.MaxTokens, report.UsagePercent)
+	fmt.Fprintf(w, "Remaining:   %d tokens\n", report.TokensRemaining)
+	fmt.Fprintf(w, "Checkpoints: %d\n", report.CheckpointCount)
+	if last := tracker.GetLastCheckpoint(); last != nil {
+		fmt.Fprintf(w, "  last:      %s at %.1f%% — %s\n", last.Timestamp.Format(time.RFC3339), last.PercentUsage*100, last.Description)
+	}
+	fmt.Fprintf(w, "Summarized:  %d times\n", report.SummarizationCount)
+	fmt.Fprintf(w, "\n%s\n", report.Recommendation)
+	return nil
+}
+
+func runContextCheckpoint(cmd *cobra.Command, args []string) error {
+	switch contextTestStatus {
+	case "", aocontext.TestStatusPassing, aocontext.TestStatusFailing, aocontext.TestStatusNone:
+	default:
+		return fmt.Errorf("invalid --test-status %q (use passing, failing, none)", contextTestStatus)
+	}
+
+	s, err := resolveContextSession(cmd.InOrStdin(), true)
+	if err != nil {
+		return err
+	}
+	usage, err := aocontext.TranscriptUsage(s.Transcript)
+	if err != nil {
+		return err
+	}
+	msgs, err := parseContextTranscript(s.Transcript)
+	if err != nil {
+		return err
+	}
+
+	state := aocontext.ExtractState(s.ID, msgs)
+	state.Timestamp = time.Now()
+	state.Notes = contextNote
+	if contextTestStatus != "" {
+		state.TestStatus = contextTestStatus
+	}
+
+	description := contextDescription
+	if description == "" {
+		description = state.CurrentTask
+	}
+	if description == "" && contextHook {
+		description = "pre-compaction checkpoint"
+	}
+
+	tracker := loadContextTracker(cmd, s)
+	tracker.UpdateUsage(usage)
+	id := fmt.Sprintf("cp-%d", len(tracker.Checkpoints)+1)
+	cp := tracker.CreateCheckpoint(id, description, state.FilesChanged, state.TestStatus)
+
+	if GetDryRun() {
+		fmt.Fprintf(cmd.OutOrStdout(), "[dry-run] Would record checkpoint %s for session %s\n", cp.ID, s.ID)
+		return nil
+	}
+	if err := tracker.Save(s.BaseDir); err != nil {
+		return fmt.Errorf("save budget: %w", err)
+	}
+	if err := aocontext.NewSummarizer(tracker).SaveState(s.BaseDir, state); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+
+	// The hook stays quiet; the resumption block is emitted on SessionStart
+	if contextHook {
+		return nil
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Checkpoint aocontext.Checkpoint     `json:"checkpoint"`
+			State      aocontext.SummarizeState `json:"state"`
+		}{cp, state})
+	}
+	fmt.Fprintf(w, "Checkpoint %s recorded for session %s\n", cp.ID, s.ID)
+	fmt.Fprintf(w, "  Usage:         %.1f%%\n", cp.PercentUsage*100)
+	fmt.Fprintf(w, "  Files changed: %d\n", len(cp.FilesChanged))
+	fmt.Fprintf(w, "  Test status:   %s\n", cp.TestStatus)
+	if state.CurrentTask != "" {
+		fmt.Fprintf(w, "  Current task:  %s\n", state.CurrentTask)
+	}
+	return nil
+}
+
+func runContextSummarize(cmd *cobra.Command, args []string) error {
+	if contextTarget <= 0 || contextTarget > 1 {
+		return fmt.Errorf("--target must be between 0 and 1, got %g", contextTarget)
+	}
+
+	s, err := resolveContextSession(cmd.InOrStdin(), true)
+	if err != nil {
+		return err
+	}
+	usage, err := aocontext.TranscriptUsage(s.Transcript)
+	if err != nil {
+		return err
+	}
+	msgs, err := parseContextTranscript(s.Transcript)
+	if err != nil {
+		return err
+	}
+
+	tracker := loadContextTracker(cmd, s)
+	tracker.UpdateUsage(usage)
+	summarizer := aocontext.NewSummarizer(tracker)
+	summarizer.Config.TargetUsage = contextTarget
+
+	state := aocontext.ExtractState(s.ID, msgs)
+	kept, event := summarizer.SummarizeContext(summarizer.ItemsFromState(state, msgs))
+	tracker.RecordSummarization(event.TokensBefore, event.TokensAfter, event.PreservedContext)
+
+	if !GetDryRun() {
+		if err := tracker.Save(s.BaseDir); err != nil {
+			return fmt.Errorf("save budget: %w", err)
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Event aocontext.SummarizationEvent `json:"event"`
+			Items []aocontext.ContextItem      `json:"items"`
+		}{event, kept})
+	}
+
+	fmt.Fprintf(w, "# Session Summary (%s)\n\n", s.ID)
+	fmt.Fprintf(w, "%d → %d tokens (target %.0f%% of %d)\n\n", event.TokensBefore, event.TokensAfter, contextTarget*100, tracker.MaxTokens)
+	for _, item := range kept {
+		fmt.Fprintf(w, "- [%s] %s\n", item.Type, strings.Join(strings.Fields(item.Content), " "))
+	}
+	return nil
+}
+
+func runContextResume(cmd *cobra.Command, args []string) error {
+	s, err := resolveContextSession(cmd.InOrStdin(), false)
+	if err != nil {
+		return err
+	}
+
+	// A fresh session has nothing to resume
+	if contextHook && s.Source != "compact" && s.Source != "resume" {
+		return nil
+	}
+
+	var state *aocontext.SummarizeState
+	if s.ID != "" {
+		state, err = aocontext.LoadState(s.BaseDir, s.ID)
+	} else {
+		state, err = aocontext.LatestState(s.BaseDir)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			VerbosePrintf("No saved context state\n")
+			return nil
+		}
+		return fmt.Errorf("load state: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(state)
+	}
+	tracker := aocontext.NewBudgetTracker(state.SessionID)
+	_, err = fmt.Fprint(w, aocontext.NewSummarizer(tracker).GenerateResumptionContext(*state))
+	return err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
+)
+
+// writeContextTranscript writes a small Claude Code transcript that edits a
+// file, runs failing tests and reports usage.
+func writeContextTranscript(t *testing.T, dir string) string {
+	t.Helper()
+	lines := []string{
+		`{"type":"user","sessionId":"sess-1","message":{"role":"user","content":"fix the parser"}}`,
+		`{"type":"assistant","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"parser.go"}}]}}`,
+		`{"type":"assistant","sessionId":"sess-1","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{"command":"go test ./..."}}]}}`,
+		`{"type":"user","sessionId":"sess-1","message":{"role":"user","content":[{"type":"tool_result","is_error":true,"content":"--- FAIL: TestParse (0.00s)\nFAIL"}]}}`,
+		`{"type":"assistant","sessionId":"sess-1","message":{"role":"assistant","content":"still failing","usage":{"input_tokens":100,"cache_read_input_tokens":169900,"output_tokens":0}}}`,
+	}
+	path := filepath.Join(dir, "sess-1.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// resetContextFlags restores the context command globals after a test.
+func resetContextFlags(t *testing.T) {
+	t.Cleanup(func() {
+		contextSessionID, contextTranscript, contextDescription, contextTestStatus, contextNote = "", "", "", "", ""
+		contextMaxTokens = aocontext.DefaultMaxTokens
+		contextHook = false
+		contextTarget = aocontext.DefaultSummaryConfig().TargetUsage
+		output = "table"
+	})
+}
+
+func TestContextHookCheckpointAndResume(t *testing.T) {
+	resetContextFlags(t)
+	tmp := t.TempDir()
+	transcript := writeContextTranscript(t, tmp)
+	payload := func(source string) *bytes.Buffer {
+		data, _ := json.Marshal(contextHookInput{SessionID: "sess-1", TranscriptPath: transcript, Cwd: tmp, Source: source})
+		return bytes.NewBuffer(data)
+	}
+
+	contextHook = true
+	var out bytes.Buffer
+	contextCheckpointCmd.SetIn(payload(""))
+	contextCheckpointCmd.SetOut(&out)
+	if err := runContextCheckpoint(contextCheckpointCmd, nil); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("checkpoint --hook should be silent, got %q", out.String())
+	}
+
+	tracker, err := aocontext.Load(tmp, "sess-1")
+	if err != nil {
+		t.Fatalf("load budget: %v", err)
+	}
+	if tracker.EstimatedUsage != 170000 || len(tracker.Checkpoints) != 1 {
+		t.Fatalf("budget = %d tokens, %d checkpoints; want 170000, 1", tracker.EstimatedUsage, len(tracker.Checkpoints))
+	}
+	cp := tracker.Checkpoints[0]
+	if cp.TestStatus != aocontext.TestStatusFailing || len(cp.FilesChanged) != 1 || cp.Description != "fix the parser" {
+		t.Errorf("checkpoint = %+v", cp)
+	}
+
+	// A fresh session gets nothing; a compacted one gets the block
+	out.Reset()
+	contextResumeCmd.SetIn(payload("startup"))
+	contextResumeCmd.SetOut(&out)
+	if err := runContextResume(contextResumeCmd, nil); err != nil {
+		t.Fatalf("resume startup: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("resume on startup should be silent, got %q", out.String())
+	}
+
+	contextResumeCmd.SetIn(payload("compact"))
+	if err := runContextResume(contextResumeCmd, nil); err != nil {
+		t.Fatalf("resume compact: %v", err)
+	}
+	for _, want := range []string{"# Session Resumption Context", "- parser.go", "failing", "- TestParse", "fix the parser"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("resumption block missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestContextStatusAndSummarize(t *testing.T) {
+	resetContextFlags(t)
+	tmp := t.TempDir()
+	contextTranscript = writeContextTranscript(t, tmp)
+	contextHook = true // only to root the state in tmp via the payload's cwd
+
+	var out bytes.Buffer
+	output = "json"
+	contextStatusCmd.SetIn(strings.NewReader(`{"cwd":"` + tmp + `"}`))
+	contextStatusCmd.SetOut(&out)
+	if err := runContextStatus(contextStatusCmd, nil); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var report aocontext.BudgetReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.SessionID != "sess-1" || report.Status != aocontext.StatusCritical || report.TokensUsed != 170000 {
+		t.Errorf("report = %+v", report)
+	}
+
+	out.Reset()
+	output = "table"
+	contextSummarizeCmd.SetIn(strings.NewReader(`{"cwd":"` + tmp + `"}`))
+	contextSummarizeCmd.SetOut(&out)
+	if err := runContextSummarize(contextSummarizeCmd, nil); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	for _, want := range []string{"[file_change] parser.go", "[failing_test] TestParse", "[context] fix the parser"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+
+	tracker, err := aocontext.Load(tmp, "sess-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracker.SummarizationEvents) != 1 {
+		t.Errorf("summarization events = %d, want 1", len(tracker.SummarizationEvents))
+	}
+}
+
+func TestContextCheckpointRejectsBadTestStatus(t *testing.T) {
+	resetContextFlags(t)
+	contextTestStatus = "green"
+	if err := runContextCheckpoint(contextCheckpointCmd, nil); err == nil {
+		t.Error("expected error for invalid --test-status")
+	}
+}
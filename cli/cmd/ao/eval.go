@@ -0,0 +1,487 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/eval"
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// defaultEvalConfigs are the ranking configurations evaluated by default.
+var defaultEvalConfigs = []string{"inject", "inject:lambda=0", "keyword", "vector", "hybrid"}
+
+var (
+	evalK             int
+	evalConfigs       []string
+	evalDataset       string
+	evalSaveDataset   string
+	evalBaseline      string
+	evalWriteBaseline string
+	evalTolerance     float64
+	evalMinReward     float64
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Evaluate knowledge retrieval offline",
+	Long: `Measure how well AgentOps surfaces the knowledge sessions end up using.
+
+Examples:
+  ao eval retrieval
+  ao eval retrieval --baseline .agents/ao/eval/baseline.json`,
+}
+
+var evalRetrievalCmd = &cobra.Command{
+	Use:   "retrieval",
+	Short: "Score inject and search ranking against past sessions",
+	Long: `Replay 'ao inject' and 'ao search' ranking against a frozen snapshot of
+the learnings and report NDCG@k, MRR@k and recall@k per configuration.
+
+Judged queries come from history. A learning is relevant to a session when
+'ao feedback-loop' gave it a positive reward for a citation the transcript
+grounded: applied learnings are graded 2, retrieved ones 1, and learnings
+that were only injected are not judged. The query is the session's first
+user prompt, else its summary, else the query its injection ran with.
+
+The dataset (queries, judgements and the learnings as they were, with their
+ages frozen) can be saved with --save-dataset and replayed with --dataset,
+so results stay comparable as the corpus changes. Save a report with
+--write-baseline, then pass it as --baseline: the command fails when any
+metric drops more than --tolerance below it, which suits CI.
+
+Configurations:
+  inject            composite freshness + utility ranking (λ = 0.5)
+  inject:lambda=X   the same with utility weighted by X
+  keyword           BM25 over the session's query
+  vector            local embeddings
+  hybrid            keyword and vector fused by reciprocal rank
+
+Examples:
+  ao eval retrieval
+  ao eval retrieval --config inject,inject:lambda=1 --k 5
+  ao eval retrieval --save-dataset .agents/ao/eval/dataset.json \
+    --write-baseline .agents/ao/eval/baseline.json
+  ao eval retrieval --dataset .agents/ao/eval/dataset.json \
+    --baseline .agents/ao/eval/baseline.json`,
+	Args: cobra.NoArgs,
+	RunE: runEvalRetrieval,
+}
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+	evalCmd.AddCommand(evalRetrievalCmd)
+	evalRetrievalCmd.Flags().IntVar(&evalK, "k", 10, "Rank cutoff for the metrics")
+	evalRetrievalCmd.Flags().StringSliceVar(&evalConfigs, "config", defaultEvalConfigs, "Ranking configurations to evaluate")
+	evalRetrievalCmd.Flags().StringVar(&evalDataset, "dataset", "", "Replay a saved dataset instead of building one from history")
+	evalRetrievalCmd.Flags().StringVar(&evalSaveDataset, "save-dataset", "", "Save the dataset to this file")
+	evalRetrievalCmd.Flags().StringVar(&evalBaseline, "baseline", "", "Fail if results regress from this report")
+	evalRetrievalCmd.Flags().StringVar(&evalWriteBaseline, "write-baseline", "", "Save the report to this file")
+	evalRetrievalCmd.Flags().Float64Var(&evalTolerance, "tolerance", 0.01, "Allowed drop below the baseline per metric")
+	evalRetrievalCmd.Flags().Float64Var(&evalMinReward, "min-reward", 0, "Judge learnings relevant only above this feedback reward")
+}
+
+// evalRetrievalOutput is the report with the outcome of a baseline check.
+type evalRetrievalOutput struct {
+	*eval.Report
+	Baseline    string            `json:"baseline,omitempty"`
+	Regressions []eval.Regression `json:"regressions,omitempty"`
+}
+
+func runEvalRetrieval(cmd *cobra.Command, args []string) error {
+	if evalK <= 0 {
+		return fmt.Errorf("--k must be positive")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	var dataset *eval.Dataset
+	if evalDataset != "" {
+		dataset, err = eval.LoadDataset(evalDataset)
+	} else {
+		dataset, err = buildRetrievalDataset(cwd, evalMinReward)
+	}
+	if err != nil {
+		return fmt.Errorf("load dataset: %w", err)
+	}
+	if len(dataset.Queries) == 0 {
+		return fmt.Errorf("no judged queries: run 'ao feedback-loop' with transcripts to record grounded citations")
+	}
+
+	if evalSaveDataset != "" {
+		if GetDryRun() {
+			fmt.Printf("[dry-run] Would save dataset to %s\n", evalSaveDataset)
+		} else if err := eval.SaveJSON(evalSaveDataset, dataset); err != nil {
+			return fmt.Errorf("save dataset: %w", err)
+		}
+	}
+
+	report, err := evaluateRetrieval(dataset, evalConfigs, evalK)
+	if err != nil {
+		return err
+	}
+	out := evalRetrievalOutput{Report: report}
+
+	if evalBaseline != "" {
+		baseline, err := eval.LoadReport(evalBaseline)
+		if err != nil {
+			return fmt.Errorf("load baseline: %w", err)
+		}
+		out.Baseline = evalBaseline
+		out.Regressions, err = eval.Compare(baseline, report, evalTolerance)
+		if err != nil {
+			return err
+		}
+		for _, config := range eval.MissingConfigs(baseline, report) {
+			VerbosePrintf("Baseline configuration %s was not evaluated\n", config)
+		}
+	}
+
+	if err := outputEvalRetrieval(cmd.OutOrStdout(), &out); err != nil {
+		return err
+	}
+
+	if evalWriteBaseline != "" {
+		if GetDryRun() {
+			fmt.Printf("[dry-run] Would save baseline to %s\n", evalWriteBaseline)
+		} else if err := eval.SaveJSON(evalWriteBaseline, report); err != nil {
+			return fmt.Errorf("save baseline: %w", err)
+		}
+	}
+
+	if len(out.Regressions) > 0 {
+		return fmt.Errorf("retrieval regressed on %d metric(s)", len(out.Regressions))
+	}
+	return nil
+}
+
+func outputEvalRetrieval(w io.Writer, out *evalRetrievalOutput) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	r := out.Report
+	fmt.Fprintf(w, "Retrieval evaluation: %d queries, %d documents, k=%d (dataset %s)\n\n", r.Queries, r.Documents, r.K, r.Dataset)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CONFIG\tNDCG@%d\tMRR@%d\tRECALL@%d\n", r.K, r.K, r.K) //nolint:errcheck // CLI tabwriter output
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\n", res.Config, res.NDCG, res.MRR, res.Recall) //nolint:errcheck // CLI tabwriter output
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if out.Baseline == "" {
+		return nil
+	}
+	fmt.Fprintln(w)
+	if len(out.Regressions) == 0 {
+		fmt.Fprintf(w, "✓ No regressions against %s\n", out.Baseline)
+		return nil
+	}
+	for _, reg := range out.Regressions {
+		fmt.Fprintf(w, "✗ %s\n", reg)
+	}
+	return nil
+}
+
+// evaluateRetrieval materialises the dataset's snapshot and scores each
+// configuration on it.
+func evaluateRetrieval(d *eval.Dataset, configs []string, k int) (*eval.Report, error) {
+	fingerprint, err := d.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := os.MkdirTemp("", "ao-eval-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(root) //nolint:errcheck // temp snapshot cleanup
+	}()
+	agentsDir := filepath.Join(root, ".agents")
+	if err := d.Materialize(agentsDir); err != nil {
+		return nil, fmt.Errorf("materialize snapshot: %w", err)
+	}
+
+	report := &eval.Report{
+		Dataset:     fingerprint,
+		K:           k,
+		Queries:     len(d.Queries),
+		Documents:   len(d.Documents),
+		EvaluatedAt: time.Now(),
+	}
+	corpus := &evalCorpus{root: root, agentsDir: agentsDir, at: d.SnapshotAt}
+	for _, config := range configs {
+		rank, err := corpus.ranker(config)
+		if err != nil {
+			return nil, err
+		}
+		res, err := eval.Evaluate(config, d.Queries, k, rank)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+// evalCorpus is a materialised snapshot with lazily built search indexes.
+type evalCorpus struct {
+	root      string
+	agentsDir string
+	at        time.Time
+
+	once    sync.Once
+	idx     *search.Index
+	vectors *search.VectorIndex
+	err     error
+}
+
+func (c *evalCorpus) index() (*search.Index, *search.VectorIndex, error) {
+	c.once.Do(func() {
+		c.idx, c.err = search.BuildIndex(c.agentsDir)
+		if c.err == nil {
+			c.vectors = search.BuildVectors(c.idx, search.DefaultVectorDim)
+		}
+	})
+	return c.idx, c.vectors, c.err
+}
+
+// ranker returns the ranking function for a configuration name.
+func (c *evalCorpus) ranker(config string) (eval.Ranker, error) {
+	name, settings, err := eval.SplitConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "inject":
+		lambda := types.DefaultLambda
+		for key, value := range settings {
+			if key != "lambda" {
+				return nil, fmt.Errorf("config %q: unknown setting %q", config, key)
+			}
+			if lambda, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("config %q: lambda: %w", config, err)
+			}
+		}
+		return func(q eval.Query) ([]string, error) {
+			learnings, err := rankLearningsAt(c.root, q.InjectQuery, c.at, lambda)
+			if err != nil {
+				return nil, err
+			}
+			paths := make([]string, 0, len(learnings))
+			for _, l := range learnings {
+				paths = append(paths, l.Source)
+			}
+			return c.relPaths(paths), nil
+		}, nil
+
+	case "keyword", "vector", "hybrid":
+		if len(settings) > 0 {
+			return nil, fmt.Errorf("config %q: %s takes no settings", config, name)
+		}
+		idx, vectors, err := c.index()
+		if err != nil {
+			return nil, fmt.Errorf("index snapshot: %w", err)
+		}
+		var r search.Retriever
+		switch name {
+		case "keyword":
+			r = search.NewKeywordRetriever(idx)
+		case "vector":
+			r = search.NewVectorRetriever(idx, vectors)
+		default:
+			r = search.NewHybridRetriever(search.NewKeywordRetriever(idx), search.NewVectorRetriever(idx, vectors))
+		}
+		return func(q eval.Query) ([]string, error) {
+			// Plain terms, so prompt text is never read as query syntax
+			query := search.PlainQuery(q.Text)
+			if query == "" {
+				return nil, nil
+			}
+			hits, err := r.Retrieve(query, 0)
+			if err != nil {
+				return nil, err
+			}
+			paths := make([]string, 0, len(hits))
+			for _, h := range hits {
+				paths = append(paths, h.Path)
+			}
+			return c.relPaths(paths), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown configuration %q (use inject, inject:lambda=X, keyword, vector, hybrid)", config)
+}
+
+// relPaths converts snapshot paths to the dataset's relative form.
+func (c *evalCorpus) relPaths(paths []string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if rel, ok := eval.RelPath(c.agentsDir, p); ok {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+// buildRetrievalDataset snapshots the learnings under cwd and judges them
+// against past sessions' feedback.
+func buildRetrievalDataset(cwd string, minReward float64) (*eval.Dataset, error) {
+	learningsDir := findLearningsDir(cwd)
+	if learningsDir == "" {
+		return nil, fmt.Errorf("no .agents/learnings directory found")
+	}
+	agentsDir := filepath.Dir(learningsDir)
+	files, err := learningFiles(learningsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &eval.Dataset{Version: eval.DatasetVersion, SnapshotAt: time.Now().UTC().Truncate(time.Second)}
+	inSnapshot := make(map[string]bool, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, ok := eval.RelPath(agentsDir, file)
+		if !ok {
+			continue
+		}
+		d.Documents = append(d.Documents, eval.Document{Path: rel, ModTime: info.ModTime().UTC(), Content: string(content)})
+		inSnapshot[rel] = true
+	}
+
+	citations, err := ratchet.LoadCitations(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("load citations: %w", err)
+	}
+	feedback, err := loadFeedbackEvents(cwd)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("load feedback: %w", err)
+	}
+
+	// The strongest use the transcript shows per session and learning.
+	// Inject-time citations, including the "retrieved" ones older versions
+	// of ao inject recorded, say nothing about whether the learning helped.
+	detected := make(map[string]string)
+	injectQueries := make(map[string]string)
+	for _, c := range citations {
+		key := c.SessionID + "\x00" + filepath.Base(c.ArtifactPath)
+		if c.Source == types.CitationSourceTranscript && citationRanks[c.CitationType] > citationRanks[detected[key]] {
+			detected[key] = c.CitationType
+		}
+		if c.Query != "" && injectQueries[c.SessionID] == "" {
+			injectQueries[c.SessionID] = c.Query
+		}
+	}
+
+	judged := make(map[string]map[string]int)
+	for _, f := range feedback {
+		if f.Reward <= minReward {
+			continue
+		}
+		grade := relevanceGrade(detected[f.SessionID+"\x00"+filepath.Base(f.ArtifactPath)])
+		rel := "learnings/" + filepath.Base(f.ArtifactPath)
+		if grade == 0 || !inSnapshot[rel] {
+			continue
+		}
+		if judged[f.SessionID] == nil {
+			judged[f.SessionID] = make(map[string]int)
+		}
+		judged[f.SessionID][rel] = max(judged[f.SessionID][rel], grade)
+	}
+
+	sessions := make([]string, 0, len(judged))
+	for id := range judged {
+		sessions = append(sessions, id)
+	}
+	sort.Strings(sessions)
+	for _, id := range sessions {
+		text := sessionQueryText(cwd, id)
+		if text == "" {
+			text = injectQueries[id]
+		}
+		if text == "" {
+			VerbosePrintf("Skipping session %s: no prompt, summary or inject query\n", id)
+			continue
+		}
+		d.Queries = append(d.Queries, eval.Query{
+			SessionID:   id,
+			Text:        text,
+			InjectQuery: injectQueries[id],
+			Relevant:    judged[id],
+		})
+	}
+	return d, nil
+}
+
+// relevanceGrade maps the citation type the transcript shows to a relevance
+// grade. Learnings the transcript shows no use of are not judged.
+func relevanceGrade(citationType string) int {
+	switch citationType {
+	case types.CitationTypeApplied:
+		return eval.GradeApplied
+	case types.CitationTypeRetrieved:
+		return eval.GradeRetrieved
+	default:
+		return 0
+	}
+}
+
+// sessionQueryText returns what a session set out to do: the first user
+// prompt of its transcript, else its forged summary.
+func sessionQueryText(cwd, sessionID string) string {
+	if path, err := findTranscriptBySessionID(sessionID); err == nil {
+		if prompt := firstUserPrompt(path); prompt != "" {
+			return prompt
+		}
+	}
+
+	fs := storage.NewFileStorage(storage.WithBaseDir(filepath.Join(cwd, storage.DefaultBaseDir)))
+	if s, err := fs.ReadSession(sessionID); err == nil && !strings.HasPrefix(s.Summary, "Session from ") {
+		return s.Summary
+	}
+	return ""
+}
+
+// firstUserPrompt returns the first user message with text in a transcript.
+func firstUserPrompt(path string) string {
+	result, _, err := parser.ParseTranscript(path, parser.NewParser())
+	if err != nil {
+		return ""
+	}
+	for _, msg := range result.Messages {
+		if (msg.Role == "user" || msg.Type == "user") && strings.TrimSpace(msg.Content) != "" {
+			return truncateText(strings.TrimSpace(msg.Content), 500)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/eval"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestBuildAndEvaluateRetrievalDataset(t *testing.T) {
+	tmp := chdirTemp(t)
+	t.Setenv("HOME", tmp)
+
+	learningsDir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(learningsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{}
+	for name, content := range map[string]string{
+		"mutex.md":   "# Mutex\n\nHold the cache mutex while refreshing entries to avoid a deadlock.\n",
+		"backoff.md": "# Backoff\n\nRetry flaky network calls with jittered backoff.\n",
+		"linter.md":  "# Linter\n\nPin tool versions in CI.\n",
+	} {
+		paths[name] = filepath.Join(learningsDir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The session's first prompt is its query
+	sessionID := "session-20260301-090000"
+	projectDir := filepath.Join(tmp, ".claude", "projects", "repo")
+	if err := os.MkdirAll(projectDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	transcript := `{"type":"user","sessionId":"s1","message":{"role":"user","content":"Fix the deadlock in the cache refresh"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(projectDir, sessionID+".jsonl"), []byte(transcript), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	injected := []learning{
+		{ID: "backoff.md", Source: paths["backoff.md"]},
+		{ID: "mutex.md", Source: paths["mutex.md"]},
+	}
+	if err := recordCitations(tmp, injected, sessionID, "cache"); err != nil {
+		t.Fatal(err)
+	}
+	// Only the transcript shows a use; the "retrieved" citation older
+	// versions of ao inject recorded does not
+	for _, c := range []types.CitationEvent{
+		{ArtifactPath: paths["mutex.md"], SessionID: sessionID, CitationType: types.CitationTypeApplied, Source: types.CitationSourceTranscript},
+		{ArtifactPath: paths["backoff.md"], SessionID: sessionID, CitationType: types.CitationTypeRetrieved},
+	} {
+		if err := ratchet.RecordCitation(tmp, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seedFeedbackLedger(t, tmp, []FeedbackEvent{
+		{SessionID: sessionID, ArtifactPath: paths["mutex.md"], Reward: 1, CitationType: types.CitationTypeApplied},
+		{SessionID: sessionID, ArtifactPath: paths["backoff.md"], Reward: 0.8, CitationType: types.CitationTypeRetrieved},
+	})
+
+	d, err := buildRetrievalDataset(tmp, 0)
+	if err != nil {
+		t.Fatalf("buildRetrievalDataset: %v", err)
+	}
+	if len(d.Documents) != 3 || len(d.Queries) != 1 {
+		t.Fatalf("dataset has %d documents and %d queries, want 3 and 1", len(d.Documents), len(d.Queries))
+	}
+	q := d.Queries[0]
+	if q.Text != "Fix the deadlock in the cache refresh" || q.InjectQuery != "cache" {
+		t.Errorf("query = %+v, want the first prompt and the inject query", q)
+	}
+	if len(q.Relevant) != 1 || q.Relevant["learnings/mutex.md"] != eval.GradeApplied {
+		t.Errorf("relevant = %v, want only the applied learning", q.Relevant)
+	}
+
+	report, err := evaluateRetrieval(d, defaultEvalConfigs, 10)
+	if err != nil {
+		t.Fatalf("evaluateRetrieval: %v", err)
+	}
+	if len(report.Results) != len(defaultEvalConfigs) {
+		t.Fatalf("got %d results, want %d", len(report.Results), len(defaultEvalConfigs))
+	}
+	for _, r := range report.Results {
+		if r.Recall != 1 {
+			t.Errorf("%s: recall %.2f, want 1", r.Config, r.Recall)
+		}
+	}
+
+	// A saved dataset replays the same scores after the corpus changes
+	datasetPath := filepath.Join(tmp, "dataset.json")
+	if err := eval.SaveJSON(datasetPath, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(paths["mutex.md"]); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := eval.LoadDataset(datasetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := evaluateRetrieval(loaded, defaultEvalConfigs, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Dataset != report.Dataset {
+		t.Errorf("fingerprint %s, want %s", replayed.Dataset, report.Dataset)
+	}
+	regressions, err := eval.Compare(report, replayed, 0)
+	if err != nil || len(regressions) != 0 {
+		t.Errorf("replay regressed: %v %v", regressions, err)
+	}
+
+	if _, err := (&evalCorpus{}).ranker("inject:alpha=1"); err == nil {
+		t.Error("ranker accepted an unknown setting")
+	}
+}
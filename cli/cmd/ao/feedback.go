@@ -15,10 +15,11 @@ import (
 )
 
 var (
-	feedbackReward  float64
-	feedbackAlpha   float64
-	feedbackHelpful bool
-	feedbackHarmful bool
+	feedbackReward    float64
+	feedbackAlpha     float64
+	feedbackHelpful   bool
+	feedbackHarmful   bool
+	feedbackSessionID string
 )
 
 var feedbackCmd = &cobra.Command{
@@ -42,13 +43,20 @@ CASS Integration:
   - Tracks helpful_count and harmful_count for maturity transitions
   - Repeated harmful feedback can promote to anti-pattern status
 
+Feedback is recorded in the ledger (.agents/ao/feedback.jsonl). With
+--session, a learning takes one manual reward per session, so repeating the
+command does not count twice. Use 'ao feedback replay' to recompute
+utilities from the ledger.
+
 Examples:
   ao feedback L001 --helpful        # Learning was helpful (same as --reward 1.0)
   ao feedback L001 --harmful        # Learning was harmful (same as --reward 0.0)
   ao feedback L001 --reward 1.0     # Learning was helpful (success)
   ao feedback L001 --reward 0.0     # Learning was not helpful (failure)
   ao feedback L001 --reward 0.75    # Partial success
-  ao feedback L001 --reward 1.0 --alpha 0.2   # Faster learning rate`,
+  ao feedback L001 --reward 1.0 --alpha 0.2   # Faster learning rate
+  ao feedback L001 --helpful --session session-20260125-120000
+  ao feedback replay --alpha 0.2              # Re-derive all utilities`,
 	Args: cobra.ExactArgs(1),
 	RunE: runFeedback,
 }
@@ -59,6 +67,7 @@ func init() {
 	feedbackCmd.Flags().Float64Var(&feedbackAlpha, "alpha", types.DefaultAlpha, "EMA learning rate")
 	feedbackCmd.Flags().BoolVar(&feedbackHelpful, "helpful", false, "Mark as helpful (shortcut for --reward 1.0)")
 	feedbackCmd.Flags().BoolVar(&feedbackHarmful, "harmful", false, "Mark as harmful (shortcut for --reward 0.0)")
+	feedbackCmd.Flags().StringVar(&feedbackSessionID, "session", "", "Session the feedback is for (default: a new one)")
 	// Note: reward is no longer required since --helpful/--harmful can be used instead
 }
 
@@ -108,12 +117,6 @@ func runFeedback(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Update utility
-	oldUtility, newUtility, err := updateLearningUtility(learningPath, feedbackReward, feedbackAlpha)
-	if err != nil {
-		return fmt.Errorf("update utility: %w", err)
-	}
-
 	// Determine feedback type for display
 	feedbackType := "custom"
 	if feedbackHelpful {
@@ -121,6 +124,33 @@ func runFeedback(cmd *cobra.Command, args []string) error {
 	} else if feedbackHarmful {
 		feedbackType = "harmful"
 	}
+	event := FeedbackEvent{
+		SessionID:    canonicalSessionID(feedbackSessionID),
+		ArtifactPath: learningPath,
+		Reward:       feedbackReward,
+		Alpha:        feedbackAlpha,
+		Source:       RewardSourceManual,
+	}
+	if feedbackType != "custom" {
+		event.Verdict = feedbackType
+	}
+
+	// Update utility through the ledger
+	ledger, err := openFeedbackLedger(cwd)
+	if err != nil {
+		return err
+	}
+	defer ledger.Close() //nolint:errcheck // lock released on exit regardless
+	event, applied, err := ledger.apply(event)
+	if err != nil {
+		return fmt.Errorf("update utility: %w", err)
+	}
+	if !applied {
+		fmt.Printf("%s already has feedback for session %s (reward=%.2f); not applied again\n",
+			learningID, event.SessionID, event.Reward)
+		return nil
+	}
+	oldUtility, newUtility := event.UtilityBefore, event.UtilityAfter
 
 	switch GetOutput() {
 	case "json":
@@ -199,15 +229,8 @@ func findLearningFile(baseDir, learningID string) (string, error) {
 	return "", fmt.Errorf("learning not found: %s", learningID)
 }
 
-// updateLearningUtility applies the EMA update rule and writes back.
-func updateLearningUtility(path string, reward, alpha float64) (oldUtility, newUtility float64, err error) {
-	if strings.HasSuffix(path, ".jsonl") {
-		return updateJSONLUtility(path, reward, alpha)
-	}
-	return updateMarkdownUtility(path, reward, alpha)
-}
-
-// updateJSONLUtility updates utility in a JSONL file.
+// updateJSONLUtility updates utility in a JSONL file. Rewards are applied
+// through feedbackLedger.apply, which records them.
 // Also tracks helpful_count and harmful_count for CASS maturity transitions.
 func updateJSONLUtility(path string, reward, alpha float64) (oldUtility, newUtility float64, err error) {
 	// Read the file
@@ -280,6 +303,7 @@ func updateJSONLUtility(path string, reward, alpha float64) (oldUtility, newUtil
 }
 
 // updateMarkdownUtility updates utility in a markdown file with front matter.
+// Rewards are applied through feedbackLedger.apply, which records them.
 func updateMarkdownUtility(path string, reward, alpha float64) (oldUtility, newUtility float64, err error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/outcome"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const (
+	// creditTraceDecay is the eligibility left to a learning last used at
+	// the start of the session; one used at its end keeps all of it.
+	creditTraceDecay = 0.8
+
+	// creditFailureFactor scales the credit of learnings cited before a
+	// failing test.
+	creditFailureFactor = 0.5
+
+	// creditCommitBonus scales the credit of learnings that overlap the
+	// files the session committed.
+	creditCommitBonus = 1.25
+
+	// creditMinFileName keeps short file names like "a.go" from matching
+	// learning prose.
+	creditMinFileName = 5
+)
+
+// CreditAssignment is how a learning's share of a session's reward was
+// computed. The learning's reward is the product of the factors, clamped to
+// [0, 1], so maturity decisions built on it can be traced back to the
+// session's events.
+type CreditAssignment struct {
+	// SessionReward is the session's outcome reward.
+	SessionReward float64 `json:"session_reward"`
+
+	// TypeWeight weights how the learning was used: applied, retrieved or
+	// only injected.
+	TypeWeight float64 `json:"type_weight"`
+
+	// Trace is the eligibility left from the learning's last use to the end
+	// of the session.
+	Trace float64 `json:"trace"`
+
+	// Timing is reduced when a test failed after the learning was cited.
+	Timing float64 `json:"timing"`
+
+	// Relevance is raised when the learning overlaps the committed files.
+	Relevance float64 `json:"relevance"`
+
+	// Reasons explain the factors that are not neutral.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Reward returns the learning's reward.
+func (c CreditAssignment) Reward() float64 {
+	return clampReward(c.SessionReward * c.TypeWeight * c.Trace * c.Timing * c.Relevance)
+}
+
+func (c CreditAssignment) String() string {
+	s := fmt.Sprintf("%.2f session × %.2f type × %.2f trace × %.2f timing × %.2f relevance",
+		c.SessionReward, c.TypeWeight, c.Trace, c.Timing, c.Relevance)
+	if len(c.Reasons) > 0 {
+		s += " (" + strings.Join(c.Reasons, "; ") + ")"
+	}
+	return s
+}
+
+// creditTimeline is what a session's transcript shows about when learnings
+// were used relative to its tests and commit.
+type creditTimeline struct {
+	messages     int
+	uses         map[string]citationUse // by learning file name
+	testFailures []int                  // message indices of failed test runs
+	commitAt     int                    // last git commit, -1 without one
+	changedFiles []string
+	written      map[string]map[string]bool // shingles written to each file before the commit
+}
+
+// buildCreditTimeline scans a session's tool calls for failing test runs,
+// the last successful commit and the files written before it.
+func buildCreditTimeline(messages []types.TranscriptMessage, uses map[string]citationUse) *creditTimeline {
+	tl := &creditTimeline{
+		messages: len(messages),
+		uses:     make(map[string]citationUse, len(uses)),
+		commitAt: -1,
+		written:  make(map[string]map[string]bool),
+	}
+	for path, use := range uses {
+		tl.uses[filepath.Base(path)] = use
+	}
+
+	for _, call := range outcome.Calls(messages) {
+		if report, ok := outcome.ParseTestOutput(call.Output); ok && report.Failed > 0 {
+			tl.testFailures = append(tl.testFailures, call.ResultMessage)
+		}
+		if strings.Contains(call.Command, "git commit") && call.Succeeded() {
+			tl.commitAt = call.Message
+		}
+	}
+	if tl.commitAt < 0 {
+		return tl
+	}
+
+	for _, msg := range messages[:tl.commitAt] {
+		for _, tool := range msg.Tools {
+			file, _ := tool.Input["file_path"].(string)
+			if file == "" {
+				continue
+			}
+			if tl.written[file] == nil {
+				tl.written[file] = make(map[string]bool)
+				tl.changedFiles = append(tl.changedFiles, file)
+			}
+			for _, key := range []string{"content", "new_string"} {
+				if text, ok := tool.Input[key].(string); ok {
+					for s := range wordShingles(text) {
+						tl.written[file][s] = true
+					}
+				}
+			}
+		}
+	}
+	return tl
+}
+
+// assignCredit splits the session reward for one cited learning. Without a
+// timeline only the citation type is weighted.
+func assignCredit(tl *creditTimeline, citation types.CitationEvent, learningPath string, reward float64) CreditAssignment {
+	c := CreditAssignment{
+		SessionReward: reward,
+		TypeWeight:    citationWeight(citation.CitationType),
+		Trace:         1,
+		Timing:        1,
+		Relevance:     1,
+	}
+	if tl == nil || tl.messages == 0 {
+		return c
+	}
+
+	// Learnings the transcript never shows in use were injected up front
+	use := tl.uses[filepath.Base(learningPath)]
+	if tl.messages > 1 {
+		remaining := 1 - float64(use.last)/float64(tl.messages-1)
+		c.Trace = math.Pow(creditTraceDecay, remaining)
+		if remaining > 0 {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("last used at message %d of %d", use.last+1, tl.messages))
+		}
+	}
+
+	for _, at := range tl.testFailures {
+		if at >= use.first {
+			c.Timing = creditFailureFactor
+			c.Reasons = append(c.Reasons, fmt.Sprintf("cited before a failing test at message %d", at+1))
+			break
+		}
+	}
+
+	if file := tl.committedOverlap(learningPath); file != "" {
+		c.Relevance = creditCommitBonus
+		c.Reasons = append(c.Reasons, "overlaps committed "+file)
+	}
+	return c
+}
+
+// committedOverlap returns the committed file a learning relates to: one it
+// names, or one the session wrote enough of the learning's text into.
+func (tl *creditTimeline) committedOverlap(learningPath string) string {
+	if len(tl.changedFiles) == 0 {
+		return ""
+	}
+	content, err := os.ReadFile(learningPath)
+	if err != nil {
+		return ""
+	}
+	text := string(content)
+	shingles := wordShingles(text)
+	for _, file := range tl.changedFiles {
+		name := filepath.Base(file)
+		if len(name) >= creditMinFileName && strings.Contains(text, name) {
+			return name
+		}
+		shared := 0
+		for s := range shingles {
+			if tl.written[file][s] {
+				shared++
+			}
+		}
+		if shared >= citationMinOverlap {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// creditTranscript reads a learning, hits a failing test, applies another
+// learning to fix it and commits the change.
+const creditTranscript = `{"type":"user","sessionId":"s1","message":{"role":"user","content":"Fix the stale cache entries"}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"tool_use","name":"Read","input":{"file_path":".agents/learnings/early.md"}}]}}
+{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","content":"Check cache.go for stale entries."}]}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":"Exit code 1\n--- FAIL: TestCacheRefresh (0.00s)\nFAIL\nFAIL\texample.com/cache\t0.01s"}]}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"text","text":"Following L-late-fix-7, refresh under the lock."},{"type":"tool_use","name":"Edit","input":{"file_path":"/repo/cache.go","new_string":"c.mu.Lock()\nc.refresh()"}}]}}
+{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{"command":"git commit -am 'Fix stale cache'"}}]}}
+{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","content":"[main abc123] Fix stale cache\n 1 file changed"}]}}
+`
+
+func TestAssignCredit(t *testing.T) {
+	tmp := t.TempDir()
+	learningsDir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(learningsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{}
+	for name, content := range map[string]string{
+		"early.md":    "# Early\n\nCheck cache.go for stale entries.\n",
+		"late.jsonl":  `{"id":"L-late-fix-7","title":"Late","summary":"Refresh the cache under its lock.","utility":0.5}`,
+		"injected.md": "# Injected\n\nKeep migrations backwards compatible.\n",
+	} {
+		paths[name] = filepath.Join(learningsDir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transcript := filepath.Join(tmp, "session.jsonl")
+	if err := os.WriteFile(transcript, []byte(creditTranscript), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, timeline, err := detectSessionCitations(tmp, transcript, "s1")
+	if err != nil {
+		t.Fatalf("detectSessionCitations: %v", err)
+	}
+	if len(events) != 2 || timeline.commitAt != 6 || len(timeline.testFailures) != 1 {
+		t.Fatalf("events=%v commit=%d failures=%v", events, timeline.commitAt, timeline.testFailures)
+	}
+
+	tests := []struct {
+		name                     string
+		citationType             string
+		trace, timing, relevance float64
+	}{
+		// Read before the failing test, names the committed file
+		{"early.md", types.CitationTypeRetrieved, math.Pow(creditTraceDecay, 6.0/7), creditFailureFactor, creditCommitBonus},
+		// Applied after the failure, close to the end
+		{"late.jsonl", types.CitationTypeApplied, math.Pow(creditTraceDecay, 2.0/7), 1, 1},
+		// Never used after injection
+		{"injected.md", types.CitationTypeInjected, creditTraceDecay, creditFailureFactor, 1},
+	}
+	for _, tt := range tests {
+		c := assignCredit(timeline, types.CitationEvent{CitationType: tt.citationType}, paths[tt.name], 1)
+		if math.Abs(c.Trace-tt.trace) > 1e-9 || c.Timing != tt.timing || c.Relevance != tt.relevance {
+			t.Errorf("%s: credit %s, want trace %.2f timing %.2f relevance %.2f", tt.name, c, tt.trace, tt.timing, tt.relevance)
+		}
+		want := citationWeight(tt.citationType) * tt.trace * tt.timing * tt.relevance
+		if math.Abs(c.Reward()-want) > 1e-9 {
+			t.Errorf("%s: reward %.3f, want %.3f", tt.name, c.Reward(), want)
+		}
+		if len(c.Reasons) == 0 {
+			t.Errorf("%s: no reasons for %s", tt.name, c)
+		}
+	}
+
+	// The applied learning outranks the ones used before the failure
+	late := assignCredit(timeline, types.CitationEvent{CitationType: types.CitationTypeApplied}, paths["late.jsonl"], 1).Reward()
+	early := assignCredit(timeline, types.CitationEvent{CitationType: types.CitationTypeRetrieved}, paths["early.md"], 1).Reward()
+	if late <= early {
+		t.Errorf("late applied learning got %.3f, early read one %.3f", late, early)
+	}
+
+	// Without a transcript only the citation type counts
+	c := assignCredit(nil, types.CitationEvent{CitationType: types.CitationTypeRetrieved}, paths["early.md"], 0.5)
+	if c.Reward() != 0.5*citationWeight(types.CitationTypeRetrieved) || len(c.Reasons) != 0 {
+		t.Errorf("credit without timeline = %s", c)
+	}
+}
+
+func TestRecentLearningFeedback(t *testing.T) {
+	tmp := t.TempDir()
+	credit := &CreditAssignment{SessionReward: 1, TypeWeight: 1, Trace: 1, Timing: 1, Relevance: 1}
+	seedFeedbackLedger(t, tmp, []FeedbackEvent{
+		{SessionID: "s1", ArtifactPath: "/a/.agents/learnings/x.md", Reward: 0.2},
+		{SessionID: "s2", ArtifactPath: "/a/.agents/learnings/y.md", Reward: 0.4},
+		{SessionID: "s3", ArtifactPath: "/a/.agents/learnings/x.md", Reward: 1, Credit: credit},
+	})
+
+	got := recentLearningFeedback(tmp, filepath.Join(tmp, ".agents", "learnings", "x.md"), 5)
+	if len(got) != 2 || got[0].SessionID != "s3" || got[0].Credit == nil || got[1].SessionID != "s1" {
+		t.Errorf("recentLearningFeedback() = %+v, want s3 then s1", got)
+	}
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Reward sources. The ledger keys a reward by session, learning and source,
+// so the feedback loop and a manual or task reward for the same session do
+// not hide each other, while the same source never rewards twice. Task
+// rewards are keyed per task, as "task:<task-id>".
+const (
+	RewardSourceFeedbackLoop = "feedback-loop"
+	RewardSourceManual       = "manual"
+	RewardSourceTask         = "task"
+
+	// RewardSourceBaseline marks the rewards a learning had before the
+	// ledger recorded them, imported by ao feedback replay.
+	RewardSourceBaseline = "baseline"
+)
+
+// feedbackLedger is the feedback log (.agents/ao/feedback.jsonl) held open
+// under an exclusive lock. Every utility update goes through it: a reward is
+// applied only if the log does not already record it, and is appended to the
+// log when it is.
+type feedbackLedger struct {
+	f       *os.File
+	events  []FeedbackEvent
+	applied map[string]int // ledger key → index in events
+
+	// torn is set while the log ends in a line without its newline; the
+	// next append ends it first so the new event gets a line of its own.
+	torn bool
+}
+
+// ledgerKey identifies a reward. Events logged before sources were recorded
+// came from the feedback loop.
+func ledgerKey(e FeedbackEvent) string {
+	source := e.Source
+	if source == "" {
+		source = RewardSourceFeedbackLoop
+	}
+	return e.SessionID + "\x00" + filepath.Base(e.ArtifactPath) + "\x00" + source
+}
+
+// openFeedbackLedger opens and locks the feedback log under baseDir, waiting
+// for other writers to finish. Close releases it.
+func openFeedbackLedger(baseDir string) (*feedbackLedger, error) {
+	path := filepath.Join(baseDir, FeedbackFilePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create feedback directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open feedback ledger: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close() //nolint:errcheck // already failing
+		return nil, fmt.Errorf("lock feedback ledger: %w", err)
+	}
+
+	l := &feedbackLedger{f: f, applied: make(map[string]int)}
+	err = scanFeedbackEvents(f, func(event FeedbackEvent) {
+		if _, ok := l.applied[ledgerKey(event)]; !ok {
+			l.applied[ledgerKey(event)] = len(l.events)
+		}
+		l.events = append(l.events, event)
+	})
+	if err == nil {
+		l.torn, err = endsTorn(f)
+	}
+	if err != nil {
+		_ = l.Close() //nolint:errcheck // already failing
+		return nil, fmt.Errorf("read feedback ledger: %w", err)
+	}
+	return l, nil
+}
+
+// scanFeedbackEvents calls fn for each event in r, one per line. Lines that
+// do not decode (a torn write, or a field of the wrong type) are skipped, so
+// events logged after them still count.
+func scanFeedbackEvents(r io.Reader, fn func(FeedbackEvent)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event FeedbackEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		fn(event)
+	}
+	return scanner.Err()
+}
+
+// endsTorn reports whether f's last line is missing its newline, as a write
+// cut short leaves it.
+func endsTorn(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// Close unlocks and closes the ledger.
+func (l *feedbackLedger) Close() error {
+	_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
+	return l.f.Close()
+}
+
+// apply updates the learning's utility with the event's reward and records
+// it, unless the ledger already holds a reward with the same key. It
+// returns the recorded event and whether this call applied it.
+func (l *feedbackLedger) apply(event FeedbackEvent) (FeedbackEvent, bool, error) {
+	key := ledgerKey(event)
+	if i, ok := l.applied[key]; ok {
+		return l.events[i], false, nil
+	}
+
+	// The EMA update rule, written back to the learning
+	update := updateMarkdownUtility
+	if strings.HasSuffix(event.ArtifactPath, ".jsonl") {
+		update = updateJSONLUtility
+	}
+	oldUtility, newUtility, err := update(event.ArtifactPath, event.Reward, event.Alpha)
+	if err != nil {
+		return event, false, err
+	}
+	event.UtilityBefore, event.UtilityAfter = oldUtility, newUtility
+	if event.RecordedAt.IsZero() {
+		event.RecordedAt = time.Now()
+	}
+
+	if err := l.append(event); err != nil {
+		return event, false, err
+	}
+	return event, true, nil
+}
+
+// append records an event without touching the learning.
+func (l *feedbackLedger) append(event FeedbackEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if l.torn {
+		data = append([]byte{'\n'}, data...)
+	}
+	if _, err := l.f.Write(data); err != nil {
+		return fmt.Errorf("write feedback event: %w", err)
+	}
+	l.torn = false
+	key := ledgerKey(event)
+	if _, ok := l.applied[key]; !ok {
+		l.applied[key] = len(l.events)
+	}
+	l.events = append(l.events, event)
+	return nil
+}
+
+// utilityState is a learning's reward history folded into its utility
+// fields.
+type utilityState struct {
+	Utility      float64
+	RewardCount  int
+	HelpfulCount int
+	HarmfulCount int
+	LastReward   float64
+	LastRewardAt time.Time
+}
+
+// RewardBaseline holds the counts of a baseline event: the rewards a
+// learning had before the ledger recorded them. The event's UtilityAfter is
+// the utility they left.
+type RewardBaseline struct {
+	RewardCount  int `json:"reward_count"`
+	HelpfulCount int `json:"helpful_count,omitempty"`
+	HarmfulCount int `json:"harmful_count,omitempty"`
+}
+
+// replayUtility folds rewards into a utility, starting from the learning's
+// baseline or, without one, from InitialUtility. A non-zero alpha overrides
+// the rate each reward was recorded with.
+func replayUtility(events []FeedbackEvent, alpha float64) utilityState {
+	s := utilityState{Utility: types.InitialUtility}
+	for _, e := range events {
+		if e.Source == RewardSourceBaseline && e.Baseline != nil {
+			s.Utility = e.UtilityAfter
+			s.RewardCount = e.Baseline.RewardCount
+			s.HelpfulCount = e.Baseline.HelpfulCount
+			s.HarmfulCount = e.Baseline.HarmfulCount
+		}
+	}
+	for _, e := range events {
+		if e.Source == RewardSourceBaseline {
+			continue
+		}
+		a := alpha
+		if a == 0 {
+			a = e.Alpha
+		}
+		if a == 0 {
+			a = types.DefaultAlpha
+		}
+		s.Utility = (1-a)*s.Utility + a*e.Reward
+		s.RewardCount++
+		switch e.Verdict {
+		case "helpful":
+			s.HelpfulCount++
+		case "harmful":
+			s.HarmfulCount++
+		}
+		s.LastReward, s.LastRewardAt = e.Reward, e.RecordedAt
+	}
+	return s
+}
+
+// setLearningUtility overwrites a learning's utility fields with s.
+func setLearningUtility(path string, s utilityState) error {
+	if strings.HasSuffix(path, ".jsonl") {
+		return setJSONLUtility(path, s)
+	}
+	return setMarkdownUtility(path, s)
+}
+
+func setJSONLUtility(path string, s utilityState) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(content), "\n")
+	if strings.TrimSpace(lines[0]) == "" {
+		return fmt.Errorf("empty JSONL file")
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &data); err != nil {
+		return fmt.Errorf("parse JSONL: %w", err)
+	}
+
+	data["utility"] = s.Utility
+	data["reward_count"] = s.RewardCount
+	data["last_reward"] = s.LastReward
+	data["last_reward_at"] = s.LastRewardAt.Format(time.RFC3339)
+	data["confidence"] = 1.0 - (1.0 / (1.0 + float64(s.RewardCount)/5.0))
+	for field, count := range map[string]int{"helpful_count": s.HelpfulCount, "harmful_count": s.HarmfulCount} {
+		if _, ok := data[field]; ok || count > 0 {
+			data[field] = count
+		}
+	}
+
+	newJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	lines[0] = string(newJSON)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func setMarkdownUtility(path string, s utilityState) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fields := map[string]string{
+		"utility":        fmt.Sprintf("%.4f", s.Utility),
+		"last_reward":    fmt.Sprintf("%.2f", s.LastReward),
+		"reward_count":   strconv.Itoa(s.RewardCount),
+		"last_reward_at": s.LastRewardAt.Format(time.RFC3339),
+	}
+
+	lines := strings.Split(string(content), "\n")
+	body := lines
+	var frontMatter []string
+	if strings.TrimSpace(lines[0]) == "---" {
+		end := -1
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				end = i
+				break
+			}
+		}
+		if end == -1 {
+			return fmt.Errorf("malformed front matter: no closing ---")
+		}
+		frontMatter, body = lines[1:end], lines[end+1:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	for _, line := range updateFrontMatterFields(frontMatter, fields) {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("---\n")
+	sb.WriteString(strings.Join(body, "\n"))
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+var feedbackReplayAlpha float64
+
+var feedbackReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Recompute learning utilities from the feedback ledger",
+	Long: `Recompute every rewarded learning's utility from scratch by replaying the
+feedback ledger (.agents/ao/feedback.jsonl) in order, starting each learning
+at utility 0.5.
+
+Every reward is applied once per session, learning and source, so replaying
+also undoes rewards that older versions applied twice. Use --alpha to
+re-derive utilities with a different learning rate; by default each reward
+uses the rate it was recorded with. Learnings with no ledger entries are
+left unchanged.
+
+Rewards given before the ledger recorded them (e.g. by older versions of
+ao feedback) are kept: the first replay of such a learning logs its utility
+and reward, helpful and harmful counts from before its first ledger entry as
+a baseline, and replays start from it. A learning with more rewards than
+its ledger entries and baseline account for is left unchanged.
+
+Examples:
+  ao feedback replay
+  ao feedback replay --alpha 0.2
+  ao feedback replay --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runFeedbackReplay,
+}
+
+func init() {
+	feedbackCmd.AddCommand(feedbackReplayCmd)
+	feedbackReplayCmd.Flags().Float64Var(&feedbackReplayAlpha, "alpha", 0, "EMA learning rate for every reward (0 = as recorded)")
+}
+
+// replayResult is one learning's utility before and after a replay.
+type replayResult struct {
+	Learning string  `json:"learning"`
+	Path     string  `json:"path,omitempty"`
+	Rewards  int     `json:"rewards"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
+	Missing  bool    `json:"missing,omitempty"`
+
+	// Baseline is the number of unlogged rewards imported as a baseline.
+	Baseline int `json:"baseline,omitempty"`
+
+	// Unlogged is the number of rewards the ledger does not account for;
+	// the learning is left unchanged.
+	Unlogged int `json:"unlogged,omitempty"`
+}
+
+func runFeedbackReplay(cmd *cobra.Command, args []string) error {
+	if feedbackReplayAlpha < 0 || feedbackReplayAlpha > 1 {
+		return fmt.Errorf("alpha must be between 0 and 1, got: %f", feedbackReplayAlpha)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	ledger, err := openFeedbackLedger(cwd)
+	if err != nil {
+		return err
+	}
+	defer ledger.Close() //nolint:errcheck // lock released on exit regardless
+
+	results, err := replayFeedbackLedger(cwd, ledger, feedbackReplayAlpha, GetDryRun())
+	if err != nil {
+		return err
+	}
+
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	if len(results) == 0 {
+		fmt.Println("Feedback ledger is empty")
+		return nil
+	}
+	if GetDryRun() {
+		fmt.Println("[dry-run] Would set utilities:")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEARNING\tREWARDS\tBEFORE\tAFTER") //nolint:errcheck // CLI tabwriter output
+	for _, r := range results {
+		if r.Missing {
+			fmt.Fprintf(w, "%s\t%d\t-\t(learning not found)\n", r.Learning, r.Rewards) //nolint:errcheck // CLI tabwriter output
+			continue
+		}
+		if r.Unlogged > 0 {
+			fmt.Fprintf(w, "%s\t%d\t%.3f\t(unchanged: %d rewards not in the ledger)\n", r.Learning, r.Rewards, r.Before, r.Unlogged) //nolint:errcheck // CLI tabwriter output
+			continue
+		}
+		if r.Baseline > 0 {
+			fmt.Fprintf(w, "%s\t%d+%d\t%.3f\t%.3f\n", r.Learning, r.Rewards, r.Baseline, r.Before, r.After) //nolint:errcheck // CLI tabwriter output
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.3f\t%.3f\n", r.Learning, r.Rewards, r.Before, r.After) //nolint:errcheck // CLI tabwriter output
+	}
+	return w.Flush()
+}
+
+// replayFeedbackLedger recomputes the utility of every learning in the
+// ledger and, unless dryRun, writes it back.
+func replayFeedbackLedger(cwd string, ledger *feedbackLedger, alpha float64, dryRun bool) ([]replayResult, error) {
+	byLearning := make(map[string][]FeedbackEvent)
+	paths := make(map[string]string)
+	logged := make(map[string][]FeedbackEvent) // including duplicates
+	for i, e := range ledger.events {
+		name := filepath.Base(e.ArtifactPath)
+		logged[name] = append(logged[name], e)
+		if ledger.applied[ledgerKey(e)] != i {
+			continue // A duplicate from before the ledger deduplicated
+		}
+		byLearning[name] = append(byLearning[name], e)
+		paths[name] = e.ArtifactPath
+	}
+
+	names := make([]string, 0, len(byLearning))
+	for name := range byLearning {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]replayResult, 0, len(names))
+	for _, name := range names {
+		r := replayResult{Learning: name, Rewards: len(byLearning[name])}
+		path, err := findLearningFile(cwd, name)
+		if err != nil {
+			if _, statErr := os.Stat(paths[name]); statErr != nil {
+				r.Missing = true
+				results = append(results, r)
+				continue
+			}
+			path = paths[name]
+		}
+		r.Path = path
+
+		l, err := parseLearningFile(path)
+		if err != nil {
+			return results, fmt.Errorf("read %s: %w", name, err)
+		}
+		r.Before = l.Utility
+		if r.Before == 0 {
+			r.Before = types.InitialUtility
+		}
+
+		baseline, err := unloggedRewards(path, l, logged[name])
+		if err != nil {
+			return results, fmt.Errorf("read %s: %w", name, err)
+		}
+		if baseline != nil {
+			if hasBaseline(byLearning[name]) {
+				r.Unlogged = baseline.Baseline.RewardCount
+				results = append(results, r)
+				continue
+			}
+			r.Baseline = baseline.Baseline.RewardCount
+			byLearning[name] = append(byLearning[name], *baseline)
+			if !dryRun {
+				if err := ledger.append(*baseline); err != nil {
+					return results, err
+				}
+			}
+		}
+
+		state := replayUtility(byLearning[name], alpha)
+		r.After = state.Utility
+		if !dryRun {
+			if err := setLearningUtility(path, state); err != nil {
+				return results, fmt.Errorf("update %s: %w", name, err)
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// unloggedRewards returns a baseline event for the rewards a learning has
+// beyond those its ledger entries (logged, duplicates included) account
+// for, or nil if there are none. Its utility is the one the first ledger
+// entry started from.
+func unloggedRewards(path string, l learning, logged []FeedbackEvent) (*FeedbackEvent, error) {
+	var counted RewardBaseline
+	var first *FeedbackEvent
+	for i, e := range logged {
+		if e.Source == RewardSourceBaseline {
+			if e.Baseline != nil {
+				counted.RewardCount += e.Baseline.RewardCount
+				counted.HelpfulCount += e.Baseline.HelpfulCount
+				counted.HarmfulCount += e.Baseline.HarmfulCount
+			}
+			continue
+		}
+		if first == nil {
+			first = &logged[i]
+		}
+		counted.RewardCount++
+		switch e.Verdict {
+		case "helpful":
+			counted.HelpfulCount++
+		case "harmful":
+			counted.HarmfulCount++
+		}
+	}
+	if l.RewardCount <= counted.RewardCount {
+		return nil, nil
+	}
+
+	helpful, harmful, err := learningVerdictCounts(path)
+	if err != nil {
+		return nil, err
+	}
+	utility := types.InitialUtility
+	if first != nil && first.UtilityBefore > 0 {
+		utility = first.UtilityBefore
+	}
+	return &FeedbackEvent{
+		ArtifactPath: path,
+		UtilityAfter: utility,
+		RecordedAt:   time.Now(),
+		Source:       RewardSourceBaseline,
+		Baseline: &RewardBaseline{
+			RewardCount:  l.RewardCount - counted.RewardCount,
+			HelpfulCount: max(helpful-counted.HelpfulCount, 0),
+			HarmfulCount: max(harmful-counted.HarmfulCount, 0),
+		},
+	}, nil
+}
+
+// hasBaseline reports whether events hold a baseline.
+func hasBaseline(events []FeedbackEvent) bool {
+	for _, e := range events {
+		if e.Source == RewardSourceBaseline {
+			return true
+		}
+	}
+	return false
+}
+
+// learningVerdictCounts reads a JSONL learning's helpful and harmful
+// counts; markdown learnings do not track them.
+func learningVerdictCounts(path string) (helpful, harmful int, err error) {
+	if !strings.HasSuffix(path, ".jsonl") {
+		return 0, 0, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	line, _, _ := strings.Cut(string(content), "\n")
+	var counts struct {
+		HelpfulCount int `json:"helpful_count"`
+		HarmfulCount int `json:"harmful_count"`
+	}
+	if err := json.Unmarshal([]byte(line), &counts); err != nil {
+		return 0, 0, fmt.Errorf("parse JSONL: %w", err)
+	}
+	return counts.HelpfulCount, counts.HarmfulCount, nil
+}
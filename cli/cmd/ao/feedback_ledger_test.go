@@ -0,0 +1,326 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+// ledgerLearnings writes a markdown and a JSONL learning under dir.
+func ledgerLearnings(t *testing.T, dir string) (md, jsonl string) {
+	t.Helper()
+	learningsDir := filepath.Join(dir, ".agents", "learnings")
+	if err := os.MkdirAll(learningsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	md = filepath.Join(learningsDir, "md.md")
+	jsonl = filepath.Join(learningsDir, "jl.jsonl")
+	if err := os.WriteFile(md, []byte("---\nutility: 0.5000\n---\n# MD\n\nBody.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jsonl, []byte(`{"id":"L-jl","summary":"JSONL learning.","utility":0.5}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return md, jsonl
+}
+
+// seedFeedbackLedger appends events to the feedback log under dir as they
+// are, without applying them.
+func seedFeedbackLedger(t *testing.T, dir string, events []FeedbackEvent) {
+	t.Helper()
+	ledger, err := openFeedbackLedger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close() //nolint:errcheck // test cleanup
+	for _, e := range events {
+		if err := ledger.append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFeedbackLedgerAppliesOnce(t *testing.T) {
+	tmp := t.TempDir()
+	_, jsonl := ledgerLearnings(t, tmp)
+	event := FeedbackEvent{SessionID: "s1", ArtifactPath: jsonl, Reward: 1, Alpha: 0.5, Source: RewardSourceFeedbackLoop}
+
+	for run := 0; run < 2; run++ {
+		ledger, err := openFeedbackLedger(tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, applied, err := ledger.apply(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied != (run == 0) || got.UtilityAfter != 0.75 {
+			t.Errorf("run %d: applied=%v utility %.3f, want applied only once and 0.75", run, applied, got.UtilityAfter)
+		}
+		// The same session rewarded by hand is a different ledger entry
+		if run == 1 {
+			manual := event
+			manual.Source = RewardSourceManual
+			if _, applied, err := ledger.apply(manual); err != nil || !applied {
+				t.Errorf("manual reward applied=%v err=%v, want applied", applied, err)
+			}
+		}
+		if err := ledger.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err := parseLearningFile(jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(l.Utility-0.875) > 1e-9 || l.RewardCount != 2 {
+		t.Errorf("utility %.3f after %d rewards, want 0.875 after 2", l.Utility, l.RewardCount)
+	}
+	if events, _ := loadFeedbackEvents(tmp); len(events) != 2 {
+		t.Errorf("ledger has %d events, want 2", len(events))
+	}
+}
+
+func TestFeedbackLedgerSkipsTornLines(t *testing.T) {
+	tmp := t.TempDir()
+	_, jsonl := ledgerLearnings(t, tmp)
+	path := filepath.Join(tmp, FeedbackFilePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A torn write and a line with a field of the wrong type
+	torn := `{"session_id":"s0","artifact_path":"x.jsonl","rew` + "\n" +
+		`{"session_id":"s0","artifact_path":"x.jsonl","reward":"high"}` + "\n"
+	if err := os.WriteFile(path, []byte(torn), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	event := FeedbackEvent{SessionID: "s1", ArtifactPath: jsonl, Reward: 1, Alpha: 0.5, Source: RewardSourceFeedbackLoop}
+	other := event
+	other.SessionID = "s2"
+	for run, e := range []FeedbackEvent{event, event, other, other} {
+		ledger, err := openFeedbackLedger(tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, applied, err := ledger.apply(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied != (run%2 == 0) {
+			t.Errorf("run %d: applied=%v, want %v", run, applied, run%2 == 0)
+		}
+		if err := ledger.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// A write cut short at the end of the log does not swallow the
+		// next event
+		if run == 1 {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteString(`{"session_id":"s1","rew`); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	l, err := parseLearningFile(jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.RewardCount != 2 {
+		t.Errorf("learning rewarded %d times, want 2", l.RewardCount)
+	}
+	if events, _ := loadFeedbackEvents(tmp); len(events) != 2 {
+		t.Errorf("ledger has %d events, want 2", len(events))
+	}
+}
+
+func TestFeedbackLoopIsIdempotent(t *testing.T) {
+	for _, sessionID := range []string{
+		"session-20260301-090000",
+		// Claude session IDs must key the ledger the same way on every run
+		"2d608ace-e8e4-4649-8ac0-70aeba0dcfee",
+	} {
+		t.Run(sessionID, func(t *testing.T) {
+			testFeedbackLoopIsIdempotent(t, sessionID)
+		})
+	}
+}
+
+func testFeedbackLoopIsIdempotent(t *testing.T, sessionID string) {
+	tmp := chdirTemp(t)
+	t.Setenv("HOME", tmp)
+	md, jsonl := ledgerLearnings(t, tmp)
+	if err := recordCitations(tmp, []learning{{ID: "md.md", Source: md}, {ID: "jl.jsonl", Source: jsonl}}, sessionID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := []interface{}{feedbackLoopSessionID, feedbackLoopReward, feedbackLoopTranscript, feedbackLoopAlpha, feedbackLoopCitationType}
+	t.Cleanup(func() {
+		feedbackLoopSessionID = prev[0].(string)
+		feedbackLoopReward = prev[1].(float64)
+		feedbackLoopTranscript = prev[2].(string)
+		feedbackLoopAlpha = prev[3].(float64)
+		feedbackLoopCitationType = prev[4].(string)
+	})
+	feedbackLoopSessionID = sessionID
+	feedbackLoopReward = 1
+	feedbackLoopTranscript = ""
+	feedbackLoopAlpha = 0.5
+	feedbackLoopCitationType = "all"
+
+	for run := 0; run < 2; run++ {
+		if err := runFeedbackLoop(feedbackLoopCmd, nil); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	events, err := loadFeedbackEvents(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("ledger has %d events after two runs, want 2", len(events))
+	}
+	for _, e := range events {
+		if e.SessionID != sessionID {
+			t.Errorf("ledger event keyed on session %q, want %q", e.SessionID, sessionID)
+		}
+	}
+	l, err := parseLearningFile(jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.RewardCount != 1 {
+		t.Errorf("reward_count %d after two runs, want 1", l.RewardCount)
+	}
+
+	// Citations for the session are untouched by the second run
+	if citations, _ := ratchet.LoadCitations(tmp); len(citations) != 2 {
+		t.Errorf("citations.jsonl has %d events, want 2", len(citations))
+	}
+}
+
+func TestFeedbackReplay(t *testing.T) {
+	tmp := t.TempDir()
+	md, jsonl := ledgerLearnings(t, tmp)
+
+	// An older log applied s1's reward to md twice
+	seedFeedbackLedger(t, tmp, []FeedbackEvent{
+		{SessionID: "s1", ArtifactPath: md, Reward: 1, Alpha: 0.1},
+		{SessionID: "s1", ArtifactPath: md, Reward: 1, Alpha: 0.1},
+		{SessionID: "s2", ArtifactPath: md, Reward: 0, Alpha: 0.1},
+		{SessionID: "s3", ArtifactPath: jsonl, Reward: 1, Alpha: 0.1, Source: RewardSourceManual, Verdict: "helpful"},
+		{SessionID: "s4", ArtifactPath: filepath.Join(tmp, "gone.md"), Reward: 1, Alpha: 0.1},
+	})
+
+	ledger, err := openFeedbackLedger(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close() //nolint:errcheck // test cleanup
+
+	// Dry run reports without writing
+	results, err := replayFeedbackLedger(tmp, ledger, 0.5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || !results[0].Missing {
+		t.Fatalf("results = %+v, want gone.md missing then jl.jsonl and md.md", results)
+	}
+	if l, _ := parseLearningFile(md); l.Utility != 0.5 {
+		t.Errorf("dry run changed md utility to %.3f", l.Utility)
+	}
+
+	if _, err := replayFeedbackLedger(tmp, ledger, 0.5, false); err != nil {
+		t.Fatal(err)
+	}
+	l, err := parseLearningFile(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.5 → 0.75 (s1, once) → 0.375 (s2)
+	if math.Abs(l.Utility-0.375) > 1e-4 || l.RewardCount != 2 {
+		t.Errorf("md utility %.4f after %d rewards, want 0.375 after 2", l.Utility, l.RewardCount)
+	}
+	data, err := os.ReadFile(jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"utility":0.75`) || !strings.Contains(string(data), `"helpful_count":1`) {
+		t.Errorf("jsonl after replay = %s", data)
+	}
+}
+
+func TestFeedbackReplayKeepsUnloggedHistory(t *testing.T) {
+	tmp := t.TempDir()
+	_, jsonl := ledgerLearnings(t, tmp)
+	// Four rewards from before the ledger recorded them
+	if err := os.WriteFile(jsonl, []byte(`{"id":"L-jl","utility":0.8,"reward_count":4,"helpful_count":3,"harmful_count":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err := openFeedbackLedger(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close() //nolint:errcheck // test cleanup
+	if _, _, err := ledger.apply(FeedbackEvent{SessionID: "s1", ArtifactPath: jsonl, Reward: 0, Alpha: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 0; run < 2; run++ {
+		results, err := replayFeedbackLedger(tmp, ledger, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantBaseline := 4
+		if run > 0 {
+			wantBaseline = 0 // Logged by the first replay
+		}
+		if len(results) != 1 || results[0].Baseline != wantBaseline || results[0].Unlogged != 0 {
+			t.Fatalf("replay %d results = %+v, want a baseline of %d", run, results, wantBaseline)
+		}
+		data, err := os.ReadFile(jsonl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 0.8 from the baseline → 0.4 (s1)
+		for _, want := range []string{`"utility":0.4`, `"reward_count":5`, `"helpful_count":3`, `"harmful_count":1`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("replay %d: jsonl = %s, want %s", run, data, want)
+			}
+		}
+	}
+	if events, _ := loadFeedbackEvents(tmp); len(events) != 1 {
+		t.Errorf("loadFeedbackEvents() returned %d events, want the reward only", len(events))
+	}
+
+	// A reward that bypassed the ledger after the baseline is not erased
+	unlogged := `{"id":"L-jl","utility":0.9,"reward_count":6}`
+	if err := os.WriteFile(jsonl, []byte(unlogged), 0600); err != nil {
+		t.Fatal(err)
+	}
+	results, err := replayFeedbackLedger(tmp, ledger, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Unlogged != 1 {
+		t.Errorf("results = %+v, want 1 unlogged reward", results)
+	}
+	if data, _ := os.ReadFile(jsonl); string(data) != unlogged {
+		t.Errorf("replay changed a learning with unlogged rewards: %s", data)
+	}
+}
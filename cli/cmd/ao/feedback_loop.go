@@ -23,9 +23,24 @@ type FeedbackEvent struct {
 	Alpha          float64   `json:"alpha"`
 	RecordedAt     time.Time `json:"recorded_at"`
 	TranscriptPath string    `json:"transcript_path,omitempty"`
+	CitationType   string    `json:"citation_type,omitempty"`
+
+	// Source is what gave the reward (RewardSource*); empty means the
+	// feedback loop.
+	Source string `json:"source,omitempty"`
+
+	// Verdict is "helpful" or "harmful" for manual feedback given as such.
+	Verdict string `json:"verdict,omitempty"`
+
+	// Credit is how Reward was split from the session's reward.
+	Credit *CreditAssignment `json:"credit,omitempty"`
+
+	// Baseline holds the counts of a RewardSourceBaseline event.
+	Baseline *RewardBaseline `json:"baseline,omitempty"`
 }
 
-// FeedbackFilePath is the relative path to the feedback log.
+// FeedbackFilePath is the relative path to the feedback log, which is also
+// the ledger of every reward applied to a learning.
 const FeedbackFilePath = ".agents/ao/feedback.jsonl"
 
 var feedbackLoopCmd = &cobra.Command{
@@ -35,9 +50,33 @@ var feedbackLoopCmd = &cobra.Command{
 
 This command:
 1. Reads citations for the session from .agents/ao/citations.jsonl
-2. Computes reward from session outcome (or uses --reward override)
-3. Updates utility of each cited learning via EMA rule
-4. Logs feedback events to .agents/ao/feedback.jsonl
+2. Scans the session's transcript for how learnings were used, recording
+   "retrieved" citations for learning files read or found by search and
+   "applied" citations for learnings whose ID or summary the session repeated
+3. Computes reward from session outcome (or uses --reward override)
+4. Assigns each cited learning its share of the reward (see below) and
+   updates its utility via EMA rule
+5. Logs feedback events, with each reward's breakdown, to
+   .agents/ao/feedback.jsonl
+
+The feedback log is a ledger: a learning is rewarded at most once per
+session by the feedback loop, so running this again, or running
+'ao batch-feedback' after it, changes nothing. 'ao feedback replay'
+recomputes utilities from the ledger.
+
+A learning's reward is the session reward multiplied by:
+  - its citation type: applied 1.0, retrieved 0.6, injected 0.2
+  - an eligibility trace: 1.0 if last used at the end of the session,
+    decaying to 0.8 for a learning only used at its start
+  - timing: 0.5 if a test failed after the learning was cited
+  - relevance: 1.25 if the learning names or matches a file the session
+    changed before its last git commit
+The transcript supplies everything after the type; the breakdown is shown
+by 'ao maturity <learning>'.
+
+The transcript is --transcript, or the Claude transcript named by --session.
+Without one, learnings keep the "injected" citations ao inject recorded and
+their reward is weighted by type alone.
 
 The feedback loop enables knowledge to compound:
 - High-utility learnings surface more often
@@ -65,7 +104,7 @@ func init() {
 	feedbackLoopCmd.Flags().Float64Var(&feedbackLoopReward, "reward", -1, "Override reward value (0.0-1.0); -1 = compute from transcript")
 	feedbackLoopCmd.Flags().StringVar(&feedbackLoopTranscript, "transcript", "", "Path to transcript for reward computation")
 	feedbackLoopCmd.Flags().Float64Var(&feedbackLoopAlpha, "alpha", types.DefaultAlpha, "EMA learning rate")
-	feedbackLoopCmd.Flags().StringVar(&feedbackLoopCitationType, "citation-type", "retrieved", "Filter citations by type (retrieved, applied, all)")
+	feedbackLoopCmd.Flags().StringVar(&feedbackLoopCitationType, "citation-type", "all", "Filter citations by type (injected, retrieved, applied, all)")
 }
 
 // loadSessionCitations loads and filters citations for a session.
@@ -77,15 +116,54 @@ func loadSessionCitations(cwd, sessionID, citationType string) ([]types.Citation
 
 	var sessionCitations []types.CitationEvent
 	for _, c := range allCitations {
-		if c.SessionID != sessionID {
-			continue
+		if c.SessionID == sessionID {
+			sessionCitations = append(sessionCitations, c)
 		}
-		if citationType != "all" && c.CitationType != citationType {
-			continue
+	}
+	return filterCitations(sessionCitations, citationType), nil
+}
+
+// filterCitations keeps the citations of a type ("all" keeps every one).
+func filterCitations(citations []types.CitationEvent, citationType string) []types.CitationEvent {
+	if citationType == "all" {
+		return citations
+	}
+	var filtered []types.CitationEvent
+	for _, c := range citations {
+		if c.CitationType == citationType {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// detectFeedbackCitations records citations grounded in the session's
+// transcript and returns them, with the timeline credit is assigned from.
+// The transcript is the given path, or the Claude transcript for
+// rawSessionID; without one nothing is detected.
+func detectFeedbackCitations(cwd, transcriptPath, rawSessionID, sessionID string, existing []types.CitationEvent) ([]types.CitationEvent, *creditTimeline) {
+	if transcriptPath == "" {
+		path, err := findTranscriptBySessionID(rawSessionID)
+		if err != nil {
+			VerbosePrintf("No transcript for citation detection: %v\n", err)
+			return nil, nil
 		}
-		sessionCitations = append(sessionCitations, c)
+		transcriptPath = path
 	}
-	return sessionCitations, nil
+
+	detected, timeline, err := detectSessionCitations(cwd, transcriptPath, sessionID)
+	if err != nil {
+		VerbosePrintf("Warning: citation detection failed: %v\n", err)
+		return nil, nil
+	}
+	recorded, err := recordDetectedCitations(cwd, existing, detected)
+	if err != nil {
+		VerbosePrintf("Warning: failed to record citations: %v\n", err)
+	}
+	for _, c := range recorded {
+		VerbosePrintf("Detected %s citation: %s\n", c.CitationType, filepath.Base(c.ArtifactPath))
+	}
+	return recorded, timeline
 }
 
 // computeRewardFromTranscript derives reward from transcript analysis.
@@ -98,7 +176,11 @@ func computeRewardFromTranscript(transcriptPath, sessionID string) (float64, err
 	if transcriptPath == "" {
 		return 0, fmt.Errorf("no transcript found; use --reward to specify manually")
 	}
-	outcome, err := analyzeTranscript(transcriptPath, sessionID)
+	rules, err := loadRewardRules("")
+	if err != nil {
+		return 0, err
+	}
+	outcome, err := analyzeTranscript(transcriptPath, sessionID, rules)
 	if err != nil {
 		return 0, fmt.Errorf("analyze transcript: %w", err)
 	}
@@ -106,23 +188,38 @@ func computeRewardFromTranscript(transcriptPath, sessionID string) (float64, err
 	return outcome.Reward, nil
 }
 
-// deduplicateCitations returns unique citations by artifact path.
+// deduplicateCitations returns unique citations by artifact path, each with
+// the strongest citation type recorded for it.
 func deduplicateCitations(citations []types.CitationEvent) []types.CitationEvent {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	var unique []types.CitationEvent
 	for _, c := range citations {
-		if !seen[c.ArtifactPath] {
-			seen[c.ArtifactPath] = true
+		i, ok := seen[c.ArtifactPath]
+		if !ok {
+			seen[c.ArtifactPath] = len(unique)
 			unique = append(unique, c)
+			continue
+		}
+		if citationRanks[c.CitationType] > citationRanks[unique[i].CitationType] {
+			unique[i].CitationType = c.CitationType
 		}
 	}
 	return unique
 }
 
-// processUniqueCitations updates learning utilities and returns feedback events.
-func processUniqueCitations(cwd, sessionID, transcriptPath string, citations []types.CitationEvent, reward, alpha float64) ([]FeedbackEvent, int, int) {
+// processUniqueCitations rewards the cited learnings through the ledger and
+// returns the feedback events it applied, with counts of updated, already
+// rewarded and failed learnings. Each citation's reward is its credit for the
+// session's reward.
+func processUniqueCitations(cwd, sessionID, transcriptPath string, citations []types.CitationEvent, reward, alpha float64, timeline *creditTimeline) ([]FeedbackEvent, int, int, int, error) {
+	ledger, err := openFeedbackLedger(cwd)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	defer ledger.Close() //nolint:errcheck // lock released on exit regardless
+
 	var events []FeedbackEvent
-	updatedCount, failedCount := 0, 0
+	updatedCount, skippedCount, failedCount := 0, 0, 0
 
 	for _, citation := range citations {
 		learningPath, err := findLearningFile(cwd, filepath.Base(citation.ArtifactPath))
@@ -136,31 +233,35 @@ func processUniqueCitations(cwd, sessionID, transcriptPath string, citations []t
 			}
 		}
 
-		oldUtility, newUtility, err := updateLearningUtility(learningPath, reward, alpha)
+		credit := assignCredit(timeline, citation, learningPath, reward)
+		event, applied, err := ledger.apply(FeedbackEvent{
+			SessionID:      sessionID,
+			ArtifactPath:   learningPath,
+			Reward:         credit.Reward(),
+			Alpha:          alpha,
+			TranscriptPath: transcriptPath,
+			CitationType:   citation.CitationType,
+			Source:         RewardSourceFeedbackLoop,
+			Credit:         &credit,
+		})
 		if err != nil {
 			VerbosePrintf("Warning: failed to update %s: %v\n", learningPath, err)
 			failedCount++
 			continue
 		}
-
-		event := FeedbackEvent{
-			SessionID:      sessionID,
-			ArtifactPath:   learningPath,
-			Reward:         reward,
-			UtilityBefore:  oldUtility,
-			UtilityAfter:   newUtility,
-			Alpha:          alpha,
-			RecordedAt:     time.Now(),
-			TranscriptPath: transcriptPath,
+		if !applied {
+			VerbosePrintf("Already rewarded %s for this session (reward=%.2f)\n", filepath.Base(learningPath), event.Reward)
+			skippedCount++
+			continue
 		}
 		events = append(events, event)
 		updatedCount++
 
-		VerbosePrintf("Updated %s: %.3f → %.3f (reward=%.2f)\n",
-			filepath.Base(learningPath), oldUtility, newUtility, reward)
+		VerbosePrintf("Updated %s: %.3f → %.3f (reward=%.2f = %s, %s)\n",
+			filepath.Base(learningPath), event.UtilityBefore, event.UtilityAfter, event.Reward, credit, citation.CitationType)
 	}
 
-	return events, updatedCount, failedCount
+	return events, updatedCount, skippedCount, failedCount, nil
 }
 
 func runFeedbackLoop(cmd *cobra.Command, args []string) error {
@@ -180,11 +281,13 @@ func runFeedbackLoop(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Load and filter citations
-	sessionCitations, err := loadSessionCitations(cwd, sessionID, feedbackLoopCitationType)
+	// Load citations, ground them in the transcript, then filter
+	sessionCitations, err := loadSessionCitations(cwd, sessionID, "all")
 	if err != nil {
 		return err
 	}
+	detected, timeline := detectFeedbackCitations(cwd, feedbackLoopTranscript, feedbackLoopSessionID, sessionID, sessionCitations)
+	sessionCitations = filterCitations(append(sessionCitations, detected...), feedbackLoopCitationType)
 	if len(sessionCitations) == 0 {
 		fmt.Printf("No citations found for session %s\n", sessionID)
 		return nil
@@ -201,21 +304,19 @@ func runFeedbackLoop(cmd *cobra.Command, args []string) error {
 
 	// Process citations
 	uniqueCitations := deduplicateCitations(sessionCitations)
-	feedbackEvents, updatedCount, failedCount := processUniqueCitations(
-		cwd, sessionID, feedbackLoopTranscript, uniqueCitations, reward, feedbackLoopAlpha,
+	feedbackEvents, updatedCount, skippedCount, failedCount, err := processUniqueCitations(
+		cwd, sessionID, feedbackLoopTranscript, uniqueCitations, reward, feedbackLoopAlpha, timeline,
 	)
-
-	// Write feedback events to log
-	if err := writeFeedbackEvents(cwd, feedbackEvents); err != nil {
-		VerbosePrintf("Warning: failed to write feedback log: %v\n", err)
+	if err != nil {
+		return err
 	}
 
 	// Output summary
-	return outputFeedbackSummary(sessionID, reward, len(sessionCitations), len(uniqueCitations), updatedCount, failedCount, feedbackEvents)
+	return outputFeedbackSummary(sessionID, reward, len(sessionCitations), len(uniqueCitations), updatedCount, skippedCount, failedCount, feedbackEvents)
 }
 
 // outputFeedbackSummary outputs the feedback loop results.
-func outputFeedbackSummary(sessionID string, reward float64, totalCitations, uniqueCount, updatedCount, failedCount int, events []FeedbackEvent) error {
+func outputFeedbackSummary(sessionID string, reward float64, totalCitations, uniqueCount, updatedCount, skippedCount, failedCount int, events []FeedbackEvent) error {
 	switch GetOutput() {
 	case "json":
 		result := map[string]interface{}{
@@ -224,6 +325,7 @@ func outputFeedbackSummary(sessionID string, reward float64, totalCitations, uni
 			"citations":  totalCitations,
 			"unique":     uniqueCount,
 			"updated":    updatedCount,
+			"skipped":    skippedCount,
 			"failed":     failedCount,
 			"feedback":   events,
 		}
@@ -238,6 +340,9 @@ func outputFeedbackSummary(sessionID string, reward float64, totalCitations, uni
 		fmt.Printf("Reward:      %.2f\n", reward)
 		fmt.Printf("Citations:   %d (%d unique)\n", totalCitations, uniqueCount)
 		fmt.Printf("Updated:     %d\n", updatedCount)
+		if skippedCount > 0 {
+			fmt.Printf("Skipped:     %d (already rewarded)\n", skippedCount)
+		}
 		if failedCount > 0 {
 			fmt.Printf("Failed:      %d\n", failedCount)
 		}
@@ -246,40 +351,6 @@ func outputFeedbackSummary(sessionID string, reward float64, totalCitations, uni
 	return nil
 }
 
-// writeFeedbackEvents appends feedback events to the feedback log.
-func writeFeedbackEvents(baseDir string, events []FeedbackEvent) error {
-	if len(events) == 0 {
-		return nil
-	}
-
-	feedbackPath := filepath.Join(baseDir, FeedbackFilePath)
-
-	// Create parent directory
-	if err := os.MkdirAll(filepath.Dir(feedbackPath), 0755); err != nil {
-		return fmt.Errorf("create feedback directory: %w", err)
-	}
-
-	// Open for append
-	f, err := os.OpenFile(feedbackPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("open feedback file: %w", err)
-	}
-	defer f.Close() //nolint:errcheck // write-only file, Close error non-actionable
-
-	// Write each event as JSONL
-	for _, event := range events {
-		data, err := json.Marshal(event)
-		if err != nil {
-			continue
-		}
-		if _, err := f.Write(append(data, '\n')); err != nil {
-			return fmt.Errorf("write feedback event: %w", err)
-		}
-	}
-
-	return nil
-}
-
 // batchFeedbackCmd processes feedback for multiple sessions.
 var batchFeedbackCmd = &cobra.Command{
 	Use:   "batch-feedback",
@@ -321,10 +392,12 @@ func runBatchFeedback(cmd *cobra.Command, args []string) error {
 		VerbosePrintf("Warning: failed to load feedback: %v\n", err)
 	}
 
-	// Build set of sessions that already have feedback
+	// Build set of sessions the feedback loop already rewarded
 	processedSessions := make(map[string]bool)
 	for _, f := range existingFeedback {
-		processedSessions[f.SessionID] = true
+		if f.Source == "" || f.Source == RewardSourceFeedbackLoop {
+			processedSessions[f.SessionID] = true
+		}
 	}
 
 	// Find sessions with citations but no feedback
@@ -373,7 +446,8 @@ func runBatchFeedback(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// loadFeedbackEvents reads all feedback events from the log.
+// loadFeedbackEvents reads all feedback events from the log, leaving out
+// replay baselines, which are not rewards.
 func loadFeedbackEvents(baseDir string) ([]FeedbackEvent, error) {
 	feedbackPath := filepath.Join(baseDir, FeedbackFilePath)
 
@@ -384,14 +458,10 @@ func loadFeedbackEvents(baseDir string) ([]FeedbackEvent, error) {
 	defer f.Close() //nolint:errcheck // read-only file, Close error non-actionable
 
 	var events []FeedbackEvent
-	decoder := json.NewDecoder(f)
-	for decoder.More() {
-		var event FeedbackEvent
-		if err := decoder.Decode(&event); err != nil {
-			continue // Skip malformed lines
+	err = scanFeedbackEvents(f, func(event FeedbackEvent) {
+		if event.Source != RewardSourceBaseline {
+			events = append(events, event)
 		}
-		events = append(events, event)
-	}
-
-	return events, nil
+	})
+	return events, err
 }
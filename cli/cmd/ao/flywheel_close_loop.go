@@ -13,6 +13,7 @@ import (
 
 	"github.com/boshu2/agentops/cli/internal/pool"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/scorer"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -138,7 +139,7 @@ func outputFlywheelCloseLoopResult(res flywheelCloseLoopResult) error {
 }
 
 func ingestPendingFilesToPool(cwd string, files []string) (poolIngestResult, error) {
-	p := pool.NewPool(cwd)
+	p := newIngestPool(cwd)
 	res := poolIngestResult{FilesScanned: len(files)}
 	if len(files) == 0 {
 		return res, nil
@@ -154,7 +155,7 @@ func ingestPendingFilesToPool(cwd string, files []string) (poolIngestResult, err
 		blocks := parseLearningBlocks(string(data))
 		res.CandidatesFound += len(blocks)
 		for _, b := range blocks {
-			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint)
+			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint, scorer.DefaultModel())
 			if !ok {
 				res.SkippedMalformed++
 				continue
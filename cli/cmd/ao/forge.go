@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,6 +29,7 @@ var (
 	forgeLastSession bool
 	forgeQuiet       bool
 	forgeQueue       bool
+	forgeRestart     bool
 	forgeMdQuiet     bool
 	forgeMdQueue     bool
 )
@@ -60,8 +65,18 @@ Example:
 
 var forgeTranscriptCmd = &cobra.Command{
 	Use:   "transcript <path-or-glob>",
-	Short: "Extract knowledge from Claude Code transcripts",
-	Long: `Parse Claude Code JSONL transcript files and extract knowledge candidates.
+	Short: "Extract knowledge from agent session transcripts",
+	Long: `Parse agent session transcripts and extract knowledge candidates.
+
+The format is detected per file: Claude Code JSONL, Codex CLI rollout JSONL
+(~/.codex/sessions/**/rollout-*.jsonl) and OpenCode sessions (an
+'opencode export' document or a storage/session/<project>/<id>.json file).
+
+Claude Code transcripts are checkpointed by byte offset under
+.agents/ao/forge-checkpoints/, so re-forging a transcript that has grown
+only reads the appended tail, and an unchanged one is skipped. Knowledge
+repeated within a transcript is kept once. Use --restart to ignore the
+checkpoint and forge from the beginning.
 
 The transcript forge identifies:
   - Decisions: Architectural choices with rationale
@@ -74,8 +89,10 @@ Examples:
   ao forge transcript session.jsonl
   ao forge transcript ~/.claude/projects/**/*.jsonl
   ao forge transcript /path/to/*.jsonl --output candidates.json
+  ao forge transcript ~/.codex/sessions/2026/03/*/rollout-*.jsonl
   ao forge transcript --last-session              # Process most recent transcript
-  ao forge transcript --last-session --quiet      # Silent mode for hooks`,
+  ao forge transcript --last-session --quiet      # Silent mode for hooks
+  ao forge transcript session.jsonl --restart     # Ignore the checkpoint`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		lastSession, _ := cmd.Flags().GetBool("last-session")
 		if !lastSession && len(args) < 1 {
@@ -112,6 +129,7 @@ func init() {
 	forgeTranscriptCmd.Flags().BoolVar(&forgeLastSession, "last-session", false, "Process only the most recent transcript")
 	forgeTranscriptCmd.Flags().BoolVar(&forgeQuiet, "quiet", false, "Suppress all output (for hooks)")
 	forgeTranscriptCmd.Flags().BoolVar(&forgeQueue, "queue", false, "Queue session for learning extraction at next session start")
+	forgeTranscriptCmd.Flags().BoolVar(&forgeRestart, "restart", false, "Ignore forge checkpoints and process transcripts from the start")
 
 	// Markdown flags
 	forgeMarkdownCmd.Flags().BoolVar(&forgeMdQuiet, "quiet", false, "Suppress all output (for hooks)")
@@ -201,14 +219,26 @@ func runForgeTranscript(cmd *cobra.Command, args []string) error {
 	totalDecisions := 0
 	totalKnowledge := 0
 
+	opts := forgeOptions{
+		CheckpointDir: filepath.Join(baseDir, forgeCheckpointDirName),
+		Restart:       forgeRestart,
+		Quiet:         forgeQuiet,
+	}
+
 	for _, filePath := range files {
-		session, err := processTranscript(filePath, p, extractor, forgeQuiet, w)
+		session, progress, err := processTranscript(context.Background(), filePath, p, extractor, opts, w)
 		if err != nil {
 			if !forgeQuiet {
 				fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", filePath, err)
 			}
 			continue
 		}
+		if progress.UpToDate {
+			if !forgeQuiet {
+				VerbosePrintf("  - %s unchanged since last forge\n", filepath.Base(filePath))
+			}
+			continue
+		}
 
 		// Write session
 		sessionPath, err := fs.WriteSession(session)
@@ -279,11 +309,29 @@ func runForgeTranscript(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// processTranscript parses a transcript and extracts session data.
-func processTranscript(filePath string, p *parser.Parser, extractor *parser.Extractor, quiet bool, w io.Writer) (session *storage.Session, err error) {
+// processTranscript parses a transcript and extracts session data. Claude
+// Code transcripts are streamed line by line from the last checkpointed
+// byte offset, so a transcript that has grown since its last forge only has
+// its tail read; see forgeCheckpoint. Other agents' logs (Codex CLI,
+// OpenCode) are detected and normalised by their parser.TranscriptSource.
+// Cancelling ctx stops a Claude transcript at the next line, checkpointing
+// what was read so a later run resumes there.
+func processTranscript(ctx context.Context, filePath string, p *parser.Parser, extractor *parser.Extractor, opts forgeOptions, w io.Writer) (session *storage.Session, progress *forgeProgress, err error) {
+	src, err := parser.DetectSource(filePath, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if src.Name() != parser.FormatClaudeCode {
+		session, err := processSourceTranscript(filePath, src, extractor)
+		if err != nil {
+			return nil, nil, err
+		}
+		return session, &forgeProgress{}, nil
+	}
+
 	f, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("open file: %w", err)
+		return nil, nil, fmt.Errorf("open file: %w", err)
 	}
 	defer func() {
 		if cerr := f.Close(); cerr != nil && err == nil {
@@ -293,55 +341,122 @@ func processTranscript(filePath string, p *parser.Parser, extractor *parser.Extr
 
 	info, err := f.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("stat file: %w", err)
+		return nil, nil, fmt.Errorf("stat file: %w", err)
 	}
 	fileSize := info.Size()
-	totalLines := countLines(filePath)
 
-	if _, err := f.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("seek file: %w", err)
+	session = initSession(filePath)
+	state := newTranscriptState()
+	var offset int64
+	lineNum := 0
+
+	if opts.CheckpointDir != "" && !opts.Restart {
+		if cp := loadForgeCheckpoint(opts.CheckpointDir, filePath, f, fileSize); cp != nil {
+			cp.restore(session, state)
+			offset, lineNum = cp.Offset, cp.Lines
+		}
 	}
+	progress = &forgeProgress{StartOffset: offset, EndOffset: offset}
 
-	msgCh, errCh := p.ParseChannel(f)
-	session = initSession(filePath)
-	state := &transcriptState{
-		seenFiles:  make(map[string]bool),
-		seenIssues: make(map[string]bool),
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("seek file: %w", err)
 	}
+	r := bufio.NewReaderSize(f, 64*1024)
+	lastSave := offset
+	lastProgress := offset
 
-	lineCount := 0
-	lastProgress := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			if opts.CheckpointDir != "" && offset > lastSave {
+				if cerr := saveForgeCheckpoint(opts.CheckpointDir, newForgeCheckpoint(filePath, f, offset, lineNum, session, state)); cerr != nil {
+					VerbosePrintf("Warning: failed to checkpoint %s: %v\n", filePath, cerr)
+				}
+			}
+			return nil, nil, err
+		}
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, nil, fmt.Errorf("read transcript: %w", readErr)
+		}
+		if len(line) == 0 {
+			break
+		}
 
-	for msg := range msgCh {
-		lineCount++
+		msg, parseErr := p.ParseLine(bytes.TrimSpace(line), lineNum+1)
+		if readErr == io.EOF && parseErr != nil {
+			// An unterminated last line that does not parse is still being
+			// written; leave it for the next run
+			break
+		}
+		offset += int64(len(line))
+		lineNum++
+		if parseErr != nil && len(bytes.TrimSpace(line)) > 0 && !p.SkipMalformed {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, parseErr)
+		}
+		if msg != nil {
+			updateSessionMeta(session, *msg)
+			extractMessageKnowledge(*msg, extractor, state)
+			extractMessageRefs(*msg, session, state)
+		}
 
-		// Progress output every 1000 lines
-		if !quiet && lineCount-lastProgress >= 1000 {
-			pct := 0
-			if totalLines > 0 {
-				pct = lineCount * 100 / totalLines
+		// Progress output every 16MB
+		if !opts.Quiet && offset-lastProgress >= forgeCheckpointBytes {
+			fmt.Fprintf(w, "\r[forge] Processing... %s/%s (%d%%)  ", humanSize(offset), humanSize(fileSize), offset*100/fileSize)
+			lastProgress = offset
+		}
+		if opts.CheckpointDir != "" && offset-lastSave >= forgeCheckpointBytes {
+			if err := saveForgeCheckpoint(opts.CheckpointDir, newForgeCheckpoint(filePath, f, offset, lineNum, session, state)); err != nil {
+				VerbosePrintf("Warning: failed to checkpoint %s: %v\n", filePath, err)
 			}
-			fmt.Fprintf(w, "\r[forge] Processing... %d/%d (%d%%)  ", lineCount, totalLines, pct)
-			lastProgress = lineCount
+			lastSave = offset
+		}
+		if readErr == io.EOF {
+			break
 		}
-
-		updateSessionMeta(session, msg)
-		extractMessageKnowledge(msg, extractor, state)
-		extractMessageRefs(msg, session, state)
 	}
 
-	if !quiet {
+	if !opts.Quiet && lastProgress > progress.StartOffset {
 		fmt.Fprintf(w, "\r%s\r", "                                                    ")
 	}
 
-	select {
-	case err := <-errCh:
-		if err != nil {
-			return nil, err
+	progress.EndOffset = offset
+	progress.UpToDate = offset == progress.StartOffset && progress.StartOffset > 0
+	finishSession(session, state, offset)
+
+	if opts.CheckpointDir != "" && !progress.UpToDate {
+		if err := saveForgeCheckpoint(opts.CheckpointDir, newForgeCheckpoint(filePath, f, offset, lineNum, session, state)); err != nil {
+			VerbosePrintf("Warning: failed to checkpoint %s: %v\n", filePath, err)
 		}
-	default:
 	}
+	return session, progress, nil
+}
 
+// processSourceTranscript extracts session data from a non-Claude session
+// log through its TranscriptSource.
+func processSourceTranscript(filePath string, src parser.TranscriptSource, extractor *parser.Extractor) (*storage.Session, error) {
+	result, err := src.ParseFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s transcript: %w", src.Name(), err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	session := initSession(filePath)
+	state := newTranscriptState()
+	for _, msg := range result.Messages {
+		updateSessionMeta(session, msg)
+		extractMessageKnowledge(msg, extractor, state)
+		extractMessageRefs(msg, session, state)
+	}
+
+	finishSession(session, state, info.Size())
+	return session, nil
+}
+
+// finishSession fills in a session's summary and extracted fields.
+func finishSession(session *storage.Session, state *transcriptState, fileSize int64) {
 	session.Summary = generateSummary(state.decisions, state.knowledge, session.Date)
 	session.Decisions = dedup(state.decisions)
 	session.Knowledge = dedup(state.knowledge)
@@ -351,8 +466,6 @@ func processTranscript(filePath string, p *parser.Parser, extractor *parser.Extr
 		Total:     int(fileSize / CharsPerToken),
 		Estimated: true,
 	}
-
-	return session, nil
 }
 
 // transcriptState holds accumulated state during transcript processing.
@@ -363,6 +476,27 @@ type transcriptState struct {
 	issues       []string
 	seenFiles    map[string]bool
 	seenIssues   map[string]bool
+	seenContent  map[string]bool // normalizeForDedup hashes of decisions and knowledge
+}
+
+func newTranscriptState() *transcriptState {
+	return &transcriptState{
+		seenFiles:   make(map[string]bool),
+		seenIssues:  make(map[string]bool),
+		seenContent: make(map[string]bool),
+	}
+}
+
+// firstSighting records text's content hash and reports whether it is new,
+// so knowledge repeated in a transcript, or re-read on a resumed forge, is
+// kept once.
+func (s *transcriptState) firstSighting(text string) bool {
+	key := normalizeForDedup(text)
+	if s.seenContent[key] {
+		return false
+	}
+	s.seenContent[key] = true
+	return true
 }
 
 // initSession creates a new session with default values.
@@ -391,6 +525,9 @@ func extractMessageKnowledge(msg types.TranscriptMessage, extractor *parser.Extr
 	results := extractor.Extract(msg)
 	for _, result := range results {
 		text := extractSnippet(msg.Content, result.StartIndex, SnippetMaxLength)
+		if !state.firstSighting(text) {
+			continue
+		}
 		switch result.Type {
 		case types.KnowledgeTypeDecision:
 			state.decisions = append(state.decisions, text)
@@ -453,36 +590,6 @@ func generateSummary(decisions, knowledge []string, date time.Time) string {
 	return fmt.Sprintf("Session from %s", date.Format("2006-01-02"))
 }
 
-// countLines quickly counts lines in a file.
-func countLines(path string) int {
-	f, err := os.Open(path)
-	if err != nil {
-		return 0
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only line count, close error non-fatal
-	}()
-
-	buf := make([]byte, 64*1024)
-	count := 0
-
-	for {
-		n, err := f.Read(buf)
-		if n > 0 {
-			for _, b := range buf[:n] {
-				if b == '\n' {
-					count++
-				}
-			}
-		}
-		if err != nil {
-			break
-		}
-	}
-
-	return count
-}
-
 // extractSnippet extracts a text snippet around a match.
 func extractSnippet(content string, startIdx, maxLen int) string {
 	if startIdx < 0 {
@@ -830,14 +937,21 @@ func splitMarkdownSections(content string) []string {
 
 // updateSearchIndexForFile loads the search index (if it exists), updates the
 // entry for the given file path, and saves it back. If no index exists yet
-// this is a no-op -- the user can create one with `ao search --rebuild-index`.
+// this is a no-op -- the next `ao search` builds one.
+// An index in an outdated format is rebuilt from the corpus directories.
 func updateSearchIndexForFile(baseDir, filePath string, quiet bool) {
-	idxPath := filepath.Join(baseDir, "index.jsonl")
+	idxPath := filepath.Join(baseDir, searchIndexFileName)
 	if _, err := os.Stat(idxPath); os.IsNotExist(err) {
 		return // no index yet -- nothing to update
 	}
 
 	idx, err := search.LoadIndex(idxPath)
+	if errors.Is(err, search.ErrIndexVersion) {
+		if _, err := rebuildSearchIndex(baseDir); err != nil && !quiet {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rebuild search index: %v\n", err)
+		}
+		return
+	}
 	if err != nil {
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "Warning: failed to load search index: %v\n", err)
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+const (
+	// forgeCheckpointDirName holds per-transcript forge checkpoints, alongside
+	// the forged index (relative to the storage base dir).
+	forgeCheckpointDirName = "forge-checkpoints"
+
+	// forgeCheckpointBytes is how much transcript is read between
+	// checkpoint saves (and progress updates).
+	forgeCheckpointBytes = 16 << 20
+
+	// forgeHeadBytes is how much of the start of a transcript is hashed to
+	// tell a grown file from a replaced one.
+	forgeHeadBytes = 4096
+)
+
+// forgeOptions controls how processTranscript reads a transcript.
+type forgeOptions struct {
+	// CheckpointDir is where checkpoints are kept; empty disables resume.
+	CheckpointDir string
+
+	// Restart ignores any existing checkpoint and forges from the start.
+	Restart bool
+
+	// Quiet suppresses progress output.
+	Quiet bool
+}
+
+// forgeProgress describes how much of a transcript one forge run read.
+type forgeProgress struct {
+	// StartOffset is the byte offset the run resumed from.
+	StartOffset int64 `json:"start_offset"`
+
+	// EndOffset is the offset after the last complete line read.
+	EndOffset int64 `json:"end_offset"`
+
+	// UpToDate is set when a checkpoint existed and nothing was appended.
+	UpToDate bool `json:"up_to_date,omitempty"`
+}
+
+// forgeCheckpoint is the resumable state of a transcript forge: the byte
+// offset reached and everything extracted up to it.
+type forgeCheckpoint struct {
+	Path      string    `json:"path"`
+	Offset    int64     `json:"offset"`
+	Lines     int       `json:"lines"`
+	HeadHash  string    `json:"head_hash"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SessionID    string         `json:"session_id,omitempty"`
+	Date         time.Time      `json:"date"`
+	Decisions    []string       `json:"decisions,omitempty"`
+	Knowledge    []string       `json:"knowledge,omitempty"`
+	FilesChanged []string       `json:"files_changed,omitempty"`
+	Issues       []string       `json:"issues,omitempty"`
+	ToolCalls    map[string]int `json:"tool_calls,omitempty"`
+}
+
+// forgeCheckpointDir returns the checkpoint directory under cwd.
+func forgeCheckpointDir(cwd string) string {
+	return filepath.Join(cwd, storage.DefaultBaseDir, forgeCheckpointDirName)
+}
+
+// forgeCheckpointPath names a transcript's checkpoint by a hash of its
+// absolute path.
+func forgeCheckpointPath(dir, transcript string) string {
+	if abs, err := filepath.Abs(transcript); err == nil {
+		transcript = abs
+	}
+	sum := sha256.Sum256([]byte(transcript))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// headHash hashes the first n bytes (at most forgeHeadBytes) of f.
+func headHash(f io.ReaderAt, n int64) string {
+	if n > forgeHeadBytes {
+		n = forgeHeadBytes
+	}
+	buf := make([]byte, n)
+	read, _ := f.ReadAt(buf, 0) //nolint:errcheck // short reads hash what was read
+	sum := sha256.Sum256(buf[:read])
+	return hex.EncodeToString(sum[:8])
+}
+
+// newForgeCheckpoint captures the state of a forge at offset.
+func newForgeCheckpoint(path string, f io.ReaderAt, offset int64, lines int, session *storage.Session, state *transcriptState) *forgeCheckpoint {
+	return &forgeCheckpoint{
+		Path:         path,
+		Offset:       offset,
+		Lines:        lines,
+		HeadHash:     headHash(f, offset),
+		UpdatedAt:    time.Now(),
+		SessionID:    session.ID,
+		Date:         session.Date,
+		Decisions:    state.decisions,
+		Knowledge:    state.knowledge,
+		FilesChanged: state.filesChanged,
+		Issues:       state.issues,
+		ToolCalls:    session.ToolCalls,
+	}
+}
+
+// loadForgeCheckpoint returns the checkpoint for the transcript open as f,
+// or nil when there is none or it no longer applies: the file shrank or its
+// head changed, meaning it was replaced rather than appended to.
+func loadForgeCheckpoint(dir, path string, f io.ReaderAt, size int64) *forgeCheckpoint {
+	data, err := os.ReadFile(forgeCheckpointPath(dir, path))
+	if err != nil {
+		return nil
+	}
+	var cp forgeCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		VerbosePrintf("Warning: ignoring corrupt forge checkpoint for %s: %v\n", path, err)
+		return nil
+	}
+	if cp.Offset > size || cp.HeadHash != headHash(f, cp.Offset) {
+		VerbosePrintf("Transcript %s changed since its checkpoint; forging from the start\n", path)
+		return nil
+	}
+	return &cp
+}
+
+// forgeCheckpointBehind reports whether a transcript has a checkpoint that
+// stops short of its current size, i.e. it has grown since it was forged.
+func forgeCheckpointBehind(dir, path string, size int64) bool {
+	data, err := os.ReadFile(forgeCheckpointPath(dir, path))
+	if err != nil {
+		return false
+	}
+	var cp forgeCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return false
+	}
+	return cp.Offset < size
+}
+
+// restore seeds a session and extraction state from the checkpoint.
+func (cp *forgeCheckpoint) restore(session *storage.Session, state *transcriptState) {
+	session.ID = cp.SessionID
+	session.Date = cp.Date
+	for name, n := range cp.ToolCalls {
+		session.ToolCalls[name] = n
+	}
+	for _, d := range cp.Decisions {
+		state.decisions = append(state.decisions, d)
+		state.seenContent[normalizeForDedup(d)] = true
+	}
+	for _, k := range cp.Knowledge {
+		state.knowledge = append(state.knowledge, k)
+		state.seenContent[normalizeForDedup(k)] = true
+	}
+	for _, fp := range cp.FilesChanged {
+		state.filesChanged = append(state.filesChanged, fp)
+		state.seenFiles[fp] = true
+	}
+	for _, id := range cp.Issues {
+		state.issues = append(state.issues, id)
+		state.seenIssues[id] = true
+	}
+}
+
+// saveForgeCheckpoint writes a checkpoint atomically.
+func saveForgeCheckpoint(dir string, cp *forgeCheckpoint) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create checkpoint directory: %w", err)
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".checkpoint-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close() //nolint:errcheck // write error takes precedence
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), forgeCheckpointPath(dir, cp.Path))
+}
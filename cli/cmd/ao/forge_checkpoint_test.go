@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+)
+
+func forgeTestLine(text string) string {
+	return `{"type":"assistant","sessionId":"sess-forge","timestamp":"2026-01-02T10:00:00Z","message":{"role":"assistant","content":"` + text + `"}}` + "\n"
+}
+
+func forgeTestFile(t *testing.T, dir string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func appendForgeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // test
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func forgeTestRun(t *testing.T, path string, opts forgeOptions) ([]string, *forgeProgress) {
+	t.Helper()
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	opts.Quiet = true
+	session, progress, err := processTranscript(context.Background(), path, p, parser.NewExtractor(), opts, io.Discard)
+	if err != nil {
+		t.Fatalf("processTranscript: %v", err)
+	}
+	if session.ID != "sess-forge" {
+		t.Errorf("session ID = %q, want sess-forge", session.ID)
+	}
+	return session.Knowledge, progress
+}
+
+func TestProcessTranscriptResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	opts := forgeOptions{CheckpointDir: filepath.Join(dir, "checkpoints")}
+	first := forgeTestLine("I learned that the cache must be warmed before the benchmark.")
+	path := forgeTestFile(t, dir, first, first)
+
+	knowledge, progress := forgeTestRun(t, path, opts)
+	if len(knowledge) != 1 {
+		t.Fatalf("first run knowledge = %v, want the repeated learning once", knowledge)
+	}
+	if progress.StartOffset != 0 || progress.EndOffset != int64(2*len(first)) {
+		t.Errorf("first run progress = %+v", progress)
+	}
+
+	// Nothing appended: up to date
+	_, progress = forgeTestRun(t, path, opts)
+	if !progress.UpToDate {
+		t.Errorf("second run progress = %+v, want up to date", progress)
+	}
+
+	// Appended tail: only the tail is read; the repeat is suppressed
+	second := forgeTestLine("Key insight: retries need jitter to avoid a thundering herd.")
+	appendForgeTestFile(t, path, first+second)
+	knowledge, progress = forgeTestRun(t, path, opts)
+	if progress.StartOffset != int64(2*len(first)) || progress.UpToDate {
+		t.Errorf("resumed progress = %+v, want start at the old end", progress)
+	}
+	if len(knowledge) != 2 || !strings.Contains(knowledge[1], "jitter") {
+		t.Errorf("resumed knowledge = %v, want both learnings once", knowledge)
+	}
+
+	if !forgeCheckpointBehind(opts.CheckpointDir, path, progress.EndOffset+1) {
+		t.Error("checkpoint should be behind a larger file")
+	}
+	if forgeCheckpointBehind(opts.CheckpointDir, path, progress.EndOffset) {
+		t.Error("checkpoint should not be behind the file it covers")
+	}
+}
+
+func TestProcessTranscriptLeavesPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	opts := forgeOptions{CheckpointDir: filepath.Join(dir, "checkpoints")}
+	first := forgeTestLine("I learned that the cache must be warmed before the benchmark.")
+	second := forgeTestLine("Key insight: retries need jitter to avoid a thundering herd.")
+	path := forgeTestFile(t, dir, first, second[:40])
+
+	knowledge, progress := forgeTestRun(t, path, opts)
+	if progress.EndOffset != int64(len(first)) {
+		t.Errorf("EndOffset = %d, want %d (partial line unread)", progress.EndOffset, len(first))
+	}
+	if len(knowledge) != 1 {
+		t.Errorf("knowledge = %v, want 1", knowledge)
+	}
+
+	appendForgeTestFile(t, path, second[40:])
+	knowledge, progress = forgeTestRun(t, path, opts)
+	if progress.StartOffset != int64(len(first)) || progress.EndOffset != int64(len(first)+len(second)) {
+		t.Errorf("progress = %+v", progress)
+	}
+	if len(knowledge) != 2 {
+		t.Errorf("knowledge = %v, want 2 after the line completes", knowledge)
+	}
+}
+
+func TestProcessTranscriptRestartsWhenReplaced(t *testing.T) {
+	dir := t.TempDir()
+	opts := forgeOptions{CheckpointDir: filepath.Join(dir, "checkpoints")}
+	first := forgeTestLine("I learned that the cache must be warmed before the benchmark.")
+	path := forgeTestFile(t, dir, first)
+	forgeTestRun(t, path, opts)
+
+	// Rewritten with different content at least as long: head hash differs
+	second := forgeTestLine("Key insight: retries need jitter to avoid a thundering herd.")
+	forgeTestFile(t, dir, second, first)
+	knowledge, progress := forgeTestRun(t, path, opts)
+	if progress.StartOffset != 0 {
+		t.Errorf("StartOffset = %d, want 0 for a replaced transcript", progress.StartOffset)
+	}
+	if len(knowledge) != 2 {
+		t.Errorf("knowledge = %v, want 2", knowledge)
+	}
+
+	// --restart ignores a valid checkpoint
+	_, progress = forgeTestRun(t, path, forgeOptions{CheckpointDir: opts.CheckpointDir, Restart: true})
+	if progress.StartOffset != 0 || progress.UpToDate {
+		t.Errorf("restart progress = %+v", progress)
+	}
+}
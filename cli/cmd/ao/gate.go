@@ -11,6 +11,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/types"
 )
 
 var (
@@ -26,10 +27,13 @@ var gateCmd = &cobra.Command{
 	Long: `Manage human review gates for bronze-tier candidates.
 
 Bronze-tier candidates (score 0.50-0.69) require human review
-before promotion. The gate command provides the review interface.
+before promotion. A reproducible sample of silver and gold candidates is
+also routed to review as a quality check; 'ao gate stats' reports how those
+reviews go. The gate command provides the review interface.
 
 Examples:
   ao gate pending
+  ao gate stats
   ao gate approve <candidate-id>
   ao gate reject <candidate-id> --reason="Too vague"`,
 }
@@ -37,10 +41,10 @@ Examples:
 var gatePendingCmd = &cobra.Command{
 	Use:   "pending",
 	Short: "List candidates pending review",
-	Long: `List bronze-tier candidates awaiting human review.
+	Long: `List bronze-tier and sampled candidates awaiting human review.
 
 Shows age/urgency with oldest items first.
-Highlights items approaching 24h auto-promote threshold.
+Highlights items approaching the auto-promote age set by the promotion policy.
 
 Examples:
   ao gate pending
@@ -90,24 +94,30 @@ func outputGatePending(entries []pool.PoolEntry) error {
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		//nolint:errcheck // CLI tabwriter output to stdout, errors unlikely and non-recoverable
-		fmt.Fprintln(w, "ID\tTIER\tAGE\tUTILITY\tURGENCY")
+		fmt.Fprintln(w, "ID\tTIER\tREVIEW\tAGE\tUTILITY\tURGENCY")
 		//nolint:errcheck // CLI tabwriter output to stdout
-		fmt.Fprintln(w, "--\t----\t---\t-------\t-------")
+		fmt.Fprintln(w, "--\t----\t------\t---\t-------\t-------")
 
 		for _, e := range entries {
 			urgency := ""
 			if e.ApproachingAutoPromote {
-				urgency = "HIGH (approaching 24h)"
+				urgency = "HIGH (approaching auto-promote)"
 			} else if e.Age > 12*time.Hour {
 				urgency = "MEDIUM"
 			} else {
 				urgency = "LOW"
 			}
 
+			review := pool.ReviewRequired
+			if e.ReviewSampled {
+				review = pool.ReviewSampled
+			}
+
 			//nolint:errcheck // CLI tabwriter output to stdout
-			fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%s\n",
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\n",
 				truncateID(e.Candidate.ID, 16),
 				e.Candidate.Tier,
+				review,
 				e.AgeString,
 				e.Candidate.Utility,
 				urgency,
@@ -127,7 +137,7 @@ func outputGatePending(entries []pool.PoolEntry) error {
 			}
 		}
 		if approaching > 0 {
-			fmt.Printf("! %d candidate(s) approaching 24h auto-promote threshold\n", approaching)
+			fmt.Printf("! %d candidate(s) approaching auto-promote threshold\n", approaching)
 		}
 
 		return nil
@@ -232,25 +242,29 @@ Examples:
 
 var gateBulkApproveCmd = &cobra.Command{
 	Use:   "bulk-approve",
-	Short: "Bulk approve silver candidates",
-	Long: `Approve all silver-tier candidates older than a threshold.
+	Short: "Bulk approve candidates the promotion policy allows",
+	Long: `Approve all pending candidates that the promotion policy allows
+(.agents/policy/promotion.yaml; see 'ao pool policy').
 
-Silver candidates auto-promote after 24h if not rejected.
-This command accelerates the process for reviewed batches.
+With the default policy, silver and gold candidates qualify after 24h if not
+rejected. --older-than and --tier narrow the selection further.
 
 Examples:
   ao gate bulk-approve
-  ao gate bulk-approve --older-than=12h
-  ao gate bulk-approve --older-than=24h --dry-run`,
+  ao gate bulk-approve --older-than=48h
+  ao gate bulk-approve --tier=silver --dry-run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Parse older-than duration
-		threshold := 24 * time.Hour
+		var threshold time.Duration
 		if gateOlderThan != "" {
 			var err error
 			threshold, err = time.ParseDuration(gateOlderThan)
 			if err != nil {
 				return fmt.Errorf("invalid duration %q: %w", gateOlderThan, err)
 			}
+			if threshold < pool.MinBulkApproveThreshold {
+				return pool.ErrThresholdTooLow
+			}
 		}
 
 		cwd, err := os.Getwd()
@@ -263,7 +277,17 @@ Examples:
 		// Get reviewer from system user (not spoofable via env)
 		reviewer := GetCurrentUser()
 
-		approved, err := p.BulkApprove(threshold, reviewer, GetDryRun())
+		pctx, err := loadPromotionPolicyContext(cwd)
+		if err != nil {
+			return err
+		}
+
+		approved, err := p.ApproveWhere(func(e pool.PoolEntry) bool {
+			if gateTier != "" && e.Candidate.Tier != types.Tier(gateTier) {
+				return false
+			}
+			return e.Age >= threshold && pctx.decide(e).Promote()
+		}, "bulk-approve: allowed by promotion policy", reviewer, GetDryRun())
 		if err != nil {
 			return fmt.Errorf("bulk approve: %w", err)
 		}
@@ -307,6 +331,6 @@ func init() {
 	gateRejectCmd.Flags().StringVar(&gateReason, "reason", "", "Required rejection reason")
 	_ = gateRejectCmd.MarkFlagRequired("reason") //nolint:errcheck
 
-	gateBulkApproveCmd.Flags().StringVar(&gateOlderThan, "older-than", "24h", "Age threshold for bulk approval")
-	gateBulkApproveCmd.Flags().StringVar(&gateTier, "tier", "silver", "Tier to bulk approve (default: silver)")
+	gateBulkApproveCmd.Flags().StringVar(&gateOlderThan, "older-than", "", "Additional age threshold for bulk approval (default: policy decides)")
+	gateBulkApproveCmd.Flags().StringVar(&gateTier, "tier", "", "Only approve this tier (default: any tier the policy allows)")
 }
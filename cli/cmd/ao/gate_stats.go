@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+)
+
+var gateStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report review sampling and reject rates per tier",
+	Long: `Report, per tier, how many candidates reached human review because the
+tier requires it versus because they were sampled, and how often each kind
+of review rejected.
+
+Sampled reviews are a quality check on tiers that otherwise auto-promote.
+When more than 20% of at least 10 sampled reviews of a tier are rejected,
+that tier's sample rate is raised for new candidates (shown as RATE).
+
+Examples:
+  ao gate stats
+  ao gate stats -o json`,
+	Args: cobra.NoArgs,
+	RunE: runGateStats,
+}
+
+func init() {
+	gateCmd.AddCommand(gateStatsCmd)
+}
+
+func runGateStats(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	// Base rates as ingest applies them
+	stats, err := newIngestPool(cwd).ReviewStats()
+	if err != nil {
+		return fmt.Errorf("review stats: %w", err)
+	}
+	return outputGateStats(cmd.OutOrStdout(), stats)
+}
+
+func outputGateStats(out io.Writer, stats []pool.ReviewStats) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	if len(stats) == 0 {
+		fmt.Fprintln(out, "No review history yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	//nolint:errcheck // CLI tabwriter output
+	fmt.Fprintln(w, "TIER\tADDED\tREQUIRED\tSAMPLED\tREQ REJECTS\tSAMPLE REJECTS\tRATE")
+	//nolint:errcheck // CLI tabwriter output
+	fmt.Fprintln(w, "----\t-----\t--------\t-------\t-----------\t--------------\t----")
+	var escalated []pool.ReviewStats
+	for _, s := range stats {
+		rate := fmt.Sprintf("%.0f%%", s.Rate*100)
+		if s.Escalated() {
+			rate += fmt.Sprintf(" (base %.0f%%)", s.BaseRate*100)
+			escalated = append(escalated, s)
+		}
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			s.Tier, s.Added, s.Required, s.Sampled,
+			formatRejects(s.RequiredRejected, s.RequiredReviewed),
+			formatRejects(s.SampledRejected, s.SampledReviewed),
+			rate)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, s := range escalated {
+		fmt.Fprintf(out, "\n! %s: %.0f%% of sampled reviews rejected (> %.0f%%); sampling raised to %.0f%%\n",
+			s.Tier, s.SampledRejectRate()*100, taxonomy.SampleEscalationRejectRate*100, s.Rate*100)
+	}
+	return nil
+}
+
+// formatRejects renders rejected/reviewed with the reject rate.
+func formatRejects(rejected, reviewed int) string {
+	if reviewed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.0f%%)", rejected, reviewed, 100*float64(rejected)/float64(reviewed))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/scorer"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+)
+
+var (
+	gateTrainModel   string
+	gateTrainHoldout float64
+	gateTrainSeed    string
+)
+
+var gateTrainCmd = &cobra.Command{
+	Use:   "train",
+	Short: "Learn the extraction scorer from gate decisions",
+	Long: `Fit the candidate scorer to historical 'ao gate approve' and
+'ao gate reject' decisions and save it to .agents/ao/scorer.json.
+
+Models:
+  weights    Rubric weights fitted to decisions; a drop-in for the defaults
+  logistic   Logistic model over the rubric and content features
+             (length, code blocks, lists, a source section)
+
+A held-out split of the decisions (--holdout, chosen reproducibly by
+--seed) is kept out of training, and the report compares the learned
+scorer's accuracy on it with the default rubric weights.
+
+Ingest uses the saved model with 'ao pool ingest --scorer learned'.
+
+Examples:
+  ao gate train
+  ao gate train --model logistic
+  ao gate train --dry-run -o json`,
+	Args: cobra.NoArgs,
+	RunE: runGateTrain,
+}
+
+func init() {
+	gateCmd.AddCommand(gateTrainCmd)
+	gateTrainCmd.Flags().StringVar(&gateTrainModel, "model", scorer.KindWeights, "Model to fit: weights or logistic")
+	gateTrainCmd.Flags().Float64Var(&gateTrainHoldout, "holdout", scorer.DefaultHoldout, "Fraction of decisions held out for evaluation")
+	gateTrainCmd.Flags().StringVar(&gateTrainSeed, "seed", "", "Seed for the holdout split")
+}
+
+func runGateTrain(cmd *cobra.Command, args []string) error {
+	if gateTrainHoldout <= 0 || gateTrainHoldout >= 1 {
+		return fmt.Errorf("--holdout must be between 0 and 1")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	examples, skipped, err := pool.NewPool(cwd).GateDecisions()
+	if err != nil {
+		return fmt.Errorf("load gate decisions: %w", err)
+	}
+	if skipped > 0 {
+		VerbosePrintf("Skipped %d decision(s) whose candidate is no longer in the pool\n", skipped)
+	}
+
+	model, err := scorer.Train(examples, scorer.TrainOptions{
+		Kind:    gateTrainModel,
+		Holdout: gateTrainHoldout,
+		Seed:    gateTrainSeed,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !GetDryRun() {
+		if err := scorer.Save(cwd, model); err != nil {
+			return err
+		}
+	}
+	return outputGateTrain(cmd.OutOrStdout(), model)
+}
+
+func outputGateTrain(w io.Writer, m *scorer.Model) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}
+
+	r := m.Report
+	fmt.Fprintf(w, "Trained %s scorer on %d gate decisions (%d approved, %d rejected)\n",
+		m.Kind, r.Examples, r.Approved, r.Rejected)
+	fmt.Fprintf(w, "  Train: %d  Held out: %d\n\n", r.Train, r.Holdout)
+
+	split := "Held-out"
+	if r.InSample {
+		split = "In-sample (no decisions fell in the holdout split)"
+	}
+	fmt.Fprintf(w, "%s accuracy:\n", split)
+	fmt.Fprintf(w, "  default weights  %.2f (threshold %.2f)\n", r.DefaultAccuracy, r.DefaultThreshold)
+	fmt.Fprintf(w, "  learned          %.2f (threshold %.2f)\n\n", r.LearnedAccuracy, m.Threshold)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if m.Kind == scorer.KindLogistic {
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintln(tw, "FEATURE\tCOEFFICIENT")
+		for _, name := range scorer.AllFeatures {
+			//nolint:errcheck // CLI tabwriter output
+			fmt.Fprintf(tw, "%s\t%+.3f\n", name, m.Coefficients[name])
+		}
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintf(tw, "(intercept)\t%+.3f\n", m.Intercept)
+	} else {
+		def, learned := taxonomy.DefaultRubricWeights, m.Weights
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintln(tw, "DIMENSION\tDEFAULT\tLEARNED")
+		rows := []struct {
+			name         string
+			def, learned float64
+		}{
+			{scorer.FeatureSpecificity, def.Specificity, learned.Specificity},
+			{scorer.FeatureActionability, def.Actionability, learned.Actionability},
+			{scorer.FeatureNovelty, def.Novelty, learned.Novelty},
+			{scorer.FeatureContext, def.Context, learned.Context},
+			{scorer.FeatureConfidence, def.Confidence, learned.Confidence},
+		}
+		for _, row := range rows {
+			//nolint:errcheck // CLI tabwriter output
+			fmt.Fprintf(tw, "%s\t%.2f\t%.2f\n", row.name, row.def, row.learned)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if r.LearnedAccuracy < r.DefaultAccuracy {
+		fmt.Fprintln(w, "Warning: the learned scorer is less accurate than the default weights on held-out decisions")
+	}
+	if GetDryRun() {
+		fmt.Fprintf(w, "[dry-run] Would save scorer to %s\n", scorer.ModelFile)
+	} else {
+		fmt.Fprintf(w, "Saved: %s (use with 'ao pool ingest --scorer learned')\n", scorer.ModelFile)
+	}
+	return nil
+}
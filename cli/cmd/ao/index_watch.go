@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+var (
+	indexWatchDebounce   time.Duration
+	indexWatchCheckpoint time.Duration
+	indexWatchQuiet      bool
+)
+
+var indexWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep the search index current as knowledge files change",
+	Long: `Watch the search corpus and update the search index incrementally.
+
+Listens for filesystem notifications under:
+  .agents/learnings
+  .agents/patterns
+  .agents/ao/sessions
+
+Created and modified files are re-indexed, deleted or renamed files are
+removed from the index, and the index is checkpointed atomically to
+.agents/ao/index.jsonl whenever it changes. Only the changed
+document's terms are touched, so updates stay fast on large corpora.
+
+Runs until interrupted (Ctrl-C), then applies pending changes and writes a
+final checkpoint.
+
+Examples:
+  ao index watch
+  ao index watch --checkpoint-interval 30s
+  ao index watch --quiet`,
+	RunE: runIndexWatch,
+}
+
+func init() {
+	indexCmd.AddCommand(indexWatchCmd)
+	indexWatchCmd.Flags().DurationVar(&indexWatchDebounce, "debounce", search.DefaultWatchDebounce, "Wait this long after the last event before re-indexing")
+	indexWatchCmd.Flags().DurationVar(&indexWatchCheckpoint, "checkpoint-interval", search.DefaultCheckpointInterval, "How often to save a changed index")
+	indexWatchCmd.Flags().BoolVar(&indexWatchQuiet, "quiet", false, "Only report errors and the final summary")
+}
+
+func runIndexWatch(cmd *cobra.Command, args []string) error {
+	if indexWatchDebounce <= 0 || indexWatchCheckpoint <= 0 {
+		return fmt.Errorf("--debounce and --checkpoint-interval must be positive")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
+	dirs := searchCorpusDirs(baseDir)
+
+	if GetDryRun() {
+		fmt.Printf("[dry-run] Would watch %d directories and update %s\n",
+			len(dirs), filepath.Join(baseDir, searchIndexFileName))
+		return nil
+	}
+
+	idx, err := openSearchIndex(baseDir)
+	if err != nil {
+		return err
+	}
+
+	w := search.NewWatcher(idx, filepath.Join(baseDir, searchIndexFileName), dirs...)
+	w.Debounce = indexWatchDebounce
+	w.CheckpointInterval = indexWatchCheckpoint
+	if !indexWatchQuiet {
+		w.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, "%s "+format+"\n", append([]interface{}{time.Now().Format("15:04:05")}, args...)...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if !indexWatchQuiet {
+		fmt.Fprintf(os.Stderr, "Watching %d directories (%d docs indexed), Ctrl-C to exit\n", len(dirs), len(idx.Docs))
+	}
+
+	stats, err := w.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("watch search corpus: %w", err)
+	}
+
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	fmt.Printf("Index watch stopped: %d updated, %d removed, %d checkpoints\n",
+		stats.Updated, stats.Removed, stats.Checkpoints)
+	return nil
+}
@@ -19,9 +19,6 @@ const (
 	// DefaultInjectMaxTokens is the default token budget for injection (~1500 tokens ≈ 6KB)
 	DefaultInjectMaxTokens = 1500
 
-	// InjectCharsPerToken is the approximate characters per token (conservative estimate)
-	InjectCharsPerToken = 4
-
 	// MaxLearningsToInject is the maximum number of learnings to include
 	MaxLearningsToInject = 10
 
@@ -39,6 +36,11 @@ var (
 	injectSessionID  string
 	injectNoCite     bool
 	injectApplyDecay bool
+	injectSemantic   bool
+	injectQuotas     map[string]int
+
+	injectExplore     float64
+	injectExploreSeed string
 )
 
 type olConstraint struct {
@@ -56,6 +58,9 @@ type injectedKnowledge struct {
 	OLConstraints []olConstraint `json:"ol_constraints,omitempty"`
 	Timestamp     time.Time      `json:"timestamp"`
 	Query         string         `json:"query,omitempty"`
+
+	// Budget reports the token budget and any items it left out.
+	Budget *injectBudgetReport `json:"budget,omitempty"`
 }
 
 type learning struct {
@@ -66,7 +71,10 @@ type learning struct {
 	FreshnessScore float64 `json:"freshness_score,omitempty"`
 	AgeWeeks       float64 `json:"age_weeks,omitempty"`
 	Utility        float64 `json:"utility,omitempty"`         // MemRL utility value
+	RewardCount    int     `json:"reward_count,omitempty"`    // Feedback events behind Utility
 	CompositeScore float64 `json:"composite_score,omitempty"` // Two-Phase ranking score
+	Exploratory    bool    `json:"exploratory,omitempty"`     // Chosen by Thompson sampling
+	Sample         float64 `json:"sample,omitempty"`          // Utility drawn when exploring
 	Superseded     bool    `json:"-"`                         // Internal flag - not serialized
 }
 
@@ -95,15 +103,29 @@ Searches:
   3. Recent session summaries (.agents/ao/sessions/)
 
 Uses file-based search with Two-Phase retrieval (freshness + utility scoring).
+Each learning, pattern, session and constraint is kept or dropped whole:
+items are packed into --max-tokens by value (knapsack), after guaranteeing
+each section its --quota minimum. JSON output lists dropped items and why.
 CASS integration adds maturity weighting and confidence decay.
+With --semantic, learnings are matched to the query by fused keyword and
+local vector retrieval (see 'ao search --hybrid') instead of substring
+matching, so conceptually related learnings are found offline.
+With --explore, a fraction of the learning slots goes to learnings drawn by
+Thompson sampling: each learning's utility is modelled as a Beta posterior
+from its reward history and sampled, so learnings with little feedback get
+injected and earn some. Draws depend on --explore-seed and the session ID.
+Exploratory picks are flagged in the JSON output and in citations.jsonl.
 
 Examples:
   ao inject                     # Inject general knowledge
   ao inject "authentication"    # Inject knowledge about auth
   ao inject --max-tokens 2000   # Larger budget
+  ao inject --quota learnings=4 # Keep at least 4 learnings if they fit
   ao inject --format json       # JSON output
   ao inject --no-cite           # Skip citation recording
-  ao inject --apply-decay       # Apply confidence decay before ranking`,
+  ao inject --apply-decay       # Apply confidence decay before ranking
+  ao inject --semantic "auth"   # Match related learnings semantically
+  ao inject --explore 0.2       # Explore 2 of 10 learning slots`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInject,
 }
@@ -116,6 +138,10 @@ func init() {
 	injectCmd.Flags().StringVar(&injectSessionID, "session", "", "Session ID for citation tracking (auto-generated if empty)")
 	injectCmd.Flags().BoolVar(&injectNoCite, "no-cite", false, "Disable citation recording")
 	injectCmd.Flags().BoolVar(&injectApplyDecay, "apply-decay", false, "Apply confidence decay before ranking")
+	injectCmd.Flags().BoolVar(&injectSemantic, "semantic", false, "Match learnings to the query with keyword + vector retrieval")
+	injectCmd.Flags().Float64Var(&injectExplore, "explore", 0, "Fraction of learning slots filled by Thompson sampling (default: inject.explore from config)")
+	injectCmd.Flags().StringVar(&injectExploreSeed, "explore-seed", "", "Seed for exploration draws, combined with the session ID (default: inject.explore_seed from config)")
+	injectCmd.Flags().StringToIntVar(&injectQuotas, "quota", nil, "Minimum items per section when they fit, e.g. learnings=3,sessions=0 (default learnings=2,patterns=1,sessions=1,constraints=1)")
 }
 
 func runInject(cmd *cobra.Command, args []string) error {
@@ -139,8 +165,14 @@ func runInject(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	// Calculate character budget from tokens
-	charBudget := injectMaxTokens * InjectCharsPerToken
+	quotas, err := parseInjectQuotas(injectQuotas)
+	if err != nil {
+		return err
+	}
+	explore, exploreSeed, err := resolveInjectExplore(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Get or generate session ID for citation tracking
 	sessionID := canonicalSessionID(injectSessionID)
@@ -151,19 +183,16 @@ func runInject(cmd *cobra.Command, args []string) error {
 		Query:     query,
 	}
 
-	// Search learnings
-	learnings, err := collectLearnings(cwd, query, MaxLearningsToInject)
+	// Search learnings, exploring part of the slots when asked
+	learnings, err := rankLearnings(cwd, query)
 	if err != nil {
 		VerbosePrintf("Warning: failed to collect learnings: %v\n", err)
 	}
-	knowledge.Learnings = learnings
-
-	// Record citations for retrieved learnings (Phase 0: Critical for MemRL feedback loop)
-	if !injectNoCite && len(learnings) > 0 {
-		if err := recordCitations(cwd, learnings, sessionID, query); err != nil {
-			VerbosePrintf("Warning: failed to record citations: %v\n", err)
-		} else {
-			VerbosePrintf("Recorded %d citations for session %s\n", len(learnings), sessionID)
+	slots := exploreSlots(explore, MaxLearningsToInject)
+	knowledge.Learnings = exploreLearnings(learnings, MaxLearningsToInject, slots, exploreRand(exploreSeed, sessionID))
+	for _, l := range knowledge.Learnings {
+		if l.Exploratory {
+			VerbosePrintf("Exploring %s (sampled utility %.2f)\n", l.ID, l.Sample)
 		}
 	}
 
@@ -188,6 +217,23 @@ func runInject(cmd *cobra.Command, args []string) error {
 	}
 	knowledge.OLConstraints = olConstraints
 
+	// Pack whole items into the token budget
+	if injectMaxTokens > 0 {
+		knowledge.Budget = packKnowledge(knowledge, injectMaxTokens, injectFormat, quotas)
+		for _, d := range knowledge.Budget.Dropped {
+			VerbosePrintf("Dropped %s %q (%d tokens): %s\n", d.Section, d.ID, d.Tokens, d.Reason)
+		}
+	}
+
+	// Record citations for injected learnings (Phase 0: Critical for MemRL feedback loop)
+	if !injectNoCite && len(knowledge.Learnings) > 0 {
+		if err := recordCitations(cwd, knowledge.Learnings, sessionID, query); err != nil {
+			VerbosePrintf("Warning: failed to record citations: %v\n", err)
+		} else {
+			VerbosePrintf("Recorded %d citations for session %s\n", len(knowledge.Learnings), sessionID)
+		}
+	}
+
 	// Format output
 	var output string
 	if injectFormat == "json" {
@@ -200,11 +246,6 @@ func runInject(cmd *cobra.Command, args []string) error {
 		output = formatKnowledgeMarkdown(knowledge)
 	}
 
-	// Trim to budget if needed
-	if len(output) > charBudget {
-		output = trimToCharBudget(output, charBudget)
-	}
-
 	fmt.Println(output)
 	return nil
 }
@@ -249,11 +290,7 @@ func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 	if len(k.Learnings) > 0 {
 		sb.WriteString("### Recent Learnings\n")
 		for _, l := range k.Learnings {
-			if l.Summary != "" {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", l.ID, l.Summary))
-			} else {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", l.ID, l.Title))
-			}
+			sb.WriteString(formatLearningLine(l))
 		}
 		sb.WriteString("\n")
 	}
@@ -261,11 +298,7 @@ func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 	if len(k.Patterns) > 0 {
 		sb.WriteString("### Active Patterns\n")
 		for _, p := range k.Patterns {
-			if p.Description != "" {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", p.Name, p.Description))
-			} else {
-				sb.WriteString(fmt.Sprintf("- **%s**\n", p.Name))
-			}
+			sb.WriteString(formatPatternLine(p))
 		}
 		sb.WriteString("\n")
 	}
@@ -273,7 +306,7 @@ func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 	if len(k.Sessions) > 0 {
 		sb.WriteString("### Recent Sessions\n")
 		for _, s := range k.Sessions {
-			sb.WriteString(fmt.Sprintf("- [%s] %s\n", s.Date, s.Summary))
+			sb.WriteString(formatSessionLine(s))
 		}
 		sb.WriteString("\n")
 	}
@@ -281,7 +314,7 @@ func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 	if len(k.OLConstraints) > 0 {
 		sb.WriteString("### Olympus Constraints\n")
 		for _, c := range k.OLConstraints {
-			sb.WriteString(fmt.Sprintf("- **[olympus constraint]** %s: %s\n", c.Pattern, c.Detection))
+			sb.WriteString(formatConstraintLine(c))
 		}
 		sb.WriteString("\n")
 	}
@@ -290,30 +323,42 @@ func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 		sb.WriteString("*No prior knowledge found.*\n\n")
 	}
 
+	if k.Budget != nil && len(k.Budget.Dropped) > 0 {
+		sb.WriteString(fmt.Sprintf(injectOmittedFormat, len(k.Budget.Dropped)))
+	}
+
 	sb.WriteString(fmt.Sprintf("*Last injection: %s*\n", k.Timestamp.Format(time.RFC3339)))
 
 	return sb.String()
 }
 
-// trimToCharBudget truncates output to fit character budget
-func trimToCharBudget(output string, budget int) string {
-	if len(output) <= budget {
-		return output
+// injectOmittedFormat notes how many items the token budget left out.
+const injectOmittedFormat = "*[%d items omitted to fit token budget]*\n"
+
+// formatLearningLine renders one learning as a markdown list item.
+func formatLearningLine(l learning) string {
+	if l.Summary != "" {
+		return fmt.Sprintf("- **%s**: %s\n", l.ID, l.Summary)
 	}
+	return fmt.Sprintf("- **%s**: %s\n", l.ID, l.Title)
+}
 
-	// Try to truncate at a section boundary
-	lines := strings.Split(output, "\n")
-	var result strings.Builder
-	for _, line := range lines {
-		if result.Len()+len(line)+1 > budget-50 { // Leave room for truncation marker
-			break
-		}
-		result.WriteString(line)
-		result.WriteString("\n")
+// formatPatternLine renders one pattern as a markdown list item.
+func formatPatternLine(p pattern) string {
+	if p.Description != "" {
+		return fmt.Sprintf("- **%s**: %s\n", p.Name, p.Description)
 	}
+	return fmt.Sprintf("- **%s**\n", p.Name)
+}
 
-	result.WriteString("\n*[truncated to fit token budget]*\n")
-	return result.String()
+// formatSessionLine renders one session summary as a markdown list item.
+func formatSessionLine(s session) string {
+	return fmt.Sprintf("- [%s] %s\n", s.Date, s.Summary)
+}
+
+// formatConstraintLine renders one Olympus constraint as a markdown list item.
+func formatConstraintLine(c olConstraint) string {
+	return fmt.Sprintf("- **[olympus constraint]** %s: %s\n", c.Pattern, c.Detection)
 }
 
 // truncateText truncates a string to max length with ellipsis
@@ -326,7 +371,8 @@ func truncateText(s string, maxLen int) string {
 
 // canonicalSessionID normalizes session IDs to a consistent format.
 // Addresses pre-mortem C2: session ID format mismatch causing zero citation matches.
-// Format: session-YYYYMMDD-HHMMSS (auto-generated if empty or random string).
+// Format: session-YYYYMMDD-HHMMSS when generated for an empty ID; UUIDs and
+// other IDs are kept, so the same session always maps to the same ID.
 func canonicalSessionID(raw string) string {
 	if raw == "" {
 		// Generate new session ID with timestamp
@@ -339,11 +385,11 @@ func canonicalSessionID(raw string) string {
 		return raw
 	}
 
-	// Check for UUID format (e.g., from Claude sessions)
+	// UUIDs (e.g., from Claude sessions) are stable already; deriving a
+	// timestamp from the clock would give each call a new ID
 	uuidPattern := regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
 	if uuidPattern.MatchString(raw) {
-		// Convert UUID to canonical by prepending "session-" and using current timestamp
-		return fmt.Sprintf("session-%s", time.Now().Format("20060102-150405"))
+		return raw
 	}
 
 	// Return as-is for other formats (e.g., user-provided IDs)
@@ -388,7 +434,7 @@ func collectOLConstraints(cwd, query string) ([]olConstraint, error) {
 	return filtered, nil
 }
 
-// recordCitations records citation events for retrieved learnings.
+// recordCitations records citation events for injected learnings.
 // This is critical for closing the MemRL feedback loop (Phase 0).
 // Citations link: session → learning → feedback → utility update.
 func recordCitations(baseDir string, learnings []learning, sessionID, query string) error {
@@ -397,8 +443,9 @@ func recordCitations(baseDir string, learnings []learning, sessionID, query stri
 			ArtifactPath: l.Source,
 			SessionID:    sessionID,
 			CitedAt:      time.Now(),
-			CitationType: "retrieved", // Will be upgraded to "applied" if session succeeds
+			CitationType: types.CitationTypeInjected, // ao feedback-loop detects actual use from the transcript
 			Query:        query,
+			Exploratory:  l.Exploratory,
 		}
 
 		if err := ratchet.RecordCitation(baseDir, event); err != nil {
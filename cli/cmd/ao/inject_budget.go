@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
+)
+
+// Inject sections, as used in --quota and the dropped-items report.
+const (
+	injectSectionLearnings   = "learnings"
+	injectSectionPatterns    = "patterns"
+	injectSectionSessions    = "sessions"
+	injectSectionConstraints = "constraints"
+)
+
+// defaultInjectQuotas is the minimum number of items kept per section when
+// they fit, so one large section cannot crowd out the others.
+var defaultInjectQuotas = map[string]int{
+	injectSectionLearnings:   2,
+	injectSectionPatterns:    1,
+	injectSectionSessions:    1,
+	injectSectionConstraints: 1,
+}
+
+// injectBudgetReport explains how the token budget was spent.
+type injectBudgetReport struct {
+	MaxTokens  int                     `json:"max_tokens"`
+	UsedTokens int                     `json:"used_tokens"`
+	Dropped    []aocontext.DroppedItem `json:"dropped,omitempty"`
+}
+
+// packKnowledge keeps the most valuable learnings, patterns, sessions and
+// constraints whose estimated cost in the given output format fits in
+// maxTokens, honouring per-section minimum quotas. Items are kept whole and
+// in their original order. Fixed output (headers, footer, the omitted-items
+// note and the heading of every non-empty section) is reserved before
+// packing; the JSON budget report itself is not counted.
+func packKnowledge(k *injectedKnowledge, maxTokens int, format string, quotas map[string]int) *injectBudgetReport {
+	var items []aocontext.PackItem
+	add := func(section, id string, v interface{}, text string, value float64) {
+		if format == "json" {
+			data, err := json.MarshalIndent(v, "    ", "  ")
+			if err == nil {
+				text = string(data) + ",\n"
+			}
+		}
+		items = append(items, aocontext.PackItem{
+			Section: section,
+			ID:      id,
+			Tokens:  aocontext.EstimateTokens(text),
+			Value:   value,
+		})
+	}
+
+	for _, l := range k.Learnings {
+		value := sigmoid(l.CompositeScore)
+		if l.Exploratory {
+			// Exploratory picks compete on their sampled utility
+			value = l.Sample
+		}
+		add(injectSectionLearnings, l.ID, l, formatLearningLine(l), value)
+	}
+	for i, p := range k.Patterns {
+		add(injectSectionPatterns, p.Name, p, formatPatternLine(p), rankValue(0.5, i))
+	}
+	for i, s := range k.Sessions {
+		add(injectSectionSessions, s.Date+" "+truncateText(s.Summary, 40), s, formatSessionLine(s), rankValue(0.4, i))
+	}
+	for _, c := range k.OLConstraints {
+		value := c.Confidence
+		if value <= 0 {
+			value = 0.5
+		}
+		add(injectSectionConstraints, c.Pattern, c, formatConstraintLine(c), value)
+	}
+
+	fixed := injectFixedTokens(k, format)
+	res := aocontext.Pack(items, maxTokens-fixed, quotas)
+
+	keep := make(map[int]bool, len(res.Selected))
+	for _, i := range res.Selected {
+		keep[i] = true
+	}
+	n := 0
+	k.Learnings = keepIndexed(k.Learnings, keep, &n)
+	k.Patterns = keepIndexed(k.Patterns, keep, &n)
+	k.Sessions = keepIndexed(k.Sessions, keep, &n)
+	k.OLConstraints = keepIndexed(k.OLConstraints, keep, &n)
+
+	return &injectBudgetReport{
+		MaxTokens:  maxTokens,
+		UsedTokens: fixed + res.Tokens,
+		Dropped:    res.Dropped,
+	}
+}
+
+// injectFixedTokens estimates the cost of everything but the items.
+func injectFixedTokens(k *injectedKnowledge, format string) int {
+	if format == "json" {
+		empty := injectedKnowledge{Timestamp: k.Timestamp, Query: k.Query}
+		data, err := json.MarshalIndent(empty, "", "  ")
+		if err != nil {
+			return 0
+		}
+		tokens := aocontext.EstimateTokens(string(data))
+		for _, n := range []int{len(k.Learnings), len(k.Patterns), len(k.Sessions), len(k.OLConstraints)} {
+			if n > 0 {
+				tokens += aocontext.EstimateTokens(`  "ol_constraints": [` + "\n  ],\n")
+			}
+		}
+		return tokens
+	}
+
+	n := len(k.Learnings) + len(k.Patterns) + len(k.Sessions) + len(k.OLConstraints)
+	tokens := aocontext.EstimateTokens(formatKnowledgeMarkdown(&injectedKnowledge{Timestamp: k.Timestamp}))
+	tokens += aocontext.EstimateTokens(fmt.Sprintf(injectOmittedFormat, n))
+	for heading, n := range map[string]int{
+		"### Recent Learnings\n":    len(k.Learnings),
+		"### Active Patterns\n":     len(k.Patterns),
+		"### Recent Sessions\n":     len(k.Sessions),
+		"### Olympus Constraints\n": len(k.OLConstraints),
+	} {
+		if n > 0 {
+			tokens += aocontext.EstimateTokens(heading + "\n")
+		}
+	}
+	return tokens
+}
+
+// keepIndexed filters s to the positions in keep, where positions are
+// numbered across successive calls through *offset.
+func keepIndexed[T any](s []T, keep map[int]bool, offset *int) []T {
+	var out []T
+	for i, v := range s {
+		if keep[*offset+i] {
+			out = append(out, v)
+		}
+	}
+	*offset += len(s)
+	return out
+}
+
+// sigmoid maps a z-normalised composite score to (0, 1).
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// rankValue gives unscored items a value that decays with their recency
+// rank, so newer patterns and sessions are preferred.
+func rankValue(base float64, rank int) float64 {
+	return base / (1 + 0.25*float64(rank))
+}
+
+// parseInjectQuotas validates --quota values.
+func parseInjectQuotas(q map[string]int) (map[string]int, error) {
+	out := make(map[string]int, len(defaultInjectQuotas))
+	for s, n := range defaultInjectQuotas {
+		out[s] = n
+	}
+	for s, n := range q {
+		if _, ok := defaultInjectQuotas[s]; !ok {
+			return nil, fmt.Errorf("unknown --quota section %q (use learnings, patterns, sessions, constraints)", s)
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("--quota %s must not be negative", s)
+		}
+		out[s] = n
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/config"
+)
+
+// resolveInjectExplore returns the exploration fraction and seed from
+// --explore and --explore-seed, falling back to the inject config.
+func resolveInjectExplore(cmd *cobra.Command) (float64, string, error) {
+	explore, seed := injectExplore, injectExploreSeed
+	if cfg, err := config.Load(nil); err == nil {
+		if !cmd.Flags().Changed("explore") {
+			explore = cfg.Inject.Explore
+		}
+		if seed == "" {
+			seed = cfg.Inject.ExploreSeed
+		}
+	}
+	if explore < 0 || explore > 1 {
+		return 0, "", fmt.Errorf("--explore must be between 0 and 1, got %g", explore)
+	}
+	return explore, seed, nil
+}
+
+// exploreSlots returns how many of limit learning slots a fraction of
+// exploration fills. Any positive fraction explores at least one slot.
+func exploreSlots(fraction float64, limit int) int {
+	if fraction <= 0 || limit <= 0 {
+		return 0
+	}
+	n := int(math.Round(fraction * float64(limit)))
+	return min(max(n, 1), limit)
+}
+
+// exploreRand returns the sampler for a session. Draws depend only on the
+// seed and session ID, so an injection can be reproduced, while sessions
+// sharing a seed still explore different learnings.
+func exploreRand(seed, sessionID string) *rand.Rand {
+	h := sha256.Sum256([]byte(seed + "\x00" + sessionID))
+	return rand.New(rand.NewPCG(binary.BigEndian.Uint64(h[:8]), binary.BigEndian.Uint64(h[8:16])))
+}
+
+// exploreLearnings picks limit learnings from ranked (highest composite
+// score first). The top limit-slots are taken greedily; the remaining slots
+// go to the rest of the candidates with the highest utility drawn from
+// their Beta posteriors (Thompson sampling), marked Exploratory. Learnings
+// with little feedback have wide posteriors, so they are drawn high often
+// enough to earn feedback of their own.
+func exploreLearnings(ranked []learning, limit, slots int, r *rand.Rand) []learning {
+	if len(ranked) <= limit || slots <= 0 {
+		return ranked[:min(len(ranked), limit)]
+	}
+	slots = min(slots, limit)
+
+	greedy := limit - slots
+	picked := append([]learning(nil), ranked[:greedy]...)
+	rest := append([]learning(nil), ranked[greedy:]...)
+	for i := range rest {
+		alpha, beta := betaPosterior(rest[i])
+		rest[i].Sample = sampleBeta(r, alpha, beta)
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		return rest[i].Sample > rest[j].Sample
+	})
+	for _, l := range rest[:slots] {
+		l.Exploratory = true
+		picked = append(picked, l)
+	}
+	return picked
+}
+
+// betaPosterior returns the Beta(α, β) posterior over a learning's utility,
+// starting from a uniform Beta(1, 1) prior. Rewards lie in [0, 1], so each
+// feedback event adds its reward to α and the remainder to β; utility ×
+// reward_count approximates those sums from the stored running average.
+func betaPosterior(l learning) (alpha, beta float64) {
+	n := float64(max(l.RewardCount, 0))
+	u := math.Min(math.Max(l.Utility, 0), 1)
+	return 1 + u*n, 1 + (1-u)*n
+}
+
+// sampleBeta draws from Beta(alpha, beta) as the ratio of two gamma draws.
+func sampleBeta(r *rand.Rand, alpha, beta float64) float64 {
+	x := sampleGamma(r, alpha)
+	y := sampleGamma(r, beta)
+	return x / (x + y)
+}
+
+// sampleGamma draws from Gamma(shape, 1) with the Marsaglia-Tsang method.
+func sampleGamma(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// Boost: Gamma(a) = Gamma(a+1) × U^(1/a)
+		return sampleGamma(r, shape+1) * math.Pow(1-r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := 1 - r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+func TestExploreSlots(t *testing.T) {
+	tests := []struct {
+		fraction float64
+		limit    int
+		want     int
+	}{
+		{0, 10, 0},
+		{0.2, 10, 2},
+		{0.01, 10, 1},
+		{1, 10, 10},
+		{0.5, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := exploreSlots(tt.fraction, tt.limit); got != tt.want {
+			t.Errorf("exploreSlots(%g, %d) = %d, want %d", tt.fraction, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestBetaPosterior(t *testing.T) {
+	if a, b := betaPosterior(learning{Utility: 0.5}); a != 1 || b != 1 {
+		t.Errorf("cold start posterior = Beta(%g, %g), want Beta(1, 1)", a, b)
+	}
+	if a, b := betaPosterior(learning{Utility: 0.8, RewardCount: 10}); math.Abs(a-9) > 1e-9 || math.Abs(b-3) > 1e-9 {
+		t.Errorf("posterior = Beta(%g, %g), want Beta(9, 3)", a, b)
+	}
+
+	// The sampler's mean matches the posterior mean α/(α+β)
+	r := exploreRand("seed", "session")
+	var sum float64
+	const n = 20000
+	for i := 0; i < n; i++ {
+		sum += sampleBeta(r, 9, 3)
+	}
+	if mean := sum / n; math.Abs(mean-0.75) > 0.01 {
+		t.Errorf("Beta(9, 3) sample mean = %.3f, want 0.75", mean)
+	}
+}
+
+func TestExploreLearnings(t *testing.T) {
+	// Ten proven learnings outrank ten that have never been injected
+	var ranked []learning
+	for i := 0; i < 10; i++ {
+		ranked = append(ranked, learning{ID: fmt.Sprintf("proven-%d", i), Utility: 0.6, RewardCount: 40, CompositeScore: float64(20 - i)})
+	}
+	for i := 0; i < 10; i++ {
+		ranked = append(ranked, learning{ID: fmt.Sprintf("cold-%d", i), Utility: 0.5, CompositeScore: float64(10 - i)})
+	}
+
+	if got := exploreLearnings(ranked, 10, 0, exploreRand("", "s")); len(got) != 10 || got[9].ID != "proven-9" {
+		t.Fatalf("without exploration got %v, want the greedy top 10", got)
+	}
+
+	picked := exploreLearnings(ranked, 10, 3, exploreRand("seed", "session-a"))
+	if len(picked) != 10 {
+		t.Fatalf("got %d learnings, want 10", len(picked))
+	}
+	for i, l := range picked {
+		if want := i >= 7; l.Exploratory != want {
+			t.Errorf("picked[%d] = %s exploratory=%v, want %v", i, l.ID, l.Exploratory, want)
+		}
+		if l.Exploratory && (l.Sample <= 0 || l.Sample >= 1) {
+			t.Errorf("exploratory %s has sample %g", l.ID, l.Sample)
+		}
+	}
+
+	// Same seed and session reproduce the draw; cold learnings get explored
+	// across sessions instead of the same three every time
+	again := exploreLearnings(ranked, 10, 3, exploreRand("seed", "session-a"))
+	for i := range picked {
+		if picked[i].ID != again[i].ID {
+			t.Fatalf("draw not reproducible: %s vs %s", picked[i].ID, again[i].ID)
+		}
+	}
+	explored := map[string]bool{}
+	for s := 0; s < 20; s++ {
+		for _, l := range exploreLearnings(ranked, 10, 3, exploreRand("seed", fmt.Sprint("session-", s))) {
+			if l.Exploratory {
+				explored[l.ID] = true
+			}
+		}
+	}
+	cold := 0
+	for id := range explored {
+		if strings.HasPrefix(id, "cold-") {
+			cold++
+		}
+	}
+	if cold < 8 {
+		t.Errorf("20 sessions explored %d cold learnings (%v), want most of the 10", cold, explored)
+	}
+
+	// The input ranking is left untouched
+	if ranked[15].Exploratory || ranked[15].Sample != 0 {
+		t.Error("exploreLearnings modified its input")
+	}
+}
+
+func TestRecordCitationsMarksExploratory(t *testing.T) {
+	tmp := t.TempDir()
+	learnings := []learning{
+		{ID: "a", Source: filepath.Join(tmp, "a.md")},
+		{ID: "b", Source: filepath.Join(tmp, "b.md"), Exploratory: true},
+	}
+	if err := recordCitations(tmp, learnings, "session-1", ""); err != nil {
+		t.Fatalf("recordCitations: %v", err)
+	}
+
+	citations, err := ratchet.LoadCitations(tmp)
+	if err != nil {
+		t.Fatalf("LoadCitations: %v", err)
+	}
+	if len(citations) != 2 || citations[0].Exploratory || !citations[1].Exploratory {
+		t.Errorf("citations = %+v, want only b exploratory", citations)
+	}
+}
+
+func TestParseLearningFileReadsRewardHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "l.md")
+	content := "---\nutility: 0.8200\nreward_count: 4\n---\n# Title\n\nBody.\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := parseLearningFile(path)
+	if err != nil {
+		t.Fatalf("parseLearningFile: %v", err)
+	}
+	if l.Utility != 0.82 || l.RewardCount != 4 {
+		t.Errorf("utility=%g reward_count=%d, want 0.82 and 4", l.Utility, l.RewardCount)
+	}
+}
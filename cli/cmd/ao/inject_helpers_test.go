@@ -140,49 +140,100 @@ func TestFormatKnowledgeMarkdown(t *testing.T) {
 	})
 }
 
-func TestTrimToCharBudget(t *testing.T) {
-	tests := []struct {
-		name   string
-		input  string
-		budget int
-		check  func(string) bool
-		desc   string
-	}{
-		{
-			name:   "under budget passes through",
-			input:  "short",
-			budget: 100,
-			check:  func(s string) bool { return s == "short" },
-			desc:   "should return unchanged",
-		},
-		{
-			name:   "over budget truncated",
-			input:  "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\nline9\nline10",
-			budget: 30,
-			check: func(s string) bool {
-				return len(s) <= 80 && strings.Contains(s, "truncated")
+func TestPackKnowledge(t *testing.T) {
+	long := strings.Repeat("detail ", 60) // ~100 tokens per line
+	newKnowledge := func() *injectedKnowledge {
+		return &injectedKnowledge{
+			Timestamp: time.Now(),
+			Learnings: []learning{
+				{ID: "L1", Summary: "top " + long, CompositeScore: 2},
+				{ID: "L2", Summary: "second " + long, CompositeScore: 1},
+				{ID: "L3", Summary: "third " + long, CompositeScore: -1},
+				{ID: "L4", Summary: "short one", CompositeScore: -2},
 			},
-			desc: "should be truncated with marker",
-		},
-		{
-			name:   "exact budget",
-			input:  "exact",
-			budget: 5,
-			check:  func(s string) bool { return s == "exact" },
-			desc:   "should return unchanged at exact budget",
-		},
+			Patterns: []pattern{{Name: "retry", Description: long}},
+			Sessions: []session{{Date: "2026-01-02", Summary: long}},
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := trimToCharBudget(tt.input, tt.budget)
-			if !tt.check(got) {
-				t.Errorf("trimToCharBudget(): %s; got %q", tt.desc, got)
+	t.Run("fits everything", func(t *testing.T) {
+		k := newKnowledge()
+		report := packKnowledge(k, 5000, "markdown", defaultInjectQuotas)
+		if len(report.Dropped) != 0 || len(k.Learnings) != 4 {
+			t.Fatalf("expected nothing dropped, got %+v", report.Dropped)
+		}
+		if got := aocontextEstimate(formatKnowledgeMarkdown(k)); got > report.UsedTokens+5 {
+			t.Errorf("rendered %d tokens but reported %d used", got, report.UsedTokens)
+		}
+	})
+
+	t.Run("keeps items whole and honours quotas", func(t *testing.T) {
+		k := newKnowledge()
+		report := packKnowledge(k, 450, "markdown", defaultInjectQuotas)
+		k.Budget = report
+
+		out := formatKnowledgeMarkdown(k)
+		if got := aocontextEstimate(out); got > 450 {
+			t.Errorf("rendered %d tokens, over the 450 budget", got)
+		}
+		if len(k.Patterns) != 1 || len(k.Sessions) != 1 {
+			t.Errorf("expected section quotas to keep a pattern and a session, got %d/%d", len(k.Patterns), len(k.Sessions))
+		}
+		var ids []string
+		for _, l := range k.Learnings {
+			ids = append(ids, l.ID)
+		}
+		if strings.Join(ids, ",") != "L1,L4" {
+			t.Errorf("learnings = %v, want the best long one plus the cheap one", ids)
+		}
+		if !strings.Contains(out, "items omitted to fit token budget") {
+			t.Error("expected omitted-items note in markdown")
+		}
+		for _, d := range report.Dropped {
+			if d.Section != injectSectionLearnings || d.Reason != "lower_value" {
+				t.Errorf("unexpected drop %+v", d)
 			}
-		})
+		}
+		if len(report.Dropped) != 2 {
+			t.Errorf("expected 2 dropped learnings, got %d", len(report.Dropped))
+		}
+	})
+
+	t.Run("json budget counts json size", func(t *testing.T) {
+		k := newKnowledge()
+		packKnowledge(k, 450, "json", defaultInjectQuotas)
+		k.Budget = nil
+		data, err := json.MarshalIndent(k, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := aocontextEstimate(string(data)); got > 450 {
+			t.Errorf("json output is %d tokens, over the 450 budget", got)
+		}
+	})
+}
+
+func TestParseInjectQuotas(t *testing.T) {
+	q, err := parseInjectQuotas(map[string]int{"learnings": 4, "sessions": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q["learnings"] != 4 || q["sessions"] != 0 || q["patterns"] != 1 {
+		t.Errorf("unexpected quotas %v", q)
+	}
+	if _, err := parseInjectQuotas(map[string]int{"bogus": 1}); err == nil {
+		t.Error("expected error for unknown section")
+	}
+	if _, err := parseInjectQuotas(map[string]int{"patterns": -1}); err == nil {
+		t.Error("expected error for negative quota")
 	}
 }
 
+// aocontextEstimate mirrors the estimator packKnowledge budgets with.
+func aocontextEstimate(s string) int {
+	return len(s) / 4
+}
+
 func TestTruncateText(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -342,6 +393,23 @@ superseded_by: L99
 		}
 	})
 
+	t.Run("semantic match finds related word forms", func(t *testing.T) {
+		injectSemantic = true
+		defer func() { injectSemantic = false }()
+
+		// Substring matching would miss "pool" vs "pooling"; vectors catch it
+		got, err := collectLearnings(tmpDir, "connection pool", 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 1 || got[0].Title != "Database Pooling" {
+			t.Errorf("got %+v, want only Database Pooling", got)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, ".agents", "ao", "vectors.jsonl")); err != nil {
+			t.Errorf("expected vectors to be persisted beside the index: %v", err)
+		}
+	})
+
 	t.Run("respects limit", func(t *testing.T) {
 		got, err := collectLearnings(tmpDir, "", 1)
 		if err != nil {
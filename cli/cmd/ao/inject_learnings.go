@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
@@ -10,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -17,27 +20,50 @@ import (
 // Implements MemRL Two-Phase retrieval: Phase A (similarity/freshness) + Phase B (utility-weighted)
 // With CASS integration: applies confidence decay when --apply-decay is set
 func collectLearnings(cwd, query string, limit int) ([]learning, error) {
-	learningsDir := filepath.Join(cwd, ".agents", "learnings")
-	if _, err := os.Stat(learningsDir); os.IsNotExist(err) {
-		// Try rig root
-		learningsDir = findAgentsSubdir(cwd, "learnings")
-		if learningsDir == "" {
-			return nil, nil // No learnings directory
-		}
+	learnings, err := rankLearnings(cwd, query)
+	if err != nil {
+		return nil, err
 	}
 
-	files, err := filepath.Glob(filepath.Join(learningsDir, "*.md"))
+	// Limit results
+	if len(learnings) > limit {
+		learnings = learnings[:limit]
+	}
+
+	return learnings, nil
+}
+
+// rankLearnings returns every learning matching query, highest composite
+// score first.
+func rankLearnings(cwd, query string) ([]learning, error) {
+	return rankLearningsAt(cwd, query, time.Now(), types.DefaultLambda)
+}
+
+// rankLearningsAt ranks learnings as of now, weighting utility by lambda.
+// 'ao eval retrieval' replays it against frozen snapshots.
+func rankLearningsAt(cwd, query string, now time.Time, lambda float64) ([]learning, error) {
+	learningsDir := findLearningsDir(cwd)
+	if learningsDir == "" {
+		return nil, nil // No learnings directory
+	}
+
+	files, err := learningFiles(learningsDir)
 	if err != nil {
 		return nil, err
 	}
 
-	// Also check .jsonl files
-	jsonlFiles, _ := filepath.Glob(filepath.Join(learningsDir, "*.jsonl"))
-	files = append(files, jsonlFiles...)
-
 	var learnings []learning
 	queryLower := strings.ToLower(query)
-	now := time.Now()
+
+	// Semantic mode: match by retrieval over the search index instead of substring
+	var related map[string]bool
+	if injectSemantic && query != "" {
+		related, err = semanticLearningMatches(learningsDir, query)
+		if err != nil {
+			VerbosePrintf("Warning: semantic match failed, using substring match: %v\n", err)
+			related = nil
+		}
+	}
 
 	for _, file := range files {
 		l, err := parseLearningFile(file)
@@ -52,7 +78,11 @@ func collectLearnings(cwd, query string, limit int) ([]learning, error) {
 		}
 
 		// Filter by query if provided
-		if query != "" {
+		if related != nil {
+			if !related[file] {
+				continue
+			}
+		} else if query != "" {
 			content := strings.ToLower(l.Title + " " + l.Summary)
 			if !strings.Contains(content, queryLower) {
 				continue
@@ -86,19 +116,63 @@ func collectLearnings(cwd, query string, limit int) ([]learning, error) {
 
 	// Phase B: Calculate composite scores with z-normalization
 	// Score = z_norm(freshness) + λ × z_norm(utility)
-	applyCompositeScoring(learnings, types.DefaultLambda)
+	applyCompositeScoring(learnings, lambda)
 
 	// Sort by composite score (highest first) - Two-Phase retrieval
 	sort.Slice(learnings, func(i, j int) bool {
 		return learnings[i].CompositeScore > learnings[j].CompositeScore
 	})
 
-	// Limit results
-	if len(learnings) > limit {
-		learnings = learnings[:limit]
+	return learnings, nil
+}
+
+// findLearningsDir returns .agents/learnings under cwd, or at the rig root,
+// or "" if there is none.
+func findLearningsDir(cwd string) string {
+	learningsDir := filepath.Join(cwd, ".agents", "learnings")
+	if _, err := os.Stat(learningsDir); os.IsNotExist(err) {
+		// Try rig root
+		return findAgentsSubdir(cwd, "learnings")
 	}
+	return learningsDir
+}
 
-	return learnings, nil
+// learningFiles lists the markdown and JSONL learnings in learningsDir.
+func learningFiles(learningsDir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(learningsDir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Also check .jsonl files
+	jsonlFiles, _ := filepath.Glob(filepath.Join(learningsDir, "*.jsonl"))
+	return append(files, jsonlFiles...), nil
+}
+
+// semanticLearningMatches returns the learning files related to query,
+// using hybrid keyword + vector retrieval over the search index that sits
+// beside learningsDir (.agents/ao/).
+func semanticLearningMatches(learningsDir, query string) (map[string]bool, error) {
+	baseDir := filepath.Join(filepath.Dir(learningsDir), filepath.Base(storage.DefaultBaseDir))
+	idx, err := openSearchIndex(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := search.NewHybridRetriever(
+		search.NewKeywordRetriever(idx),
+		search.NewVectorRetriever(idx, openVectorIndex(baseDir, idx)),
+	)
+	hits, err := r.Retrieve(query+" type:learning", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make(map[string]bool, len(hits))
+	for _, h := range hits {
+		related[h.Path] = true
+	}
+	return related, nil
 }
 
 // applyConfidenceDecay applies time-based confidence decay to a learning.
@@ -166,6 +240,8 @@ func applyConfidenceDecay(l learning, filePath string, now time.Time) learning {
 // frontMatter holds parsed YAML front matter fields
 type frontMatter struct {
 	SupersededBy string
+	Utility      float64
+	RewardCount  int
 }
 
 // parseFrontMatter extracts YAML front matter from markdown content
@@ -186,6 +262,12 @@ func parseFrontMatter(lines []string) (frontMatter, int) {
 		if strings.HasPrefix(line, "superseded_by:") || strings.HasPrefix(line, "superseded-by:") {
 			fm.SupersededBy = strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
 		}
+		if strings.HasPrefix(line, "utility:") {
+			_, _ = fmt.Sscanf(line, "utility: %f", &fm.Utility) //nolint:errcheck // best effort parse
+		}
+		if strings.HasPrefix(line, "reward_count:") {
+			_, _ = fmt.Sscanf(line, "reward_count: %d", &fm.RewardCount) //nolint:errcheck // best effort parse
+		}
 	}
 	return fm, endLine
 }
@@ -236,6 +318,8 @@ func parseLearningFile(path string) (learning, error) {
 		l.Superseded = true
 		return l, nil
 	}
+	l.Utility = fm.Utility
+	l.RewardCount = fm.RewardCount
 
 	// Parse body content
 	for i := contentStart; i < len(lines); i++ {
@@ -300,6 +384,9 @@ func parseLearningJSONL(path string) (learning, error) {
 			if utility, ok := data["utility"].(float64); ok && utility > 0 {
 				l.Utility = utility
 			}
+			if count, ok := data["reward_count"].(float64); ok {
+				l.RewardCount = int(count)
+			}
 		}
 	}
 
@@ -39,6 +39,9 @@ Transition Rules:
   established → candidate:    utility < 0.5 (demotion)
   candidate → provisional:    utility < 0.3 (demotion)
 
+Checking one learning also lists its latest feedback, with how each reward
+was split from its session's reward (see 'ao feedback-loop --help').
+
 Examples:
   ao maturity L001                    # Check maturity status of a learning
   ao maturity L001 --apply            # Check and apply transition if needed
@@ -104,18 +107,57 @@ func runMaturity(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("check maturity: %w", err)
 	}
+	feedback := recentLearningFeedback(cwd, learningPath, maturityFeedbackLimit)
 
 	// Output results
 	if GetOutput() == "json" {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(result)
+		return enc.Encode(struct {
+			*ratchet.MaturityTransitionResult
+			Feedback []FeedbackEvent `json:"feedback,omitempty"`
+		}{result, feedback})
 	}
 
 	displayMaturityResult(result, maturityApply)
+	displayLearningFeedback(feedback)
 	return nil
 }
 
+// maturityFeedbackLimit is how many feedback events explain a learning's
+// maturity.
+const maturityFeedbackLimit = 5
+
+// recentLearningFeedback returns the latest feedback events for a learning,
+// newest first.
+func recentLearningFeedback(cwd, learningPath string, limit int) []FeedbackEvent {
+	events, err := loadFeedbackEvents(cwd)
+	if err != nil {
+		return nil
+	}
+	var recent []FeedbackEvent
+	for i := len(events) - 1; i >= 0 && len(recent) < limit; i-- {
+		if filepath.Base(events[i].ArtifactPath) == filepath.Base(learningPath) {
+			recent = append(recent, events[i])
+		}
+	}
+	return recent
+}
+
+// displayLearningFeedback shows how recent rewards were assigned.
+func displayLearningFeedback(events []FeedbackEvent) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Println("  Recent feedback:")
+	for _, e := range events {
+		fmt.Printf("    %s  reward %.2f, utility %.3f → %.3f\n", e.SessionID, e.Reward, e.UtilityBefore, e.UtilityAfter)
+		if e.Credit != nil {
+			fmt.Printf("      = %s\n", e.Credit)
+		}
+	}
+}
+
 func runMaturityScan(learningsDir string) error {
 	if GetDryRun() {
 		fmt.Printf("[dry-run] Would scan learnings in: %s\n", learningsDir)
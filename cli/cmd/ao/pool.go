@@ -23,6 +23,7 @@ var (
 	poolThreshold string
 	poolDoPromote bool
 	poolGold      bool
+	poolAsOf      string
 )
 
 var poolCmd = &cobra.Command{
@@ -52,7 +53,13 @@ Examples:
   ao pool list
   ao pool list --tier=gold
   ao pool list --status=pending
-  ao pool list --tier=bronze --status=staged`,
+  ao pool list --tier=bronze --status=staged
+  ao pool list --as-of=2026-01-15
+  ao pool list --as-of=7d
+
+With --as-of, the pool is reconstructed from its chain log as it stood at
+that time: an RFC 3339 timestamp, a date (end of that day), or a duration
+ago such as 36h or 7d.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if GetDryRun() {
 			fmt.Printf("[dry-run] Would list pool entries")
@@ -85,7 +92,16 @@ Examples:
 			opts.Status = types.PoolStatus(poolStatus)
 		}
 
-		result, err := p.ListPaginated(opts)
+		var result *pool.ListResult
+		if poolAsOf != "" {
+			asOf, perr := parsePoolAsOf(poolAsOf, time.Now())
+			if perr != nil {
+				return perr
+			}
+			result, err = p.ListAsOf(asOf, opts)
+		} else {
+			result, err = p.ListPaginated(opts)
+		}
 		if err != nil {
 			return fmt.Errorf("list pool: %w", err)
 		}
@@ -355,28 +371,31 @@ Examples:
 
 var poolAutoPromoteCmd = &cobra.Command{
 	Use:   "auto-promote",
-	Short: "Auto-promote eligible candidates older than threshold",
-	Long: `Automatically approve (and optionally promote) high-quality candidates
-for longer than the specified threshold.
+	Short: "Auto-promote candidates the promotion policy allows",
+	Long: `Automatically approve (and optionally promote) candidates that the
+promotion policy allows (.agents/policy/promotion.yaml; see 'ao pool policy').
 
 By default, this command bulk-approves eligible candidates. With --promote,
 it will also stage + promote them into .agents/learnings/ or .agents/patterns/.
 
-This is a bulk operation - use with caution. The threshold must be at least
-1 hour to prevent accidental mass approval of recently added candidates.
+This is a bulk operation - use with caution. --threshold adds a minimum age
+on top of the policy and must be at least 1 hour.
 
 Examples:
-  ao pool auto-promote --threshold=24h
+  ao pool auto-promote
   ao pool auto-promote --threshold=48h --dry-run
-  ao pool auto-promote --threshold=24h --promote`,
+  ao pool auto-promote --promote`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if poolThreshold == "" {
-			return fmt.Errorf("--threshold is required")
-		}
-
-		threshold, err := time.ParseDuration(poolThreshold)
-		if err != nil {
-			return fmt.Errorf("invalid threshold: %w", err)
+		var threshold time.Duration
+		if poolThreshold != "" {
+			var err error
+			threshold, err = time.ParseDuration(poolThreshold)
+			if err != nil {
+				return fmt.Errorf("invalid threshold: %w", err)
+			}
+			if threshold < pool.MinBulkApproveThreshold {
+				return pool.ErrThresholdTooLow
+			}
 		}
 
 		cwd, err := os.Getwd()
@@ -387,12 +406,19 @@ Examples:
 		p := pool.NewPool(cwd)
 		reviewer := GetCurrentUser()
 
+		pctx, err := loadPromotionPolicyContext(cwd)
+		if err != nil {
+			return err
+		}
+
 		if !poolDoPromote {
 			if GetDryRun() {
-				fmt.Printf("[dry-run] Would auto-promote (approve) eligible candidates older than %s\n", threshold)
+				fmt.Printf("[dry-run] Would auto-promote (approve) candidates allowed by %s\n", pctx.policy.Source)
 			}
 
-			approved, err := p.BulkApprove(threshold, reviewer, GetDryRun())
+			approved, err := p.ApproveWhere(func(e pool.PoolEntry) bool {
+				return e.Age >= threshold && pctx.decide(e).Promote()
+			}, "auto-promote: allowed by promotion policy", reviewer, GetDryRun())
 			if err != nil {
 				return fmt.Errorf("auto-promote: %w", err)
 			}
@@ -413,8 +439,8 @@ Examples:
 			return nil
 		}
 
-		// --promote mode: stage + promote high-quality candidates (silver, plus gold if enabled).
-		return runPoolAutoPromoteAndPromote(p, threshold, reviewer)
+		// --promote mode: stage + promote candidates the policy allows.
+		return runPoolAutoPromoteAndPromote(p, pctx, threshold, reviewer)
 	},
 }
 
@@ -427,7 +453,7 @@ type poolAutoPromotePromoteResult struct {
 	SkippedIDs []string `json:"skipped_ids,omitempty"`
 }
 
-func runPoolAutoPromoteAndPromote(p *pool.Pool, threshold time.Duration, reviewer string) error {
+func runPoolAutoPromoteAndPromote(p *pool.Pool, pctx *promotionPolicyContext, threshold time.Duration, reviewer string) error {
 	entries, err := p.List(pool.ListOptions{
 		Status: types.PoolStatusPending,
 	})
@@ -436,21 +462,23 @@ func runPoolAutoPromoteAndPromote(p *pool.Pool, threshold time.Duration, reviewe
 	}
 
 	result := poolAutoPromotePromoteResult{
-		Threshold: threshold.String(),
+		Threshold: "policy",
+	}
+	if threshold > 0 {
+		result.Threshold = threshold.String()
 	}
 
 	for _, e := range entries {
-		// Only auto-promote high-quality tiers.
-		if e.Candidate.Tier != types.TierSilver && !(poolGold && e.Candidate.Tier == types.TierGold) {
+		if !poolGold && e.Candidate.Tier == types.TierGold {
 			continue
 		}
-		// Never auto-promote human-gated items.
-		if e.ScoringResult.GateRequired {
-			result.Skipped++
-			result.SkippedIDs = append(result.SkippedIDs, e.Candidate.ID)
+		if e.Age < threshold {
 			continue
 		}
-		if e.Age < threshold {
+		if d := pctx.decide(e); !d.Promote() {
+			result.Skipped++
+			result.SkippedIDs = append(result.SkippedIDs, e.Candidate.ID)
+			VerbosePrintf("Holding %s: %s\n", e.Candidate.ID, d.Reason())
 			continue
 		}
 
@@ -461,8 +489,9 @@ func runPoolAutoPromoteAndPromote(p *pool.Pool, threshold time.Duration, reviewe
 			continue
 		}
 
-		// Stage (enforces min tier) then promote to knowledge base.
-		if err := p.Stage(e.Candidate.ID, types.TierSilver); err != nil {
+		// Stage then promote to knowledge base; the policy has already
+		// decided which tiers qualify.
+		if err := p.Stage(e.Candidate.ID, types.TierBronze); err != nil {
 			result.Skipped++
 			result.SkippedIDs = append(result.SkippedIDs, e.Candidate.ID)
 			VerbosePrintf("Warning: stage %s: %v\n", e.Candidate.ID, err)
@@ -521,6 +550,7 @@ func init() {
 	poolListCmd.Flags().StringVar(&poolStatus, "status", "", "Filter by status (pending, staged, promoted, rejected)")
 	poolListCmd.Flags().IntVar(&poolLimit, "limit", 50, "Maximum results to return (default 50, 0 for unlimited)")
 	poolListCmd.Flags().IntVar(&poolOffset, "offset", 0, "Skip first N results (for pagination)")
+	poolListCmd.Flags().StringVar(&poolAsOf, "as-of", "", "List the pool as it stood at a time (RFC 3339, YYYY-MM-DD, or a duration ago like 7d)")
 
 	// Add flags to stage command
 	poolStageCmd.Flags().StringVar(&poolTier, "min-tier", "", "Minimum tier threshold (default: bronze)")
@@ -530,10 +560,9 @@ func init() {
 	_ = poolRejectCmd.MarkFlagRequired("reason") //nolint:errcheck
 
 	// Add flags to auto-promote command
-	poolAutoPromoteCmd.Flags().StringVar(&poolThreshold, "threshold", "", "Minimum age for auto-promotion (e.g., 24h)")
+	poolAutoPromoteCmd.Flags().StringVar(&poolThreshold, "threshold", "", "Additional minimum age for auto-promotion (e.g., 24h; default: policy decides)")
 	poolAutoPromoteCmd.Flags().BoolVar(&poolDoPromote, "promote", false, "Also stage+promote eligible candidates into .agents/ (not just approval)")
 	poolAutoPromoteCmd.Flags().BoolVar(&poolGold, "include-gold", true, "Include gold-tier candidates when using --promote")
-	_ = poolAutoPromoteCmd.MarkFlagRequired("threshold") //nolint:errcheck
 }
 
 // truncateID shortens an ID for display.
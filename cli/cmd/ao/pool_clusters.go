@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+)
+
+var (
+	poolClustersRecluster bool
+	poolClustersThreshold float64
+	poolClustersKeep      string
+)
+
+var poolClustersCmd = &cobra.Command{
+	Use:   "clusters",
+	Short: "List clusters of near-duplicate candidates",
+	Long: `List groups of near-duplicate candidates in the pool.
+
+When a candidate is added, its content is fingerprinted (MinHash over word
+shingles) and compared with the pending and staged candidates. If one is at
+least --threshold similar, the new candidate joins its cluster and is marked
+duplicate_of the cluster's representative. Duplicates are left out of
+'ao gate pending'; review the representative and merge the rest.
+
+Use --recluster to fingerprint and cluster a pool populated before
+clustering existed, or after changing the threshold.
+
+Examples:
+  ao pool clusters
+  ao pool clusters --recluster --threshold 0.5
+  ao pool clusters -o json
+  ao pool clusters merge cand-abc123
+  ao pool clusters merge cand-abc123 --keep cand-def456`,
+	RunE: runPoolClusters,
+}
+
+var poolClustersMergeCmd = &cobra.Command{
+	Use:   "merge <cluster-id>",
+	Short: "Merge a cluster into one candidate",
+	Long: `Keep one candidate of a cluster (the representative unless --keep is
+given) and reject the other members as merged into it.
+
+Examples:
+  ao pool clusters merge cand-abc123
+  ao pool clusters merge cand-abc123 --keep cand-def456`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPoolClustersMerge,
+}
+
+func init() {
+	poolCmd.AddCommand(poolClustersCmd)
+	poolClustersCmd.AddCommand(poolClustersMergeCmd)
+
+	poolClustersCmd.Flags().BoolVar(&poolClustersRecluster, "recluster", false, "Recompute fingerprints and clusters for all live candidates first")
+	poolClustersCmd.Flags().Float64Var(&poolClustersThreshold, "threshold", pool.DefaultDuplicateThreshold, "Similarity (0-1) at which candidates cluster, used with --recluster")
+	poolClustersMergeCmd.Flags().StringVar(&poolClustersKeep, "keep", "", "Candidate to keep (default: the cluster representative)")
+}
+
+func runPoolClusters(cmd *cobra.Command, args []string) error {
+	if poolClustersThreshold <= 0 || poolClustersThreshold > 1 {
+		return fmt.Errorf("--threshold must be in (0, 1], got %v", poolClustersThreshold)
+	}
+	if GetDryRun() && poolClustersRecluster {
+		fmt.Fprintln(cmd.OutOrStdout(), "[dry-run] Would recluster pool candidates")
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	p := pool.NewPool(cwd)
+	p.DuplicateThreshold = poolClustersThreshold
+
+	if poolClustersRecluster {
+		n, err := p.Recluster()
+		if err != nil {
+			return fmt.Errorf("recluster: %w", err)
+		}
+		VerbosePrintf("Reclustered pool: %d duplicate(s)\n", n)
+	}
+
+	clusters, err := p.Clusters()
+	if err != nil {
+		return fmt.Errorf("list clusters: %w", err)
+	}
+	return outputPoolClusters(cmd.OutOrStdout(), clusters)
+}
+
+func outputPoolClusters(w io.Writer, clusters []pool.Cluster) error {
+	if GetOutput() == "json" {
+		if clusters == nil {
+			clusters = []pool.Cluster{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(clusters)
+	}
+
+	if len(clusters) == 0 {
+		fmt.Fprintln(w, "No near-duplicate clusters found")
+		return nil
+	}
+
+	for i, c := range clusters {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Cluster %s (%d candidates)\n", c.ID, c.Size())
+		if c.Representative != nil {
+			fmt.Fprintf(w, "  * %-24s %-8s %-8s %s\n", c.Representative.Candidate.ID, c.Representative.Candidate.Tier, c.Representative.Status, clusterSnippet(c.Representative.Candidate.Content))
+		} else {
+			fmt.Fprintf(w, "  * %-24s (no longer in pool)\n", c.ID)
+		}
+		for _, d := range c.Duplicates {
+			fmt.Fprintf(w, "    %-24s %-8s %-8s %.2f  %s\n", d.Candidate.ID, d.Candidate.Tier, d.Status, d.Similarity, clusterSnippet(d.Candidate.Content))
+		}
+	}
+	return nil
+}
+
+// clusterSnippet returns the first line of content, shortened for listing.
+func clusterSnippet(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return truncateString(line, 60)
+}
+
+func runPoolClustersMerge(cmd *cobra.Command, args []string) error {
+	clusterID := args[0]
+	w := cmd.OutOrStdout()
+
+	if GetDryRun() {
+		fmt.Fprintf(w, "[dry-run] Would merge cluster %s\n", clusterID)
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	p := pool.NewPool(cwd)
+
+	kept, merged, err := p.MergeCluster(clusterID, poolClustersKeep, GetCurrentUser())
+	if err != nil {
+		return fmt.Errorf("merge cluster: %w", err)
+	}
+
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{"cluster": clusterID, "kept": kept, "merged": merged})
+	}
+	fmt.Fprintf(w, "Merged %d candidate(s) into %s\n", len(merged), kept)
+	for _, id := range merged {
+		fmt.Fprintf(w, "  - %s\n", id)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+)
+
+var poolHistoryCmd = &cobra.Command{
+	Use:   "history <candidate-id>",
+	Short: "Show a candidate's event timeline",
+	Long: `Show every recorded pool operation on a candidate, oldest first:
+when it was added and how it was routed for review, who approved or
+rejected it and why, and where it was promoted to.
+
+Examples:
+  ao pool history cand-abc123
+  ao pool history cand-abc123 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPoolHistory,
+}
+
+var poolRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Reconstruct pool directories from the chain log",
+	Long: `Replay the pool chain log and repair the pool directories to match it.
+
+  moved              entry in the wrong directory for its status
+  restored           entry missing; recreated from its add snapshot
+  removed            stray copy, or a promoted candidate still in the pool
+  artifact-restored  promoted artifact missing; re-rendered
+  unrecoverable      missing, with no snapshot to restore from
+  untracked          entry with no chain history; left in place
+
+Candidates added before snapshots were recorded on the chain can be moved
+but not restored. Restored entries lose their near-duplicate cluster; run
+'ao pool clusters --recluster' afterwards.
+
+Examples:
+  ao pool rebuild --dry-run
+  ao pool rebuild`,
+	Args: cobra.NoArgs,
+	RunE: runPoolRebuild,
+}
+
+func init() {
+	poolCmd.AddCommand(poolHistoryCmd)
+	poolCmd.AddCommand(poolRebuildCmd)
+}
+
+func runPoolHistory(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	events, err := pool.NewPool(cwd).History(args[0])
+	if err != nil {
+		return err
+	}
+	return outputPoolHistory(cmd.OutOrStdout(), args[0], events)
+}
+
+func outputPoolHistory(w io.Writer, candidateID string, events []pool.ChainEvent) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	}
+
+	fmt.Fprintf(w, "History of %s (%d events)\n\n", candidateID, len(events))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	//nolint:errcheck // CLI tabwriter output
+	fmt.Fprintln(tw, "TIME\tOPERATION\tSTATUS\tBY\tDETAIL")
+	for _, e := range events {
+		status := ""
+		switch {
+		case e.FromStatus != "" && e.ToStatus != "":
+			status = fmt.Sprintf("%s -> %s", e.FromStatus, e.ToStatus)
+		case e.ToStatus != "":
+			status = "-> " + string(e.ToStatus)
+		}
+		reviewer := e.Reviewer
+		if reviewer == "" {
+			reviewer = "-"
+		}
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Operation, status, reviewer, historyDetail(e))
+	}
+	return tw.Flush()
+}
+
+// historyDetail summarises what an event adds beyond its operation.
+func historyDetail(e pool.ChainEvent) string {
+	var parts []string
+	if e.Tier != "" {
+		parts = append(parts, "tier "+string(e.Tier))
+	}
+	switch e.Review {
+	case pool.ReviewRequired:
+		parts = append(parts, "review required")
+	case pool.ReviewSampled:
+		parts = append(parts, fmt.Sprintf("sampled for review (draw %.3f < %.3f)", e.SampleDraw, e.SampleRate))
+	}
+	if e.Reason != "" {
+		parts = append(parts, fmt.Sprintf("%q", e.Reason))
+	}
+	if e.ArtifactPath != "" {
+		parts = append(parts, e.ArtifactPath)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func runPoolRebuild(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	divergences, err := pool.NewPool(cwd).Rebuild(GetDryRun())
+	if err != nil {
+		return fmt.Errorf("rebuild pool: %w", err)
+	}
+	return outputPoolRebuild(cmd.OutOrStdout(), divergences)
+}
+
+func outputPoolRebuild(w io.Writer, divergences []pool.Divergence) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(divergences)
+	}
+
+	if len(divergences) == 0 {
+		fmt.Fprintln(w, "Pool matches its chain log")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	//nolint:errcheck // CLI tabwriter output
+	fmt.Fprintln(tw, "CANDIDATE\tEXPECTED\tACTUAL\tACTION\tDETAIL")
+	counts := make(map[string]int)
+	for _, d := range divergences {
+		actual := string(d.Actual)
+		if actual == "" {
+			actual = "missing"
+		}
+		expected := string(d.Expected)
+		if expected == "" {
+			expected = "-"
+		}
+		//nolint:errcheck // CLI tabwriter output
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.CandidateID, expected, actual, d.Action, d.Detail)
+		counts[d.Action]++
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if GetDryRun() {
+		fmt.Fprintf(w, "[dry-run] Would repair %d divergence(s)\n", len(divergences)-counts[pool.RebuildUnrecovered]-counts[pool.RebuildUntracked])
+	} else {
+		fmt.Fprintf(w, "Repaired %d divergence(s)\n", len(divergences)-counts[pool.RebuildUnrecovered]-counts[pool.RebuildUntracked])
+	}
+	if n := counts[pool.RebuildUnrecovered]; n > 0 {
+		fmt.Fprintf(w, "%d candidate(s) could not be recovered\n", n)
+	}
+	if n := counts[pool.RebuildUntracked]; n > 0 {
+		fmt.Fprintf(w, "%d untracked entries left in place\n", n)
+	}
+	return nil
+}
+
+// parsePoolAsOf resolves an RFC 3339 time, a YYYY-MM-DD date (the end of
+// that day, local time), or a duration ago such as 36h or 7d.
+func parsePoolAsOf(v string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	d, err := parseDuration(v)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --as-of %q (use RFC 3339, YYYY-MM-DD, or a duration like 7d)", v)
+	}
+	return now.Add(-d), nil
+}
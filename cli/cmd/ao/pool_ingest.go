@@ -13,13 +13,17 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/config"
 	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/scorer"
 	"github.com/boshu2/agentops/cli/internal/taxonomy"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
 var (
-	poolIngestDir string
+	poolIngestDir        string
+	poolIngestSampleSeed string
+	poolIngestScorer     string
 )
 
 type poolIngestResult struct {
@@ -42,10 +46,20 @@ This command bridges LLM-authored markdown learnings (typically written to
 
 If no args are provided, it ingests *.md from --dir (default: .agents/knowledge/pending).
 
+Candidates whose tier does not require human review are sampled into the
+review queue at the tier's sample rate (gate.sample_rates in config; gold
+0.02 and silver 0.05 by default). The sample is reproducible: it
+depends only on the candidate ID and the seed (--sample-seed, gate.sample_seed
+in config, or AGENTOPS_SAMPLE_SEED).
+
+Candidates are scored with the default rubric weights unless --scorer learned
+selects the model fitted by 'ao gate train'.
+
 Examples:
   ao pool ingest
   ao pool ingest --dir .agents/knowledge/pending
   ao pool ingest .agents/knowledge/pending/*.md
+  ao pool ingest --scorer learned
   ao pool ingest --dry-run -o json`,
 	RunE: runPoolIngest,
 }
@@ -53,6 +67,8 @@ Examples:
 func init() {
 	poolCmd.AddCommand(poolIngestCmd)
 	poolIngestCmd.Flags().StringVar(&poolIngestDir, "dir", filepath.Join(".agents", "knowledge", "pending"), "Directory to ingest from when no args are provided")
+	poolIngestCmd.Flags().StringVar(&poolIngestSampleSeed, "sample-seed", "", "Seed for review sampling (default: gate.sample_seed from config)")
+	poolIngestCmd.Flags().StringVar(&poolIngestScorer, "scorer", "default", "Candidate scorer: default or learned (from 'ao gate train')")
 }
 
 func runPoolIngest(cmd *cobra.Command, args []string) error {
@@ -60,7 +76,11 @@ func runPoolIngest(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
-	p := pool.NewPool(cwd)
+	p := newIngestPool(cwd)
+	model, err := loadIngestScorer(cwd, poolIngestScorer)
+	if err != nil {
+		return err
+	}
 
 	files, err := resolveIngestFiles(cwd, poolIngestDir, args)
 	if err != nil {
@@ -86,7 +106,7 @@ func runPoolIngest(cmd *cobra.Command, args []string) error {
 		res.CandidatesFound += len(blocks)
 
 		for _, b := range blocks {
-			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint)
+			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint, model)
 			if !ok {
 				res.SkippedMalformed++
 				continue
@@ -117,6 +137,59 @@ func runPoolIngest(cmd *cobra.Command, args []string) error {
 	return outputPoolIngestResult(res)
 }
 
+// newIngestPool opens the pool with the review sample seed (--sample-seed,
+// else gate.sample_seed) and the tier sample rates (gate.sample_rates) from
+// config.
+func newIngestPool(cwd string) *pool.Pool {
+	p := pool.NewPool(cwd)
+	cfg, err := config.Load(nil)
+	if err != nil {
+		cfg = config.Default()
+	}
+	p.SampleSeed = poolIngestSampleSeed
+	if p.SampleSeed == "" {
+		p.SampleSeed = cfg.Gate.SampleSeed
+	}
+	p.TierConfigs = reviewTierConfigs(cfg.Gate.SampleRates)
+	return p
+}
+
+// reviewTierConfigs returns the default tier settings with the configured
+// sample rates. Unknown tiers and rates outside [0, 1] are ignored.
+func reviewTierConfigs(rates map[string]float64) map[types.Tier]taxonomy.TierConfig {
+	configs := make(map[types.Tier]taxonomy.TierConfig, len(taxonomy.DefaultTierConfigs))
+	for tier, c := range taxonomy.DefaultTierConfigs {
+		configs[tier] = c
+	}
+	for name, rate := range rates {
+		c, ok := configs[types.Tier(name)]
+		if !ok || rate < 0 || rate > 1 {
+			VerbosePrintf("Warning: ignoring gate.sample_rates.%s = %v\n", name, rate)
+			continue
+		}
+		c.HumanGateSampleRate = rate
+		configs[types.Tier(name)] = c
+	}
+	return configs
+}
+
+// loadIngestScorer returns the scorer named by --scorer.
+func loadIngestScorer(cwd, name string) (*scorer.Model, error) {
+	switch name {
+	case "", "default":
+		return scorer.DefaultModel(), nil
+	case "learned":
+		model, err := scorer.Load(cwd)
+		if err != nil {
+			return nil, err
+		}
+		VerbosePrintf("Using learned %s scorer trained %s\n", model.Kind, model.TrainedAt.Format(time.RFC3339))
+		return model, nil
+	default:
+		return nil, fmt.Errorf("unknown --scorer %q (want default or learned)", name)
+	}
+}
+
 func outputPoolIngestResult(res poolIngestResult) error {
 	switch GetOutput() {
 	case "json":
@@ -281,7 +354,7 @@ func parsePendingFileHeader(md, path string) (fileDate time.Time, sessionHint st
 	return fileDate, sessionHint
 }
 
-func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate time.Time, sessionHint string) (types.Candidate, types.Scoring, bool) {
+func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate time.Time, sessionHint string, model *scorer.Model) (types.Candidate, types.Scoring, bool) {
 	if strings.TrimSpace(b.Title) == "" || strings.TrimSpace(b.Body) == "" {
 		return types.Candidate{}, types.Scoring{}, false
 	}
@@ -302,7 +375,7 @@ func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate t
 
 	confDim := confidenceToScore(b.Confidence)
 	rubric := computeRubricScores(b.Body, confDim)
-	weighted := rubricWeightedSum(rubric, taxonomy.DefaultRubricWeights)
+	weighted := model.Score(b.Body, rubric)
 	raw := (taxonomy.GetBaseScore(types.KnowledgeTypeLearning) + weighted) / 2.0
 
 	// Pending learnings already reflect some human/LLM filtering (they were written intentionally),
@@ -446,14 +519,6 @@ func computeRubricScores(body string, confidence float64) types.RubricScores {
 	}
 }
 
-func rubricWeightedSum(r types.RubricScores, w taxonomy.RubricWeights) float64 {
-	return r.Specificity*w.Specificity +
-		r.Actionability*w.Actionability +
-		r.Novelty*w.Novelty +
-		r.Context*w.Context +
-		r.Confidence*w.Confidence
-}
-
 func slugify(s string) string {
 	s = strings.ToLower(s)
 	var b strings.Builder
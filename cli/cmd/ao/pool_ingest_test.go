@@ -3,8 +3,10 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -148,3 +150,51 @@ Session: ag-xyz
 		t.Fatalf("index category=%q, want %q", ie.Category, "process")
 	}
 }
+
+func TestIngestSamplesWithShippedConfig(t *testing.T) {
+	tmp := chdirTemp(t)
+	t.Setenv("HOME", tmp)
+	prev := poolIngestSampleSeed
+	t.Cleanup(func() { poolIngestSampleSeed = prev })
+	poolIngestSampleSeed = ""
+
+	// High-confidence learnings land in tiers that do not require review
+	var b strings.Builder
+	b.WriteString("# Learnings: ag-abc — Sampling\n\n**Date:** 2026-01-01\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "\n# Learning: Check %d\n\n**ID**: L%d\n**Category**: process\n**Confidence**: high\n\n"+
+			"## What We Learned\n\nRun `go vet ./pkg%d/...` before committing to catch shadowed variables in handler%d.go.\n", i, i, i, i)
+	}
+	pendingFile := filepath.Join(tmp, ".agents", "knowledge", "pending", "2026-01-01-ag-abc-learnings.md")
+	if err := os.MkdirAll(filepath.Dir(pendingFile), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pendingFile, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ingestPendingFilesToPool(tmp, []string{pendingFile})
+	if err != nil || res.Added != 40 {
+		t.Fatalf("ingest added %d, err %v; want 40", res.Added, err)
+	}
+	p := newIngestPool(tmp)
+	stats, err := p.ReviewStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled := 0
+	for _, s := range stats {
+		if s.Required == 0 {
+			sampled += s.Sampled
+			if s.BaseRate <= 0 {
+				t.Errorf("%s: base rate %.2f, want the configured default", s.Tier, s.BaseRate)
+			}
+		}
+	}
+	if sampled == 0 {
+		t.Error("no non-gated candidate was sampled for review")
+	}
+	if review, err := p.ListPendingReview(); err != nil || len(review) != sampled {
+		t.Errorf("review queue has %d candidates (err %v), want the %d sampled", len(review), err, sampled)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+var poolPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the pool promotion policy",
+	Long: `Inspect the promotion policy consulted by 'ao pool auto-promote',
+'ao pool batch-promote' and 'ao gate bulk-approve'.
+
+The policy lives in .agents/policy/promotion.yaml. Rules are evaluated in
+order; the first whose conditions all hold decides whether a candidate may
+be promoted without a human (action: promote) or stays in the pool
+(action: hold). If no rule matches, the policy's default applies.
+
+Conditions (all optional, all must hold):
+  tier                 [gold, silver, bronze]
+  type                 [decision, solution, learning, failure, reference]
+  min_age              time in the pool, e.g. 24h
+  min_citations        times the candidate has been cited
+  min_utility          candidate utility (0-1)
+  min_score            candidate raw score (0-1)
+  min_session_reward   reward of the source session (from 'ao feedback-loop')
+  awaiting_review      gated on a human review not yet approved (true/false)
+
+Without a policy file, the built-in default applies; 'ao pool policy show'
+prints it as a starting point.
+
+Examples:
+  ao pool policy show > .agents/policy/promotion.yaml
+  ao pool policy explain cand-abc123`,
+}
+
+var poolPolicyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective promotion policy",
+	Args:  cobra.NoArgs,
+	RunE:  runPoolPolicyShow,
+}
+
+var poolPolicyExplainCmd = &cobra.Command{
+	Use:   "explain <candidate-id>",
+	Short: "Show which policy rule decides a candidate",
+	Long: `Evaluate every promotion rule against a candidate and show which
+conditions passed, which rule fired, and the resulting action.
+
+Examples:
+  ao pool policy explain cand-abc123
+  ao pool policy explain cand-abc123 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPoolPolicyExplain,
+}
+
+func init() {
+	poolCmd.AddCommand(poolPolicyCmd)
+	poolPolicyCmd.AddCommand(poolPolicyShowCmd)
+	poolPolicyCmd.AddCommand(poolPolicyExplainCmd)
+}
+
+// promotionPolicyContext evaluates the promotion policy with the facts the
+// pool does not track itself: citations and source-session rewards.
+type promotionPolicyContext struct {
+	policy         *pool.PromotionPolicy
+	citationCounts map[string]int
+	sessionRewards map[string]float64
+}
+
+// loadPromotionPolicyContext loads the repository's policy and the citation
+// and feedback logs it may refer to.
+func loadPromotionPolicyContext(cwd string) (*promotionPolicyContext, error) {
+	policy, err := pool.LoadPromotionPolicy(cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	citations, err := ratchet.LoadCitations(cwd)
+	if err != nil {
+		VerbosePrintf("Warning: could not load citations: %v\n", err)
+	}
+
+	// The latest recorded reward of each session
+	rewards := make(map[string]float64)
+	events, err := loadFeedbackEvents(cwd)
+	if err != nil && !os.IsNotExist(err) {
+		VerbosePrintf("Warning: could not load feedback: %v\n", err)
+	}
+	latest := make(map[string]FeedbackEvent)
+	for _, e := range events {
+		if prev, ok := latest[e.SessionID]; !ok || !e.RecordedAt.Before(prev.RecordedAt) {
+			latest[e.SessionID] = e
+			rewards[e.SessionID] = e.Reward
+		}
+	}
+
+	return &promotionPolicyContext{
+		policy:         policy,
+		citationCounts: buildCitationCounts(citations, cwd),
+		sessionRewards: rewards,
+	}, nil
+}
+
+// facts gathers what the policy needs to know about entry beyond the pool.
+func (c *promotionPolicyContext) facts(entry pool.PoolEntry) pool.PolicyFacts {
+	facts := pool.PolicyFacts{Citations: c.citationCounts[entry.Candidate.ID]}
+	// Citations may reference the pool file rather than the candidate ID
+	if facts.Citations == 0 && entry.FilePath != "" {
+		facts.Citations = c.citationCounts[entry.FilePath]
+	}
+	if reward, ok := c.sessionRewards[entry.Candidate.Source.SessionID]; ok {
+		facts.SessionReward = &reward
+	}
+	return facts
+}
+
+// decide evaluates the policy for entry.
+func (c *promotionPolicyContext) decide(entry pool.PoolEntry) pool.PolicyDecision {
+	return c.policy.Evaluate(entry, c.facts(entry))
+}
+
+func runPoolPolicyShow(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	policy, err := pool.LoadPromotionPolicy(cwd)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "# Source: %s\n", policy.Source)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(policy); err != nil {
+		return fmt.Errorf("encode policy: %w", err)
+	}
+	return enc.Close()
+}
+
+func runPoolPolicyExplain(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	p := pool.NewPool(cwd)
+	entry, err := p.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("get candidate: %w", err)
+	}
+
+	pctx, err := loadPromotionPolicyContext(cwd)
+	if err != nil {
+		return err
+	}
+	return outputPolicyDecision(cmd.OutOrStdout(), pctx.decide(*entry))
+}
+
+func outputPolicyDecision(w io.Writer, d pool.PolicyDecision) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+
+	fmt.Fprintf(w, "Candidate: %s\n", d.CandidateID)
+	fmt.Fprintf(w, "Policy:    %s\n", d.Source)
+	fmt.Fprintln(w)
+	for _, r := range d.Results {
+		marker, note := " ", ""
+		switch {
+		case r.Rule == d.Rule:
+			marker, note = ">", " <- fired"
+		case r.Matched:
+			note = " (matches, but an earlier rule decided)"
+		}
+		fmt.Fprintf(w, "%s %s (%s)%s\n", marker, r.Rule, r.Action, note)
+		if len(r.Checks) == 0 {
+			fmt.Fprintln(w, "    (no conditions)")
+		}
+		for _, c := range r.Checks {
+			status := "FAIL"
+			if c.Pass {
+				status = "ok  "
+			}
+			fmt.Fprintf(w, "    %s %-18s %s\n", status, c.Condition, c.Detail)
+		}
+	}
+	fmt.Fprintln(w)
+	if d.Rule == "" {
+		fmt.Fprintf(w, "Decision: %s (no rule matched, policy default)\n", d.Action)
+	} else {
+		fmt.Fprintf(w, "Decision: %s (rule %q)\n", d.Action, d.Rule)
+	}
+	return nil
+}
@@ -116,3 +116,27 @@ func TestPoolBulkApproveThreshold(t *testing.T) {
 		t.Errorf("approved=%d, want 1", len(approved))
 	}
 }
+
+func TestParsePoolAsOf(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := parsePoolAsOf("2026-03-01T08:00:00Z", now)
+	if err != nil || !got.Equal(time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC 3339: got %v, %v", got, err)
+	}
+
+	got, err = parsePoolAsOf("7d", now)
+	if err != nil || !got.Equal(now.Add(-7*24*time.Hour)) {
+		t.Errorf("7d: got %v, %v", got, err)
+	}
+
+	// A date means the end of that day
+	got, err = parsePoolAsOf("2026-03-01", now)
+	if err != nil || got.Local().Day() != 1 || got.Local().Hour() != 23 {
+		t.Errorf("date: got %v, %v", got, err)
+	}
+
+	if _, err := parsePoolAsOf("last tuesday", now); err == nil {
+		t.Error("expected error for an unparseable time")
+	}
+}
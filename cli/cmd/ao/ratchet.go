@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/ratchet"
@@ -20,6 +22,8 @@ Inspection:
   next (n)      Show next pending RPI step
   spec          Get current spec path
   validate      Validate step requirements
+  verify        Detect rewritten history or modified locked artifacts
+  workflow      Show the workflow definition
 
 Progression:
   record        Record step completion
@@ -33,8 +37,11 @@ Search & Trace:
 Management:
   migrate            Migrate legacy chain format
   migrate-artifacts  Add schema_version to artifacts
+  keygen             Create a local key for signing chain entries
 
-The ratchet chain is stored in .agents/ao/chain.jsonl`,
+The ratchet chain is stored in .agents/ao/chain.jsonl. Steps, their order
+and their gates come from .agents/ao/workflow.yaml when it exists, otherwise
+from the built-in RPI workflow.`,
 }
 
 // Ratchet command flags (shared across subcommands)
@@ -63,6 +70,7 @@ type ratchetStepInfo struct {
 	Location    string             `json:"location,omitempty"`
 	Cycle       int                `json:"cycle,omitempty"`
 	ParentEpic  string             `json:"parent_epic,omitempty"`
+	Gates       []string           `json:"gates,omitempty"`
 }
 
 // ratchetStatusOutput holds the full status output structure.
@@ -97,6 +105,20 @@ func statusIcon(status ratchet.StepStatus) string {
 	}
 }
 
+// parseRatchetStep resolves a step name or alias against the workflow found
+// from cwd.
+func parseRatchetStep(cwd, name string) (ratchet.Step, error) {
+	workflow, err := ratchet.LoadWorkflow(cwd)
+	if err != nil {
+		return "", fmt.Errorf("load workflow: %w", err)
+	}
+	step := workflow.ParseStep(name)
+	if step == "" {
+		return "", fmt.Errorf("unknown step: %s", name)
+	}
+	return step, nil
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -18,11 +18,20 @@ func init() {
 		Short:   "Check if step gate is met",
 		Long: `Check if prerequisites are satisfied for a workflow step.
 
-Returns exit code 0 if gate passes, 1 if not.
+Runs every gate the workflow configures for the step. A gate is built in
+(artifact, epic, command), registered in Go, or an external executable.
+Each reports pass, warn or fail; soft gates warn instead of failing.
+Returns exit code 0 if no gate fails, 1 if any does.
 
-Steps: research, pre-mortem, plan, implement, crank, vibe, post-mortem
+Steps (built-in workflow): research, pre-mortem, plan, implement, crank,
+vibe, post-mortem
 Aliases: premortem, postmortem, autopilot, validate, review
 
+External gates receive the step, its input/output artifacts, the gate's
+"with" settings and the chain as JSON on stdin, and write
+{"status": "pass|warn|fail", "message": "..."} to stdout. One that prints
+nothing passes on exit code 0 and fails otherwise. See 'ao ratchet workflow'.
+
 Examples:
   ao ratchet check research
   ao ratchet check plan
@@ -35,17 +44,16 @@ Examples:
 
 // runRatchetCheck validates a step gate.
 func runRatchetCheck(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	step, err := parseRatchetStep(cwd, args[0])
+	if err != nil {
+		return err
+	}
+
 	checker, err := ratchet.NewGateChecker(cwd)
 	if err != nil {
 		return fmt.Errorf("create gate checker: %w", err)
@@ -72,9 +80,27 @@ func runRatchetCheck(cmd *cobra.Command, args []string) error {
 			}
 		} else {
 			fmt.Fprintf(w, "GATE FAILED: %s\n", result.Message)
+		}
+		if len(result.Checks) > 1 || (len(result.Checks) == 1 && result.Checks[0].Status != ratchet.GatePass) {
+			for _, c := range result.Checks {
+				fmt.Fprintf(w, "  %s %s: %s\n", gateStatusIcon(c.Status), c.Gate, c.Message)
+			}
+		}
+		if !result.Passed {
 			os.Exit(1)
 		}
 	}
 
 	return nil
 }
+
+func gateStatusIcon(status ratchet.GateStatus) string {
+	switch status {
+	case ratchet.GatePass:
+		return "✓"
+	case ratchet.GateWarn:
+		return "!"
+	default:
+		return "✗"
+	}
+}
@@ -21,17 +21,6 @@ type NextResult struct {
 	Complete     bool   `json:"complete" yaml:"complete"`
 }
 
-// stepSkillMap maps ratchet steps to their corresponding skill commands.
-var stepSkillMap = map[string]string{
-	"research":    "/research",
-	"pre-mortem":  "/pre-mortem",
-	"plan":        "/plan",
-	"implement":   "/implement or /crank",
-	"crank":       "/implement or /crank",
-	"vibe":        "/vibe",
-	"post-mortem": "/post-mortem",
-}
-
 func init() {
 	ratchetNextCmd := &cobra.Command{
 		Use:     "next",
@@ -41,7 +30,8 @@ func init() {
 		Long: `Show the next pending step in the RPI workflow.
 
 Returns structured output indicating what to do next based on the current
-ratchet chain state. Returns "complete" if all steps are locked.
+ratchet chain state and the workflow definition (.agents/ao/workflow.yaml,
+or the built-in RPI flow). Returns "complete" if all steps are locked.
 
 Examples:
   ao ratchet next
@@ -74,28 +64,17 @@ func runRatchetNext(cmd *cobra.Command, args []string) error {
 	return outputNextResult(&result)
 }
 
-// computeNextStep analyzes the chain and determines the next step.
+// computeNextStep analyzes the chain and determines the next step from the
+// chain's workflow: the first step not completed directly, through an
+// interchangeable step (implement or crank), or by a later step.
 func computeNextStep(chain *ratchet.Chain) NextResult {
-	allSteps := ratchet.AllSteps()
-
-	// If chain is empty, start at the beginning
-	if len(chain.Entries) == 0 {
-		return NextResult{
-			Next:     string(allSteps[0]),
-			Reason:   "no steps completed yet",
-			Skill:    stepSkillMap[string(allSteps[0])],
-			Complete: false,
-		}
-	}
+	workflow := chain.Workflow()
 
 	// Find the last locked or skipped step
-	var lastStep ratchet.Step
 	var lastEntry *ratchet.ChainEntry
-
 	for i := len(chain.Entries) - 1; i >= 0; i-- {
 		entry := &chain.Entries[i]
 		if entry.Locked || entry.Skipped {
-			lastStep = entry.Step
 			lastEntry = entry
 			break
 		}
@@ -103,80 +82,41 @@ func computeNextStep(chain *ratchet.Chain) NextResult {
 
 	// If no locked/skipped steps found, start at the beginning
 	if lastEntry == nil {
+		first := workflow.Steps[0].Name
+		reason := "no steps locked yet"
+		if len(chain.Entries) == 0 {
+			reason = "no steps completed yet"
+		}
 		return NextResult{
-			Next:     string(allSteps[0]),
-			Reason:   "no steps locked yet",
-			Skill:    stepSkillMap[string(allSteps[0])],
+			Next:     string(first),
+			Reason:   reason,
+			Skill:    workflow.Skill(first),
 			Complete: false,
 		}
 	}
 
-	// Find position of last step in AllSteps
-	var lastStepIndex = -1
-	for i, step := range allSteps {
-		if step == lastStep {
-			lastStepIndex = i
-			break
-		}
-	}
-
-	// Check if we're complete (last locked step is post-mortem)
-	if lastStep == ratchet.StepPostMortem {
+	next := workflow.Next(chain)
+	if next == "" {
 		return NextResult{
 			Next:         "",
 			Reason:       "all steps completed",
-			LastStep:     string(lastStep),
+			LastStep:     string(lastEntry.Step),
 			LastArtifact: lastEntry.Output,
 			Skill:        "",
 			Complete:     true,
 		}
 	}
 
-	// Handle implement/crank special case
-	// Either implement or crank satisfies that position in the chain
-	// If we just completed implement, next is vibe (skip crank)
-	// If we just completed crank, next is also vibe
-	if lastStep == ratchet.StepImplement || lastStep == ratchet.StepCrank {
-		// Next step after implement/crank is vibe
-		return NextResult{
-			Next:         string(ratchet.StepVibe),
-			Reason:       fmt.Sprintf("%s locked", lastStep),
-			LastStep:     string(lastStep),
-			LastArtifact: lastEntry.Output,
-			Skill:        stepSkillMap[string(ratchet.StepVibe)],
-			Complete:     false,
-		}
-	}
-
-	// For all other cases, next step is the one after the last locked step
-	if lastStepIndex >= 0 && lastStepIndex < len(allSteps)-1 {
-		nextStep := allSteps[lastStepIndex+1]
-
-		// Skip crank if we just completed plan (go straight to implement)
-		// Actually, according to AllSteps order, after plan comes implement, then crank
-		// But the requirement says either implement or crank satisfies that position
-		// So we should suggest implement first
-		if lastStep == ratchet.StepPlan {
-			nextStep = ratchet.StepImplement
-		}
-
-		return NextResult{
-			Next:         string(nextStep),
-			Reason:       fmt.Sprintf("%s locked", lastStep),
-			LastStep:     string(lastStep),
-			LastArtifact: lastEntry.Output,
-			Skill:        stepSkillMap[string(nextStep)],
-			Complete:     false,
-		}
+	verb := "locked"
+	if lastEntry.Skipped {
+		verb = "skipped"
 	}
-
-	// Shouldn't reach here, but handle gracefully
 	return NextResult{
-		Next:         "",
-		Reason:       "unexpected state",
-		LastStep:     string(lastStep),
+		Next:         string(next),
+		Reason:       fmt.Sprintf("%s %s", lastEntry.Step, verb),
+		LastStep:     string(lastEntry.Step),
 		LastArtifact: lastEntry.Output,
-		Skill:        "",
+		Skill:        workflow.Skill(next),
 		Complete:     false,
 	}
 }
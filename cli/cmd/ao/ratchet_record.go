@@ -38,17 +38,16 @@ Examples:
 
 // runRatchetRecord records step completion.
 func runRatchetRecord(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	step, err := parseRatchetStep(cwd, args[0])
+	if err != nil {
+		return err
+	}
+
 	if GetDryRun() {
 		fmt.Printf("Would record step: %s\n", step)
 		fmt.Printf("  Input: %s\n", ratchetInput)
@@ -17,7 +17,9 @@ func init() {
 		Short:   "Get current spec path",
 		Long: `Find and output the current spec artifact path.
 
-Searches for specs in priority order: crew → rig → town.
+Searches the output patterns of the workflow's spec step (by default
+pre-mortem: specs/*-v*.md, then synthesis/*.md) in priority order:
+crew → rig → town.
 
 Examples:
   ao ratchet spec
@@ -39,13 +41,16 @@ func runRatchetSpec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("create locator: %w", err)
 	}
 
-	// Search for specs in order
-	patterns := []string{
-		"specs/*-v*.md",
-		"synthesis/*.md",
+	workflow, err := ratchet.LoadWorkflow(cwd)
+	if err != nil {
+		return fmt.Errorf("load workflow: %w", err)
+	}
+	if workflow.Spec == "" {
+		return fmt.Errorf("workflow defines no spec step")
 	}
 
-	for _, pattern := range patterns {
+	// Search the spec step's output patterns in order
+	for _, pattern := range workflow.Step(workflow.Spec).Output.Patterns {
 		path, loc, err := locator.FindFirst(pattern)
 		if err == nil {
 			switch GetOutput() {
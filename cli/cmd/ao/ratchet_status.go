@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -21,7 +22,8 @@ func init() {
 		Short:   "Show ratchet chain state",
 		Long: `Display the current state of the ratchet chain.
 
-Shows all steps and their status (pending, in_progress, locked, skipped).
+Shows all workflow steps and their status (pending, in_progress, locked,
+skipped).
 
 Examples:
   ao ratchet status
@@ -58,11 +60,15 @@ func runRatchetStatus(cmd *cobra.Command, args []string) error {
 		Steps:   make([]ratchetStepInfo, 0),
 	}
 
-	for _, step := range ratchet.AllSteps() {
+	for _, def := range chain.Workflow().Steps {
+		step := def.Name
 		info := ratchetStepInfo{
 			Step:   step,
 			Status: allStatus[step],
 		}
+		for _, g := range def.GateSpecs() {
+			info.Gates = append(info.Gates, g.DisplayName())
+		}
 
 		// Get details from latest entry
 		if entry := chain.GetLatest(step); entry != nil {
@@ -113,13 +119,13 @@ func outputRatchetStatus(w io.Writer, data *ratchetStatusOutput) error {
 		}
 		fmt.Fprintln(w)
 
-		fmt.Fprintf(w, "%-15s %-12s %-40s\n", "STEP", "STATUS", "OUTPUT")
-		fmt.Fprintf(w, "%-15s %-12s %-40s\n", "----", "------", "------")
+		fmt.Fprintf(w, "%-15s %-12s %-40s %s\n", "STEP", "STATUS", "OUTPUT", "GATES")
+		fmt.Fprintf(w, "%-15s %-12s %-40s %s\n", "----", "------", "------", "-----")
 
 		for _, s := range data.Steps {
 			icon := statusIcon(s.Status)
 			out := truncate(s.Output, 40)
-			fmt.Fprintf(w, "%-15s %s %-10s %-40s\n", s.Step, icon, s.Status, out)
+			fmt.Fprintf(w, "%-15s %s %-10s %-40s %s\n", s.Step, icon, s.Status, out, strings.Join(s.Gates, ", "))
 		}
 
 		fmt.Fprintf(w, "\nPath: %s\n", data.Path)
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -40,17 +39,16 @@ Examples:
 
 // runRatchetValidate validates step requirements.
 func runRatchetValidate(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	step, err := parseRatchetStep(cwd, args[0])
+	if err != nil {
+		return err
+	}
+
 	validator, err := ratchet.NewValidator(cwd)
 	if err != nil {
 		return fmt.Errorf("create validator: %w", err)
@@ -87,20 +85,27 @@ func runRatchetValidate(cmd *cobra.Command, args []string) error {
 }
 
 // resolveValidationFiles determines which files to validate.
-// Uses explicit --changes files if provided, otherwise locates expected output.
+// Uses explicit --changes files if provided, otherwise locates the step's
+// output from the workflow's output patterns.
 func resolveValidationFiles(cwd string, step ratchet.Step) []string {
 	if len(ratchetFiles) > 0 {
 		return ratchetFiles
 	}
 
-	locator, _ := ratchet.NewLocator(cwd)
-	pattern := ratchet.GetExpectedOutput(step)
-	if strings.HasPrefix(pattern, "epic:") || strings.HasPrefix(pattern, "issue:") {
+	workflow, err := ratchet.LoadWorkflow(cwd)
+	if err != nil {
+		return nil
+	}
+	def := workflow.Step(step)
+	if def == nil {
 		return nil
 	}
 
-	if path, _, err := locator.FindFirst(pattern); err == nil {
-		return []string{path}
+	locator, _ := ratchet.NewLocator(cwd)
+	for _, pattern := range def.Output.Patterns {
+		if path, _, err := locator.FindFirst(pattern); err == nil {
+			return []string{path}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+var (
+	ratchetRequireSigned bool
+	ratchetAnchor        string
+)
+
+func init() {
+	verifySubCmd := &cobra.Command{
+		Use:     "verify",
+		GroupID: "inspection",
+		Short:   "Detect rewritten history or modified locked artifacts",
+		Long: `Verify the ratchet chain's integrity.
+
+Each entry records the hash of the entry before it, a hash of itself and,
+when its output is a file, a hash of that artifact. Verify fails when:
+  - an entry was edited, removed, reordered or inserted
+  - the latest locked artifact at an output path changed or disappeared
+  - a signature does not match, or its signer is not trusted
+
+Entries recorded before hashing was introduced are reported as legacy and
+accepted only before the first hashed entry.
+
+Signing is optional. Create a key with 'ao ratchet keygen'; entries are then
+signed as they are recorded. Public keys listed in .agents/ao/ratchet-keys
+are the trusted signers; --require-signed fails unsigned entries.
+
+Pass the head hash of a previous run as --anchor to prove the chain has
+only been appended to since.
+
+Examples:
+  ao ratchet verify
+  ao ratchet verify --require-signed -o json
+  ao ratchet verify --anchor sha256:4f1c...`,
+		Args: cobra.NoArgs,
+		RunE: runRatchetVerify,
+	}
+	verifySubCmd.Flags().BoolVar(&ratchetRequireSigned, "require-signed", false, "Fail entries without a trusted signature")
+	verifySubCmd.Flags().StringVar(&ratchetAnchor, "anchor", "", "Entry hash the chain must still contain")
+	ratchetCmd.AddCommand(verifySubCmd)
+
+	keygenSubCmd := &cobra.Command{
+		Use:     "keygen",
+		GroupID: "management",
+		Short:   "Create a local key for signing chain entries",
+		Long: `Create an ed25519 key at ~/.agentops/ratchet.key (or $AGENTOPS_RATCHET_KEY).
+Once it exists, every recorded chain entry is signed with it.
+
+Add the printed public key to .agents/ao/ratchet-keys to trust it in
+'ao ratchet verify'.
+
+Examples:
+  ao ratchet keygen
+  ao ratchet keygen >> .agents/ao/ratchet-keys`,
+		Args: cobra.NoArgs,
+		RunE: runRatchetKeygen,
+	}
+	ratchetCmd.AddCommand(keygenSubCmd)
+}
+
+// runRatchetVerify verifies the chain and fails if any issue is found.
+func runRatchetVerify(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	chain, err := ratchet.LoadChain(cwd)
+	if err != nil {
+		return fmt.Errorf("load chain: %w", err)
+	}
+	if chain.Path() == "" {
+		return fmt.Errorf("no .agents directory found")
+	}
+	if _, err := os.Stat(chain.Path()); os.IsNotExist(err) {
+		return fmt.Errorf("no chain to verify at %s", chain.Path())
+	}
+
+	report, err := ratchet.VerifyChainFile(chain.Path(), ratchet.VerifyOptions{
+		RequireSigned: ratchetRequireSigned,
+		Anchor:        ratchetAnchor,
+	})
+	if err != nil {
+		return fmt.Errorf("verify chain: %w", err)
+	}
+	if err := outputRatchetVerify(cmd.OutOrStdout(), report); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return fmt.Errorf("chain verification failed: %d issue(s)", len(report.Issues))
+	}
+	return nil
+}
+
+func outputRatchetVerify(w io.Writer, r *ratchet.VerifyReport) error {
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	fmt.Fprintf(w, "Chain: %s\n", r.Path)
+	fmt.Fprintf(w, "Entries: %d (%d hashed, %d signed, %d legacy)\n", r.Entries, r.Hashed, r.Signed, r.Legacy)
+	fmt.Fprintf(w, "Locked artifacts checked: %d\n", r.Artifacts)
+	if r.Head != "" {
+		fmt.Fprintf(w, "Head: %s\n", r.Head)
+	}
+	fmt.Fprintln(w)
+
+	if r.OK() {
+		fmt.Fprintln(w, "✓ Chain verified")
+		return nil
+	}
+	for _, issue := range r.Issues {
+		where := "chain"
+		if issue.Line > 0 {
+			where = fmt.Sprintf("line %d", issue.Line)
+			if issue.Step != "" {
+				where += fmt.Sprintf(" (%s)", issue.Step)
+			}
+		}
+		fmt.Fprintf(w, "✗ %s: %s: %s\n", where, issue.Kind, issue.Detail)
+	}
+	return nil
+}
+
+// runRatchetKeygen creates the local signing key and prints its public key.
+func runRatchetKeygen(cmd *cobra.Command, args []string) error {
+	path, err := ratchet.DefaultSigningKeyPath()
+	if err != nil {
+		return err
+	}
+	if GetDryRun() {
+		fmt.Fprintf(cmd.OutOrStdout(), "[dry-run] Would create signing key at %s\n", path)
+		return nil
+	}
+
+	pub, err := ratchet.GenerateSigningKey(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Created signing key: %s\n", path)
+	fmt.Fprintln(cmd.OutOrStdout(), ratchet.EncodePublicKey(pub))
+	return nil
+}
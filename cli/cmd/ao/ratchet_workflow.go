@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+func init() {
+	workflowSubCmd := &cobra.Command{
+		Use:     "workflow",
+		GroupID: "inspection",
+		Short:   "Show the workflow definition",
+		Long: `Print the effective ratchet workflow: .agents/ao/workflow.yaml when it
+exists, otherwise the built-in RPI workflow.
+
+Each step has a name, aliases accepted on the command line, the skill
+suggested by 'ao ratchet next', and:
+  after       steps that must be locked or skipped first (earlier steps only)
+  satisfies   a step this one stands in for (crank satisfies implement)
+  input       what the step consumes: artifact patterns relative to .agents
+              and/or bd epic statuses, looked for by the artifact and epic gates
+  output      what the step produces; 'ao ratchet validate' looks here
+  gates       checks run by 'ao ratchet check', each with:
+                uses     a registered gate (see below), or
+                exec     an executable (relative to the repo root, or on PATH)
+                         and its args
+                with     gate settings
+                soft     warn instead of failing
+                message  shown when the check does not pass
+
+Registered gates:
+  artifact    an input pattern matches (with: patterns, comma-separated)
+  epic        bd has an epic in an input status (with: status)
+  command     with.command exits zero (sh -c from the repo root)
+
+The workflow's spec step is the one whose output 'ao ratchet spec' reports.
+
+Examples:
+  ao ratchet workflow > workflow.yaml && mv workflow.yaml .agents/ao/
+  ao ratchet workflow -o json`,
+		Args: cobra.NoArgs,
+		RunE: runRatchetWorkflow,
+	}
+	ratchetCmd.AddCommand(workflowSubCmd)
+}
+
+// runRatchetWorkflow prints the effective workflow definition.
+func runRatchetWorkflow(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	workflow, err := ratchet.LoadWorkflow(cwd)
+	if err != nil {
+		return fmt.Errorf("load workflow: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(workflow)
+	}
+
+	source := workflow.Source
+	if source == "" {
+		source = "built-in default"
+	}
+	fmt.Fprintf(w, "# Source: %s\n", source)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(workflow); err != nil {
+		return fmt.Errorf("encode workflow: %w", err)
+	}
+	return enc.Close()
+}
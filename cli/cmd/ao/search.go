@@ -3,9 +3,9 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,6 +15,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/storage"
 	"github.com/boshu2/agentops/cli/pkg/vault"
 )
@@ -25,31 +26,67 @@ const (
 
 	// MaxContextLines is the maximum number of context lines to show per result.
 	MaxContextLines = 3
+
+	// searchIndexFileName is the BM25 index (internal/search) kept under .agents/ao/.
+	searchIndexFileName = "index.jsonl"
+
+	// searchVectorsFileName holds the semantic vectors kept next to the index.
+	searchVectorsFileName = "vectors.jsonl"
 )
 
 var (
-	searchLimit   int
-	searchType    string
-	searchUseSC   bool
-	searchUseCASS bool
+	searchLimit        int
+	searchType         string
+	searchUseSC        bool
+	searchUseCASS      bool
+	searchRebuildIndex bool
+	searchUseGrep      bool
+	searchSemantic     bool
+	searchHybrid       bool
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search <query>",
 	Short: "Search knowledge base",
-	Long: `Search AgentOps knowledge using file-based search.
-
-By default, searches markdown and JSONL files in .agents/ao/sessions/.
-Optionally use Smart Connections for semantic search if Obsidian is running.
+	Long: `Search AgentOps knowledge using the built-in search index.
+
+Searches sessions (.agents/ao/sessions/), learnings and patterns. The index
+lives at .agents/ao/index.jsonl; it is built on first use and refreshed
+incrementally on every search. Results are ranked with BM25 and field
+boosts (title and tag matches count more than body matches).
+
+Query syntax:
+  mutex pattern        optional terms, ranked by relevance
+  "token bucket"       required phrase (or quoted single term)
+  -redis  -"a b"       exclude a term, phrase or filter (-type:session)
+  type:learning        filter by type (learning, pattern, session, ...)
+  maturity:established filter by maturity level
+  since:7d             only documents from the last 7 days (2w, 36h, YYYY-MM-DD)
+  a OR b, ( ... )      alternatives and grouping
+
+Use --semantic to rank by local vector similarity instead of keywords. The
+vectors (hashed word and character-trigram TF-IDF) are stored next to the
+index in .agents/ao/vectors.jsonl and need no network or external model,
+so semantic search works on headless CI machines. Use --hybrid to fuse
+keyword and semantic rankings with reciprocal rank fusion.
+
+Use --grep to fall back to grep/ripgrep over session files.
+Optionally use Smart Connections as the semantic backend if Obsidian is
+running (--use-sc); the local vectors are used when it is unavailable.
 Use --cass to enable CASS (Contextual Agent Session Search) which includes
 session context and maturity-weighted ranking.
 
 Examples:
   ao search "mutex pattern"
   ao search "authentication" --limit 20
+  ao search '"rate limit" -redis type:learning since:30d'
   ao search "database migration" --type decisions
+  ao search "authenticate" --semantic  # Also finds "authentication"
+  ao search "rate limit" --hybrid      # Fuse keyword + semantic ranks
   ao search "config" --use-sc   # Enable Smart Connections semantic search
-  ao search "auth" --cass       # Enable CASS session-aware search`,
+  ao search "auth" --cass       # Enable CASS session-aware search
+  ao search "auth" --grep       # Use grep instead of the index
+  ao search "mutex" -o json     # Include per-term score breakdown`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSearch,
 }
@@ -60,6 +97,10 @@ func init() {
 	searchCmd.Flags().StringVar(&searchType, "type", "", "Filter by type: decisions, knowledge, sessions")
 	searchCmd.Flags().BoolVar(&searchUseSC, "use-sc", false, "Enable Smart Connections semantic search (requires Obsidian)")
 	searchCmd.Flags().BoolVar(&searchUseCASS, "cass", false, "Enable CASS session-aware search with maturity weighting")
+	searchCmd.Flags().BoolVar(&searchRebuildIndex, "rebuild-index", false, "Rebuild the search index from scratch before searching")
+	searchCmd.Flags().BoolVar(&searchUseGrep, "grep", false, "Use grep/ripgrep over session files instead of the search index")
+	searchCmd.Flags().BoolVar(&searchSemantic, "semantic", false, "Rank by local vector similarity (offline semantic search)")
+	searchCmd.Flags().BoolVar(&searchHybrid, "hybrid", false, "Fuse keyword and semantic rankings (reciprocal rank fusion)")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -78,13 +119,21 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
 	sessionsDir := filepath.Join(baseDir, storage.SessionsDir)
 
-	// Check if sessions directory exists
-	if _, err := os.Stat(sessionsDir); os.IsNotExist(err) {
+	// Check if any searchable directory exists
+	if !anyDirExists(searchCorpusDirs(baseDir)) {
 		fmt.Println("No AgentOps data found.")
 		fmt.Println("Run 'ao init' and 'ao forge transcript <path>' first.")
 		return nil
 	}
 
+	if searchRebuildIndex {
+		idx, err := rebuildSearchIndex(baseDir)
+		if err != nil {
+			return fmt.Errorf("rebuild search index: %w", err)
+		}
+		VerbosePrintf("Rebuilt search index: %d documents\n", len(idx.Docs))
+	}
+
 	results, err := selectAndSearch(query, sessionsDir, searchLimit)
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
@@ -117,33 +166,44 @@ func runSearch(cmd *cobra.Command, args []string) error {
 }
 
 // selectAndSearch chooses the search backend and executes the search.
-// Default: file-based search. Optional: Smart Connections with --use-sc flag.
+// Default: native BM25 index. Optional: grep with --grep, semantic vectors
+// with --semantic (Smart Connections with --use-sc), or both fused with
+// --hybrid.
 // CASS mode (--cass) adds session context and maturity-weighted ranking.
 func selectAndSearch(query, sessionsDir string, limit int) ([]searchResult, error) {
+	if searchUseGrep {
+		VerbosePrintf("Using grep-based search...\n")
+		return searchFiles(query, sessionsDir, limit)
+	}
+
+	baseDir := filepath.Dir(sessionsDir)
+
 	// CASS mode: search with session context and maturity weighting
 	if searchUseCASS {
 		VerbosePrintf("Using CASS session-aware search...\n")
-		return searchCASS(query, sessionsDir, limit)
-	}
-
-	// Only use Smart Connections if explicitly requested with --use-sc
-	if searchUseSC {
-		vaultPath := vault.DetectVault("")
-		if vaultPath != "" && vault.HasSmartConnections(vaultPath) {
-			VerbosePrintf("Using Smart Connections for semantic search...\n")
-			results, err := searchSmartConnections(query, sessionsDir, limit)
-			if err != nil {
-				// Fall back to file search
-				VerbosePrintf("Smart Connections failed, falling back to file search: %v\n", err)
-				return searchFiles(query, sessionsDir, limit)
-			}
-			return results, nil
+		idx, err := openSearchIndex(baseDir)
+		if err != nil {
+			return nil, err
 		}
-		VerbosePrintf("Smart Connections not available, using file-based search...\n")
+		return searchCASS(query, sessionsDir, idx, limit)
+	}
+
+	idx, err := openSearchIndex(baseDir)
+	if err != nil {
+		return nil, err
 	}
 
-	VerbosePrintf("Using file-based search...\n")
-	return searchFiles(query, sessionsDir, limit)
+	if searchSemantic || searchHybrid || searchUseSC {
+		var r search.Retriever = semanticRetriever(baseDir, idx)
+		if searchHybrid {
+			r = search.NewHybridRetriever(search.NewKeywordRetriever(idx), r)
+		}
+		VerbosePrintf("Using %s search...\n", r.Name())
+		return retrieveResults(r, query, limit)
+	}
+
+	VerbosePrintf("Using index search...\n")
+	return searchWithIndex(idx, query, limit)
 }
 
 // displaySearchResults formats and prints search results to stdout.
@@ -165,10 +225,186 @@ func displaySearchResults(query string, results []searchResult) {
 }
 
 type searchResult struct {
-	Path    string  `json:"path"`
-	Score   float64 `json:"score,omitempty"`
-	Context string  `json:"context,omitempty"`
-	Type    string  `json:"type,omitempty"`
+	Path      string             `json:"path"`
+	Score     float64            `json:"score,omitempty"`
+	Context   string             `json:"context,omitempty"`
+	Type      string             `json:"type,omitempty"`
+	Breakdown []search.TermScore `json:"breakdown,omitempty"`
+	Ranks     map[string]int     `json:"ranks,omitempty"`
+}
+
+// searchCorpusDirs returns the directories covered by the search index.
+func searchCorpusDirs(baseDir string) []string {
+	agentsDir := filepath.Dir(baseDir)
+	return []string{
+		filepath.Join(baseDir, storage.SessionsDir),
+		filepath.Join(agentsDir, "learnings"),
+		filepath.Join(agentsDir, "patterns"),
+	}
+}
+
+// anyDirExists reports whether at least one of dirs exists.
+func anyDirExists(dirs []string) bool {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// openSearchIndex loads the search index under baseDir and refreshes it
+// against the corpus directories, saving it when anything changed. A
+// missing index, or one written in an older format, is built from scratch.
+func openSearchIndex(baseDir string) (*search.Index, error) {
+	idxPath := filepath.Join(baseDir, searchIndexFileName)
+
+	idx, err := search.LoadIndex(idxPath)
+	switch {
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist):
+		VerbosePrintf("Building search index...\n")
+		idx = search.NewIndex()
+	case errors.Is(err, search.ErrIndexVersion):
+		VerbosePrintf("Search index format is outdated, rebuilding...\n")
+		idx = search.NewIndex()
+	default:
+		return nil, fmt.Errorf("load search index: %w", err)
+	}
+
+	stats, err := search.Refresh(idx, searchCorpusDirs(baseDir)...)
+	if err != nil {
+		return nil, fmt.Errorf("refresh search index: %w", err)
+	}
+	if stats.Changed() || len(idx.Docs) == 0 {
+		VerbosePrintf("Search index: %d added, %d updated, %d removed\n", stats.Added, stats.Updated, stats.Removed)
+		if err := search.SaveIndex(idx, idxPath); err != nil {
+			// Non-fatal: the in-memory index is still usable
+			VerbosePrintf("Warning: save search index: %v\n", err)
+		}
+	}
+	return idx, nil
+}
+
+// rebuildSearchIndex builds a fresh index over the corpus directories and
+// saves it under baseDir.
+func rebuildSearchIndex(baseDir string) (*search.Index, error) {
+	idx := search.NewIndex()
+	if _, err := search.Refresh(idx, searchCorpusDirs(baseDir)...); err != nil {
+		return nil, err
+	}
+	if err := search.SaveIndex(idx, filepath.Join(baseDir, searchIndexFileName)); err != nil {
+		return nil, err
+	}
+	// Vectors depend on corpus-wide IDF; rebuild them on next use
+	if err := os.Remove(filepath.Join(baseDir, searchVectorsFileName)); err != nil && !os.IsNotExist(err) {
+		VerbosePrintf("Warning: remove search vectors: %v\n", err)
+	}
+	return idx, nil
+}
+
+// openVectorIndex loads the semantic vectors under baseDir, rebuilding and
+// saving them when they are missing, outdated or behind idx.
+func openVectorIndex(baseDir string, idx *search.Index) *search.VectorIndex {
+	vecPath := filepath.Join(baseDir, searchVectorsFileName)
+	vi, err := search.LoadVectors(vecPath)
+	if err == nil && !vi.Stale(idx) {
+		return vi
+	}
+
+	VerbosePrintf("Building search vectors for %d documents...\n", len(idx.Docs))
+	vi = search.BuildVectors(idx, search.DefaultVectorDim)
+	if err := search.SaveVectors(vi, vecPath); err != nil {
+		// Non-fatal: the in-memory vectors are still usable
+		VerbosePrintf("Warning: save search vectors: %v\n", err)
+	}
+	return vi
+}
+
+// semanticRetriever returns the semantic backend: Smart Connections when
+// requested with --use-sc and available, backed by the local vectors.
+func semanticRetriever(baseDir string, idx *search.Index) search.Retriever {
+	local := search.NewVectorRetriever(idx, openVectorIndex(baseDir, idx))
+	if !searchUseSC {
+		return local
+	}
+
+	vaultPath := vault.DetectVault("")
+	if vaultPath == "" || !vault.HasSmartConnections(vaultPath) {
+		VerbosePrintf("Smart Connections not available, using local vectors...\n")
+		return local
+	}
+	return &fallbackRetriever{primary: search.NewSmartConnectionsRetriever(), fallback: local}
+}
+
+// fallbackRetriever uses fallback whenever primary fails.
+type fallbackRetriever struct {
+	primary  search.Retriever
+	fallback search.Retriever
+}
+
+func (f *fallbackRetriever) Name() string { return f.primary.Name() }
+
+func (f *fallbackRetriever) Retrieve(query string, limit int) ([]search.Hit, error) {
+	hits, err := f.primary.Retrieve(query, limit)
+	if err == nil {
+		return hits, nil
+	}
+	VerbosePrintf("%s failed, falling back to %s: %v\n", f.primary.Name(), f.fallback.Name(), err)
+	return f.fallback.Retrieve(query, limit)
+}
+
+// retrieveResults runs a retriever and converts its hits to search results.
+func retrieveResults(r search.Retriever, query string, limit int) ([]searchResult, error) {
+	hits, err := r.Retrieve(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]searchResult, 0, len(hits))
+	for _, h := range hits {
+		context := h.Snippet
+		if len(context) > ContextLineMaxLength {
+			context = context[:ContextLineMaxLength] + "..."
+		}
+		if context == "" {
+			context = getFileContext(h.Path, query)
+		}
+		results = append(results, searchResult{
+			Path:    h.Path,
+			Score:   h.Score,
+			Context: context,
+			Type:    classifyResultType(h.Path),
+			Ranks:   h.Ranks,
+		})
+	}
+	return results, nil
+}
+
+// searchWithIndex parses the query and ranks indexed documents with BM25.
+func searchWithIndex(idx *search.Index, query string, limit int) ([]searchResult, error) {
+	q, err := search.ParseQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	hits := search.SearchQuery(idx, q, limit, search.DefaultOptions())
+	snippetQuery := strings.Join(q.Terms, " ")
+	if len(q.Required) > 0 {
+		snippetQuery = strings.Join(q.Required[0], " ")
+	}
+
+	results := make([]searchResult, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, searchResult{
+			Path:      h.Path,
+			Score:     h.Score,
+			Context:   getFileContext(h.Path, snippetQuery),
+			Type:      classifyResultType(h.Path),
+			Breakdown: h.Breakdown,
+		})
+	}
+	return results, nil
 }
 
 // searchFiles performs grep-based search on markdown and JSONL files.
@@ -375,72 +611,6 @@ func searchJSONL(query string, dir string, limit int) ([]searchResult, error) {
 	return results, nil
 }
 
-// searchSmartConnections uses Smart Connections HTTP API for semantic search.
-// Smart Connections exposes an HTTP API at localhost:37042 when Obsidian is running.
-// Falls back to file-based search if not available.
-func searchSmartConnections(query, dir string, limit int) ([]searchResult, error) {
-	// Smart Connections HTTP API endpoint
-	const scAPIBase = "http://localhost:37042"
-
-	// Try to connect to Smart Connections API
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	// Build search request
-	searchURL := fmt.Sprintf("%s/search?query=%s&limit=%d",
-		scAPIBase, url.QueryEscape(query), limit)
-
-	resp, err := client.Get(searchURL)
-	if err != nil {
-		// API not available - fall back to file search
-		VerbosePrintf("Smart Connections API not available: %v\n", err)
-		return nil, fmt.Errorf("smart connections not running: %w", err)
-	}
-	defer func() {
-		_ = resp.Body.Close() //nolint:errcheck // HTTP response body close best-effort
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("smart connections API error: %s", resp.Status)
-	}
-
-	// Parse response
-	var scResponse struct {
-		Results []struct {
-			Path    string  `json:"path"`
-			Score   float64 `json:"score"`
-			Content string  `json:"content,omitempty"`
-			Title   string  `json:"title,omitempty"`
-		} `json:"results"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&scResponse); err != nil {
-		return nil, fmt.Errorf("parse Smart Connections response: %w", err)
-	}
-
-	// Convert to searchResult format
-	var results []searchResult
-	for _, r := range scResponse.Results {
-		context := r.Content
-		if context == "" && r.Title != "" {
-			context = r.Title
-		}
-		if len(context) > ContextLineMaxLength {
-			context = context[:ContextLineMaxLength] + "..."
-		}
-
-		results = append(results, searchResult{
-			Path:    r.Path,
-			Score:   r.Score,
-			Context: context,
-			Type:    classifyResultType(r.Path),
-		})
-	}
-
-	return results, nil
-}
-
 // classifyResultType determines the knowledge type based on file path.
 func classifyResultType(path string) string {
 	pathLower := strings.ToLower(path)
@@ -472,7 +642,11 @@ func classifyResultType(path string) string {
 // 1. Session context (what was the session about)
 // 2. Maturity level (provisional vs established)
 // 3. Confidence decay (older untested learnings rank lower)
-func searchCASS(query, dir string, limit int) ([]searchResult, error) {
+//
+// Patterns and sessions come from the search index; their BM25 scores are
+// scaled to the best hit and weighted like unrated knowledge (patterns as
+// established) so they interleave with the maturity-weighted learnings.
+func searchCASS(query, dir string, idx *search.Index, limit int) ([]searchResult, error) {
 	var results []searchResult
 
 	// Search learnings with maturity weighting
@@ -485,29 +659,36 @@ func searchCASS(query, dir string, limit int) ([]searchResult, error) {
 		results = append(results, learningResults...)
 	}
 
-	// Search patterns (established knowledge)
-	patternsDir := filepath.Join(filepath.Dir(dir), "patterns")
-	if _, err := os.Stat(patternsDir); err == nil {
-		patternResults, err := grepFiles(query, patternsDir, "*.md", limit)
-		if err != nil {
-			VerbosePrintf("CASS patterns search error: %v\n", err)
-		}
-		// Mark patterns as established maturity
-		for i := range patternResults {
-			patternResults[i].Type = "pattern"
+	// Search patterns (established knowledge) and sessions for context
+	indexResults, err := searchWithIndex(idx, query, 0)
+	if err != nil {
+		return nil, err
+	}
+	var top float64
+	for _, r := range indexResults {
+		if r.Score > top {
+			top = r.Score
 		}
-		results = append(results, patternResults...)
 	}
-
-	// Also search sessions for context
-	sessionResults, err := searchFiles(query, dir, limit)
-	if err != nil {
-		VerbosePrintf("CASS sessions search error: %v\n", err)
+	patternWeight := calculateCASSScore(map[string]interface{}{"maturity": "established"})
+	sessionWeight := calculateCASSScore(map[string]interface{}{})
+	for _, r := range indexResults {
+		weight := sessionWeight
+		switch r.Type {
+		case "pattern":
+			weight = patternWeight
+		case "session":
+		default:
+			continue // learnings are covered above
+		}
+		if top > 0 {
+			r.Score = r.Score / top * weight
+		}
+		results = append(results, r)
 	}
-	results = append(results, sessionResults...)
 
 	// Sort by score (maturity-weighted)
-	sort.Slice(results, func(i, j int) bool {
+	sort.SliceStable(results, func(i, j int) bool {
 		return results[i].Score > results[j].Score
 	})
 
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/search"
 )
 
 func TestClassifyResultType(t *testing.T) {
@@ -372,6 +375,162 @@ Database migration patterns for PostgreSQL.
 	})
 }
 
+func TestSearchWithIndex(t *testing.T) {
+	tmp := t.TempDir()
+	baseDir := filepath.Join(tmp, ".agents", "ao")
+	sessDir := filepath.Join(baseDir, "sessions")
+	if err := os.MkdirAll(sessDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	short := "# Mutex Pattern\n\nUse a mutex for shared state.\n"
+	long := "# Session: long\n\nmutex\n" + strings.Repeat("unrelated session chatter\n", 500)
+	if err := os.WriteFile(filepath.Join(sessDir, "short.md"), []byte(short), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessDir, "long.md"), []byte(long), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No index yet: built on first open and persisted
+	idxPath := filepath.Join(baseDir, searchIndexFileName)
+	idx, err := openSearchIndex(baseDir)
+	if err != nil {
+		t.Fatalf("openSearchIndex: %v", err)
+	}
+	if len(idx.Docs) != 2 {
+		t.Errorf("expected 2 indexed docs, got %d", len(idx.Docs))
+	}
+	if _, err := os.Stat(idxPath); err != nil {
+		t.Errorf("expected index to be saved: %v", err)
+	}
+
+	// Old-format index is detected and rebuilt
+	if err := os.WriteFile(idxPath, []byte(`{"term":"mutex","paths":["x.md"]}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = openSearchIndex(baseDir)
+	if err != nil {
+		t.Fatalf("openSearchIndex: %v", err)
+	}
+	if len(idx.Docs) != 2 {
+		t.Errorf("expected 2 indexed docs after rebuild, got %d", len(idx.Docs))
+	}
+
+	results, err := searchWithIndex(idx, "mutex", 10)
+	if err != nil {
+		t.Fatalf("searchWithIndex: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if filepath.Base(results[0].Path) != "short.md" {
+		t.Errorf("expected short.md ranked first, got %s", results[0].Path)
+	}
+	if results[0].Type != "session" || len(results[0].Breakdown) == 0 {
+		t.Errorf("expected session type and score breakdown, got %+v", results[0])
+	}
+
+	// Regex metacharacters are plain text, exclusion and phrases apply
+	results, err = searchWithIndex(idx, `mutex.* -chatter`, 10)
+	if err != nil {
+		t.Fatalf("searchWithIndex: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "short.md" {
+		t.Errorf("expected only short.md after excluding chatter, got %+v", results)
+	}
+	results, _ = searchWithIndex(idx, `"shared state"`, 10)
+	if len(results) != 1 {
+		t.Errorf("expected phrase to match 1 doc, got %d", len(results))
+	}
+
+	// New learnings are picked up lazily on the next open
+	learnDir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(learnDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(learnDir, "l1.md"), []byte("# Mutex fairness\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = openSearchIndex(baseDir)
+	if err != nil {
+		t.Fatalf("openSearchIndex: %v", err)
+	}
+	results, _ = searchWithIndex(idx, "mutex type:learning", 10)
+	if len(results) != 1 || results[0].Type != "learning" {
+		t.Errorf("expected new learning via type filter, got %+v", results)
+	}
+
+	if _, err := searchWithIndex(idx, "since:never", 10); err == nil {
+		t.Error("expected error for invalid since: filter")
+	}
+}
+
+func TestSemanticAndHybridSearch(t *testing.T) {
+	tmp := t.TempDir()
+	baseDir := filepath.Join(tmp, ".agents", "ao")
+	learnDir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(learnDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"auth.md":  "# Authentication\n\nRotate authentication tokens before expiry.\n",
+		"db.md":    "# Migrations\n\nRun database migrations in a transaction.\n",
+		"cache.md": "# Caching\n\nInvalidate cached entries on write.\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(learnDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := openSearchIndex(baseDir)
+	if err != nil {
+		t.Fatalf("openSearchIndex: %v", err)
+	}
+
+	semantic := semanticRetriever(baseDir, idx)
+	if semantic.Name() != "vector" {
+		t.Fatalf("expected local vector retriever, got %s", semantic.Name())
+	}
+	results, err := retrieveResults(semantic, "authenticate", 10)
+	if err != nil {
+		t.Fatalf("retrieveResults: %v", err)
+	}
+	if len(results) == 0 || filepath.Base(results[0].Path) != "auth.md" || results[0].Type != "learning" {
+		t.Fatalf("expected auth.md as top semantic learning, got %+v", results)
+	}
+
+	hybrid := search.NewHybridRetriever(search.NewKeywordRetriever(idx), semantic)
+	results, err = retrieveResults(hybrid, "transaction", 10)
+	if err != nil {
+		t.Fatalf("retrieveResults: %v", err)
+	}
+	if len(results) == 0 || filepath.Base(results[0].Path) != "db.md" {
+		t.Fatalf("expected db.md first in hybrid results, got %+v", results)
+	}
+	if results[0].Ranks["keyword"] != 1 || results[0].Ranks["vector"] != 1 {
+		t.Errorf("expected both retrievers to rank db.md first, got %v", results[0].Ranks)
+	}
+}
+
+func TestFallbackRetriever(t *testing.T) {
+	idx := search.NewIndex()
+	search.AddDocument(idx, "/k/a.md", search.Fields{Body: "mutex"})
+
+	down := search.NewSmartConnectionsRetriever()
+	down.BaseURL = "http://127.0.0.1:1"
+	r := &fallbackRetriever{primary: down, fallback: search.NewKeywordRetriever(idx)}
+
+	hits, err := r.Retrieve("mutex", 5)
+	if err != nil {
+		t.Fatalf("expected fallback to succeed: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Path != "/k/a.md" {
+		t.Errorf("hits = %+v, want fallback result", hits)
+	}
+}
+
 func TestSearchFilesNoData(t *testing.T) {
 	tmp := t.TempDir()
 	// Use an empty (but existing) directory — grep returns error for nonexistent dirs
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -222,7 +223,8 @@ func forgeTranscriptForClose(transcriptPath, cwd string) (*storage.Session, erro
 
 	extractor := parser.NewExtractor()
 
-	session, err := processTranscript(transcriptPath, p, extractor, true, os.Stdout)
+	opts := forgeOptions{CheckpointDir: filepath.Join(baseDir, forgeCheckpointDirName), Quiet: true}
+	session, _, err := processTranscript(context.Background(), transcriptPath, p, extractor, opts, os.Stdout)
 	if err != nil {
 		return nil, fmt.Errorf("process transcript: %w", err)
 	}
@@ -1,144 +1,31 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
-)
 
-// Signal represents a detected outcome signal from a transcript.
-type Signal struct {
-	Name   string  `json:"name"`
-	Value  bool    `json:"value"`
-	Weight float64 `json:"weight"`
-}
+	"github.com/boshu2/agentops/cli/internal/outcome"
+	"github.com/boshu2/agentops/cli/internal/parser"
+)
 
 // SessionOutcome represents the analyzed outcome of a session.
 type SessionOutcome struct {
-	SessionID  string    `json:"session_id"`
-	Reward     float64   `json:"reward"`
-	Signals    []Signal  `json:"signals"`
-	AnalyzedAt time.Time `json:"analyzed_at"`
-	Transcript string    `json:"transcript,omitempty"`
-	TotalLines int       `json:"total_lines,omitempty"`
-}
-
-// Signal weights (positive and negative)
-const (
-	// Positive signals
-	weightTestsPass   = 0.30
-	weightGitPush     = 0.20
-	weightGitCommit   = 0.15
-	weightBeadsClosed = 0.15
-	weightRatchetLock = 0.10
-	weightNoErrors    = 0.10
-
-	// Penalty signals (negative)
-	penaltyTestFailure = 0.20
-	penaltyException   = 0.15
-	penaltyNoCommit    = 0.10
-)
-
-// signalPatterns are pre-compiled regex patterns for signal detection.
-// Compiled once at package init to avoid per-call overhead.
-var signalPatterns = struct {
-	testPass      *regexp.Regexp
-	testFail      *regexp.Regexp
-	gitPush       *regexp.Regexp
-	gitCommit     *regexp.Regexp
-	beadsClose    *regexp.Regexp
-	ratchetRecord *regexp.Regexp
-	pythonTrace   *regexp.Regexp
-	goPanic       *regexp.Regexp
-	exitZero      *regexp.Regexp
-	exitNonZero   *regexp.Regexp
-}{
-	testPass:      regexp.MustCompile(`(?i)(PASSED|tests? passed|✓|ok$|All tests passed)`),
-	testFail:      regexp.MustCompile(`(?i)(FAILED|FAILURE|tests? failed|✗|ERROR.*test)`),
-	gitPush:       regexp.MustCompile(`(?i)(git push|pushed to|Enumerating objects:.*done.*Writing objects:)`),
-	gitCommit:     regexp.MustCompile(`(?i)(git commit|\[.+\]\s+\d+ files? changed|\[\w+\s+\w+\])`),
-	beadsClose:    regexp.MustCompile(`(?i)(bd close|issue.*closed|status.*closed)`),
-	ratchetRecord: regexp.MustCompile(`(?i)(ol ratchet record|ratchet.*locked|Ratchet chain updated)`),
-	pythonTrace:   regexp.MustCompile(`(?i)(Traceback \(most recent call|raise \w+Error|^\s+File ".+", line \d+)`),
-	goPanic:       regexp.MustCompile(`(?i)(panic:|runtime error:|goroutine \d+ \[)`),
-	exitZero:      regexp.MustCompile(`exit (code|status):?\s*0`),
-	exitNonZero:   regexp.MustCompile(`exit (code|status):?\s*[1-9]\d*`),
-}
-
-// signalState holds detection state during transcript scanning.
-type signalState struct {
-	testsFound       bool
-	testsPassed      bool
-	testsFailed      bool
-	gitPushFound     bool
-	gitCommitFound   bool
-	beadsClosedFound bool
-	ratchetLockFound bool
-	exceptionsFound  bool
-}
-
-// detectTestSignals checks for test-related signals in a line.
-func (s *signalState) detectTestSignals(line string) {
-	if signalPatterns.testPass.MatchString(line) || signalPatterns.exitZero.MatchString(line) {
-		s.testsFound = true
-		s.testsPassed = true
-	}
-	if signalPatterns.testFail.MatchString(line) || signalPatterns.exitNonZero.MatchString(line) {
-		s.testsFound = true
-		s.testsFailed = true
-	}
-}
-
-// detectGitSignals checks for git-related signals.
-func (s *signalState) detectGitSignals(line string) {
-	if signalPatterns.gitPush.MatchString(line) {
-		s.gitPushFound = true
-	}
-	if signalPatterns.gitCommit.MatchString(line) {
-		s.gitCommitFound = true
-	}
-}
-
-// detectWorkflowSignals checks for beads/ratchet signals.
-func (s *signalState) detectWorkflowSignals(line string) {
-	if signalPatterns.beadsClose.MatchString(line) {
-		s.beadsClosedFound = true
-	}
-	if signalPatterns.ratchetRecord.MatchString(line) {
-		s.ratchetLockFound = true
-	}
-}
-
-// detectErrorSignals checks for exceptions/panics.
-func (s *signalState) detectErrorSignals(line string) {
-	if signalPatterns.pythonTrace.MatchString(line) || signalPatterns.goPanic.MatchString(line) {
-		s.exceptionsFound = true
-	}
-}
-
-// addSignal appends a signal and updates reward.
-func addSignal(signals *[]Signal, reward *float64, name string, found bool, weight float64) {
-	if found {
-		*signals = append(*signals, Signal{Name: name, Value: true, Weight: weight})
-		*reward += weight
-	} else {
-		*signals = append(*signals, Signal{Name: name, Value: false, Weight: 0})
-	}
-}
-
-// addPenalty appends a penalty signal and updates reward.
-func addPenalty(signals *[]Signal, reward *float64, name string, condition bool, penalty float64) {
-	if condition {
-		*signals = append(*signals, Signal{Name: name, Value: true, Weight: -penalty})
-		*reward -= penalty
-	}
+	SessionID  string                 `json:"session_id"`
+	Reward     float64                `json:"reward"`
+	Signals    []outcome.SignalResult `json:"signals"`
+	AnalyzedAt time.Time              `json:"analyzed_at"`
+	Transcript string                 `json:"transcript,omitempty"`
+	TotalLines int                    `json:"total_lines,omitempty"`
+	ToolCalls  int                    `json:"tool_calls"`
+
+	// Rules is the reward rules file, or empty for the built-in rules.
+	Rules string `json:"rules,omitempty"`
 }
 
 // clampReward constrains reward to [0, 1].
@@ -152,58 +39,62 @@ func clampReward(reward float64) float64 {
 	return reward
 }
 
-// buildSignals converts detection state to reward signals.
-func (s *signalState) buildSignals() ([]Signal, float64) {
-	var signals []Signal
-	reward := 0.0
-
-	// Test signal has special logic
-	testsPassed := s.testsFound && s.testsPassed && !s.testsFailed
-	if testsPassed {
-		addSignal(&signals, &reward, "tests_pass", true, weightTestsPass)
-	} else if s.testsFound {
-		signals = append(signals, Signal{Name: "tests_pass", Value: false, Weight: 0})
-	}
-
-	// Positive signals
-	addSignal(&signals, &reward, "git_push", s.gitPushFound, weightGitPush)
-	addSignal(&signals, &reward, "git_commit", s.gitCommitFound, weightGitCommit)
-	addSignal(&signals, &reward, "beads_closed", s.beadsClosedFound, weightBeadsClosed)
-	addSignal(&signals, &reward, "ratchet_lock", s.ratchetLockFound, weightRatchetLock)
-	addSignal(&signals, &reward, "no_errors", !s.exceptionsFound, weightNoErrors)
-
-	// Penalties
-	addPenalty(&signals, &reward, "test_failure", s.testsFound && s.testsFailed, penaltyTestFailure)
-	addPenalty(&signals, &reward, "exceptions", s.exceptionsFound, penaltyException)
-	addPenalty(&signals, &reward, "no_commit", !s.gitCommitFound, penaltyNoCommit)
-
-	return signals, clampReward(reward)
-}
-
 var sessionOutcomeCmd = &cobra.Command{
 	Use:   "session-outcome [transcript-path]",
 	Short: "Analyze session transcript to derive reward signal",
-	Long: `Parse a Claude Code session transcript and derive a composite reward signal.
-
-The reward signal (0.0 - 1.0) is computed from detected success/failure indicators:
-
-Positive signals:
-  - Tests pass (+0.30): "PASSED", "OK", exit code 0
-  - Git push (+0.20): successful push to remote
-  - Git commit (+0.15): successful commit
-  - Beads closed (+0.15): bd close succeeded
-  - Ratchet lock (+0.10): ol ratchet record succeeded
-  - No errors (+0.10): absence of exceptions/panics
-
-Penalties:
-  - Test failure (-0.20): "FAILED", non-zero exit
-  - Exceptions (-0.15): Python tracebacks, Go panics
-  - No commits (-0.10): session ended without git activity
+	Long: `Parse a session transcript and derive a composite reward signal.
+
+Claude Code, Codex CLI and OpenCode session logs are detected automatically.
+
+The reward (0.0 - 1.0) is scored from the session's tool calls, each paired
+with its result: a command's exit code, the test report in its output
+(go test, go test -json, pytest, cargo test) and the git commands it ran.
+Each signal lists the calls that decided it (call number, transcript line,
+exit code and the output line that matched) so a reward can be audited.
+
+Built-in signals:
+  - tests_pass (+0.30): the last test run passed
+  - git_push (+0.20): git push exited 0
+  - git_commit (+0.15): git commit exited 0
+  - beads_closed (+0.15): bd close exited 0
+  - ratchet_lock (+0.10): ao ratchet record exited 0
+  - no_errors (+0.10): no traceback or panic in any output
+  - test_failure (-0.20): the last test run failed
+  - exceptions (-0.15): a traceback or panic in an output
+  - no_commit (-0.10): the session committed nothing
+
+Teams replace the signals with .agents/ao/reward.yaml (or --rules). A rule
+matches calls by tool, command and output patterns, exit (zero, nonzero) and
+tests (pass, fail); "when" is any (default), last, none, or increase or
+decrease of the number the output pattern captures:
+
+  version: 1
+  signals:
+    - name: tests_pass
+      weight: 0.40
+      when: last
+      command: '\bgo test\b'
+      tests: pass
+    - name: lint_clean
+      weight: 0.20
+      when: last
+      command: 'golangci-lint run'
+      exit: zero
+    - name: coverage_up
+      weight: 0.20
+      when: increase
+      command: '\bgo test\b.*-cover'
+      output: 'coverage: ([\d.]+)% of statements'
+    - name: pr_opened
+      weight: 0.20
+      command: '\bgh pr create\b'
+      exit: zero
 
 Examples:
   ao session-outcome ~/.claude/projects/*/transcript.jsonl
+  ao session-outcome ~/.codex/sessions/2026/03/14/rollout-*.jsonl
   ao session-outcome --session abc123
-  ao session-outcome --output json`,
+  ao session-outcome --rules reward.yaml --output json`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSessionOutcome,
 }
@@ -211,12 +102,14 @@ Examples:
 var (
 	sessionOutcomeSessionID string
 	sessionOutcomeOutput    string
+	sessionOutcomeRules     string
 )
 
 func init() {
 	rootCmd.AddCommand(sessionOutcomeCmd)
 	sessionOutcomeCmd.Flags().StringVar(&sessionOutcomeSessionID, "session", "", "Session ID (extracted from transcript if not provided)")
 	sessionOutcomeCmd.Flags().StringVar(&sessionOutcomeOutput, "output", "text", "Output format: text, json")
+	sessionOutcomeCmd.Flags().StringVar(&sessionOutcomeRules, "rules", "", "Reward rules file (default: "+outcome.RulesFile+" or built-in rules)")
 }
 
 func runSessionOutcome(cmd *cobra.Command, args []string) error {
@@ -234,13 +127,18 @@ func runSessionOutcome(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	rules, err := loadRewardRules(sessionOutcomeRules)
+	if err != nil {
+		return err
+	}
+
 	if GetDryRun() {
 		fmt.Printf("[dry-run] Would analyze transcript: %s\n", transcriptPath)
 		return nil
 	}
 
 	// Parse and analyze transcript
-	outcome, err := analyzeTranscript(transcriptPath, sessionOutcomeSessionID)
+	result, err := analyzeTranscript(transcriptPath, sessionOutcomeSessionID, rules)
 	if err != nil {
 		return fmt.Errorf("analyze transcript: %w", err)
 	}
@@ -250,100 +148,100 @@ func runSessionOutcome(cmd *cobra.Command, args []string) error {
 	case "json":
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(outcome)
+		return enc.Encode(result)
 
 	default:
+		rulesSource := result.Rules
+		if rulesSource == "" {
+			rulesSource = "built-in"
+		}
 		fmt.Printf("Session Outcome Analysis\n")
 		fmt.Printf("========================\n")
-		fmt.Printf("Session ID:  %s\n", outcome.SessionID)
-		fmt.Printf("Reward:      %.2f\n", outcome.Reward)
-		fmt.Printf("Lines:       %d\n", outcome.TotalLines)
+		fmt.Printf("Session ID:  %s\n", result.SessionID)
+		fmt.Printf("Reward:      %.2f\n", result.Reward)
+		fmt.Printf("Lines:       %d\n", result.TotalLines)
+		fmt.Printf("Tool calls:  %d\n", result.ToolCalls)
+		fmt.Printf("Rules:       %s\n", rulesSource)
 		fmt.Printf("\nSignals detected:\n")
-		for _, s := range outcome.Signals {
+		for _, s := range result.Signals {
 			status := "✗"
 			if s.Value {
 				status = "✓"
 			}
 			fmt.Printf("  %s %-20s (weight: %+.2f)\n", status, s.Name, s.Weight)
+			for _, e := range s.Evidence {
+				fmt.Printf("      %s\n", formatEvidence(e))
+			}
 		}
 	}
 
 	return nil
 }
 
-// analyzeTranscript parses a transcript and computes reward signal.
-func analyzeTranscript(path string, sessionID string) (*SessionOutcome, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open transcript: %w", err)
+// formatEvidence describes the call behind a signal on one line.
+func formatEvidence(e outcome.Evidence) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "call %d, line %d: %s", e.Call, e.Line, e.Tool)
+	if e.Command != "" {
+		fmt.Fprintf(&b, " %q", truncateText(e.Command, 60))
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only transcript analysis, close error non-fatal
-	}()
-
-	outcome := &SessionOutcome{
-		SessionID:  sessionID,
-		Transcript: path,
-		AnalyzedAt: time.Now(),
-		Signals:    []Signal{},
+	if e.ExitCode != outcome.NoExitCode {
+		fmt.Fprintf(&b, " exit %d", e.ExitCode)
 	}
-
-	state := &signalState{}
-	totalLines := scanTranscript(f, outcome, state)
-	outcome.TotalLines = totalLines
-
-	// Generate session ID if still empty
-	if outcome.SessionID == "" {
-		outcome.SessionID = canonicalSessionID("")
+	if e.Match != "" {
+		fmt.Fprintf(&b, "; output line %d: %s", e.OutputLine, truncateText(e.Match, 60))
 	}
-
-	// Build signals and compute reward
-	outcome.Signals, outcome.Reward = state.buildSignals()
-	return outcome, nil
+	return b.String()
 }
 
-// scanTranscript reads lines from the file and detects signals.
-func scanTranscript(f *os.File, outcome *SessionOutcome, state *signalState) int {
-	scanner := bufio.NewScanner(f)
-	// Use larger buffer for long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	totalLines := 0
-	for scanner.Scan() {
-		totalLines++
-		line := scanner.Text()
-
-		// Try to extract session ID if not provided
-		if outcome.SessionID == "" {
-			outcome.SessionID = extractSessionID(line)
-		}
-
-		// Detect signals using focused helpers
-		state.detectTestSignals(line)
-		state.detectGitSignals(line)
-		state.detectWorkflowSignals(line)
-		state.detectErrorSignals(line)
+// loadRewardRules loads the rules file given with --rules, or the
+// repository's rules, falling back to the built-in ones.
+func loadRewardRules(path string) (*outcome.Rules, error) {
+	if path != "" {
+		return outcome.LoadRulesFile(path)
 	}
-
-	return totalLines
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get working directory: %w", err)
+	}
+	return outcome.LoadRules(cwd)
 }
 
-// extractSessionID tries to extract session ID from a JSON line.
-func extractSessionID(line string) string {
-	// Try to parse as JSON and extract sessionId
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(line), &data); err != nil {
-		return ""
+// analyzeTranscript parses a transcript and scores its tool calls.
+func analyzeTranscript(path string, sessionID string, rules *outcome.Rules) (*SessionOutcome, error) {
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	parsed, src, err := parser.ParseTranscript(path, p)
+	if err != nil {
+		if src == nil {
+			return nil, fmt.Errorf("detect transcript format: %w", err)
+		}
+		return nil, fmt.Errorf("parse %s transcript: %w", src.Name(), err)
 	}
 
-	if sessionID, ok := data["sessionId"].(string); ok {
-		return sessionID
+	for _, msg := range parsed.Messages {
+		if sessionID != "" {
+			break
+		}
+		sessionID = msg.SessionID
 	}
-	if sessionID, ok := data["session_id"].(string); ok {
-		return sessionID
+	// Generate session ID if still empty
+	if sessionID == "" {
+		sessionID = canonicalSessionID("")
 	}
-	return ""
+
+	calls := outcome.Calls(parsed.Messages)
+	scored := rules.Evaluate(calls)
+	return &SessionOutcome{
+		SessionID:  sessionID,
+		Reward:     scored.Reward,
+		Signals:    scored.Signals,
+		AnalyzedAt: time.Now(),
+		Transcript: path,
+		TotalLines: parsed.TotalLines,
+		ToolCalls:  len(calls),
+		Rules:      rules.Source,
+	}, nil
 }
 
 // findMostRecentTranscript finds the most recently modified transcript in the directory.
@@ -1,137 +1,179 @@
 package main
 
 import (
+	"encoding/json"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/outcome"
 )
 
+// bashCall and bashResult build Claude Code transcript lines for a Bash
+// call and its result.
+func bashCall(id, command string) string {
+	line, _ := json.Marshal(map[string]interface{}{
+		"type": "assistant", "sessionId": "s1",
+		"message": map[string]interface{}{"role": "assistant", "content": []interface{}{
+			map[string]interface{}{"type": "tool_use", "id": id, "name": "Bash", "input": map[string]interface{}{"command": command}},
+		}},
+	})
+	return string(line)
+}
+
+func bashResult(id, output string, isError bool) string {
+	line, _ := json.Marshal(map[string]interface{}{
+		"type": "user", "sessionId": "s1",
+		"message": map[string]interface{}{"role": "user", "content": []interface{}{
+			map[string]interface{}{"type": "tool_result", "tool_use_id": id, "content": output, "is_error": isError},
+		}},
+	})
+	return string(line)
+}
+
 func TestAnalyzeTranscript(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "session-outcome-test-*")
-	if err != nil {
-		t.Fatalf("create temp dir: %v", err)
-	}
-	defer func() {
-		_ = os.RemoveAll(tempDir) //nolint:errcheck // test cleanup
-	}()
+	tempDir := t.TempDir()
+	prompt := `{"type":"user","sessionId":"s1","message":{"role":"user","content":"run tests"}}`
 
 	tests := []struct {
-		name           string
-		content        string
-		expectedReward float64
-		minReward      float64
-		maxReward      float64
+		name   string
+		lines  []string
+		reward float64
+		fired  []string
 	}{
 		{
 			name: "successful session with tests and push",
-			content: `{"type": "user", "sessionId": "test-001", "message": {"content": "run tests"}}
-{"type": "assistant", "message": {"content": "Running tests..."}}
-{"type": "tool_result", "content": "PASSED 5 tests in 1.2s"}
-{"type": "assistant", "message": {"content": "All tests passed. Committing..."}}
-{"type": "tool_result", "content": "[main abc1234] feat: add feature\n 3 files changed, 100 insertions(+)"}
-{"type": "assistant", "message": {"content": "Pushing to remote..."}}
-{"type": "tool_result", "content": "Enumerating objects: 5, done.\nWriting objects: 100% (5/5), done."}`,
-			minReward: 0.6, // tests + commit + push + no errors
-			maxReward: 1.0,
+			lines: []string{
+				prompt,
+				bashCall("t1", "go test ./..."),
+				bashResult("t1", "ok  \texample.com/cache\t0.2s", false),
+				bashCall("t2", "git commit -am 'feat: add feature'"),
+				bashResult("t2", "[main abc1234] feat: add feature\n 3 files changed, 100 insertions(+)", false),
+				bashCall("t3", "git push"),
+				bashResult("t3", "To github.com:org/repo.git\n   abc1234..def5678  main -> main", false),
+			},
+			reward: 0.75,
+			fired:  []string{"tests_pass", "git_commit", "git_push", "no_errors"},
+		},
+		{
+			name: "failing tests fixed before the end",
+			lines: []string{
+				prompt,
+				bashCall("t1", "go test ./..."),
+				bashResult("t1", "Exit code 1\n--- FAIL: TestRefresh (0.00s)\nFAIL\nFAIL\texample.com/cache\t0.01s", true),
+				bashCall("t2", "go test ./..."),
+				bashResult("t2", "ok  \texample.com/cache\t0.2s", false),
+			},
+			reward: 0.30,
+			fired:  []string{"tests_pass", "no_errors", "no_commit"},
 		},
 		{
 			name: "failed tests",
-			content: `{"type": "user", "sessionId": "test-002", "message": {"content": "run tests"}}
-{"type": "tool_result", "content": "FAILED 2 tests"}
-{"type": "tool_result", "content": "exit code: 1"}`,
-			minReward: 0.0, // test failure penalty
-			maxReward: 0.3,
+			lines: []string{
+				prompt,
+				bashCall("t1", "pytest -q"),
+				bashResult("t1", "Exit code 1\nFAILED tests/test_cache.py::test_refresh\n1 failed, 4 passed in 0.12s", true),
+			},
+			reward: 0,
+			fired:  []string{"test_failure", "no_errors", "no_commit"},
 		},
 		{
-			name: "session with python traceback",
-			content: `{"type": "user", "sessionId": "test-003", "message": {"content": "run script"}}
-{"type": "tool_result", "content": "Traceback (most recent call last):\n  File \"test.py\", line 10, in <module>\n    raise ValueError()"}`,
-			minReward: 0.0,
-			maxReward: 0.4, // exception penalty
+			name: "test words in prose are not test results",
+			lines: []string{
+				prompt,
+				`{"type":"assistant","sessionId":"s1","message":{"role":"assistant","content":"All tests PASSED ✓ ok"}}`,
+			},
+			reward: 0,
+			fired:  []string{"no_errors", "no_commit"},
 		},
 		{
-			name: "session with beads close and ratchet",
-			content: `{"type": "user", "sessionId": "test-004", "message": {"content": "close issue"}}
-{"type": "tool_result", "content": "bd close ol-0001 succeeded\nissue ol-0001 closed"}
-{"type": "tool_result", "content": "ao ratchet record succeeded\nRatchet chain updated"}`,
-			minReward: 0.2, // beads + ratchet
-			maxReward: 0.5,
+			name: "session with python traceback",
+			lines: []string{
+				prompt,
+				bashCall("t1", "python script.py"),
+				bashResult("t1", "Exit code 1\nTraceback (most recent call last):\n  File \"script.py\", line 10, in <module>\n    raise ValueError()", true),
+			},
+			reward: 0,
+			fired:  []string{"exceptions", "no_commit"},
 		},
 		{
-			name: "minimal session - no activity",
-			content: `{"type": "user", "sessionId": "test-005", "message": {"content": "hello"}}
-{"type": "assistant", "message": {"content": "Hello! How can I help?"}}`,
-			minReward: 0.0, // no commit penalty
-			maxReward: 0.2,
+			name: "failed commit",
+			lines: []string{
+				prompt,
+				bashCall("t1", "git commit -am wip"),
+				bashResult("t1", "Exit code 1\nnothing to commit, working tree clean", true),
+				bashCall("t2", "bd close ol-0001"),
+				bashResult("t2", "Closed ol-0001", false),
+			},
+			reward: 0.15,
+			fired:  []string{"beads_closed", "no_errors", "no_commit"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Write transcript
 			transcriptPath := filepath.Join(tempDir, tt.name+".jsonl")
-			if err := os.WriteFile(transcriptPath, []byte(tt.content), 0644); err != nil {
+			if err := os.WriteFile(transcriptPath, []byte(strings.Join(tt.lines, "\n")+"\n"), 0644); err != nil {
 				t.Fatalf("write transcript: %v", err)
 			}
 
-			// Analyze
-			outcome, err := analyzeTranscript(transcriptPath, "")
+			result, err := analyzeTranscript(transcriptPath, "", outcome.DefaultRules())
 			if err != nil {
 				t.Fatalf("analyze transcript: %v", err)
 			}
-
-			// Check reward bounds
-			if outcome.Reward < tt.minReward {
-				t.Errorf("reward %.2f below minimum %.2f", outcome.Reward, tt.minReward)
+			if math.Abs(result.Reward-tt.reward) > 1e-9 {
+				t.Errorf("reward %.2f, want %.2f", result.Reward, tt.reward)
 			}
-			if outcome.Reward > tt.maxReward {
-				t.Errorf("reward %.2f above maximum %.2f", outcome.Reward, tt.maxReward)
+			var fired []string
+			for _, s := range result.Signals {
+				if s.Value {
+					fired = append(fired, s.Name)
+				}
 			}
-
-			// Check session ID extraction
-			if outcome.SessionID == "" {
-				t.Error("session ID not extracted")
+			sort.Strings(fired)
+			sort.Strings(tt.fired)
+			if !slices.Equal(fired, tt.fired) {
+				t.Errorf("fired %v, want %v", fired, tt.fired)
+			}
+			if result.SessionID != "s1" {
+				t.Errorf("session ID %q, want s1", result.SessionID)
 			}
 		})
 	}
 }
 
-func TestExtractSessionID(t *testing.T) {
-	tests := []struct {
-		name     string
-		line     string
-		expected string
-	}{
-		{
-			name:     "camelCase sessionId",
-			line:     `{"type": "user", "sessionId": "abc-123"}`,
-			expected: "abc-123",
-		},
-		{
-			name:     "snake_case session_id",
-			line:     `{"type": "user", "session_id": "def-456"}`,
-			expected: "def-456",
-		},
-		{
-			name:     "no session ID",
-			line:     `{"type": "user", "message": "hello"}`,
-			expected: "",
-		},
-		{
-			name:     "invalid JSON",
-			line:     `not json at all`,
-			expected: "",
-		},
+func TestAnalyzeTranscriptEvidence(t *testing.T) {
+	lines := []string{
+		bashCall("t1", "go test ./..."),
+		bashResult("t1", "Exit code 1\n=== RUN   TestRefresh\n--- FAIL: TestRefresh (0.00s)\nFAIL", true),
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractSessionID(tt.line)
-			if result != tt.expected {
-				t.Errorf("got %q, want %q", result, tt.expected)
-			}
-		})
+	result, err := analyzeTranscript(path, "", outcome.DefaultRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range result.Signals {
+		if s.Name != "test_failure" {
+			continue
+		}
+		if !s.Value || len(s.Evidence) != 1 {
+			t.Fatalf("test_failure = %+v, want one piece of evidence", s)
+		}
+		e := s.Evidence[0]
+		if e.Call != 1 || e.Line != 1 || e.ResultLine != 2 || e.ExitCode != 1 || e.OutputLine != 3 || e.Match != "--- FAIL: TestRefresh (0.00s)" {
+			t.Errorf("evidence = %+v", e)
+		}
+		if got := formatEvidence(e); !strings.Contains(got, "line 1") || !strings.Contains(got, "output line 3") {
+			t.Errorf("formatEvidence() = %q", got)
+		}
 	}
 }
 
@@ -173,17 +215,28 @@ func TestFindMostRecentTranscript(t *testing.T) {
 	}
 }
 
-func TestSignalWeights(t *testing.T) {
-	// Verify weights sum to <= 1.0 for positive signals
-	positiveSum := weightTestsPass + weightGitPush + weightGitCommit +
-		weightBeadsClosed + weightRatchetLock + weightNoErrors
-
-	if positiveSum > 1.0 {
-		t.Errorf("positive weights sum to %.2f (should be <= 1.0)", positiveSum)
+func TestAnalyzeTranscriptCodex(t *testing.T) {
+	rollout := `{"timestamp":"2026-03-14T09:00:00Z","type":"session_meta","payload":{"id":"codex-42"}}
+{"timestamp":"2026-03-14T09:00:01Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"git commit -m fix && git push\"]}","call_id":"c1"}}
+{"timestamp":"2026-03-14T09:00:02Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"{\"output\":\"[main abc1234] fix\\n 1 file changed\",\"metadata\":{\"exit_code\":0}}"}}
+`
+	path := filepath.Join(t.TempDir(), "rollout-2026-03-14-codex-42.jsonl")
+	if err := os.WriteFile(path, []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
 	}
 
-	// Verify penalty weights are positive values
-	if penaltyTestFailure <= 0 || penaltyException <= 0 || penaltyNoCommit <= 0 {
-		t.Error("penalty weights should be positive values")
+	result, err := analyzeTranscript(path, "", outcome.DefaultRules())
+	if err != nil {
+		t.Fatalf("analyze transcript: %v", err)
+	}
+	if result.SessionID != "codex-42" {
+		t.Errorf("SessionID = %q, want codex-42", result.SessionID)
+	}
+	found := make(map[string]bool)
+	for _, s := range result.Signals {
+		found[s.Name] = s.Value
+	}
+	if !found["git_commit"] || !found["git_push"] {
+		t.Errorf("expected commit and push signals, got %+v", result.Signals)
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -21,6 +22,7 @@ var (
 	storeLimit   int
 	storeRebuild bool
 	storeCategorize bool
+	storeSort    string
 )
 
 const (
@@ -72,9 +74,15 @@ type IndexEntry struct {
 
 // SearchResult represents a search match.
 type SearchResult struct {
-	Entry    IndexEntry `json:"entry"`
-	Score    float64    `json:"score"`
-	Snippet  string     `json:"snippet,omitempty"`
+	Entry   IndexEntry `json:"entry"`
+	Score   float64    `json:"score"`
+	Snippet string     `json:"snippet,omitempty"`
+
+	// Relevance is the BM25 score before the utility boost.
+	Relevance float64 `json:"relevance"`
+
+	// Breakdown is the per-term BM25 contribution to Relevance.
+	Breakdown []search.TermScore `json:"breakdown,omitempty"`
 }
 
 var storeCmd = &cobra.Command{
@@ -127,16 +135,33 @@ Examples:
 		Short: "Search the index",
 		Long: `Search for artifacts matching a query.
 
-Returns results ranked by relevance with snippets.
+Returns results ranked by BM25 relevance (title and tag matches boosted),
+weighted by MemRL utility, with snippets. JSON output includes the
+per-term score breakdown.
+
+Query syntax (all clauses must match):
+  mutex pattern         keywords; at least one must appear, they drive ranking
+  "token bucket"        required phrase
+  -redis  -tag:legacy   exclude a keyword, phrase or filter
+  tag:auth              tag (requires --categorize when indexing)
+  category:security     category
+  type:learning         artifact type
+  maturity:established  maturity level
+  utility>0.6           utility comparison (>, >=, <, <=, =)
+  since:7d              modified within 7d, 2w, 36h, or since YYYY-MM-DD
+  a OR b, ( ... )       alternatives and grouping
 
 Examples:
   ao store search "mutex pattern"
   ao store search "error handling" --limit 5
+  ao store search 'tag:auth maturity:established utility>0.6 "token bucket" -redis'
+  ao store search 'type:learning since:7d' --sort recency
   ao store search "authentication" -o json`,
 		Args: cobra.ExactArgs(1),
 		RunE: runStoreSearch,
 	}
 	searchCmd.Flags().IntVar(&storeLimit, "limit", 10, "Maximum results to return")
+	searchCmd.Flags().StringVar(&storeSort, "sort", "score", "Sort by: score, utility, recency")
 	storeCmd.AddCommand(searchCmd)
 
 	// rebuild subcommand
@@ -232,7 +257,13 @@ func runStoreSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	results, err := searchIndex(cwd, query, storeLimit)
+	switch storeSort {
+	case "score", "utility", "recency":
+	default:
+		return fmt.Errorf("invalid --sort %q: use score, utility or recency", storeSort)
+	}
+
+	results, err := searchIndex(cwd, query, storeLimit, storeSort)
 	if err != nil {
 		return fmt.Errorf("search: %w", err)
 	}
@@ -444,8 +475,18 @@ func appendToIndex(baseDir string, entry *IndexEntry) error {
 	return err
 }
 
-// searchIndex searches the index for matching entries.
-func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
+// searchIndex evaluates a store query (see search.Query) against the
+// index and returns matches ordered by sortBy: "score" (BM25 relevance
+// weighted by utility), "utility" or "recency".
+func searchIndex(baseDir, query string, limit int, sortBy string) ([]SearchResult, error) {
+	q, err := search.ParseQuery(query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("parse query: %w", err)
+	}
+	if q.Empty() {
+		return nil, fmt.Errorf("parse query: empty query")
+	}
+
 	indexPath := filepath.Join(baseDir, IndexDir, IndexFileName)
 
 	f, err := os.Open(indexPath)
@@ -459,8 +500,10 @@ func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
 		_ = f.Close() //nolint:errcheck // read-only index search, close error non-fatal
 	}()
 
-	queryTerms := strings.Fields(strings.ToLower(query))
-	var results []SearchResult
+	// Load entries into a BM25 index. Later entries for the same path
+	// (re-indexed artifacts) replace earlier ones.
+	idx := search.NewIndex()
+	entries := make(map[string]IndexEntry)
 
 	scanner := bufio.NewScanner(f)
 	// Increase buffer size for large entries
@@ -471,69 +514,87 @@ func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
 		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
 			continue
 		}
+		entries[entry.Path] = entry
+		search.AddDocument(idx, entry.Path, search.Fields{
+			Title: entry.Title,
+			Tags:  append(append([]string{}, entry.Keywords...), entry.Tags...),
+			Body:  entry.Content,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-		score := computeSearchScore(entry, queryTerms)
-		if score > 0 {
-			snippet := createSearchSnippet(entry.Content, query, 150)
-			results = append(results, SearchResult{
-				Entry:   entry,
-				Score:   score,
-				Snippet: snippet,
-			})
+	hits := make(map[string]search.IndexResult)
+	if rank := q.RankTerms(); len(rank) > 0 {
+		for _, hit := range search.Search(idx, strings.Join(rank, " "), 0) {
+			hits[hit.Path] = hit
 		}
 	}
 
-	// Sort by score (descending) then by utility (descending)
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Score != results[j].Score {
-			return results[i].Score > results[j].Score
+	snippet := storeSnippet(q)
+	var results []SearchResult
+	for path, entry := range entries {
+		if !q.Match(&storeQueryEntry{IndexEntry: entry, idx: idx}) {
+			continue
 		}
-		return results[i].Entry.Utility > results[j].Entry.Utility
-	})
+		hit := hits[path]
+		results = append(results, SearchResult{
+			Entry:     entry,
+			Score:     computeSearchScore(entry, hit.Score),
+			Snippet:   createSearchSnippet(entry.Content, snippet, 150),
+			Relevance: hit.Score,
+			Breakdown: hit.Breakdown,
+		})
+	}
+
+	sortSearchResults(results, sortBy)
 
 	// Apply limit
 	if limit > 0 && len(results) > limit {
 		results = results[:limit]
 	}
 
-	return results, scanner.Err()
+	return results, nil
 }
 
-// computeSearchScore calculates relevance score for a query.
-func computeSearchScore(entry IndexEntry, queryTerms []string) float64 {
-	var score float64
-
-	lowerContent := strings.ToLower(entry.Content)
-	lowerTitle := strings.ToLower(entry.Title)
-
-	for _, term := range queryTerms {
-		// Title matches are worth more
-		if strings.Contains(lowerTitle, term) {
-			score += 3.0
+// sortSearchResults orders results by the given mode, breaking ties by
+// score, then utility, then recency, then path.
+func sortSearchResults(results []SearchResult, sortBy string) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch sortBy {
+		case "utility":
+			if a.Entry.Utility != b.Entry.Utility {
+				return a.Entry.Utility > b.Entry.Utility
+			}
+		case "recency":
+			if !a.Entry.ModifiedAt.Equal(b.Entry.ModifiedAt) {
+				return a.Entry.ModifiedAt.After(b.Entry.ModifiedAt)
+			}
 		}
-
-		// Content matches
-		if strings.Contains(lowerContent, term) {
-			score += 1.0
+		if a.Score != b.Score {
+			return a.Score > b.Score
 		}
-
-		// Keyword matches
-		for _, kw := range entry.Keywords {
-			if strings.Contains(strings.ToLower(kw), term) {
-				score += 2.0
-				break
-			}
+		if a.Entry.Utility != b.Entry.Utility {
+			return a.Entry.Utility > b.Entry.Utility
 		}
-	}
+		if !a.Entry.ModifiedAt.Equal(b.Entry.ModifiedAt) {
+			return a.Entry.ModifiedAt.After(b.Entry.ModifiedAt)
+		}
+		return a.Entry.Path < b.Entry.Path
+	})
+}
 
+// computeSearchScore weights a BM25 relevance score by the entry's MemRL utility.
+func computeSearchScore(entry IndexEntry, relevance float64) float64 {
 	// Boost by utility (MemRL integration)
 	// Lambda = 0.5 (balanced weighting)
 	lambda := types.DefaultLambda
 	if entry.Utility > 0 {
-		score = (1-lambda)*score + lambda*entry.Utility*score
+		return (1-lambda)*relevance + lambda*entry.Utility*relevance
 	}
-
-	return score
+	return relevance
 }
 
 // extractTitle gets the title from markdown content.
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/search"
+)
+
+// Store queries use the search query language (see search.Query), e.g.
+//
+//	tag:auth maturity:established utility>0.6 "token bucket" -redis
+//
+// Unlike ao search, every clause must match: an entry must contain at
+// least one of a group's keywords, which also drive BM25 ranking.
+
+// storeQueryEntry is an entry under evaluation as a search.Doc.
+type storeQueryEntry struct {
+	IndexEntry
+	idx *search.Index
+}
+
+// HasTerm reports whether the entry contains term in any field.
+func (e *storeQueryEntry) HasTerm(term string) bool {
+	_, ok := e.idx.Terms[term][e.Path]
+	return ok
+}
+
+// Text returns the entry's indexed text.
+func (e *storeQueryEntry) Text() string {
+	tags := append(append([]string{}, e.Keywords...), e.Tags...)
+	return e.Title + "\n" + strings.Join(tags, "\n") + "\n" + e.Content
+}
+
+// Field returns the entry's metadata values.
+func (e *storeQueryEntry) Field(name string) []string {
+	switch name {
+	case "type":
+		return []string{e.Type}
+	case "maturity":
+		return []string{e.Maturity}
+	case "tag":
+		return e.Tags
+	case "category":
+		return []string{e.Category}
+	}
+	return nil
+}
+
+// Date is when the entry was last modified.
+func (e *storeQueryEntry) Date() time.Time { return e.ModifiedAt }
+
+// Number returns the entry's utility.
+func (e *storeQueryEntry) Number(name string) (float64, bool) {
+	if name == "utility" {
+		return e.Utility, true
+	}
+	return 0, false
+}
+
+// storeSnippet is the text to centre result snippets on: the first phrase,
+// else the first keyword.
+func storeSnippet(q search.Query) string {
+	if len(q.Required) > 0 {
+		return strings.Join(q.Required[0], " ")
+	}
+	if rank := q.RankTerms(); len(rank) > 0 {
+		return rank[0]
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/search"
+)
+
+func TestStoreSnippet(t *testing.T) {
+	tests := []struct {
+		query   string
+		snippet string
+	}{
+		{"mutex pattern", "mutex"},
+		{`tag:auth "token bucket" retry -redis`, "token bucket"},
+		{"-redis tag:auth", ""},
+	}
+	for _, tt := range tests {
+		q, err := search.ParseQuery(tt.query, time.Now())
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+		}
+		if got := storeSnippet(q); got != tt.snippet {
+			t.Errorf("storeSnippet(%q) = %q, want %q", tt.query, got, tt.snippet)
+		}
+	}
+}
+
+func TestSearchIndexQueryDSL(t *testing.T) {
+	tmp := t.TempDir()
+	indexDir := filepath.Join(tmp, IndexDir)
+	if err := os.MkdirAll(indexDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	entries := []IndexEntry{
+		{Path: "/k/bucket.md", Type: "learning", Title: "Rate limiting", Content: "use a token bucket per client",
+			Tags: []string{"auth"}, Utility: 0.8, Maturity: "established", ModifiedAt: now.Add(-48 * time.Hour)},
+		{Path: "/k/redis.md", Type: "learning", Title: "Distributed limits", Content: "token bucket backed by redis",
+			Tags: []string{"auth"}, Utility: 0.9, Maturity: "established", ModifiedAt: now.Add(-time.Hour)},
+		{Path: "/k/split.md", Type: "learning", Title: "Buckets", Content: "the bucket holds a token",
+			Tags: []string{"auth"}, Utility: 0.7, Maturity: "established", ModifiedAt: now.Add(-time.Hour)},
+		{Path: "/k/low.md", Type: "pattern", Title: "Token bucket", Content: "token bucket sketch",
+			Tags: []string{"auth"}, Utility: 0.4, Maturity: "established", ModifiedAt: now},
+		{Path: "/k/old.md", Type: "pattern", Title: "Legacy", Content: "session cookies",
+			Tags: []string{"Auth"}, Utility: 0.65, ModifiedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	var buf strings.Builder
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(indexDir, IndexFileName), []byte(buf.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := func(results []SearchResult) string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Entry.Path)
+		}
+		return strings.Join(out, " ")
+	}
+
+	tests := []struct {
+		query  string
+		sortBy string
+		want   string
+	}{
+		{`tag:auth maturity:established utility>0.6 "token bucket" -redis`, "score", "/k/bucket.md"},
+		{"tag:auth utility>0.6", "utility", "/k/redis.md /k/bucket.md /k/split.md /k/old.md"},
+		{"tag:auth", "recency", "/k/low.md /k/redis.md /k/split.md /k/bucket.md /k/old.md"},
+		{"type:patterns maturity:provisional", "score", "/k/old.md"},
+		{"since:3d -type:pattern", "recency", "/k/redis.md /k/split.md /k/bucket.md"},
+		{"cookies OR redis", "score", "/k/old.md /k/redis.md"},
+	}
+	if _, err := searchIndex(tmp, "- ", 0, "score"); err == nil {
+		t.Error("searchIndex with an empty query: expected error")
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := searchIndex(tmp, tt.query, 0, tt.sortBy)
+			if err != nil {
+				t.Fatalf("searchIndex: %v", err)
+			}
+			if got := paths(results); got != tt.want {
+				t.Errorf("results = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
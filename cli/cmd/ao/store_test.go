@@ -1,108 +1,120 @@
 package main
 
 import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 )
 
 func TestComputeSearchScore(t *testing.T) {
 	tests := []struct {
-		name       string
-		entry      IndexEntry
-		queryTerms []string
-		wantMin    float64
+		name      string
+		entry     IndexEntry
+		relevance float64
+		want      float64
 	}{
 		{
-			name: "title match scores highest",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern",
-				Content: "unrelated content",
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    3.0, // title match = 3.0
+			name:      "no utility leaves relevance unchanged",
+			entry:     IndexEntry{Title: "Mutex Pattern"},
+			relevance: 3.0,
+			want:      3.0,
 		},
 		{
-			name: "content match",
-			entry: IndexEntry{
-				Title:   "Some Title",
-				Content: "Use mutex for shared state",
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    1.0, // content match = 1.0
+			name:      "utility boost",
+			entry:     IndexEntry{Title: "Mutex Pattern", Utility: 0.9},
+			relevance: 3.0,
+			// (1-0.5)*3 + 0.5*0.9*3 = 1.5 + 1.35 = 2.85
+			want: 2.85,
 		},
 		{
-			name: "keyword match",
-			entry: IndexEntry{
-				Title:    "Title",
-				Content:  "content",
-				Keywords: []string{"mutex", "concurrency"},
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    2.0, // keyword match = 2.0
-		},
-		{
-			name: "all three match",
-			entry: IndexEntry{
-				Title:    "Mutex Pattern",
-				Content:  "Use mutex for shared state",
-				Keywords: []string{"mutex"},
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    6.0, // 3 + 1 + 2 = 6.0
-		},
-		{
-			name: "no match",
-			entry: IndexEntry{
-				Title:   "Database Pattern",
-				Content: "pooling connections",
-			},
-			queryTerms: []string{"auth"},
-			wantMin:    0.0,
-		},
-		{
-			name: "utility boost",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern",
-				Content: "content",
-				Utility: 0.9,
-			},
-			queryTerms: []string{"mutex"},
-			// title match (3.0) weighted by utility: (1-0.5)*3 + 0.5*0.9*3 = 1.5 + 1.35 = 2.85
-			wantMin: 2.8,
-		},
-		{
-			name: "multiple query terms",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern for Go",
-				Content: "Use mutex and channels for concurrency",
-			},
-			queryTerms: []string{"mutex", "channels"},
-			wantMin:    4.0, // mutex: title(3) + content(1), channels: content(1) = 5.0
+			name:      "zero relevance stays zero",
+			entry:     IndexEntry{Utility: 1.0},
+			relevance: 0,
+			want:      0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := computeSearchScore(tt.entry, tt.queryTerms)
-			if got < tt.wantMin {
-				t.Errorf("computeSearchScore() = %v, want >= %v", got, tt.wantMin)
+			got := computeSearchScore(tt.entry, tt.relevance)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("computeSearchScore() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 
-	// Test relative ordering
-	t.Run("title match ranks higher than content match", func(t *testing.T) {
-		titleEntry := IndexEntry{Title: "Mutex Pattern", Content: "something else"}
-		contentEntry := IndexEntry{Title: "Something", Content: "mutex is useful"}
+	t.Run("higher utility ranks higher at equal relevance", func(t *testing.T) {
+		low := computeSearchScore(IndexEntry{Utility: 0.2}, 2.0)
+		high := computeSearchScore(IndexEntry{Utility: 0.9}, 2.0)
+		if high <= low {
+			t.Errorf("high utility (%.2f) should outrank low utility (%.2f)", high, low)
+		}
+	})
+}
 
-		titleScore := computeSearchScore(titleEntry, []string{"mutex"})
-		contentScore := computeSearchScore(contentEntry, []string{"mutex"})
+func TestSearchIndexBM25(t *testing.T) {
+	tmp := t.TempDir()
+	indexDir := filepath.Join(tmp, IndexDir)
+	if err := os.MkdirAll(indexDir, 0755); err != nil {
+		t.Fatal(err)
+	}
 
-		if titleScore <= contentScore {
-			t.Errorf("title match (%.2f) should rank higher than content match (%.2f)",
-				titleScore, contentScore)
+	entries := []IndexEntry{
+		{Path: "/k/title.md", Title: "Mutex Pattern", Content: "guards shared state", Utility: 0.5},
+		{Path: "/k/body.md", Title: "Locks", Content: "a mutex guards shared state", Utility: 0.5},
+		{Path: "/k/dump.md", Title: "Session dump", Content: "mutex " + strings.Repeat("unrelated chatter ", 500), Utility: 0.5},
+		{Path: "/k/other.md", Title: "OAuth", Content: "token refresh", Utility: 0.5},
+		// Re-indexed entry for the same path replaces the earlier one
+		{Path: "/k/other.md", Title: "OAuth", Content: "token refresh without locks", Utility: 0.5},
+	}
+	var buf strings.Builder
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
 		}
-	})
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(indexDir, IndexFileName), []byte(buf.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := searchIndex(tmp, "mutex", 10, "score")
+	if err != nil {
+		t.Fatalf("searchIndex: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	order := []string{results[0].Entry.Path, results[1].Entry.Path, results[2].Entry.Path}
+	want := []string{"/k/title.md", "/k/body.md", "/k/dump.md"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ranking = %v, want %v", order, want)
+		}
+	}
+
+	top := results[0]
+	if top.Relevance <= 0 || len(top.Breakdown) != 1 || top.Breakdown[0].Term != "mutex" {
+		t.Errorf("expected BM25 breakdown for top result, got relevance=%.3f breakdown=%+v", top.Relevance, top.Breakdown)
+	}
+	if top.Breakdown[0].Title != 1 {
+		t.Errorf("expected title frequency 1 in breakdown, got %+v", top.Breakdown[0].Posting)
+	}
+
+	results, err = searchIndex(tmp, "locks", 10, "score")
+	if err != nil {
+		t.Fatalf("searchIndex: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected re-indexed entry to be searchable once, got %d results", len(results))
+	}
 }
 
 func TestExtractTitle(t *testing.T) {
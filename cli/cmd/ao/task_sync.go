@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/parser"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
@@ -76,7 +77,8 @@ with the CASS (Contextual Agent Session Search) system, enabling:
   - Feedback loop closure: Task completion signals update learning utilities
 
 The sync process:
-  1. Reads Claude Code transcript for TaskCreate/TaskUpdate tool calls
+  1. Reads the session transcript for TaskCreate/TaskUpdate/TodoWrite calls
+     (Codex CLI plans and OpenCode todos count as TodoWrite)
   2. Extracts task events and stores them in .agents/ao/tasks.jsonl
   3. Maps task status to CASS maturity levels
   4. Links task completion to the feedback loop
@@ -84,6 +86,7 @@ The sync process:
 Examples:
   ao task-sync                                # Sync from most recent transcript
   ao task-sync --transcript ~/.claude/projects/*/abc.jsonl
+  ao task-sync --transcript ~/.codex/sessions/2026/03/14/rollout-abc.jsonl
   ao task-sync --session session-20260125    # Filter by session
   ao task-sync --promote                     # Promote completed tasks to learnings`,
 	RunE: runTaskSync,
@@ -97,7 +100,7 @@ var (
 
 func init() {
 	rootCmd.AddCommand(taskSyncCmd)
-	taskSyncCmd.Flags().StringVar(&taskSyncTranscript, "transcript", "", "Path to Claude Code transcript")
+	taskSyncCmd.Flags().StringVar(&taskSyncTranscript, "transcript", "", "Path to session transcript (Claude Code, Codex CLI or OpenCode)")
 	taskSyncCmd.Flags().StringVar(&taskSyncSessionID, "session", "", "Filter tasks by session ID")
 	taskSyncCmd.Flags().BoolVar(&taskSyncPromote, "promote", false, "Promote completed tasks to learnings")
 }
@@ -198,8 +201,19 @@ func runTaskSync(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// extractTaskEvents parses a Claude Code transcript for Task tool calls.
+// extractTaskEvents reads task tool calls from a session transcript.
+// Claude Code transcripts are scanned for TaskCreate/TaskUpdate/TodoWrite;
+// Codex CLI and OpenCode logs are read through their parser.TranscriptSource,
+// whose plan and todo tools are normalised to TodoWrite.
 func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error) {
+	src, err := parser.DetectSource(transcriptPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	if src.Name() != parser.FormatClaudeCode {
+		return extractSourceTaskEvents(transcriptPath, src, filterSession)
+	}
+
 	f, err := os.Open(transcriptPath)
 	if err != nil {
 		return nil, fmt.Errorf("open transcript: %w", err)
@@ -208,8 +222,7 @@ func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error
 		_ = f.Close() //nolint:errcheck // read-only transcript extraction, close error non-fatal
 	}()
 
-	var tasks []TaskEvent
-	taskMap := make(map[string]*TaskEvent) // Track by task ID for updates
+	tracker := newTaskTracker()
 	var currentSessionID string
 
 	scanner := bufio.NewScanner(f)
@@ -259,33 +272,99 @@ func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error
 
 			toolName, _ := block["name"].(string)
 			input, _ := block["input"].(map[string]interface{})
+			tracker.apply(toolName, input, currentSessionID)
+		}
+	}
 
-			switch toolName {
-			case "TaskCreate":
-				task := parseTaskCreate(input, currentSessionID)
-				if task != nil {
-					taskMap[task.TaskID] = task
-				}
+	return tracker.events(), nil
+}
 
-			case "TaskUpdate":
-				taskID, _ := input["taskId"].(string)
-				if existing, ok := taskMap[taskID]; ok {
-					updateTask(existing, input)
-				}
+// extractSourceTaskEvents reads task tool calls from a non-Claude session
+// log's normalised messages.
+func extractSourceTaskEvents(transcriptPath string, src parser.TranscriptSource, filterSession string) ([]TaskEvent, error) {
+	result, err := src.ParseFile(transcriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s transcript: %w", src.Name(), err)
+	}
 
-			case "TaskList":
-				// TaskList returns current state; we could use this to validate
-				// but for now we rely on Create/Update events
-			}
+	tracker := newTaskTracker()
+	for _, msg := range result.Messages {
+		if filterSession != "" && msg.SessionID != filterSession {
+			continue
+		}
+		for _, tool := range msg.Tools {
+			tracker.apply(tool.Name, tool.Input, msg.SessionID)
 		}
 	}
+	return tracker.events(), nil
+}
 
-	// Convert map to slice
-	for _, t := range taskMap {
-		tasks = append(tasks, *t)
+// taskTracker accumulates task state from a session's task tool calls.
+type taskTracker struct {
+	byID   map[string]*TaskEvent
+	byTodo map[string]*TaskEvent // TodoWrite items, keyed by content
+}
+
+func newTaskTracker() *taskTracker {
+	return &taskTracker{
+		byID:   make(map[string]*TaskEvent),
+		byTodo: make(map[string]*TaskEvent),
 	}
+}
 
-	return tasks, nil
+// apply records one tool call.
+func (t *taskTracker) apply(toolName string, input map[string]interface{}, sessionID string) {
+	switch toolName {
+	case "TaskCreate":
+		task := parseTaskCreate(input, sessionID)
+		if task != nil {
+			t.byID[task.TaskID] = task
+		}
+
+	case "TaskUpdate":
+		taskID, _ := input["taskId"].(string)
+		if existing, ok := t.byID[taskID]; ok {
+			updateTask(existing, input)
+		}
+
+	case "TodoWrite":
+		// Each call is a full snapshot of the todo list
+		todos, _ := input["todos"].([]interface{})
+		for _, item := range todos {
+			todo, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			content, _ := todo["content"].(string)
+			status, _ := todo["status"].(string)
+			task, ok := t.byTodo[content]
+			if !ok {
+				task = parseTaskCreate(map[string]interface{}{"subject": content}, sessionID)
+				if task == nil {
+					continue
+				}
+				task.TaskID = fmt.Sprintf("%s-%d", task.TaskID, len(t.byTodo)+1)
+				t.byTodo[content] = task
+				t.byID[task.TaskID] = task
+			}
+			if status != "" && status != task.Status {
+				updateTask(task, map[string]interface{}{"status": status})
+			}
+		}
+
+	case "TaskList":
+		// TaskList returns current state; we could use this to validate
+		// but for now we rely on Create/Update events
+	}
+}
+
+// events returns the accumulated tasks.
+func (t *taskTracker) events() []TaskEvent {
+	var tasks []TaskEvent
+	for _, task := range t.byID {
+		tasks = append(tasks, *task)
+	}
+	return tasks
 }
 
 // parseTaskCreate extracts a TaskEvent from TaskCreate input.
@@ -544,6 +623,12 @@ func runTaskFeedback(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	ledger, err := openFeedbackLedger(cwd)
+	if err != nil {
+		return err
+	}
+	defer ledger.Close() //nolint:errcheck // lock released on exit regardless
+
 	// Process feedback for each task
 	processed := 0
 	for _, task := range processable {
@@ -557,11 +642,22 @@ func runTaskFeedback(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		oldUtility, newUtility, err := updateLearningUtility(learningPath, reward, types.DefaultAlpha)
+		event, applied, err := ledger.apply(FeedbackEvent{
+			SessionID:    task.SessionID,
+			ArtifactPath: learningPath,
+			Reward:       reward,
+			Alpha:        types.DefaultAlpha,
+			Source:       RewardSourceTask + ":" + task.TaskID,
+		})
 		if err != nil {
 			VerbosePrintf("Warning: failed to update %s: %v\n", learningPath, err)
 			continue
 		}
+		if !applied {
+			VerbosePrintf("Already rewarded %s for task %s\n", task.LearningID, task.TaskID)
+			continue
+		}
+		oldUtility, newUtility := event.UtilityBefore, event.UtilityAfter
 
 		// Check for maturity transition
 		result, err := ratchet.CheckMaturityTransition(learningPath)
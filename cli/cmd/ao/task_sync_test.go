@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestExtractTaskEventsOpenCodeTodos(t *testing.T) {
+	doc := `{
+  "info": {"id": "ses_todo"},
+  "messages": [
+    {"info": {"id": "msg_1", "sessionID": "ses_todo", "role": "assistant", "time": {"created": 1}},
+     "parts": [{"id": "prt_1", "type": "tool", "tool": "todowrite", "state": {"status": "completed",
+       "input": {"todos": [{"content": "write test", "status": "in_progress"}, {"content": "fix bug", "status": "pending"}]}}}]},
+    {"info": {"id": "msg_2", "sessionID": "ses_todo", "role": "assistant", "time": {"created": 2}},
+     "parts": [{"id": "prt_2", "type": "tool", "tool": "todowrite", "state": {"status": "completed",
+       "input": {"todos": [{"content": "write test", "status": "completed"}, {"content": "fix bug", "status": "in_progress"}]}}}]}
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "ses_todo.json")
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := extractTaskEvents(path, "")
+	if err != nil {
+		t.Fatalf("extractTaskEvents: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2: %+v", len(tasks), tasks)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Subject > tasks[j].Subject })
+	if tasks[0].Subject != "write test" || tasks[0].Status != "completed" || tasks[0].CompletedAt.IsZero() {
+		t.Errorf("first task = %+v", tasks[0])
+	}
+	if tasks[1].Subject != "fix bug" || tasks[1].Status != "in_progress" || tasks[1].SessionID != "ses_todo" {
+		t.Errorf("second task = %+v", tasks[1])
+	}
+	if tasks[0].TaskID == tasks[1].TaskID {
+		t.Error("task IDs must be unique")
+	}
+
+	if tasks, err := extractTaskEvents(path, "other-session"); err != nil || len(tasks) != 0 {
+		t.Errorf("session filter: got %d tasks, err %v", len(tasks), err)
+	}
+}
@@ -33,6 +33,35 @@ type Config struct {
 
 	// Paths settings for artifact locations (configurable, not hardcoded)
 	Paths PathsConfig `yaml:"paths" json:"paths"`
+
+	// Gate configures human review gates.
+	Gate GateConfig `yaml:"gate" json:"gate"`
+
+	// Inject configures knowledge injection.
+	Inject InjectConfig `yaml:"inject" json:"inject"`
+}
+
+// InjectConfig holds knowledge injection settings.
+type InjectConfig struct {
+	// Explore is the fraction of learning slots filled by Thompson sampling
+	// instead of greedy ranking (0 disables exploration).
+	Explore float64 `yaml:"explore" json:"explore"`
+
+	// ExploreSeed seeds the exploration sampler together with the session
+	// ID, so a session's draws can be reproduced.
+	ExploreSeed string `yaml:"explore_seed" json:"explore_seed"`
+}
+
+// GateConfig holds human review settings.
+type GateConfig struct {
+	// SampleSeed seeds the review sampler, so a repository samples the same
+	// candidates on every machine.
+	SampleSeed string `yaml:"sample_seed" json:"sample_seed"`
+
+	// SampleRates is the fraction of each tier's candidates (by tier name,
+	// e.g. "silver") sampled into human review when the tier does not
+	// require it. Tiers not listed keep their default rate.
+	SampleRates map[string]float64 `yaml:"sample_rates" json:"sample_rates"`
 }
 
 // PathsConfig holds configurable paths for artifact locations.
@@ -124,6 +153,14 @@ func Default() *Config {
 			CitationsFile:  ".agents/ao/citations.jsonl",
 			TranscriptsDir: filepath.Join(homeDir, ".claude", "projects"),
 		},
+		Gate: GateConfig{
+			// Gold and silver auto-promote; a sample of each is still
+			// reviewed so their quality is checked
+			SampleRates: map[string]float64{
+				"gold":   0.02,
+				"silver": 0.05,
+			},
+		},
 	}
 }
 
@@ -203,6 +240,12 @@ func applyEnv(cfg *Config) *Config {
 	if os.Getenv("AGENTOPS_VERBOSE") == "true" || os.Getenv("AGENTOPS_VERBOSE") == "1" {
 		cfg.Verbose = true
 	}
+	if v := os.Getenv("AGENTOPS_SAMPLE_SEED"); v != "" {
+		cfg.Gate.SampleSeed = v
+	}
+	if v := os.Getenv("AGENTOPS_EXPLORE_SEED"); v != "" {
+		cfg.Inject.ExploreSeed = v
+	}
 	if v := os.Getenv("AGENTOPS_NO_SC"); v == "true" || v == "1" {
 		cfg.Search.UseSmartConnections = false
 		cfg.Search.UseSmartConnectionsSet = true
@@ -262,6 +305,21 @@ func merge(dst, src *Config) *Config {
 	if src.Paths.TranscriptsDir != "" {
 		dst.Paths.TranscriptsDir = src.Paths.TranscriptsDir
 	}
+	if src.Gate.SampleSeed != "" {
+		dst.Gate.SampleSeed = src.Gate.SampleSeed
+	}
+	for tier, rate := range src.Gate.SampleRates {
+		if dst.Gate.SampleRates == nil {
+			dst.Gate.SampleRates = make(map[string]float64)
+		}
+		dst.Gate.SampleRates[tier] = rate
+	}
+	if src.Inject.Explore != 0 {
+		dst.Inject.Explore = src.Inject.Explore
+	}
+	if src.Inject.ExploreSeed != "" {
+		dst.Inject.ExploreSeed = src.Inject.ExploreSeed
+	}
 
 	return dst
 }
@@ -47,6 +47,22 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMerge_GateSampleRates(t *testing.T) {
+	dst := Default()
+	if dst.Gate.SampleRates["silver"] <= 0 || dst.Gate.SampleRates["gold"] <= 0 {
+		t.Fatalf("default sample rates = %v, want gold and silver sampled", dst.Gate.SampleRates)
+	}
+
+	// A listed tier is overridden, even to zero; the others keep their rate
+	result := merge(dst, &Config{Gate: GateConfig{SampleRates: map[string]float64{"silver": 0}}})
+	if rate, ok := result.Gate.SampleRates["silver"]; !ok || rate != 0 {
+		t.Errorf("silver rate = %v, want 0", rate)
+	}
+	if result.Gate.SampleRates["gold"] != 0.02 {
+		t.Errorf("gold rate = %v, want the default 0.02", result.Gate.SampleRates["gold"])
+	}
+}
+
 func TestMerge_BooleanOverride(t *testing.T) {
 	dst := Default()
 	if !dst.Search.UseSmartConnections {
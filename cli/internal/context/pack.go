@@ -0,0 +1,169 @@
+package context
+
+import "sort"
+
+// Reasons an item was left out by Pack.
+const (
+	// DropTooLarge means the item alone costs more than the whole budget.
+	DropTooLarge = "too_large"
+
+	// DropLowerValue means higher-value items used the budget instead.
+	DropLowerValue = "lower_value"
+)
+
+// PackItem is one indivisible unit of content competing for a token budget.
+type PackItem struct {
+	// Section groups items for minimum quotas (e.g. "learnings").
+	Section string `json:"section"`
+
+	// ID identifies the item in reports.
+	ID string `json:"id"`
+
+	// Tokens is the item's estimated cost.
+	Tokens int `json:"tokens"`
+
+	// Value is the item's worth; higher is better.
+	Value float64 `json:"value"`
+}
+
+// DroppedItem is an item Pack did not select, with the reason.
+type DroppedItem struct {
+	PackItem
+	Reason string `json:"reason"`
+}
+
+// PackResult is the outcome of Pack.
+type PackResult struct {
+	// Selected holds indices into the input items, ascending.
+	Selected []int `json:"selected"`
+
+	// Dropped lists the items that were not selected, in input order.
+	Dropped []DroppedItem `json:"dropped,omitempty"`
+
+	// Tokens is the total cost of the selected items.
+	Tokens int `json:"tokens"`
+
+	// Value is the total value of the selected items.
+	Value float64 `json:"value"`
+}
+
+// Pack chooses the subset of items with the greatest total value whose
+// total cost fits in budget (0/1 knapsack). Before optimising, each
+// section's quota is honoured: its highest-value items are taken first, up
+// to quotas[section] of them, as long as they fit. Items are never split.
+func Pack(items []PackItem, budget int, quotas map[string]int) PackResult {
+	if budget < 0 {
+		budget = 0
+	}
+	chosen := make([]bool, len(items))
+	remaining := budget
+
+	// Phase 1: minimum quotas. Sections take turns claiming their next
+	// best item that fits, so no section's quota starves another's.
+	bySection := make(map[string][]int)
+	maxQuota := 0
+	for i, it := range items {
+		bySection[it.Section] = append(bySection[it.Section], i)
+		if quotas[it.Section] > maxQuota {
+			maxQuota = quotas[it.Section]
+		}
+	}
+	sections := make([]string, 0, len(bySection))
+	for s, idxs := range bySection {
+		sections = append(sections, s)
+		sort.SliceStable(idxs, func(a, b int) bool {
+			return items[idxs[a]].Value > items[idxs[b]].Value
+		})
+	}
+	sort.Strings(sections)
+
+	next := make(map[string]int) // position of each section's next candidate
+	for round := 0; round < maxQuota; round++ {
+		for _, s := range sections {
+			if round >= quotas[s] {
+				continue
+			}
+			idxs := bySection[s]
+			for next[s] < len(idxs) {
+				i := idxs[next[s]]
+				next[s]++
+				if cost := itemCost(items[i]); cost <= remaining {
+					chosen[i] = true
+					remaining -= cost
+					break
+				}
+			}
+		}
+	}
+
+	// Phase 2: knapsack over the rest
+	var rest []int
+	total := 0
+	for i := range items {
+		if !chosen[i] && itemCost(items[i]) <= remaining && items[i].Value > 0 {
+			rest = append(rest, i)
+			total += itemCost(items[i])
+		}
+	}
+	if total <= remaining {
+		for _, i := range rest {
+			chosen[i] = true
+		}
+	} else {
+		for _, i := range knapsack(items, rest, remaining) {
+			chosen[i] = true
+		}
+	}
+
+	var res PackResult
+	for i, it := range items {
+		if chosen[i] {
+			res.Selected = append(res.Selected, i)
+			res.Tokens += itemCost(it)
+			res.Value += it.Value
+			continue
+		}
+		reason := DropLowerValue
+		if itemCost(it) > budget {
+			reason = DropTooLarge
+		}
+		res.Dropped = append(res.Dropped, DroppedItem{PackItem: it, Reason: reason})
+	}
+	return res
+}
+
+// knapsack solves 0/1 knapsack over the candidate indices with dynamic
+// programming on integer token costs and returns the chosen indices.
+func knapsack(items []PackItem, candidates []int, capacity int) []int {
+	best := make([]float64, capacity+1)
+	keep := make([][]bool, len(candidates))
+
+	for k, i := range candidates {
+		cost := itemCost(items[i])
+		keep[k] = make([]bool, capacity+1)
+		for w := capacity; w >= cost; w-- {
+			if v := best[w-cost] + items[i].Value; v > best[w] {
+				best[w] = v
+				keep[k][w] = true
+			}
+		}
+	}
+
+	var chosen []int
+	w := capacity
+	for k := len(candidates) - 1; k >= 0; k-- {
+		if keep[k][w] {
+			chosen = append(chosen, candidates[k])
+			w -= itemCost(items[candidates[k]])
+		}
+	}
+	return chosen
+}
+
+// itemCost is the item's token cost, at least one token.
+func itemCost(it PackItem) int {
+	if it.Tokens < 1 {
+		return 1
+	}
+	return it.Tokens
+}
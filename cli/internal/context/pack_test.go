@@ -0,0 +1,82 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackKnapsackBeatsGreedy(t *testing.T) {
+	// Greedy by value picks "big" (10) and then nothing else fits; the
+	// optimum is the two medium items (6 + 6).
+	items := []PackItem{
+		{Section: "a", ID: "big", Tokens: 60, Value: 10},
+		{Section: "a", ID: "m1", Tokens: 50, Value: 6},
+		{Section: "a", ID: "m2", Tokens: 50, Value: 6},
+	}
+	res := Pack(items, 100, nil)
+
+	if !reflect.DeepEqual(res.Selected, []int{1, 2}) {
+		t.Fatalf("Selected = %v, want [1 2]", res.Selected)
+	}
+	if res.Tokens != 100 || res.Value != 12 {
+		t.Errorf("Tokens/Value = %d/%.1f, want 100/12", res.Tokens, res.Value)
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0].ID != "big" || res.Dropped[0].Reason != DropLowerValue {
+		t.Errorf("Dropped = %+v, want big as lower_value", res.Dropped)
+	}
+}
+
+func TestPackQuotas(t *testing.T) {
+	items := []PackItem{
+		{Section: "learnings", ID: "l1", Tokens: 40, Value: 0.9},
+		{Section: "learnings", ID: "l2", Tokens: 40, Value: 0.8},
+		{Section: "learnings", ID: "l3", Tokens: 40, Value: 0.7},
+		{Section: "sessions", ID: "s1", Tokens: 40, Value: 0.1},
+	}
+
+	// Without a quota the low-value session loses
+	res := Pack(items, 100, nil)
+	if !reflect.DeepEqual(res.Selected, []int{0, 1}) {
+		t.Fatalf("no quota: Selected = %v, want [0 1]", res.Selected)
+	}
+
+	// With one, it displaces the weakest learning
+	res = Pack(items, 100, map[string]int{"sessions": 1})
+	if !reflect.DeepEqual(res.Selected, []int{0, 3}) {
+		t.Fatalf("quota: Selected = %v, want [0 3]", res.Selected)
+	}
+}
+
+func TestPackQuotasTakeTurns(t *testing.T) {
+	items := []PackItem{
+		{Section: "a", ID: "a1", Tokens: 30, Value: 1},
+		{Section: "a", ID: "a2", Tokens: 30, Value: 1},
+		{Section: "b", ID: "b1", Tokens: 30, Value: 1},
+	}
+	// Both quotas cannot be met in full; each section gets one first
+	res := Pack(items, 60, map[string]int{"a": 2, "b": 1})
+	if !reflect.DeepEqual(res.Selected, []int{0, 2}) {
+		t.Fatalf("Selected = %v, want [0 2]", res.Selected)
+	}
+}
+
+func TestPackTooLarge(t *testing.T) {
+	items := []PackItem{
+		{Section: "a", ID: "huge", Tokens: 500, Value: 100},
+		{Section: "a", ID: "small", Tokens: 10, Value: 1},
+	}
+	res := Pack(items, 100, map[string]int{"a": 2})
+	if !reflect.DeepEqual(res.Selected, []int{1}) {
+		t.Fatalf("Selected = %v, want [1]", res.Selected)
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0].Reason != DropTooLarge {
+		t.Errorf("Dropped = %+v, want huge as too_large", res.Dropped)
+	}
+}
+
+func TestPackZeroBudget(t *testing.T) {
+	res := Pack([]PackItem{{Section: "a", ID: "x", Tokens: 0, Value: 1}}, 0, nil)
+	if len(res.Selected) != 0 || len(res.Dropped) != 1 {
+		t.Errorf("expected everything dropped with no budget, got %+v", res)
+	}
+}
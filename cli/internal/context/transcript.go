@@ -0,0 +1,266 @@
+package context
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Test status values recorded in checkpoints and resumption state.
+const (
+	TestStatusPassing = "passing"
+	TestStatusFailing = "failing"
+	TestStatusNone    = "none"
+)
+
+// maxFailingTests caps how many failing test names are preserved.
+const maxFailingTests = 10
+
+// transcriptLine is the subset of a Claude Code transcript line needed to
+// measure context usage.
+type transcriptLine struct {
+	Type             string `json:"type"`
+	IsCompactSummary bool   `json:"isCompactSummary"`
+	Message          *struct {
+		Content json.RawMessage `json:"content"`
+		Usage   *struct {
+			InputTokens              int `json:"input_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			OutputTokens             int `json:"output_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// TranscriptUsage returns the number of tokens the session in a Claude Code
+// transcript currently occupies in the context window. The API usage block
+// on the latest assistant message is exact (prompt, cache reads and writes,
+// and output); transcripts without one fall back to EstimateTokens over the
+// message content since the last compaction.
+func TranscriptUsage(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // read-only file
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	reported, estimated := -1, 0
+	for scanner.Scan() {
+		var line transcriptLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Type == "summary" || line.IsCompactSummary {
+			// Everything before a compaction is gone from the window
+			reported, estimated = -1, 0
+		}
+		if line.Message == nil {
+			continue
+		}
+		estimated += EstimateTokens(string(line.Message.Content))
+		if u := line.Message.Usage; u != nil && line.Type == "assistant" {
+			reported = u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens + u.OutputTokens
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read transcript: %w", err)
+	}
+
+	if reported >= 0 {
+		return reported, nil
+	}
+	return estimated, nil
+}
+
+// testCommandPattern matches shell commands that run a test suite.
+var testCommandPattern = regexp.MustCompile(`\b(go test|pytest|npm (run )?test|yarn test|pnpm test|cargo test|make test|jest|vitest|bats)\b`)
+
+// failingTestPattern extracts test names from common runner output.
+var failingTestPattern = regexp.MustCompile(`(?m)^\s*(?:--- FAIL: (\S+)|FAILED (\S+)|not ok \d+ (.+))`)
+
+// ExtractState derives resumption state from parsed transcript messages:
+// files touched by editing tools, todo progress from TodoWrite, the outcome
+// of the most recent test run, and the task in progress (the active todo,
+// or else the latest user prompt).
+func ExtractState(sessionID string, msgs []types.TranscriptMessage) SummarizeState {
+	state := SummarizeState{
+		SessionID:  sessionID,
+		TestStatus: TestStatusNone,
+	}
+
+	seen := make(map[string]bool)
+	var lastPrompt string
+	testPending := false
+
+	for _, msg := range msgs {
+		if msg.Type == "user" && msg.Content != "" && !hasToolResult(msg) {
+			lastPrompt = msg.Content
+		}
+		for _, tool := range msg.Tools {
+			switch tool.Name {
+			case "Edit", "Write", "MultiEdit", "NotebookEdit":
+				path := inputString(tool.Input, "file_path")
+				if path == "" {
+					path = inputString(tool.Input, "notebook_path")
+				}
+				if path != "" && !seen[path] {
+					seen[path] = true
+					state.FilesChanged = append(state.FilesChanged, path)
+				}
+			case "TodoWrite":
+				state.CurrentTask, state.CompletedTasks = todoProgress(tool.Input)
+			case "Bash":
+				testPending = testCommandPattern.MatchString(inputString(tool.Input, "command"))
+			case "tool_result":
+				if !testPending {
+					continue
+				}
+				testPending = false
+				state.FailingTests = failingTests(tool.Output)
+				if tool.Error != "" || len(state.FailingTests) > 0 || strings.Contains(tool.Output, "FAIL") {
+					state.TestStatus = TestStatusFailing
+				} else {
+					state.TestStatus = TestStatusPassing
+				}
+			}
+		}
+	}
+
+	if state.CurrentTask == "" {
+		state.CurrentTask = truncateSummary(lastPrompt, DefaultSummaryConfig().MaxSummaryLength)
+	}
+	return state
+}
+
+// ItemsFromState turns extracted state plus the conversation itself into
+// classified context items for SummarizeContext.
+func (s *Summarizer) ItemsFromState(state SummarizeState, msgs []types.TranscriptMessage) []ContextItem {
+	var items []ContextItem
+	for _, f := range state.FilesChanged {
+		items = append(items, s.CreateContextItem("file_change", f, map[string]string{"path": f}))
+	}
+	for _, t := range state.FailingTests {
+		items = append(items, s.CreateContextItem("failing_test", t, nil))
+	}
+	for _, f := range state.CriticalFindings {
+		items = append(items, s.CreateContextItem("critical_finding", f, nil))
+	}
+	for _, msg := range msgs {
+		if msg.Content == "" || hasToolResult(msg) {
+			continue
+		}
+		itemType := "exploration"
+		if msg.Type == "user" {
+			itemType = "context"
+		}
+		items = append(items, s.CreateContextItem(itemType, msg.Content, map[string]string{"role": msg.Type}))
+	}
+	return items
+}
+
+// todoProgress reads a TodoWrite input and returns the in-progress task and
+// the completed ones.
+func todoProgress(input map[string]interface{}) (string, []string) {
+	todos, _ := input["todos"].([]interface{})
+	var current string
+	var completed []string
+	for _, t := range todos {
+		todo, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		content := inputString(todo, "content")
+		switch inputString(todo, "status") {
+		case "in_progress":
+			if current == "" {
+				current = content
+			}
+		case "completed":
+			completed = append(completed, content)
+		}
+	}
+	return current, completed
+}
+
+// failingTests returns the failing test names found in runner output.
+func failingTests(output string) []string {
+	var names []string
+	for _, m := range failingTestPattern.FindAllStringSubmatch(output, -1) {
+		for _, name := range m[1:] {
+			if name != "" {
+				names = append(names, strings.TrimSpace(name))
+				break
+			}
+		}
+		if len(names) == maxFailingTests {
+			break
+		}
+	}
+	return names
+}
+
+// hasToolResult reports whether a message carries tool results, which are
+// logged as user messages but are not prompts.
+func hasToolResult(msg types.TranscriptMessage) bool {
+	for _, t := range msg.Tools {
+		if t.Name == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// inputString returns a string field from a tool input map.
+func inputString(input map[string]interface{}, key string) string {
+	v, _ := input[key].(string)
+	return v
+}
+
+// truncateSummary shortens s to at most n bytes on a single line, without
+// splitting a character.
+func truncateSummary(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// LatestState loads the most recently saved resumption state under
+// baseDir. It returns os.ErrNotExist when no state has been saved.
+func LatestState(baseDir string) (*SummarizeState, error) {
+	matches, err := filepath.Glob(filepath.Join(baseDir, ".agents", "ao", "context", "state-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var newest string
+	var newestTime time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = m, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return nil, os.ErrNotExist
+	}
+	sessionID := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(newest), "state-"), ".json")
+	return LoadState(baseDir, sessionID)
+}
@@ -0,0 +1,171 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestTranscriptUsage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	reported := write("reported.jsonl",
+		`{"type":"user","message":{"role":"user","content":"hello"}}`,
+		`{"type":"assistant","message":{"role":"assistant","content":"a","usage":{"input_tokens":10,"cache_read_input_tokens":1000,"cache_creation_input_tokens":200,"output_tokens":50}}}`,
+		`{"type":"assistant","message":{"role":"assistant","content":"b","usage":{"input_tokens":20,"cache_read_input_tokens":1500,"cache_creation_input_tokens":0,"output_tokens":30}}}`,
+		`not json`,
+	)
+	if got, err := TranscriptUsage(reported); err != nil || got != 1550 {
+		t.Errorf("TranscriptUsage(reported) = %d, %v; want 1550", got, err)
+	}
+
+	// No usage blocks: estimate from content after the last compaction
+	estimated := write("estimated.jsonl",
+		`{"type":"user","message":{"role":"user","content":"`+strings.Repeat("x", 400)+`"}}`,
+		`{"type":"user","isCompactSummary":true,"message":{"role":"user","content":"`+strings.Repeat("y", 78)+`"}}`,
+	)
+	if got, err := TranscriptUsage(estimated); err != nil || got != 20 {
+		t.Errorf("TranscriptUsage(estimated) = %d, %v; want 20", got, err)
+	}
+
+	if _, err := TranscriptUsage(filepath.Join(dir, "missing.jsonl")); err == nil {
+		t.Error("expected error for missing transcript")
+	}
+}
+
+func TestExtractState(t *testing.T) {
+	msgs := []types.TranscriptMessage{
+		{Type: "user", Content: "fix the   login bug"},
+		{Type: "assistant", Tools: []types.ToolCall{
+			{Name: "Edit", Input: map[string]interface{}{"file_path": "auth/login.go"}},
+			{Name: "Write", Input: map[string]interface{}{"file_path": "auth/login_test.go"}},
+			{Name: "Edit", Input: map[string]interface{}{"file_path": "auth/login.go"}},
+		}},
+		{Type: "assistant", Tools: []types.ToolCall{
+			{Name: "TodoWrite", Input: map[string]interface{}{"todos": []interface{}{
+				map[string]interface{}{"content": "reproduce", "status": "completed"},
+				map[string]interface{}{"content": "patch token check", "status": "in_progress"},
+				map[string]interface{}{"content": "add regression test", "status": "pending"},
+			}}},
+		}},
+		{Type: "assistant", Tools: []types.ToolCall{
+			{Name: "Bash", Input: map[string]interface{}{"command": "cd auth && go test ./..."}},
+		}},
+		{Type: "user", Tools: []types.ToolCall{
+			{Name: "tool_result", Error: "tool error", Output: "--- FAIL: TestLogin (0.00s)\n--- FAIL: TestLogout (0.01s)\nFAIL"},
+		}},
+		{Type: "assistant", Tools: []types.ToolCall{
+			{Name: "Bash", Input: map[string]interface{}{"command": "git status"}},
+		}},
+		{Type: "user", Tools: []types.ToolCall{{Name: "tool_result", Output: "clean"}}},
+	}
+
+	state := ExtractState("s1", msgs)
+
+	if !reflect.DeepEqual(state.FilesChanged, []string{"auth/login.go", "auth/login_test.go"}) {
+		t.Errorf("FilesChanged = %v", state.FilesChanged)
+	}
+	if state.CurrentTask != "patch token check" {
+		t.Errorf("CurrentTask = %q", state.CurrentTask)
+	}
+	if !reflect.DeepEqual(state.CompletedTasks, []string{"reproduce"}) {
+		t.Errorf("CompletedTasks = %v", state.CompletedTasks)
+	}
+	if state.TestStatus != TestStatusFailing {
+		t.Errorf("TestStatus = %q, want failing", state.TestStatus)
+	}
+	if !reflect.DeepEqual(state.FailingTests, []string{"TestLogin", "TestLogout"}) {
+		t.Errorf("FailingTests = %v", state.FailingTests)
+	}
+
+	// A later passing run clears the failure; without todos the last
+	// prompt is the current task
+	msgs = append(msgs[:1:1], msgs[3:5]...)
+	msgs = append(msgs,
+		types.TranscriptMessage{Type: "assistant", Tools: []types.ToolCall{
+			{Name: "Bash", Input: map[string]interface{}{"command": "go test ./auth"}},
+		}},
+		types.TranscriptMessage{Type: "user", Tools: []types.ToolCall{{Name: "tool_result", Output: "ok  auth 0.2s"}}},
+	)
+	state = ExtractState("s1", msgs)
+	if state.TestStatus != TestStatusPassing || len(state.FailingTests) != 0 {
+		t.Errorf("TestStatus = %q, FailingTests = %v; want passing", state.TestStatus, state.FailingTests)
+	}
+	if state.CurrentTask != "fix the login bug" {
+		t.Errorf("CurrentTask = %q, want last prompt", state.CurrentTask)
+	}
+
+	// A long prompt is cut between characters, not inside one
+	prompt := strings.Repeat("修复登录错误 ", 20)
+	state = ExtractState("s1", []types.TranscriptMessage{{Type: "user", Content: prompt}})
+	task := strings.TrimSuffix(state.CurrentTask, "...")
+	if !utf8.ValidString(state.CurrentTask) || len(task) > DefaultSummaryConfig().MaxSummaryLength || !strings.HasPrefix(prompt, task) {
+		t.Errorf("CurrentTask = %q, want a valid prefix of the prompt", state.CurrentTask)
+	}
+
+	if state := ExtractState("s2", nil); state.TestStatus != TestStatusNone {
+		t.Errorf("empty transcript TestStatus = %q, want none", state.TestStatus)
+	}
+}
+
+func TestItemsFromState(t *testing.T) {
+	s := NewSummarizer(NewBudgetTracker("s1"))
+	state := SummarizeState{FilesChanged: []string{"a.go"}, FailingTests: []string{"TestA"}}
+	msgs := []types.TranscriptMessage{
+		{Type: "user", Content: "do the thing"},
+		{Type: "assistant", Content: "looked around"},
+		{Type: "user", Content: "ignored", Tools: []types.ToolCall{{Name: "tool_result"}}},
+	}
+
+	items := s.ItemsFromState(state, msgs)
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Type)
+	}
+	want := []string{"file_change", "failing_test", "context", "exploration"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("item types = %v, want %v", got, want)
+	}
+	if items[0].Priority != PriorityCritical || items[3].Priority != PriorityLow {
+		t.Errorf("unexpected priorities: %v, %v", items[0].Priority, items[3].Priority)
+	}
+}
+
+func TestLatestState(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LatestState(dir); !os.IsNotExist(err) {
+		t.Fatalf("LatestState(empty) error = %v, want not exist", err)
+	}
+
+	s := NewSummarizer(NewBudgetTracker("x"))
+	for _, id := range []string{"older", "newer"} {
+		if err := s.SaveState(dir, SummarizeState{SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, ".agents", "ao", "context", "state-older.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LatestState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SessionID != "newer" {
+		t.Errorf("LatestState = %q, want newer", state.SessionID)
+	}
+}
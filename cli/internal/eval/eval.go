@@ -0,0 +1,341 @@
+// Package eval measures retrieval quality offline.
+//
+// A Dataset pairs queries with the documents later judged relevant to them
+// and freezes the corpus they were ranked over, so a ranking configuration
+// can be replayed and scored with NDCG@k, MRR@k and recall@k long after the
+// fact. Reports carry the dataset's fingerprint, so a baseline report is
+// only compared with results from the same dataset and cutoff.
+package eval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DatasetVersion is the current dataset file format.
+const DatasetVersion = 1
+
+// Relevance grades. Applied documents count more than ones only retrieved.
+const (
+	GradeRetrieved = 1
+	GradeApplied   = 2
+)
+
+// Document is one file of the frozen corpus.
+type Document struct {
+	// Path is relative to the snapshot root (the .agents directory), in
+	// slash form, e.g. "learnings/mutex.md".
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Content string    `json:"content"`
+}
+
+// Query is one judged query.
+type Query struct {
+	SessionID string `json:"session_id"`
+
+	// Text is what the session set out to do: its goal or first prompt.
+	Text string `json:"text"`
+
+	// InjectQuery is the query the session's injection ran with, if any.
+	InjectQuery string `json:"inject_query,omitempty"`
+
+	// Relevant maps document paths to relevance grades.
+	Relevant map[string]int `json:"relevant"`
+}
+
+// Dataset is a frozen corpus with judged queries.
+type Dataset struct {
+	Version int `json:"version"`
+
+	// SnapshotAt is the time rankings are replayed at, so age-based scores
+	// do not drift as the dataset gets older.
+	SnapshotAt time.Time  `json:"snapshot_at"`
+	Documents  []Document `json:"documents"`
+	Queries    []Query    `json:"queries"`
+}
+
+// Fingerprint identifies the dataset's content.
+func (d *Dataset) Fingerprint() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:8]), nil
+}
+
+// Materialize writes the documents under root with their recorded
+// modification times.
+func (d *Dataset) Materialize(root string) error {
+	for _, doc := range d.Documents {
+		rel := filepath.FromSlash(doc.Path)
+		if !filepath.IsLocal(rel) {
+			return fmt.Errorf("document path %q escapes the snapshot", doc.Path)
+		}
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(doc.Content), 0600); err != nil {
+			return err
+		}
+		if err := os.Chtimes(path, doc.ModTime, doc.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadDataset reads a dataset file.
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d Dataset
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("parse dataset: %w", err)
+	}
+	if d.Version != DatasetVersion {
+		return nil, fmt.Errorf("unsupported dataset version %d (want %d)", d.Version, DatasetVersion)
+	}
+	return &d, nil
+}
+
+// SaveJSON writes v as indented JSON, creating parent directories.
+func SaveJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// NDCG returns the normalised discounted cumulative gain of the top k of
+// ranked, with gain 2^grade - 1.
+func NDCG(ranked []string, relevant map[string]int, k int) float64 {
+	var dcg float64
+	for i, path := range top(ranked, k) {
+		dcg += gain(relevant[path]) / math.Log2(float64(i+2))
+	}
+
+	grades := make([]int, 0, len(relevant))
+	for _, g := range relevant {
+		grades = append(grades, g)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+	var ideal float64
+	for i, g := range grades {
+		if i >= k {
+			break
+		}
+		ideal += gain(g) / math.Log2(float64(i+2))
+	}
+	if ideal == 0 {
+		return 0
+	}
+	return dcg / ideal
+}
+
+// ReciprocalRank returns 1/rank of the first relevant document in the top
+// k, or 0 if there is none.
+func ReciprocalRank(ranked []string, relevant map[string]int, k int) float64 {
+	for i, path := range top(ranked, k) {
+		if relevant[path] > 0 {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// Recall returns the share of relevant documents in the top k.
+func Recall(ranked []string, relevant map[string]int, k int) float64 {
+	total := 0
+	for _, g := range relevant {
+		if g > 0 {
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	found := 0
+	for _, path := range top(ranked, k) {
+		if relevant[path] > 0 {
+			found++
+		}
+	}
+	return float64(found) / float64(total)
+}
+
+func top(ranked []string, k int) []string {
+	if k > 0 && len(ranked) > k {
+		return ranked[:k]
+	}
+	return ranked
+}
+
+func gain(grade int) float64 {
+	if grade <= 0 {
+		return 0
+	}
+	return math.Exp2(float64(grade)) - 1
+}
+
+// Ranker returns document paths for a query, best first, in the dataset's
+// relative slash form.
+type Ranker func(q Query) ([]string, error)
+
+// Result is one configuration's mean scores over the dataset's queries.
+type Result struct {
+	Config  string  `json:"config"`
+	Queries int     `json:"queries"`
+	NDCG    float64 `json:"ndcg"`
+	MRR     float64 `json:"mrr"`
+	Recall  float64 `json:"recall"`
+}
+
+// Evaluate ranks every query with rank and averages the metrics at k.
+func Evaluate(config string, queries []Query, k int, rank Ranker) (Result, error) {
+	r := Result{Config: config, Queries: len(queries)}
+	if len(queries) == 0 {
+		return r, nil
+	}
+	for _, q := range queries {
+		ranked, err := rank(q)
+		if err != nil {
+			return r, fmt.Errorf("%s: query for %s: %w", config, q.SessionID, err)
+		}
+		r.NDCG += NDCG(ranked, q.Relevant, k)
+		r.MRR += ReciprocalRank(ranked, q.Relevant, k)
+		r.Recall += Recall(ranked, q.Relevant, k)
+	}
+	n := float64(len(queries))
+	r.NDCG, r.MRR, r.Recall = r.NDCG/n, r.MRR/n, r.Recall/n
+	return r, nil
+}
+
+// Report is the outcome of evaluating configurations on one dataset.
+type Report struct {
+	Dataset     string    `json:"dataset"`
+	K           int       `json:"k"`
+	Queries     int       `json:"queries"`
+	Documents   int       `json:"documents"`
+	Results     []Result  `json:"results"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+}
+
+// LoadReport reads a report, e.g. a stored baseline.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	return &r, nil
+}
+
+// Regression is a metric that fell below its baseline.
+type Regression struct {
+	Config   string  `json:"config"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %.4f -> %.4f", r.Config, r.Metric, r.Baseline, r.Current)
+}
+
+// ErrIncomparable is returned when reports come from different datasets or
+// cutoffs.
+var ErrIncomparable = errors.New("reports are not comparable")
+
+// Compare returns the metrics in current that fell more than tolerance
+// below baseline, for configurations present in both.
+func Compare(baseline, current *Report, tolerance float64) ([]Regression, error) {
+	if baseline.Dataset != current.Dataset || baseline.K != current.K {
+		return nil, fmt.Errorf("%w: baseline is %s@%d, current is %s@%d",
+			ErrIncomparable, baseline.Dataset, baseline.K, current.Dataset, current.K)
+	}
+
+	byConfig := make(map[string]Result, len(current.Results))
+	for _, r := range current.Results {
+		byConfig[r.Config] = r
+	}
+
+	var regressions []Regression
+	for _, b := range baseline.Results {
+		c, ok := byConfig[b.Config]
+		if !ok {
+			continue
+		}
+		for _, m := range []struct {
+			name          string
+			before, after float64
+		}{
+			{"ndcg", b.NDCG, c.NDCG},
+			{"mrr", b.MRR, c.MRR},
+			{"recall", b.Recall, c.Recall},
+		} {
+			if m.after < m.before-tolerance {
+				regressions = append(regressions, Regression{Config: b.Config, Metric: m.name, Baseline: m.before, Current: m.after})
+			}
+		}
+	}
+	return regressions, nil
+}
+
+// MissingConfigs lists baseline configurations absent from current.
+func MissingConfigs(baseline, current *Report) []string {
+	have := make(map[string]bool, len(current.Results))
+	for _, r := range current.Results {
+		have[r.Config] = true
+	}
+	var missing []string
+	for _, r := range baseline.Results {
+		if !have[r.Config] {
+			missing = append(missing, r.Config)
+		}
+	}
+	return missing
+}
+
+// RelPath converts a path under root to the dataset's relative slash form.
+func RelPath(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// SplitConfig splits "name:key=value:key=value" into a name and settings.
+func SplitConfig(config string) (string, map[string]string, error) {
+	parts := strings.Split(config, ":")
+	settings := make(map[string]string)
+	for _, kv := range parts[1:] {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return "", nil, fmt.Errorf("config %q: setting %q is not key=value", config, kv)
+		}
+		settings[k] = v
+	}
+	return parts[0], settings, nil
+}
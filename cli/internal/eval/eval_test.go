@@ -0,0 +1,144 @@
+package eval
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMetrics(t *testing.T) {
+	relevant := map[string]int{"a": GradeApplied, "b": GradeRetrieved}
+
+	tests := []struct {
+		name              string
+		ranked            []string
+		k                 int
+		ndcg, mrr, recall float64
+	}{
+		{"ideal", []string{"a", "b", "c"}, 10, 1, 1, 1},
+		{"swapped", []string{"b", "a"}, 10, (1 + 3/math.Log2(3)) / (3 + 1/math.Log2(3)), 1, 1},
+		{"late", []string{"x", "y", "a"}, 10, (3 / 2.0) / (3 + 1/math.Log2(3)), 1.0 / 3, 0.5},
+		{"cut off", []string{"x", "y", "a"}, 2, 0, 0, 0},
+		{"empty", nil, 10, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NDCG(tt.ranked, relevant, tt.k); !near(got, tt.ndcg) {
+				t.Errorf("NDCG = %.4f, want %.4f", got, tt.ndcg)
+			}
+			if got := ReciprocalRank(tt.ranked, relevant, tt.k); !near(got, tt.mrr) {
+				t.Errorf("ReciprocalRank = %.4f, want %.4f", got, tt.mrr)
+			}
+			if got := Recall(tt.ranked, relevant, tt.k); !near(got, tt.recall) {
+				t.Errorf("Recall = %.4f, want %.4f", got, tt.recall)
+			}
+		})
+	}
+
+	if got := NDCG([]string{"a"}, nil, 10); got != 0 {
+		t.Errorf("NDCG with no judgements = %g, want 0", got)
+	}
+}
+
+func TestEvaluateAverages(t *testing.T) {
+	queries := []Query{
+		{SessionID: "s1", Relevant: map[string]int{"a": 1}},
+		{SessionID: "s2", Relevant: map[string]int{"b": 1}},
+	}
+	rank := func(Query) ([]string, error) { return []string{"a", "b"}, nil }
+	r, err := Evaluate("fixed", queries, 10, rank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Queries != 2 || !near(r.MRR, 0.75) || !near(r.Recall, 1) {
+		t.Errorf("Evaluate() = %+v, want MRR 0.75 and recall 1", r)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Dataset: "sha256:1", K: 10, Results: []Result{
+		{Config: "inject", NDCG: 0.5, MRR: 0.5, Recall: 0.5},
+		{Config: "keyword", NDCG: 0.4, MRR: 0.4, Recall: 0.4},
+	}}
+	current := &Report{Dataset: "sha256:1", K: 10, Results: []Result{
+		{Config: "inject", NDCG: 0.495, MRR: 0.3, Recall: 0.6},
+		{Config: "vector", NDCG: 0.1, MRR: 0.1, Recall: 0.1},
+	}}
+
+	regressions, err := Compare(baseline, current, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regressions) != 1 || regressions[0].Config != "inject" || regressions[0].Metric != "mrr" {
+		t.Errorf("Compare() = %v, want only inject mrr", regressions)
+	}
+	if missing := MissingConfigs(baseline, current); len(missing) != 1 || missing[0] != "keyword" {
+		t.Errorf("MissingConfigs() = %v, want [keyword]", missing)
+	}
+
+	current.K = 5
+	if _, err := Compare(baseline, current, 0.01); !errors.Is(err, ErrIncomparable) {
+		t.Errorf("Compare across cutoffs: err = %v, want ErrIncomparable", err)
+	}
+}
+
+func TestDatasetRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := &Dataset{
+		Version:    DatasetVersion,
+		SnapshotAt: mtime.Add(time.Hour),
+		Documents:  []Document{{Path: "learnings/a.md", ModTime: mtime, Content: "# A\n"}},
+		Queries:    []Query{{SessionID: "s1", Text: "a", Relevant: map[string]int{"learnings/a.md": 1}}},
+	}
+
+	path := filepath.Join(dir, "eval", "dataset.json")
+	if err := SaveJSON(path, d); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := d.Fingerprint()
+	if got, _ := loaded.Fingerprint(); got != want {
+		t.Errorf("fingerprint changed across save and load: %s vs %s", got, want)
+	}
+
+	root := filepath.Join(dir, "snapshot")
+	if err := loaded.Materialize(root); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(root, "learnings", "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("materialised mtime %v, want %v", info.ModTime(), mtime)
+	}
+
+	d.Documents[0].Path = "../escape.md"
+	if err := d.Materialize(root); err == nil {
+		t.Error("Materialize accepted a path outside the snapshot")
+	}
+}
+
+func TestSplitConfig(t *testing.T) {
+	name, settings, err := SplitConfig("inject:lambda=0.3")
+	if err != nil || name != "inject" || settings["lambda"] != "0.3" {
+		t.Errorf("SplitConfig() = %q, %v, %v", name, settings, err)
+	}
+	if name, settings, err := SplitConfig("keyword"); err != nil || name != "keyword" || len(settings) != 0 {
+		t.Errorf("SplitConfig(keyword) = %q, %v, %v", name, settings, err)
+	}
+	if _, _, err := SplitConfig("inject:lambda"); err == nil {
+		t.Error("SplitConfig accepted a setting without a value")
+	}
+}
@@ -0,0 +1,162 @@
+// Package outcome scores a session from the tool calls in its transcript.
+//
+// Calls pairs each tool call with its result, so a signal is decided by
+// what a command actually returned (its exit code, its test report) rather
+// than by text that happens to appear somewhere in the log. Rules loaded
+// from .agents/ao/reward.yaml name the signals and their weights, and every
+// signal keeps the calls and output lines that decided it.
+package outcome
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// NoExitCode is the exit code of a call whose result is not in the
+// transcript.
+const NoExitCode = -1
+
+// Call is a tool call together with its result.
+type Call struct {
+	// Index is the call's position in the session, from 1.
+	Index int
+
+	// ID is the transcript's call ID, if it records one.
+	ID string
+
+	// Message is the index of the message holding the call.
+	Message int
+
+	// Line is the transcript line of the call.
+	Line int
+
+	// ResultMessage is the index of the message holding the result, or -1.
+	ResultMessage int
+
+	// ResultLine is the transcript line of the result, or 0.
+	ResultLine int
+
+	// Tool is the tool name, e.g. "Bash".
+	Tool string
+
+	// Input holds the call's parameters.
+	Input map[string]interface{}
+
+	// Command is the shell command of a Bash call.
+	Command string
+
+	// Output is the result text.
+	Output string
+
+	// Error is the result's error, e.g. "exit code 1".
+	Error string
+
+	// ExitCode is the command's exit status: parsed from the result, 1 for
+	// other errors, 0 for results without one, and NoExitCode without a
+	// result.
+	ExitCode int
+}
+
+// HasResult reports whether the call's result is in the transcript.
+func (c Call) HasResult() bool {
+	return c.ExitCode != NoExitCode
+}
+
+// Succeeded reports whether the call returned without an error.
+func (c Call) Succeeded() bool {
+	return c.ExitCode == 0
+}
+
+// exitCodePattern finds the exit status in a result's error, or at the start
+// of a failed Bash result's output ("Exit code 1").
+var exitCodePattern = regexp.MustCompile(`(?i)^\s*exit (?:code|status):?\s*(\d+)`)
+
+// Calls pairs the tool calls in messages with their results. Results are
+// matched by call ID where the transcript records one, and otherwise in
+// order to the calls of the latest message still waiting for results.
+func Calls(messages []types.TranscriptMessage) []Call {
+	var calls []Call
+	var pending []int // calls without a result, oldest first
+
+	resolve := func(i int, msgIndex, line int, result types.ToolCall) {
+		c := &calls[i]
+		c.ResultMessage = msgIndex
+		c.ResultLine = line
+		c.Output = result.Output
+		c.Error = result.Error
+		c.ExitCode = exitCode(result)
+	}
+
+	for mi, msg := range messages {
+		for _, tool := range msg.Tools {
+			if tool.Name != "tool_result" {
+				command, _ := tool.Input["command"].(string)
+				calls = append(calls, Call{
+					Index:         len(calls) + 1,
+					ID:            tool.ID,
+					Message:       mi,
+					Line:          msg.MessageIndex,
+					ResultMessage: -1,
+					Tool:          tool.Name,
+					Input:         tool.Input,
+					Command:       command,
+					ExitCode:      NoExitCode,
+				})
+				pending = append(pending, len(calls)-1)
+				continue
+			}
+
+			// One call ID can cover several calls, e.g. a patch
+			// touching several files
+			matched := false
+			if tool.ID != "" {
+				kept := pending[:0]
+				for _, i := range pending {
+					if calls[i].ID == tool.ID {
+						resolve(i, mi, msg.MessageIndex, tool)
+						matched = true
+						continue
+					}
+					kept = append(kept, i)
+				}
+				pending = kept
+			}
+			if !matched && len(pending) > 0 {
+				j := oldestInLastMessage(calls, pending)
+				resolve(pending[j], mi, msg.MessageIndex, tool)
+				pending = append(pending[:j], pending[j+1:]...)
+			}
+		}
+	}
+	return calls
+}
+
+// oldestInLastMessage picks the pending call a result without an ID
+// answers: the first of the latest message's unanswered calls, since calls
+// that never got a result are left behind by later ones.
+func oldestInLastMessage(calls []Call, pending []int) int {
+	last := calls[pending[len(pending)-1]].Message
+	for j, i := range pending {
+		if calls[i].Message == last {
+			return j
+		}
+	}
+	return 0
+}
+
+// exitCode derives a result's exit status.
+func exitCode(result types.ToolCall) int {
+	for _, text := range []string{result.Error, result.Output} {
+		if m := exitCodePattern.FindStringSubmatch(text); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return n
+			}
+		}
+	}
+	if result.Error != "" {
+		return 1
+	}
+	return 0
+}
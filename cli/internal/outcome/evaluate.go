@@ -0,0 +1,149 @@
+package outcome
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxEvidence caps the calls kept for one signal.
+const maxEvidence = 5
+
+// Evidence is a call that decided a signal.
+type Evidence struct {
+	// Call is the call's position in the session, from 1.
+	Call int `json:"call"`
+
+	// Line is the transcript line of the call.
+	Line int `json:"line"`
+
+	// ResultLine is the transcript line of its result.
+	ResultLine int `json:"result_line,omitempty"`
+
+	Tool    string `json:"tool"`
+	Command string `json:"command,omitempty"`
+
+	// ExitCode is the call's exit status, or NoExitCode without a result.
+	ExitCode int `json:"exit_code"`
+
+	// OutputLine is the line of the output, from 1, that Match is from.
+	OutputLine int `json:"output_line,omitempty"`
+
+	// Match is the output line that matched, or the test report's first
+	// failure or summary.
+	Match string `json:"match,omitempty"`
+}
+
+func newEvidence(c Call) Evidence {
+	return Evidence{
+		Call:       c.Index,
+		Line:       c.Line,
+		ResultLine: c.ResultLine,
+		Tool:       c.Tool,
+		Command:    c.Command,
+		ExitCode:   c.ExitCode,
+	}
+}
+
+// SignalResult is one scored signal.
+type SignalResult struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Value       bool    `json:"value"`
+	Weight      float64 `json:"weight"`
+
+	// Evidence are the calls that decided the signal: those that matched,
+	// the last call for "last", or the first and last for "increase" and
+	// "decrease". A "none" signal that fired has none.
+	Evidence []Evidence `json:"evidence,omitempty"`
+}
+
+// Result is a session's scored outcome.
+type Result struct {
+	// Reward is the sum of the weights of the signals that fired, clamped
+	// to [0, 1].
+	Reward  float64        `json:"reward"`
+	Signals []SignalResult `json:"signals"`
+}
+
+// Evaluate scores a session's calls.
+func (r *Rules) Evaluate(calls []Call) Result {
+	res := Result{Signals: make([]SignalResult, 0, len(r.Signals))}
+	for i := range r.Signals {
+		s := r.Signals[i].evaluate(calls)
+		if s.Value {
+			res.Reward += s.Weight
+		}
+		res.Signals = append(res.Signals, s)
+	}
+	res.Reward = min(max(res.Reward, 0), 1)
+	return res
+}
+
+// evaluate scores one rule.
+func (s *Rule) evaluate(calls []Call) SignalResult {
+	res := SignalResult{Name: s.Name, Description: s.Description, Weight: s.Weight}
+
+	switch s.When {
+	case WhenLast:
+		for i := len(calls) - 1; i >= 0; i-- {
+			if s.selects(calls[i]) {
+				e, ok := s.meets(calls[i])
+				res.Value = ok
+				res.Evidence = []Evidence{e}
+				break
+			}
+		}
+
+	case WhenIncrease, WhenDecrease:
+		var first, last Evidence
+		var from, to float64
+		n := 0
+		for _, c := range calls {
+			if !s.selects(c) {
+				continue
+			}
+			e, ok := s.meets(c)
+			if !ok {
+				continue
+			}
+			v, ok := s.capture(c)
+			if !ok {
+				continue
+			}
+			if n == 0 {
+				first, from = e, v
+			}
+			last, to = e, v
+			n++
+		}
+		if n > 1 {
+			res.Evidence = []Evidence{first, last}
+			res.Value = to > from && s.When == WhenIncrease || to < from && s.When == WhenDecrease
+		}
+
+	default:
+		for _, c := range calls {
+			if !s.selects(c) {
+				continue
+			}
+			if e, ok := s.meets(c); ok && len(res.Evidence) < maxEvidence {
+				res.Evidence = append(res.Evidence, e)
+			}
+		}
+		res.Value = len(res.Evidence) > 0
+		if s.When == WhenNone {
+			res.Value = !res.Value
+		}
+	}
+	return res
+}
+
+// capture returns the number the output pattern's first group captures.
+func (s *Rule) capture(c Call) (float64, bool) {
+	m := s.output.FindStringSubmatch(c.Output)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(m[1], "%"), 64)
+	return v, err == nil
+}
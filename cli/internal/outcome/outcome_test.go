@@ -0,0 +1,223 @@
+package outcome
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// bash returns a message with a Bash call and, unless output is empty, a
+// message with its result.
+func bash(line int, id, command, output, errText string) []types.TranscriptMessage {
+	msgs := []types.TranscriptMessage{{MessageIndex: line, Tools: []types.ToolCall{
+		{Name: "Bash", ID: id, Input: map[string]interface{}{"command": command}},
+	}}}
+	if output != "" || errText != "" {
+		msgs = append(msgs, types.TranscriptMessage{MessageIndex: line + 1, Tools: []types.ToolCall{
+			{Name: "tool_result", ID: id, Output: output, Error: errText},
+		}})
+	}
+	return msgs
+}
+
+func TestCalls(t *testing.T) {
+	var messages []types.TranscriptMessage
+	// Two calls in one message answered out of order by ID
+	messages = append(messages, types.TranscriptMessage{MessageIndex: 1, Tools: []types.ToolCall{
+		{Name: "Bash", ID: "a", Input: map[string]interface{}{"command": "make build"}},
+		{Name: "Read", ID: "b", Input: map[string]interface{}{"file_path": "x.go"}},
+	}})
+	messages = append(messages, types.TranscriptMessage{MessageIndex: 2, Tools: []types.ToolCall{
+		{Name: "tool_result", ID: "b", Output: "package x"},
+		{Name: "tool_result", ID: "a", Output: "Exit code 2\nmake: *** [build] Error 2", Error: "tool error"},
+	}})
+	// A call that never got a result, then one answered without an ID
+	messages = append(messages, bash(3, "", "sleep 100", "", "")...)
+	messages = append(messages, bash(5, "", "git status", "clean", "")...)
+	messages = append(messages, bash(7, "", "false", "", "exit code 1")...)
+
+	calls := Calls(messages)
+	if len(calls) != 5 {
+		t.Fatalf("got %d calls, want 5", len(calls))
+	}
+	tests := []struct {
+		command    string
+		resultLine int
+		exitCode   int
+	}{
+		{"make build", 2, 2},
+		{"", 2, 0},
+		{"sleep 100", 0, NoExitCode},
+		{"git status", 6, 0},
+		{"false", 8, 1},
+	}
+	for i, tt := range tests {
+		c := calls[i]
+		if c.Index != i+1 || c.Command != tt.command || c.ResultLine != tt.resultLine || c.ExitCode != tt.exitCode {
+			t.Errorf("call %d = %+v, want command %q result line %d exit %d", i+1, c, tt.command, tt.resultLine, tt.exitCode)
+		}
+	}
+	if calls[2].HasResult() {
+		t.Error("call without a result reports one")
+	}
+}
+
+func TestParseTestOutput(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		ok             bool
+		passed, failed int
+		line           int
+	}{
+		{"go plain", "ok  \texample.com/a\t0.1s\nok  \texample.com/b\t0.2s", true, 2, 0, 1},
+		{"go verbose", "=== RUN   TestA\n--- PASS: TestA (0.00s)\n=== RUN   TestB\n--- FAIL: TestB (0.00s)\nFAIL\nFAIL\texample.com/a\t0.1s", true, 1, 1, 4},
+		{"go build failure", "# example.com/a\n./a.go:3:1: syntax error\nFAIL\texample.com/a [build failed]", true, 0, 1, 3},
+		{"go json", `{"Action":"run","Test":"TestA"}
+{"Action":"output","Test":"TestA","Output":"--- FAIL: TestA\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestA"}
+{"Action":"pass","Package":"example.com/a","Test":"TestB"}
+{"Action":"fail","Package":"example.com/a"}`, true, 1, 1, 3},
+		{"pytest", "..F.\nFAILED tests/test_a.py::test_b - assert 1 == 2\n======= 1 failed, 3 passed in 0.12s =======", true, 3, 1, 3},
+		{"pytest quiet", "....\n4 passed in 0.05s", true, 4, 0, 2},
+		{"cargo", "running 2 tests\ntest result: ok. 2 passed; 0 failed; 0 ignored", true, 2, 0, 2},
+		{"prose", "All tests PASSED ✓ ok\nFAILED to connect", false, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := ParseTestOutput(tt.output)
+			if ok != tt.ok || r.Passed != tt.passed || r.Failed != tt.failed || r.Line != tt.line {
+				t.Errorf("ParseTestOutput() = %+v, %v; want %d passed, %d failed at line %d, %v",
+					r, ok, tt.passed, tt.failed, tt.line, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	r := DefaultRules()
+	positive := 0.0
+	for _, s := range r.Signals {
+		if s.Weight > 0 {
+			positive += s.Weight
+		}
+	}
+	if positive > 1.0+1e-9 {
+		t.Errorf("positive weights sum to %.2f (should be <= 1.0)", positive)
+	}
+
+	// Nothing happened: no errors, but no commit either
+	res := r.Evaluate(nil)
+	if res.Reward != 0 || len(res.Signals) != len(r.Signals) {
+		t.Errorf("Evaluate(nil) = %+v", res)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"empty", "", "empty"},
+		{"version", "version: 2\nsignals: [{name: a, weight: 0.1, tool: Bash}]", "version"},
+		{"unknown field", "version: 1\nsignals: [{name: a, weight: 0.1, tool: Bash, cmd: x}]", "cmd"},
+		{"no selector", "version: 1\nsignals: [{name: a, weight: 0.1, exit: zero}]", "tool, command or output"},
+		{"duplicate", "version: 1\nsignals: [{name: a, weight: 0.1, tool: Bash}, {name: a, weight: 0.1, tool: Read}]", "twice"},
+		{"bad pattern", "version: 1\nsignals: [{name: a, weight: 0.1, command: '('}]", "command"},
+		{"bad when", "version: 1\nsignals: [{name: a, weight: 0.1, tool: Bash, when: always}]", "when"},
+		{"increase without group", "version: 1\nsignals: [{name: a, weight: 0.1, output: 'coverage', when: increase}]", "capturing"},
+		{"weight", "version: 1\nsignals: [{name: a, weight: 2, tool: Bash}]", "weight"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseRules() error = %v, want one mentioning %q", err, tt.err)
+			}
+		})
+	}
+}
+
+const teamRules = `version: 1
+signals:
+  - name: lint_clean
+    weight: 0.3
+    when: last
+    command: 'golangci-lint run'
+    exit: zero
+  - name: coverage_up
+    weight: 0.3
+    when: increase
+    command: '\bgo test\b.*-cover'
+    output: 'coverage: ([\d.]+)% of statements'
+  - name: pr_opened
+    weight: 0.4
+    command: '\bgh pr create\b'
+    exit: zero
+    output: '(https://github.com/\S+/pull/\d+)'
+`
+
+func TestLoadRulesAndEvaluate(t *testing.T) {
+	tmp := t.TempDir()
+	sub := filepath.Join(tmp, "pkg", "a")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a rules file the defaults apply
+	r, err := LoadRules(sub)
+	if err != nil || r.Source != "" || len(r.Signals) != len(DefaultRules().Signals) {
+		t.Fatalf("LoadRules() without a file = %+v, %v", r, err)
+	}
+
+	path := filepath.Join(tmp, RulesFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(teamRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r, err = LoadRules(sub)
+	if err != nil || r.Source != path {
+		t.Fatalf("LoadRules() = %+v, %v", r, err)
+	}
+
+	var messages []types.TranscriptMessage
+	messages = append(messages, bash(1, "1", "golangci-lint run", "Exit code 1\na.go:3: unused", "tool error")...)
+	messages = append(messages, bash(3, "2", "go test -cover ./...", "ok  \texample.com/a\t0.1s\tcoverage: 61.5% of statements", "")...)
+	messages = append(messages, bash(5, "3", "golangci-lint run", "0 issues.", "")...)
+	messages = append(messages, bash(7, "4", "go test -cover ./...", "ok  \texample.com/a\t0.1s\tcoverage: 64.0% of statements", "")...)
+	messages = append(messages, bash(9, "5", "gh pr create --fill", "Creating pull request\nhttps://github.com/org/repo/pull/42", "")...)
+
+	res := r.Evaluate(Calls(messages))
+	if res.Reward != 1 {
+		t.Errorf("reward %.2f, want 1", res.Reward)
+	}
+	byName := make(map[string]SignalResult)
+	for _, s := range res.Signals {
+		byName[s.Name] = s
+		if !s.Value {
+			t.Errorf("%s did not fire: %+v", s.Name, s)
+		}
+	}
+	if e := byName["lint_clean"].Evidence; len(e) != 1 || e[0].Call != 3 || e[0].Line != 5 {
+		t.Errorf("lint_clean evidence = %+v, want the last lint run", e)
+	}
+	if e := byName["coverage_up"].Evidence; len(e) != 2 || e[0].Call != 2 || e[1].Call != 4 {
+		t.Errorf("coverage_up evidence = %+v, want the first and last runs", e)
+	}
+	if e := byName["pr_opened"].Evidence; len(e) != 1 || e[0].OutputLine != 2 || e[0].Match != "https://github.com/org/repo/pull/42" {
+		t.Errorf("pr_opened evidence = %+v, want the PR URL line", e)
+	}
+
+	// A failing last lint run loses the signal
+	messages = append(messages, bash(11, "6", "golangci-lint run", "Exit code 1\nb.go:1: unused", "tool error")...)
+	res = r.Evaluate(Calls(messages))
+	if s := res.Signals[0]; s.Value || len(s.Evidence) != 1 || s.Evidence[0].ExitCode != 1 {
+		t.Errorf("lint_clean after a failing run = %+v", s)
+	}
+}
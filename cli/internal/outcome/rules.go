@@ -0,0 +1,341 @@
+package outcome
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RulesFile is the reward rules file, relative to the repo root.
+	RulesFile = ".agents/ao/reward.yaml"
+
+	// RulesVersion is the rules file format version.
+	RulesVersion = 1
+)
+
+// When a signal fires.
+const (
+	// WhenAny fires when any call matches.
+	WhenAny = "any"
+
+	// WhenLast fires when the last call the tool and command select also
+	// meets the rule's other conditions, e.g. the final test run passed.
+	WhenLast = "last"
+
+	// WhenNone fires when no call matches.
+	WhenNone = "none"
+
+	// WhenIncrease fires when the number the output pattern captures is
+	// higher in the last matching call than in the first, e.g. coverage.
+	WhenIncrease = "increase"
+
+	// WhenDecrease fires when that number went down, e.g. lint warnings.
+	WhenDecrease = "decrease"
+)
+
+// Rules define the signals a session's reward is made of. Without a rules
+// file DefaultRules is used.
+type Rules struct {
+	// Version is the rules format version.
+	Version int `yaml:"version" json:"version"`
+
+	// Signals are scored in order.
+	Signals []Rule `yaml:"signals" json:"signals"`
+
+	// Source is the file the rules were loaded from, or empty for the
+	// built-in defaults.
+	Source string `yaml:"-" json:"source,omitempty"`
+}
+
+// Rule defines one signal. A call matches when it meets every condition
+// the rule sets.
+type Rule struct {
+	// Name identifies the signal, e.g. "tests_pass".
+	Name string `yaml:"name" json:"name"`
+
+	// Description is shown in the outcome report.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Weight is added to the reward when the signal fires; penalties are
+	// negative.
+	Weight float64 `yaml:"weight" json:"weight"`
+
+	// When is any (the default), last, none, increase or decrease.
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
+
+	// Tool is the tool name, e.g. "Bash", matched case-insensitively.
+	Tool string `yaml:"tool,omitempty" json:"tool,omitempty"`
+
+	// Command is a pattern for the call's shell command.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Output is a pattern for the call's result. Increase and decrease
+	// compare the number its first group captures.
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+
+	// Exit is "zero" or "nonzero".
+	Exit string `yaml:"exit,omitempty" json:"exit,omitempty"`
+
+	// Tests is "pass" or "fail", read from the test report in the output
+	// or, when there is none, from the exit code.
+	Tests string `yaml:"tests,omitempty" json:"tests,omitempty"`
+
+	command, output *regexp.Regexp
+}
+
+// defaultRulesYAML is the built-in rules file.
+const defaultRulesYAML = `version: 1
+signals:
+  - name: tests_pass
+    description: the last test run passed
+    weight: 0.30
+    when: last
+    command: &tests '\b(go test|pytest|py\.test|cargo test|npm (run )?test|yarn test|pnpm test|make test)\b'
+    tests: pass
+  - name: git_push
+    description: pushed to a remote
+    weight: 0.20
+    command: '\bgit push\b'
+    exit: zero
+  - name: git_commit
+    description: committed changes
+    weight: 0.15
+    command: '\bgit commit\b'
+    exit: zero
+  - name: beads_closed
+    description: closed a beads issue
+    weight: 0.15
+    command: '\bbd close\b'
+    exit: zero
+  - name: ratchet_lock
+    description: recorded a ratchet step
+    weight: 0.10
+    command: '\b(ao|ol) ratchet record\b'
+    exit: zero
+  - name: no_errors
+    description: no exception or panic in any output
+    weight: 0.10
+    when: none
+    output: &exceptions '(?m)(Traceback \(most recent call last\)|^panic: |goroutine \d+ \[running\])'
+  - name: test_failure
+    description: the last test run failed
+    weight: -0.20
+    when: last
+    command: *tests
+    tests: fail
+  - name: exceptions
+    description: an exception or panic in an output
+    weight: -0.15
+    output: *exceptions
+  - name: no_commit
+    description: the session committed nothing
+    weight: -0.10
+    when: none
+    command: '\bgit commit\b'
+    exit: zero
+`
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() *Rules {
+	r, err := ParseRules([]byte(defaultRulesYAML))
+	if err != nil {
+		panic(fmt.Sprintf("outcome: invalid default rules: %v", err))
+	}
+	return r
+}
+
+// LoadRules loads the rules file from the nearest .agents directory,
+// falling back to DefaultRules when there is none.
+func LoadRules(startDir string) (*Rules, error) {
+	dir := startDir
+	for {
+		agentsDir := filepath.Join(dir, ".agents")
+		if info, err := os.Stat(agentsDir); err == nil && info.IsDir() {
+			path := filepath.Join(agentsDir, "ao", filepath.Base(RulesFile))
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				return DefaultRules(), nil
+			}
+			return LoadRulesFile(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return DefaultRules(), nil
+		}
+		dir = parent
+	}
+}
+
+// LoadRulesFile loads a rules file.
+func LoadRulesFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read reward rules: %w", err)
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.Source = path
+	return r, nil
+}
+
+// ParseRules parses and validates a YAML rules file.
+func ParseRules(data []byte) (*Rules, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var r Rules
+	if err := dec.Decode(&r); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reward rules file is empty")
+		}
+		return nil, fmt.Errorf("parse reward rules: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Validate checks the rules and compiles their patterns.
+func (r *Rules) Validate() error {
+	if r.Version != RulesVersion {
+		return fmt.Errorf("unsupported reward rules version %d (want %d)", r.Version, RulesVersion)
+	}
+	if len(r.Signals) == 0 {
+		return fmt.Errorf("reward rules have no signals")
+	}
+	names := make(map[string]bool)
+	for i := range r.Signals {
+		s := &r.Signals[i]
+		if s.Name == "" {
+			return fmt.Errorf("signal %d has no name", i+1)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("signal %q is defined twice", s.Name)
+		}
+		names[s.Name] = true
+		if err := s.compile(); err != nil {
+			return fmt.Errorf("signal %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// compile checks a rule and compiles its patterns.
+func (s *Rule) compile() error {
+	if s.Weight < -1 || s.Weight > 1 {
+		return fmt.Errorf("weight %.2f is outside [-1, 1]", s.Weight)
+	}
+	if s.Tool == "" && s.Command == "" && s.Output == "" {
+		return fmt.Errorf("needs a tool, command or output to match")
+	}
+	switch s.Exit {
+	case "", "zero", "nonzero":
+	default:
+		return fmt.Errorf("exit must be zero or nonzero, not %q", s.Exit)
+	}
+	switch s.Tests {
+	case "", "pass", "fail":
+	default:
+		return fmt.Errorf("tests must be pass or fail, not %q", s.Tests)
+	}
+
+	var err error
+	if s.Command != "" {
+		if s.command, err = regexp.Compile(s.Command); err != nil {
+			return fmt.Errorf("command: %w", err)
+		}
+	}
+	if s.Output != "" {
+		if s.output, err = regexp.Compile(s.Output); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+	}
+
+	switch s.When {
+	case "", WhenAny, WhenLast, WhenNone:
+	case WhenIncrease, WhenDecrease:
+		if s.output == nil || s.output.NumSubexp() == 0 {
+			return fmt.Errorf("%s needs an output pattern with a group capturing the number", s.When)
+		}
+	default:
+		return fmt.Errorf("when must be any, last, none, increase or decrease, not %q", s.When)
+	}
+	return nil
+}
+
+// selects reports whether a call is one the rule is about: the tool and
+// command match.
+func (s *Rule) selects(c Call) bool {
+	if s.Tool != "" && !strings.EqualFold(s.Tool, c.Tool) {
+		return false
+	}
+	return s.command == nil || s.command.MatchString(c.Command)
+}
+
+// meets checks the rule's conditions on a selected call's result, and
+// returns the evidence for a call that meets them.
+func (s *Rule) meets(c Call) (Evidence, bool) {
+	e := newEvidence(c)
+	switch s.Exit {
+	case "zero":
+		if !c.HasResult() || !c.Succeeded() {
+			return e, false
+		}
+	case "nonzero":
+		if !c.HasResult() || c.Succeeded() {
+			return e, false
+		}
+	}
+
+	if s.Tests != "" {
+		if !c.HasResult() {
+			return e, false
+		}
+		report, ok := ParseTestOutput(c.Output)
+		failed := report.Failed > 0
+		if !ok {
+			failed = !c.Succeeded()
+		} else {
+			e.OutputLine, e.Match = report.Line, report.Text
+		}
+		if failed != (s.Tests == "fail") {
+			return e, false
+		}
+	}
+
+	if s.output != nil {
+		for _, text := range []string{c.Output, c.Error} {
+			if loc := s.output.FindStringIndex(text); loc != nil {
+				e.OutputLine, e.Match = lineAt(text, loc[0])
+				if text == c.Error {
+					e.OutputLine = 0
+				}
+				return e, true
+			}
+		}
+		return e, false
+	}
+	return e, true
+}
+
+// lineAt returns the line, from 1, holding offset in text, and that line.
+func lineAt(text string, offset int) (int, string) {
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	end := strings.IndexByte(text[offset:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += offset
+	}
+	return strings.Count(text[:offset], "\n") + 1, strings.TrimSpace(text[start:end])
+}
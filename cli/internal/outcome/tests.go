@@ -0,0 +1,122 @@
+package outcome
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TestReport is what a test runner's output says about the run.
+type TestReport struct {
+	Passed int
+	Failed int
+
+	// Line is the output line, from 1, of the first failure, or of the
+	// summary when nothing failed.
+	Line int
+
+	// Text is that line.
+	Text string
+}
+
+var testPatterns = struct {
+	goFail, goPass, goPkgFail, goPkgOK *regexp.Regexp
+	pytestSummary, pytestCount         *regexp.Regexp
+	cargoSummary                       *regexp.Regexp
+}{
+	goFail:        regexp.MustCompile(`^\s*--- FAIL: `),
+	goPass:        regexp.MustCompile(`^\s*--- PASS: `),
+	goPkgFail:     regexp.MustCompile(`^FAIL\s+\S+`),
+	goPkgOK:       regexp.MustCompile(`^ok\s+\S+\s`),
+	pytestSummary: regexp.MustCompile(`^=*\s*\d+ (passed|failed)\b.* in [\d.]+s\b`),
+	pytestCount:   regexp.MustCompile(`(\d+) (passed|failed|errors?)\b`),
+	cargoSummary:  regexp.MustCompile(`^test result: \w+\. (\d+) passed; (\d+) failed`),
+}
+
+// goTestEvent is one line of `go test -json` output.
+type goTestEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+}
+
+// ParseTestOutput reads the results of `go test` (plain or -json), pytest
+// and cargo test from a command's output. It reports false when the output
+// holds no test results.
+func ParseTestOutput(output string) (TestReport, bool) {
+	var r TestReport
+	var pkgPassed, pkgFailed int
+	sawFailure := false
+	note := func(line int, text string, failed bool) {
+		if failed && !sawFailure || r.Line == 0 {
+			r.Line, r.Text = line, strings.TrimSpace(text)
+		}
+		sawFailure = sawFailure || failed
+	}
+
+	for i, line := range strings.Split(output, "\n") {
+		n := i + 1
+		if strings.HasPrefix(line, "{") {
+			var ev goTestEvent
+			if json.Unmarshal([]byte(line), &ev) == nil && ev.Action != "" {
+				switch {
+				case ev.Action == "pass" && ev.Test != "":
+					r.Passed++
+				case ev.Action == "fail" && ev.Test != "":
+					r.Failed++
+					note(n, ev.Package+" "+ev.Test, true)
+				case ev.Action == "pass":
+					pkgPassed++
+				case ev.Action == "fail":
+					pkgFailed++
+					note(n, ev.Package, true)
+				}
+				continue
+			}
+		}
+
+		switch {
+		case testPatterns.goFail.MatchString(line):
+			r.Failed++
+			note(n, line, true)
+		case testPatterns.goPass.MatchString(line):
+			r.Passed++
+		case testPatterns.goPkgFail.MatchString(line):
+			pkgFailed++
+			note(n, line, true)
+		case testPatterns.goPkgOK.MatchString(line):
+			pkgPassed++
+			note(n, line, false)
+		case testPatterns.cargoSummary.MatchString(line):
+			m := testPatterns.cargoSummary.FindStringSubmatch(line)
+			passed, _ := strconv.Atoi(m[1])
+			failed, _ := strconv.Atoi(m[2])
+			r.Passed += passed
+			r.Failed += failed
+			note(n, line, failed > 0)
+		case testPatterns.pytestSummary.MatchString(strings.TrimSpace(line)):
+			failed := 0
+			for _, m := range testPatterns.pytestCount.FindAllStringSubmatch(line, -1) {
+				count, _ := strconv.Atoi(m[1])
+				if m[2] == "passed" {
+					r.Passed += count
+				} else {
+					r.Failed += count
+					failed += count
+				}
+			}
+			note(n, line, failed > 0)
+		}
+	}
+
+	// Without per-test lines, e.g. `go test` without -v, count packages;
+	// a package that failed to build has no failing test
+	if r.Failed == 0 && pkgFailed > 0 {
+		r.Failed = pkgFailed
+	}
+	if r.Passed == 0 && r.Failed == 0 {
+		r.Passed = pkgPassed
+	}
+	return r, r.Passed+r.Failed > 0
+}
@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// codexSource reads Codex CLI rollout logs
+// (~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl). Current rollouts wrap
+// each record as {"type": "session_meta"|"response_item"|..., "payload":
+// {...}}; older ones start with a bare metadata line and then list response
+// items unwrapped.
+type codexSource struct {
+	p *Parser
+}
+
+// codexLine is one line of a rollout file.
+type codexLine struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+
+	// Legacy metadata line
+	ID           string          `json:"id"`
+	Instructions json.RawMessage `json:"instructions"`
+}
+
+// codexItem is a response item: a message, tool call or tool output.
+type codexItem struct {
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+
+	// function_call / custom_tool_call
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Input     string `json:"input"`
+
+	// local_shell_call
+	Action *struct {
+		Command []string `json:"command"`
+	} `json:"action"`
+
+	// function_call_output / custom_tool_call_output
+	Output json.RawMessage `json:"output"`
+
+	// Links a call to its output
+	CallID string `json:"call_id"`
+
+	// session_meta
+	ID string `json:"id"`
+}
+
+// codexExitCode finds the exit code in plain-text tool output.
+var codexExitCode = regexp.MustCompile(`(?m)^Exit code: (\d+)`)
+
+func (s *codexSource) Name() string { return FormatCodex }
+
+func (s *codexSource) Detect(path string, head []byte) bool {
+	if strings.HasPrefix(filepath.Base(path), "rollout-") && filepath.Ext(path) == ".jsonl" {
+		return true
+	}
+	var line codexLine
+	if err := json.Unmarshal(firstLine(head), &line); err != nil {
+		return false
+	}
+	switch line.Type {
+	case "session_meta", "response_item", "turn_context", "event_msg":
+		return true
+	}
+	return line.Type == "" && line.ID != "" && len(line.Instructions) > 0
+}
+
+func (s *codexSource) ParseFile(path string) (result *ParseResult, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	result = &ParseResult{FilePath: path, Messages: make([]types.TranscriptMessage, 0)}
+	var sessionID string
+
+	err = scanLines(f, result, func(raw []byte, lineNum int) error {
+		var line codexLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return err
+		}
+
+		item := raw
+		switch {
+		case line.Type == "session_meta":
+			var meta codexItem
+			if err := json.Unmarshal(line.Payload, &meta); err != nil {
+				return err
+			}
+			sessionID = meta.ID
+			return nil
+		case line.Type == "response_item":
+			item = line.Payload
+		case line.Type == "" && len(line.Instructions) > 0:
+			sessionID = line.ID
+			return nil
+		case len(line.Payload) > 0:
+			return nil // turn_context, event_msg, compacted
+		}
+
+		var it codexItem
+		if err := json.Unmarshal(item, &it); err != nil {
+			return err
+		}
+		msg, ok := s.message(it)
+		if !ok {
+			return nil
+		}
+		msg.Timestamp = parseTimestamp(line.Timestamp)
+		msg.SessionID = sessionID
+		msg.MessageIndex = lineNum
+		result.Messages = append(result.Messages, msg)
+		return nil
+	})
+	return result, err
+}
+
+// message normalises a response item; ok is false for items that carry no
+// conversation content (reasoning, system and developer prompts).
+func (s *codexSource) message(it codexItem) (msg types.TranscriptMessage, ok bool) {
+	switch it.Type {
+	case "message":
+		var text []string
+		for _, c := range it.Content {
+			if c.Text != "" {
+				text = append(text, c.Text)
+			}
+		}
+		content := strings.Join(text, "\n")
+		if it.Role != "user" && it.Role != "assistant" {
+			return msg, false
+		}
+		// Injected environment and AGENTS.md blocks are not prompts
+		if it.Role == "user" && (strings.HasPrefix(content, "<environment_context>") || strings.HasPrefix(content, "<user_instructions>")) {
+			return msg, false
+		}
+		return types.TranscriptMessage{Type: it.Role, Role: it.Role, Content: s.p.truncate(content)}, content != ""
+
+	case "function_call", "custom_tool_call", "local_shell_call":
+		calls := codexToolCalls(it)
+		for i := range calls {
+			calls[i].ID = it.CallID
+		}
+		return types.TranscriptMessage{Type: "assistant", Role: "assistant", Tools: calls}, true
+
+	case "function_call_output", "custom_tool_call_output":
+		output, exitCode := codexOutput(it.Output)
+		result := types.ToolCall{Name: "tool_result", ID: it.CallID, Output: s.p.truncate(output)}
+		if exitCode != 0 {
+			result.Error = fmt.Sprintf("exit code %d", exitCode)
+		}
+		return types.TranscriptMessage{Type: "tool_result", Role: "tool", Tools: []types.ToolCall{result}}, true
+	}
+	return msg, false
+}
+
+// codexToolCalls maps a Codex tool call onto Claude Code tool names.
+func codexToolCalls(it codexItem) []types.ToolCall {
+	if it.Type == "local_shell_call" && it.Action != nil {
+		return []types.ToolCall{{Name: "Bash", Input: map[string]interface{}{"command": shellCommand(it.Action.Command)}}}
+	}
+
+	var args map[string]interface{}
+	if it.Arguments != "" {
+		_ = json.Unmarshal([]byte(it.Arguments), &args) //nolint:errcheck // unparseable arguments are kept raw below
+	}
+
+	switch it.Name {
+	case "shell", "container.exec", "exec_command", "local_shell":
+		command, _ := args["cmd"].(string)
+		switch c := args["command"].(type) {
+		case string:
+			command = c
+		case []interface{}:
+			argv := make([]string, 0, len(c))
+			for _, a := range c {
+				if s, ok := a.(string); ok {
+					argv = append(argv, s)
+				}
+			}
+			command = shellCommand(argv)
+		}
+		input := map[string]interface{}{"command": command}
+		if dir, ok := args["workdir"].(string); ok {
+			input["cwd"] = dir
+		}
+		return []types.ToolCall{{Name: "Bash", Input: input}}
+
+	case "apply_patch":
+		patch := it.Input
+		if p, ok := args["input"].(string); ok {
+			patch = p
+		}
+		if calls := patchFileCalls(patch); len(calls) > 0 {
+			return calls
+		}
+
+	case "update_plan":
+		plan, _ := args["plan"].([]interface{})
+		todos := make([]interface{}, 0, len(plan))
+		for _, p := range plan {
+			step, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			todos = append(todos, map[string]interface{}{"content": step["step"], "status": step["status"]})
+		}
+		return []types.ToolCall{{Name: "TodoWrite", Input: map[string]interface{}{"todos": todos}}}
+	}
+
+	if args == nil && (it.Arguments != "" || it.Input != "") {
+		args = map[string]interface{}{"input": it.Arguments + it.Input}
+	}
+	return []types.ToolCall{{Name: it.Name, Input: args}}
+}
+
+// codexOutput extracts the text and exit code of a tool output, which is
+// either a JSON string holding {"output", "metadata": {"exit_code"}} or
+// plain text with an "Exit code: N" line.
+func codexOutput(raw json.RawMessage) (string, int) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		text = string(raw)
+	}
+
+	var structured struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(text), &structured); err == nil && (structured.Output != "" || structured.Metadata.ExitCode != 0) {
+		return structured.Output, structured.Metadata.ExitCode
+	}
+
+	if m := codexExitCode.FindStringSubmatch(text); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return text, code
+	}
+	return text, 0
+}
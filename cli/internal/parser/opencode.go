@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// openCodeSource reads OpenCode sessions, either as the single document
+// written by `opencode export` ({"info": {...}, "messages": [{"info",
+// "parts"}]}) or straight from its storage directory, where a session file
+// storage/session/<project>/<id>.json has its messages under
+// storage/message/<id>/ and each message's parts under storage/part/<msg>/.
+type openCodeSource struct {
+	p *Parser
+}
+
+// openCodeSessionID matches OpenCode's session identifiers.
+var openCodeSessionID = regexp.MustCompile(`"(id|sessionID)"\s*:\s*"ses_`)
+
+type openCodeTime struct {
+	Created int64 `json:"created"`
+}
+
+type openCodeInfo struct {
+	ID        string       `json:"id"`
+	SessionID string       `json:"sessionID"`
+	Role      string       `json:"role"`
+	Time      openCodeTime `json:"time"`
+}
+
+type openCodePart struct {
+	ID     string `json:"id"`
+	CallID string `json:"callID"`
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Tool   string `json:"tool"`
+	State  struct {
+		Status   string                 `json:"status"`
+		Input    map[string]interface{} `json:"input"`
+		Output   string                 `json:"output"`
+		Error    string                 `json:"error"`
+		Metadata struct {
+			Exit *int `json:"exit"`
+		} `json:"metadata"`
+	} `json:"state"`
+	Synthetic bool `json:"synthetic"`
+}
+
+type openCodeMessage struct {
+	Info  openCodeInfo   `json:"info"`
+	Parts []openCodePart `json:"parts"`
+}
+
+type openCodeExport struct {
+	Info     openCodeInfo      `json:"info"`
+	Messages []openCodeMessage `json:"messages"`
+}
+
+// openCodeToolNames maps OpenCode tools to Claude Code tool names.
+var openCodeToolNames = map[string]string{
+	"bash":      "Bash",
+	"edit":      "Edit",
+	"multiedit": "MultiEdit",
+	"write":     "Write",
+	"patch":     "Edit",
+	"read":      "Read",
+	"list":      "LS",
+	"glob":      "Glob",
+	"grep":      "Grep",
+	"todowrite": "TodoWrite",
+	"todoread":  "TodoRead",
+	"webfetch":  "WebFetch",
+	"task":      "Task",
+}
+
+func (s *openCodeSource) Name() string { return FormatOpenCode }
+
+func (s *openCodeSource) Detect(path string, head []byte) bool {
+	if filepath.Ext(path) != ".json" {
+		return false
+	}
+	return openCodeSessionID.Match(head)
+}
+
+func (s *openCodeSource) ParseFile(path string) (*ParseResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	var doc openCodeExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if doc.Messages == nil {
+		// A storage session file is the bare info object; gather its
+		// messages and parts from the surrounding tree
+		if err := json.Unmarshal(data, &doc.Info); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		doc.Messages, err = loadOpenCodeMessages(path, doc.Info.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	result := &ParseResult{
+		FilePath:   path,
+		Messages:   make([]types.TranscriptMessage, 0, len(doc.Messages)),
+		TotalLines: bytes.Count(data, []byte("\n")) + 1,
+		Checksum:   hex.EncodeToString(sum[:8]),
+		ParsedAt:   time.Now(),
+	}
+	for i, m := range doc.Messages {
+		sessionID := m.Info.SessionID
+		if sessionID == "" {
+			sessionID = doc.Info.ID
+		}
+		for _, msg := range s.messages(m) {
+			msg.SessionID = sessionID
+			msg.MessageIndex = i + 1
+			result.Messages = append(result.Messages, msg)
+		}
+	}
+	return result, nil
+}
+
+// messages normalises one OpenCode message. Tool parts hold both the call
+// and its result, so an assistant message with tools is followed by a
+// tool_result message carrying their outputs.
+func (s *openCodeSource) messages(m openCodeMessage) []types.TranscriptMessage {
+	ts := time.Time{}
+	if m.Info.Time.Created > 0 {
+		ts = time.UnixMilli(m.Info.Time.Created).UTC()
+	}
+
+	var text []string
+	var calls, results []types.ToolCall
+	for _, part := range m.Parts {
+		switch part.Type {
+		case "text":
+			if !part.Synthetic && part.Text != "" {
+				text = append(text, part.Text)
+			}
+		case "tool":
+			name := openCodeToolNames[part.Tool]
+			if name == "" {
+				name = part.Tool
+			}
+			calls = append(calls, types.ToolCall{Name: name, ID: part.CallID, Input: snakeKeys(part.State.Input)})
+
+			result := types.ToolCall{Name: "tool_result", ID: part.CallID, Output: s.p.truncate(part.State.Output)}
+			switch {
+			case part.State.Status == "error":
+				result.Error = part.State.Error
+				if result.Error == "" {
+					result.Error = "tool error"
+				}
+			case part.State.Metadata.Exit != nil && *part.State.Metadata.Exit != 0:
+				result.Error = fmt.Sprintf("exit code %d", *part.State.Metadata.Exit)
+			}
+			results = append(results, result)
+		}
+	}
+
+	content := s.p.truncate(strings.Join(text, "\n"))
+	if content == "" && len(calls) == 0 {
+		return nil
+	}
+	out := []types.TranscriptMessage{{Type: m.Info.Role, Role: m.Info.Role, Timestamp: ts, Content: content, Tools: calls}}
+	if len(results) > 0 {
+		out = append(out, types.TranscriptMessage{Type: "tool_result", Role: "tool", Timestamp: ts, Tools: results})
+	}
+	return out
+}
+
+// loadOpenCodeMessages reads a session's messages and parts from the
+// storage tree that holds sessionPath, in creation order.
+func loadOpenCodeMessages(sessionPath, sessionID string) ([]openCodeMessage, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("not an OpenCode session: %s", sessionPath)
+	}
+	// storage/session/<project>/<id>.json → storage/
+	storage := filepath.Dir(filepath.Dir(filepath.Dir(sessionPath)))
+
+	infos, err := readOpenCodeDir[openCodeInfo](filepath.Join(storage, "message", sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("read messages: %w", err)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Time.Created != infos[j].Time.Created {
+			return infos[i].Time.Created < infos[j].Time.Created
+		}
+		return infos[i].ID < infos[j].ID
+	})
+
+	msgs := make([]openCodeMessage, 0, len(infos))
+	for _, info := range infos {
+		parts, err := readOpenCodeDir[openCodePart](filepath.Join(storage, "part", info.ID))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read parts: %w", err)
+		}
+		sort.SliceStable(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
+		msgs = append(msgs, openCodeMessage{Info: info, Parts: parts})
+	}
+	return msgs, nil
+}
+
+// readOpenCodeDir decodes every .json file in dir, skipping unreadable ones.
+func readOpenCodeDir[T any](dir string) ([]T, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -254,6 +254,13 @@ func (p *Parser) parseContentBlocks(blocks []interface{}) (string, []types.ToolC
 	return content, tools
 }
 
+// ParseLine parses one transcript line for callers that read the file
+// themselves (e.g. to track byte offsets). It returns nil without error for
+// valid lines that are not messages.
+func (p *Parser) ParseLine(line []byte, lineNum int) (*types.TranscriptMessage, error) {
+	return p.parseLine(line, lineNum)
+}
+
 // parseLine parses a single JSON line.
 func (p *Parser) parseLine(line []byte, lineNum int) (*types.TranscriptMessage, error) {
 	var raw rawMessage
@@ -302,6 +309,7 @@ func (p *Parser) parseToolUse(block map[string]interface{}) *types.ToolCall {
 	toolCall := &types.ToolCall{
 		Name: name,
 	}
+	toolCall.ID, _ = block["id"].(string)
 
 	// Extract input parameters
 	if input, ok := block["input"].(map[string]interface{}); ok {
@@ -316,6 +324,7 @@ func (p *Parser) parseToolResult(block map[string]interface{}) *types.ToolCall {
 	toolCall := &types.ToolCall{
 		Name: "tool_result",
 	}
+	toolCall.ID, _ = block["tool_use_id"].(string)
 
 	// Check if it's an error result
 	if isError, ok := block["is_error"].(bool); ok && isError {
@@ -448,6 +448,7 @@ func TestParser_ParseContentBlocks(t *testing.T) {
 		blocks := []interface{}{
 			map[string]interface{}{
 				"type":  "tool_use",
+				"id":    "toolu_1",
 				"name":  "Read",
 				"input": map[string]interface{}{"file_path": "/tmp/x"},
 			},
@@ -456,20 +457,21 @@ func TestParser_ParseContentBlocks(t *testing.T) {
 		if content != "" {
 			t.Errorf("content = %q, want empty", content)
 		}
-		if len(tools) != 1 || tools[0].Name != "Read" {
-			t.Errorf("tools = %+v, want 1 tool named Read", tools)
+		if len(tools) != 1 || tools[0].Name != "Read" || tools[0].ID != "toolu_1" {
+			t.Errorf("tools = %+v, want 1 tool named Read with its ID", tools)
 		}
 	})
 
 	t.Run("tool_result block", func(t *testing.T) {
 		blocks := []interface{}{
 			map[string]interface{}{
-				"type":    "tool_result",
-				"content": "result text",
+				"type":        "tool_result",
+				"tool_use_id": "toolu_1",
+				"content":     "result text",
 			},
 		}
 		_, tools := p.parseContentBlocks(blocks)
-		if len(tools) != 1 || tools[0].Output != "result text" {
+		if len(tools) != 1 || tools[0].Output != "result text" || tools[0].ID != "toolu_1" {
 			t.Errorf("tools = %+v, want 1 tool_result with output", tools)
 		}
 	})
@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Transcript formats recognised by DetectSource.
+const (
+	FormatClaudeCode = "claude-code"
+	FormatCodex      = "codex"
+	FormatOpenCode   = "opencode"
+)
+
+// detectHeadSize is how much of a file DetectSource reads to sniff its format.
+const detectHeadSize = 64 * 1024
+
+// TranscriptSource reads one agent's session log format and normalises it
+// to types.TranscriptMessage. Tool calls are mapped onto Claude Code's tool
+// names and input keys (Bash/command, Edit/file_path, TodoWrite/todos) so
+// downstream extractors need not care which agent produced the log. Tool
+// results are reported as messages carrying a "tool_result" ToolCall.
+type TranscriptSource interface {
+	// Name is the format identifier (FormatClaudeCode, FormatCodex, ...).
+	Name() string
+
+	// Detect reports whether a file, given its path and leading bytes, is
+	// in this format.
+	Detect(path string, head []byte) bool
+
+	// ParseFile reads a whole session log.
+	ParseFile(path string) (*ParseResult, error)
+}
+
+// Sources returns the known transcript sources in detection order,
+// configured with p's truncation settings. A nil p uses NewParser().
+func Sources(p *Parser) []TranscriptSource {
+	if p == nil {
+		p = NewParser()
+	}
+	return []TranscriptSource{
+		&codexSource{p: p},
+		&openCodeSource{p: p},
+		&claudeSource{p: p},
+	}
+}
+
+// SourceByName returns the source for a format name.
+func SourceByName(name string, p *Parser) (TranscriptSource, error) {
+	for _, s := range Sources(p) {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown transcript format %q (use %s, %s, %s)", name, FormatClaudeCode, FormatCodex, FormatOpenCode)
+}
+
+// DetectSource sniffs the file at path and returns the source that reads
+// it. Claude Code is the fallback when nothing else matches.
+func DetectSource(path string, p *Parser) (TranscriptSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // read-only sniff
+
+	head := make([]byte, detectHeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	head = head[:n]
+
+	sources := Sources(p)
+	for _, s := range sources {
+		if s.Detect(path, head) {
+			return s, nil
+		}
+	}
+	return sources[len(sources)-1], nil
+}
+
+// ParseTranscript detects the format of the file at path and parses it.
+func ParseTranscript(path string, p *Parser) (*ParseResult, TranscriptSource, error) {
+	src, err := DetectSource(path, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := src.ParseFile(path)
+	return result, src, err
+}
+
+// claudeSource reads Claude Code JSONL transcripts with the Parser itself.
+type claudeSource struct {
+	p *Parser
+}
+
+func (s *claudeSource) Name() string { return FormatClaudeCode }
+
+// Detect accepts anything; Claude Code is the fallback format.
+func (s *claudeSource) Detect(path string, head []byte) bool { return true }
+
+func (s *claudeSource) ParseFile(path string) (*ParseResult, error) {
+	return s.p.ParseFile(path)
+}
+
+// firstLine returns the first non-blank line of head.
+func firstLine(head []byte) []byte {
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line
+		}
+	}
+	return nil
+}
+
+// scanLines feeds each non-empty line of r to fn with its line number and
+// fills in the line count, checksum and parse time of result, matching
+// Parser.Parse.
+func scanLines(r io.Reader, result *ParseResult, fn func(line []byte, lineNum int) error) error {
+	hasher := sha256.New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		result.TotalLines = lineNum
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		hasher.Write(line)
+		hasher.Write([]byte("\n"))
+		if err := fn(line, lineNum); err != nil {
+			result.MalformedLines++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanner error: %w", err)
+	}
+
+	result.Checksum = hex.EncodeToString(hasher.Sum(nil)[:8])
+	result.ParsedAt = time.Now()
+	return nil
+}
+
+// shellCommand flattens an argv-style command, unwrapping `bash -lc "..."`.
+func shellCommand(argv []string) string {
+	if len(argv) == 3 {
+		switch argv[0] {
+		case "bash", "sh", "zsh", "/bin/bash", "/bin/sh", "/bin/zsh":
+			if argv[1] == "-c" || argv[1] == "-lc" {
+				return argv[2]
+			}
+		}
+	}
+	return strings.Join(argv, " ")
+}
+
+// patchFileCalls turns an apply_patch body into one Write or Edit call per
+// file it touches.
+func patchFileCalls(patch string) []types.ToolCall {
+	var calls []types.ToolCall
+	for _, line := range strings.Split(patch, "\n") {
+		var name, path string
+		switch {
+		case strings.HasPrefix(line, "*** Add File: "):
+			name, path = "Write", strings.TrimPrefix(line, "*** Add File: ")
+		case strings.HasPrefix(line, "*** Update File: "):
+			name, path = "Edit", strings.TrimPrefix(line, "*** Update File: ")
+		case strings.HasPrefix(line, "*** Delete File: "):
+			name, path = "Edit", strings.TrimPrefix(line, "*** Delete File: ")
+		case strings.HasPrefix(line, "*** Move to: "):
+			name, path = "Write", strings.TrimPrefix(line, "*** Move to: ")
+		default:
+			continue
+		}
+		calls = append(calls, types.ToolCall{
+			Name:  name,
+			Input: map[string]interface{}{"file_path": strings.TrimSpace(path)},
+		})
+	}
+	return calls
+}
+
+// snakeKeys copies a tool input, renaming camelCase keys to snake_case
+// (filePath → file_path) to match Claude Code's tool inputs.
+func snakeKeys(input map[string]interface{}) map[string]interface{} {
+	if input == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(input))
+	for k, v := range input {
+		var b strings.Builder
+		for i, r := range k {
+			if unicode.IsUpper(r) {
+				if i > 0 {
+					b.WriteByte('_')
+				}
+				r = unicode.ToLower(r)
+			}
+			b.WriteRune(r)
+		}
+		out[b.String()] = v
+	}
+	return out
+}
@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const codexRollout = `{"timestamp":"2026-03-14T09:00:00.000Z","type":"session_meta","payload":{"id":"0199-codex","cwd":"/repo","originator":"codex_cli_rs"}}
+{"timestamp":"2026-03-14T09:00:01.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>cwd</environment_context>"}]}}
+{"timestamp":"2026-03-14T09:00:02.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"fix the flaky test"}]}}
+{"timestamp":"2026-03-14T09:00:03.000Z","type":"event_msg","payload":{"type":"token_count"}}
+{"timestamp":"2026-03-14T09:00:04.000Z","type":"response_item","payload":{"type":"reasoning","summary":[]}}
+{"timestamp":"2026-03-14T09:00:05.000Z","type":"response_item","payload":{"type":"function_call","name":"update_plan","arguments":"{\"plan\":[{\"step\":\"reproduce\",\"status\":\"completed\"},{\"step\":\"fix\",\"status\":\"in_progress\"}]}","call_id":"c1"}}
+{"timestamp":"2026-03-14T09:00:06.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: pkg/retry.go\n@@\n-a\n+b\n*** Add File: pkg/retry_test.go\n+x\n*** End Patch","call_id":"c2"}}
+{"timestamp":"2026-03-14T09:00:07.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"go test ./pkg\"],\"workdir\":\"/repo\"}","call_id":"c3"}}
+{"timestamp":"2026-03-14T09:00:08.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c3","output":"{\"output\":\"--- FAIL: TestRetry\\nFAIL\",\"metadata\":{\"exit_code\":1}}"}}
+{"timestamp":"2026-03-14T09:00:09.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"The fix is to reset the timer."}]}}
+`
+
+func writeSourceFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// toolNames flattens the tool calls of msgs as "Name" or "Name:key=value"
+// for the given input key.
+func toolNames(msgs []types.TranscriptMessage, key string) []string {
+	var out []string
+	for _, m := range msgs {
+		for _, tool := range m.Tools {
+			if v, ok := tool.Input[key].(string); ok {
+				out = append(out, tool.Name+":"+v)
+			} else {
+				out = append(out, tool.Name)
+			}
+		}
+	}
+	return out
+}
+
+func TestCodexSource(t *testing.T) {
+	dir := t.TempDir()
+	// Detection must not rely on the rollout- file name
+	path := writeSourceFile(t, dir, "session.jsonl", codexRollout)
+
+	src, err := DetectSource(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != FormatCodex {
+		t.Fatalf("DetectSource = %s, want %s", src.Name(), FormatCodex)
+	}
+
+	result, err := src.ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	msgs := result.Messages
+	if len(msgs) != 6 {
+		t.Fatalf("got %d messages, want 6: %+v", len(msgs), msgs)
+	}
+	if msgs[0].Type != "user" || msgs[0].Content != "fix the flaky test" || msgs[0].SessionID != "0199-codex" {
+		t.Errorf("first message = %+v", msgs[0])
+	}
+	if msgs[0].Timestamp.IsZero() {
+		t.Error("timestamp not parsed")
+	}
+
+	got := toolNames(msgs, "file_path")
+	want := []string{"TodoWrite", "Edit:pkg/retry.go", "Write:pkg/retry_test.go", "Bash", "tool_result"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tools = %v, want %v", got, want)
+	}
+	if cmd := msgs[3].Tools[0].Input["command"]; cmd != "go test ./pkg" {
+		t.Errorf("Bash command = %v, want unwrapped shell command", cmd)
+	}
+	todos, _ := msgs[1].Tools[0].Input["todos"].([]interface{})
+	if len(todos) != 2 || todos[1].(map[string]interface{})["status"] != "in_progress" {
+		t.Errorf("TodoWrite todos = %v", todos)
+	}
+	res := msgs[4].Tools[0]
+	if res.Error != "exit code 1" || !strings.Contains(res.Output, "--- FAIL: TestRetry") {
+		t.Errorf("tool_result = %+v", res)
+	}
+	if res.ID != "c3" || msgs[3].Tools[0].ID != "c3" || msgs[2].Tools[1].ID != "c2" {
+		t.Errorf("call IDs = %q, %q, %q; want each call linked to its result", msgs[3].Tools[0].ID, res.ID, msgs[2].Tools[1].ID)
+	}
+	if msgs[5].Type != "assistant" || msgs[5].Content != "The fix is to reset the timer." {
+		t.Errorf("last message = %+v", msgs[5])
+	}
+}
+
+func TestCodexSourceLegacyRollout(t *testing.T) {
+	legacy := `{"id":"legacy-1","timestamp":"2025-05-01T10:00:00Z","instructions":null}
+{"record_type":"state"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"list files"}]}
+{"type":"local_shell_call","call_id":"c1","status":"completed","action":{"type":"exec","command":["ls","-la"]}}
+{"type":"function_call_output","call_id":"c1","output":"Exit code: 2\nls: cannot access"}
+`
+	path := writeSourceFile(t, t.TempDir(), "rollout-2025-05-01-legacy.jsonl", legacy)
+	result, src, err := ParseTranscript(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != FormatCodex {
+		t.Fatalf("format = %s, want codex", src.Name())
+	}
+	if len(result.Messages) != 3 || result.Messages[0].SessionID != "legacy-1" {
+		t.Fatalf("messages = %+v", result.Messages)
+	}
+	if cmd := result.Messages[1].Tools[0].Input["command"]; cmd != "ls -la" {
+		t.Errorf("command = %v, want ls -la", cmd)
+	}
+	if e := result.Messages[2].Tools[0].Error; e != "exit code 2" {
+		t.Errorf("Error = %q, want exit code 2", e)
+	}
+}
+
+const openCodeExportDoc = `{
+  "info": {"id": "ses_abc", "title": "Retry fix", "time": {"created": 1773478800000}},
+  "messages": [
+    {
+      "info": {"id": "msg_1", "sessionID": "ses_abc", "role": "user", "time": {"created": 1773478800000}},
+      "parts": [{"id": "prt_1", "type": "text", "text": "add a retry test"}]
+    },
+    {
+      "info": {"id": "msg_2", "sessionID": "ses_abc", "role": "assistant", "time": {"created": 1773478805000}},
+      "parts": [
+        {"id": "prt_2", "type": "text", "text": "Writing the test."},
+        {"id": "prt_3", "type": "tool", "tool": "write", "state": {"status": "completed", "input": {"filePath": "retry_test.go", "content": "x"}, "output": ""}},
+        {"id": "prt_4", "type": "tool", "tool": "bash", "state": {"status": "completed", "input": {"command": "go test ./..."}, "output": "FAIL", "metadata": {"exit": 1}}},
+        {"id": "prt_5", "type": "tool", "tool": "todowrite", "state": {"status": "error", "input": {"todos": []}, "error": "bad todo"}}
+      ]
+    }
+  ]
+}`
+
+func TestOpenCodeSourceExport(t *testing.T) {
+	path := writeSourceFile(t, t.TempDir(), "export.json", openCodeExportDoc)
+	result, src, err := ParseTranscript(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != FormatOpenCode {
+		t.Fatalf("format = %s, want opencode", src.Name())
+	}
+
+	msgs := result.Messages
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3: %+v", len(msgs), msgs)
+	}
+	if msgs[0].Type != "user" || msgs[0].Content != "add a retry test" || msgs[0].SessionID != "ses_abc" {
+		t.Errorf("user message = %+v", msgs[0])
+	}
+	if msgs[1].Timestamp.IsZero() || msgs[1].Content != "Writing the test." {
+		t.Errorf("assistant message = %+v", msgs[1])
+	}
+	got := toolNames(msgs, "file_path")
+	want := []string{"Write:retry_test.go", "Bash", "TodoWrite", "tool_result", "tool_result", "tool_result"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tools = %v, want %v", got, want)
+	}
+	results := msgs[2].Tools
+	if results[0].Error != "" || results[1].Error != "exit code 1" || results[2].Error != "bad todo" {
+		t.Errorf("tool results = %+v", results)
+	}
+}
+
+func TestOpenCodeSourceStorage(t *testing.T) {
+	storage := filepath.Join(t.TempDir(), "storage")
+	session := writeSourceFile(t, storage, "session/proj1/ses_xyz.json",
+		`{"id":"ses_xyz","title":"t","time":{"created":1}}`)
+	// Written out of order; parse must sort by creation time
+	writeSourceFile(t, storage, "message/ses_xyz/msg_b.json",
+		`{"id":"msg_b","sessionID":"ses_xyz","role":"assistant","time":{"created":20}}`)
+	writeSourceFile(t, storage, "message/ses_xyz/msg_a.json",
+		`{"id":"msg_a","sessionID":"ses_xyz","role":"user","time":{"created":10}}`)
+	writeSourceFile(t, storage, "part/msg_a/prt_1.json", `{"id":"prt_1","type":"text","text":"hello"}`)
+	writeSourceFile(t, storage, "part/msg_b/prt_3.json", `{"id":"prt_3","type":"text","text":"second"}`)
+	writeSourceFile(t, storage, "part/msg_b/prt_2.json", `{"id":"prt_2","type":"text","text":"first"}`)
+
+	result, src, err := ParseTranscript(session, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != FormatOpenCode {
+		t.Fatalf("format = %s, want opencode", src.Name())
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(result.Messages))
+	}
+	if result.Messages[0].Content != "hello" || result.Messages[1].Content != "first\nsecond" {
+		t.Errorf("messages = %+v", result.Messages)
+	}
+}
+
+func TestDetectSourceFallsBackToClaude(t *testing.T) {
+	dir := t.TempDir()
+	claude := writeSourceFile(t, dir, "abc.jsonl",
+		`{"type":"user","sessionId":"s","uuid":"1","message":{"role":"user","content":"hi"}}`+"\n")
+	src, err := DetectSource(claude, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Name() != FormatClaudeCode {
+		t.Errorf("DetectSource = %s, want claude-code", src.Name())
+	}
+
+	if _, err := SourceByName("cursor", nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := DetectSource(filepath.Join(dir, "missing.jsonl"), nil); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
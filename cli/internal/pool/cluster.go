@@ -0,0 +1,340 @@
+package pool
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Cluster is a group of near-duplicate candidates.
+type Cluster struct {
+	// ID is the candidate ID of the cluster's representative.
+	ID string `json:"id"`
+
+	// Representative is the candidate reviewers act on; nil if it has
+	// already left the pool (e.g. promoted).
+	Representative *PoolEntry `json:"representative,omitempty"`
+
+	// Duplicates are the other members, oldest first.
+	Duplicates []PoolEntry `json:"duplicates"`
+}
+
+// Size is the number of live members, including the representative.
+func (c Cluster) Size() int {
+	n := len(c.Duplicates)
+	if c.Representative != nil {
+		n++
+	}
+	return n
+}
+
+// duplicateThreshold returns the configured near-duplicate threshold.
+func (p *Pool) duplicateThreshold() float64 {
+	if p.DuplicateThreshold > 0 {
+		return p.DuplicateThreshold
+	}
+	return DefaultDuplicateThreshold
+}
+
+// liveEntries returns the pending and staged entries, oldest first, with
+// fingerprints filled in for entries written before fingerprinting.
+func (p *Pool) liveEntries() ([]*PoolEntry, error) {
+	entries, err := p.List(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	live := make([]*PoolEntry, 0, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		if e.Status == types.PoolStatusRejected {
+			continue
+		}
+		if len(e.Fingerprint) == 0 {
+			e.Fingerprint = Fingerprint(e.Candidate.Content)
+		}
+		live = append(live, e)
+	}
+	sort.SliceStable(live, func(i, j int) bool {
+		return live[i].AddedAt.Before(live[j].AddedAt)
+	})
+	return live, nil
+}
+
+// bestMatch returns the candidate most similar to fp, if any reaches the
+// threshold.
+func bestMatch(fp []uint32, candidates []*PoolEntry, threshold float64) (*PoolEntry, float64) {
+	var best *PoolEntry
+	var bestSim float64
+	for _, c := range candidates {
+		if sim := Similarity(fp, c.Fingerprint); sim >= threshold && sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	return best, bestSim
+}
+
+// nearestDuplicate finds the live candidate (other than id) most similar to
+// fp, at or above the duplicate threshold. Only candidates sharing a band
+// bucket with fp are read; a pool without an index is scanned whole.
+func (p *Pool) nearestDuplicate(id string, fp []uint32) (*PoolEntry, float64, error) {
+	if len(fp) == 0 {
+		return nil, 0, nil
+	}
+	var candidates []*PoolEntry
+	if p.lshIndexed() {
+		ids, err := p.lshCandidates(fp)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, cid := range ids {
+			if cid == id {
+				continue
+			}
+			e, err := p.Get(cid)
+			if err != nil || e.Status == types.PoolStatusRejected {
+				continue // left the pool since it was indexed
+			}
+			if len(e.Fingerprint) == 0 {
+				e.Fingerprint = Fingerprint(e.Candidate.Content)
+			}
+			candidates = append(candidates, e)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].AddedAt.Before(candidates[j].AddedAt)
+		})
+	} else {
+		live, err := p.liveEntries()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, e := range live {
+			if e.Candidate.ID != id {
+				candidates = append(candidates, e)
+			}
+		}
+	}
+	match, sim := bestMatch(fp, candidates, p.duplicateThreshold())
+	return match, sim, nil
+}
+
+// joinCluster makes entry a duplicate in match's cluster, making match the
+// representative of a new cluster if it is not in one yet.
+func (tx *poolTx) joinCluster(entry, match *PoolEntry, similarity float64) error {
+	if match.ClusterID == "" {
+		match.ClusterID = match.Candidate.ID
+		if err := tx.writeEntry(match.FilePath, match); err != nil {
+			return err
+		}
+	}
+	entry.ClusterID = match.ClusterID
+	entry.DuplicateOf = match.ClusterID
+	entry.Similarity = similarity
+	return nil
+}
+
+// Clusters returns the clusters with more than one live member, largest
+// first.
+func (p *Pool) Clusters() ([]Cluster, error) {
+	live, err := p.liveEntries()
+	if err != nil {
+		return nil, err
+	}
+	return groupClusters(live), nil
+}
+
+// groupClusters groups entries by cluster.
+func groupClusters(live []*PoolEntry) []Cluster {
+	byID := make(map[string]*Cluster)
+	var order []string
+	for _, e := range live {
+		if e.ClusterID == "" {
+			continue
+		}
+		c, ok := byID[e.ClusterID]
+		if !ok {
+			c = &Cluster{ID: e.ClusterID}
+			byID[e.ClusterID] = c
+			order = append(order, e.ClusterID)
+		}
+		if e.Candidate.ID == e.ClusterID {
+			c.Representative = e
+		} else {
+			c.Duplicates = append(c.Duplicates, *e)
+		}
+	}
+
+	var clusters []Cluster
+	for _, id := range order {
+		if c := byID[id]; len(c.Duplicates) > 0 {
+			clusters = append(clusters, *c)
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Size() > clusters[j].Size()
+	})
+	return clusters
+}
+
+// MergeCluster collapses a cluster onto one candidate: keepID, or the
+// representative if empty. Every other member is rejected as merged into the
+// kept one, which leaves the cluster as a plain candidate. The merge is a
+// single pool operation. It returns the kept candidate's ID and the IDs of
+// the merged members.
+func (p *Pool) MergeCluster(clusterID, keepID, reviewer string) (kept string, merged []string, err error) {
+	err = p.update("merge", clusterID, func(tx *poolTx) error {
+		live, err := p.liveEntries()
+		if err != nil {
+			return err
+		}
+		var members []*PoolEntry
+		for _, e := range live {
+			if e.ClusterID == clusterID {
+				members = append(members, e)
+			}
+		}
+		if len(members) == 0 {
+			return fmt.Errorf("cluster not found: %s", clusterID)
+		}
+
+		if keepID == "" {
+			keepID = clusterID
+			if !containsCandidate(members, keepID) {
+				// The representative has left the pool; keep the oldest
+				keepID = members[0].Candidate.ID
+			}
+		}
+		if !containsCandidate(members, keepID) {
+			return fmt.Errorf("candidate %s is not in cluster %s", keepID, clusterID)
+		}
+
+		now := time.Now()
+		for _, e := range members {
+			if e.Candidate.ID == keepID {
+				e.ClusterID, e.DuplicateOf, e.Similarity = "", "", 0
+				e.UpdatedAt = now
+				if err := tx.writeEntry(e.FilePath, e); err != nil {
+					return err
+				}
+				continue
+			}
+
+			note := fmt.Sprintf("merged into %s", keepID)
+			oldPath, fromStatus := e.FilePath, e.Status
+			newPath := filepath.Join(p.PoolPath, RejectedDir, filepath.Base(oldPath))
+			e.Status = types.PoolStatusRejected
+			e.DuplicateOf = keepID
+			e.UpdatedAt = now
+			e.HumanReview = &types.HumanReview{
+				Reviewed:   true,
+				Approved:   false,
+				Reviewer:   reviewer,
+				Notes:      note,
+				ReviewedAt: now,
+			}
+			if err := tx.writeEntry(newPath, e); err != nil {
+				return err
+			}
+			if err := tx.remove(oldPath); err != nil {
+				return err
+			}
+			if err := tx.event(ChainEvent{
+				Timestamp:   now,
+				Operation:   "merge",
+				CandidateID: e.Candidate.ID,
+				FromStatus:  fromStatus,
+				ToStatus:    types.PoolStatusRejected,
+				Reason:      note,
+				Reviewer:    reviewer,
+			}); err != nil {
+				return err
+			}
+			merged = append(merged, e.Candidate.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return keepID, merged, nil
+}
+
+// Recluster recomputes fingerprints and clusters for every live candidate,
+// as if they had been added oldest first, and rebuilds the near-duplicate
+// index. Use it after changing DuplicateThreshold or on pools populated
+// before clustering existed. It returns the number of candidates marked as
+// duplicates.
+func (p *Pool) Recluster() (int, error) {
+	duplicates := 0
+	err := p.update("recluster", "", func(tx *poolTx) error {
+		live, err := p.liveEntries()
+		if err != nil {
+			return err
+		}
+
+		type clusterState struct{ id, dup string }
+		before := make(map[*PoolEntry]clusterState, len(live))
+		for _, e := range live {
+			before[e] = clusterState{e.ClusterID, e.DuplicateOf}
+			e.ClusterID, e.DuplicateOf, e.Similarity = "", "", 0
+		}
+
+		threshold := p.duplicateThreshold()
+		for i, e := range live {
+			match, sim := bestMatch(e.Fingerprint, live[:i], threshold)
+			if match == nil {
+				continue
+			}
+			if match.ClusterID == "" {
+				match.ClusterID = match.Candidate.ID
+			}
+			e.ClusterID, e.DuplicateOf, e.Similarity = match.ClusterID, match.ClusterID, sim
+			duplicates++
+		}
+
+		if err := tx.rebuildIndex(live); err != nil {
+			return err
+		}
+		for _, e := range live {
+			if before[e] == (clusterState{e.ClusterID, e.DuplicateOf}) {
+				continue
+			}
+			if err := tx.writeEntry(e.FilePath, e); err != nil {
+				return err
+			}
+			if err := tx.event(ChainEvent{
+				Timestamp:   time.Now(),
+				Operation:   "cluster",
+				CandidateID: e.Candidate.ID,
+				Reason:      clusterReason(e),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return duplicates, err
+}
+
+// clusterReason describes an entry's cluster membership for the chain.
+func clusterReason(e *PoolEntry) string {
+	switch {
+	case e.DuplicateOf != "":
+		return fmt.Sprintf("duplicate of %s (similarity %.2f)", e.DuplicateOf, e.Similarity)
+	case e.ClusterID != "":
+		return "cluster representative"
+	default:
+		return "no longer clustered"
+	}
+}
+
+func containsCandidate(entries []*PoolEntry, id string) bool {
+	for _, e := range entries {
+		if e.Candidate.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestFingerprintSimilarity(t *testing.T) {
+	a := Fingerprint("Always run go vet before committing Go changes to catch shadowed variables.")
+	b := Fingerprint("Run go vet before committing Go changes; it catches shadowed variables.")
+	c := Fingerprint("Use PostgreSQL advisory locks to serialise schema migrations across replicas.")
+
+	if sim := Similarity(a, b); sim < DefaultDuplicateThreshold {
+		t.Errorf("paraphrase similarity = %.2f, want >= %.2f", sim, DefaultDuplicateThreshold)
+	}
+	if sim := Similarity(a, c); sim >= 0.2 {
+		t.Errorf("unrelated similarity = %.2f, want < 0.2", sim)
+	}
+	if sim := Similarity(a, a); sim != 1 {
+		t.Errorf("self similarity = %.2f, want 1", sim)
+	}
+	if fp := Fingerprint("  ... the a an "); fp != nil {
+		t.Errorf("fingerprint of stopwords = %v, want nil", fp)
+	}
+	if sim := Similarity(nil, a); sim != 0 {
+		t.Errorf("similarity with nil fingerprint = %.2f, want 0", sim)
+	}
+}
+
+// addContent adds a bronze candidate with content, one second after base
+// times n so insertion order is deterministic.
+func addContent(t *testing.T, p *Pool, id, content string, n int) {
+	t.Helper()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := types.Candidate{ID: id, Tier: types.TierBronze, Type: types.KnowledgeTypeLearning, Content: content}
+	if err := p.AddAt(c, types.Scoring{}, base.Add(time.Duration(n)*time.Second)); err != nil {
+		t.Fatalf("Add %s: %v", id, err)
+	}
+}
+
+const (
+	vetA    = "Always run go vet before committing Go changes to catch shadowed variables."
+	vetB    = "Run go vet before committing Go changes; it catches shadowed variables."
+	vetC    = "Before committing Go changes, run go vet to catch shadowed variables."
+	pgLocks = "Use PostgreSQL advisory locks to serialise schema migrations across replicas."
+)
+
+func TestPoolAddMarksNearDuplicates(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addContent(t, p, "first", vetA, 0)
+	addContent(t, p, "second", vetB, 1)
+	addContent(t, p, "other", pgLocks, 2)
+
+	first, err := p.Get("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ClusterID != "first" || first.DuplicateOf != "" {
+		t.Errorf("representative cluster=%q duplicate_of=%q", first.ClusterID, first.DuplicateOf)
+	}
+	second, err := p.Get("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.DuplicateOf != "first" || second.ClusterID != "first" || second.Similarity < DefaultDuplicateThreshold {
+		t.Errorf("duplicate cluster=%q duplicate_of=%q similarity=%.2f", second.ClusterID, second.DuplicateOf, second.Similarity)
+	}
+	other, err := p.Get("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ClusterID != "" || len(other.Fingerprint) != FingerprintSize {
+		t.Errorf("unrelated candidate cluster=%q fingerprint len=%d", other.ClusterID, len(other.Fingerprint))
+	}
+
+	review, err := p.ListPendingReview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range review {
+		if e.Candidate.ID == "second" {
+			t.Error("near-duplicate should not be queued for review")
+		}
+	}
+	if len(review) != 2 {
+		t.Errorf("pending review = %d, want 2", len(review))
+	}
+
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[1].Reason == "" {
+		t.Error("add of a duplicate should record why in the chain")
+	}
+}
+
+func TestPoolClustersAndMerge(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addContent(t, p, "first", vetA, 0)
+	addContent(t, p, "second", vetB, 1)
+	addContent(t, p, "third", vetC, 2)
+	addContent(t, p, "other", pgLocks, 3)
+
+	clusters, err := p.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	}
+	c := clusters[0]
+	if c.ID != "first" || c.Representative == nil || c.Size() != 3 {
+		t.Errorf("cluster %s size %d, want first with 3 members", c.ID, c.Size())
+	}
+
+	if _, _, err := p.MergeCluster("first", "other", "tester"); err == nil {
+		t.Error("keeping a candidate outside the cluster should fail")
+	}
+
+	kept, merged, err := p.MergeCluster("first", "second", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != "second" || len(merged) != 2 {
+		t.Errorf("kept %s merged %v, want second and 2 merged", kept, merged)
+	}
+
+	got, err := p.Get("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClusterID != "" || got.DuplicateOf != "" || got.Status != types.PoolStatusPending {
+		t.Errorf("kept candidate = cluster %q duplicate_of %q status %s", got.ClusterID, got.DuplicateOf, got.Status)
+	}
+	for _, id := range []string{"first", "third"} {
+		e, err := p.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Status != types.PoolStatusRejected || e.DuplicateOf != "second" {
+			t.Errorf("%s status %s duplicate_of %q, want rejected into second", id, e.Status, e.DuplicateOf)
+		}
+	}
+
+	clusters, err = p.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 0 {
+		t.Errorf("expected no clusters after merge, got %d", len(clusters))
+	}
+	if _, _, err := p.MergeCluster("first", "", "tester"); err == nil {
+		t.Error("merging a merged cluster should fail")
+	}
+}
+
+func TestPoolRecluster(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.DuplicateThreshold = 1 // nothing clusters on add
+	addContent(t, p, "first", vetA, 0)
+	addContent(t, p, "second", vetB, 1)
+	addContent(t, p, "other", pgLocks, 2)
+
+	clusters, err := p.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 0 {
+		t.Fatalf("expected no clusters at threshold 1, got %d", len(clusters))
+	}
+
+	p.DuplicateThreshold = DefaultDuplicateThreshold
+	n, err := p.Recluster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Recluster marked %d duplicates, want 1", n)
+	}
+	clusters, err = p.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].ID != "first" {
+		t.Fatalf("clusters after recluster = %+v", clusters)
+	}
+
+	// Reclustering again changes nothing and records no events
+	before, _ := p.GetChain()
+	if _, err := p.Recluster(); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := p.GetChain()
+	if len(after) != len(before) {
+		t.Errorf("idempotent recluster appended %d events", len(after)-len(before))
+	}
+}
+
+func TestPoolDuplicateIndex(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addContent(t, p, "first", vetA, 0)
+	addContent(t, p, "other", pgLocks, 1)
+
+	bucketed := func(id string) int {
+		t.Helper()
+		files, err := os.ReadDir(filepath.Join(p.PoolPath, LSHDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, f := range files {
+			data, err := os.ReadFile(filepath.Join(p.PoolPath, LSHDir, f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slices.Contains(strings.Fields(string(data)), id) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := bucketed("first"); n != lshBands {
+		t.Errorf("first is in %d buckets, want %d", n, lshBands)
+	}
+
+	// Lookup goes through the buckets: only what shares a band is read
+	ids, err := p.lshCandidates(Fingerprint(vetB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{"first"}) {
+		t.Errorf("candidates for a paraphrase = %v, want [first]", ids)
+	}
+
+	// A candidate that left the pool is skipped
+	addContent(t, p, "second", vetB, 2)
+	if err := p.Reject("first", "superseded", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if rep, _, err := p.nearestDuplicate("", Fingerprint(vetA)); err != nil || rep == nil || rep.Candidate.ID != "second" {
+		t.Errorf("nearestDuplicate = %v, %v; want second", rep, err)
+	}
+
+	// Recluster drops it from the index
+	if _, err := p.Recluster(); err != nil {
+		t.Fatal(err)
+	}
+	if n := bucketed("first"); n != 0 {
+		t.Errorf("rejected candidate still in %d buckets after recluster", n)
+	}
+
+	// A pool indexed before, or with another layout, is rebuilt on add
+	if err := os.RemoveAll(filepath.Join(p.PoolPath, LSHDir)); err != nil {
+		t.Fatal(err)
+	}
+	addContent(t, p, "fourth", vetA, 3)
+	fourth, err := p.Get("fourth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fourth.DuplicateOf == "" || !p.lshIndexed() || bucketed("second") != lshBands {
+		t.Errorf("add without an index: duplicate_of=%q indexed=%v", fourth.DuplicateOf, p.lshIndexed())
+	}
+}
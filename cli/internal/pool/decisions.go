@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/scorer"
+)
+
+// GateDecisions returns the pool's approve and reject decisions as scorer
+// training examples, one per candidate, labelled by its latest decision.
+// Features come from the decision's chain event; decisions recorded before
+// events carried features fall back to the candidate's pool entry, and are
+// counted in skipped when that entry is gone. Automated approvals are not
+// reviewer decisions and are left out.
+func (p *Pool) GateDecisions() (examples []scorer.Example, skipped int, err error) {
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	latest := make(map[string]ChainEvent)
+	var order []string
+	for _, e := range events {
+		if e.Operation != "approve" && e.Operation != "reject" || automatedApproval(e) {
+			continue
+		}
+		if _, ok := latest[e.CandidateID]; !ok {
+			order = append(order, e.CandidateID)
+		}
+		latest[e.CandidateID] = e
+	}
+
+	for _, id := range order {
+		e := latest[id]
+		features := e.Features
+		if features == nil {
+			entry, gerr := p.Get(id)
+			if gerr != nil {
+				skipped++
+				continue
+			}
+			features = scorer.Features(entry.Candidate.Content, entry.ScoringResult.Rubric)
+		}
+		examples = append(examples, scorer.Example{
+			CandidateID: id,
+			Features:    features,
+			Approved:    e.Operation == "approve",
+		})
+	}
+	return examples, skipped, nil
+}
+
+// automatedApproval reports whether an approval was made by a rule. Events
+// logged before approvals were marked are recognized by their note.
+func automatedApproval(e ChainEvent) bool {
+	if e.Automated {
+		return true
+	}
+	return e.Operation == "approve" &&
+		(strings.HasPrefix(e.Reason, "bulk-approve:") || strings.HasPrefix(e.Reason, "auto-promote:"))
+}
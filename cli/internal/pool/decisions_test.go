@@ -0,0 +1,53 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/scorer"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestPoolGateDecisions(t *testing.T) {
+	p := NewPool(t.TempDir())
+	rubric := types.RubricScores{Specificity: 0.9, Context: 0.4}
+	for _, id := range []string{"cand-keep", "cand-drop", "cand-open", "cand-auto"} {
+		c := types.Candidate{ID: id, Tier: types.TierBronze, Content: "content of " + id}
+		if err := p.Add(c, types.Scoring{Rubric: rubric, GateRequired: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Approve("cand-keep", "", "reviewer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reject("cand-drop", "vague", "reviewer"); err != nil {
+		t.Fatal(err)
+	}
+	// A policy approval is not a reviewer's label
+	approved, err := p.ApproveWhere(func(e PoolEntry) bool {
+		return e.Candidate.ID == "cand-auto"
+	}, "auto-promote: allowed by promotion policy", "reviewer", false)
+	if err != nil || len(approved) != 1 {
+		t.Fatalf("ApproveWhere() = %v, %v", approved, err)
+	}
+	// Promotion removes the entry; the decision stays trainable
+	if _, err := p.Promote("cand-keep"); err != nil {
+		t.Fatal(err)
+	}
+
+	examples, skipped, err := p.GateDecisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 || len(examples) != 2 {
+		t.Fatalf("examples = %+v (skipped %d), want 2", examples, skipped)
+	}
+	want := map[string]bool{"cand-keep": true, "cand-drop": false}
+	for _, e := range examples {
+		if approved, ok := want[e.CandidateID]; !ok || approved != e.Approved {
+			t.Errorf("example %s approved=%t", e.CandidateID, e.Approved)
+		}
+		if e.Features[scorer.FeatureSpecificity] != 0.9 {
+			t.Errorf("%s features = %v", e.CandidateID, e.Features)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package pool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// CandidateState is a candidate's state reconstructed by replaying the
+// chain.
+type CandidateState struct {
+	CandidateID string           `json:"candidate_id"`
+	Status      types.PoolStatus `json:"status"`
+	Tier        types.Tier       `json:"tier,omitempty"`
+	AddedAt     time.Time        `json:"added_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	// Review is the latest approve, reject or merge decision.
+	Review *types.HumanReview `json:"review,omitempty"`
+
+	// ArtifactPath is where a promoted candidate was written.
+	ArtifactPath string `json:"artifact_path,omitempty"`
+
+	// Snapshot is the entry as added, if the add event recorded it.
+	Snapshot *types.PoolEntry `json:"-"`
+
+	// Events is how many chain events touched the candidate.
+	Events int `json:"events"`
+}
+
+// History returns the chain events for a candidate, oldest first.
+func (p *Pool) History(candidateID string) ([]ChainEvent, error) {
+	if err := validateCandidateID(candidateID); err != nil {
+		return nil, fmt.Errorf("invalid candidate ID: %w", err)
+	}
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, err
+	}
+	var history []ChainEvent
+	for _, e := range events {
+		if e.CandidateID == candidateID {
+			history = append(history, e)
+		}
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no history for candidate: %s", candidateID)
+	}
+	return history, nil
+}
+
+// Replay reconstructs every candidate's state from the chain, counting only
+// events at or before asOf (all events if asOf is zero). Candidates are
+// returned in the order they were added.
+func (p *Pool) Replay(asOf time.Time) ([]CandidateState, error) {
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, err
+	}
+	return replay(events, asOf), nil
+}
+
+func replay(events []ChainEvent, asOf time.Time) []CandidateState {
+	states := make(map[string]*CandidateState)
+	var order []string
+	for _, e := range events {
+		if e.CandidateID == "" || (!asOf.IsZero() && e.Timestamp.After(asOf)) {
+			continue
+		}
+		s, ok := states[e.CandidateID]
+		if !ok {
+			s = &CandidateState{CandidateID: e.CandidateID, AddedAt: e.Timestamp}
+			states[e.CandidateID] = s
+			order = append(order, e.CandidateID)
+		}
+		s.Events++
+		s.UpdatedAt = e.Timestamp
+		if e.ToStatus != "" {
+			s.Status = e.ToStatus
+		}
+
+		switch e.Operation {
+		case "add":
+			s.AddedAt = e.Timestamp
+			s.Tier = e.Tier
+			if e.Entry != nil {
+				s.Snapshot = e.Entry
+				s.AddedAt = e.Entry.AddedAt
+				if s.Tier == "" {
+					s.Tier = e.Entry.Candidate.Tier
+				}
+			}
+		case "approve", "reject", "merge":
+			s.Review = &types.HumanReview{
+				Reviewed:   true,
+				Approved:   e.Operation == "approve",
+				Reviewer:   e.Reviewer,
+				Notes:      e.Reason,
+				ReviewedAt: e.Timestamp,
+			}
+		case "promote":
+			s.ArtifactPath = e.ArtifactPath
+		}
+	}
+
+	out := make([]CandidateState, 0, len(order))
+	for _, id := range order {
+		out = append(out, *states[id])
+	}
+	return out
+}
+
+// ListAsOf returns the entries that were in the pool at asOf, with the
+// status they had then. Candidate details come from the add event's
+// snapshot or, for candidates added before snapshots were recorded, the
+// candidate's current entry.
+func (p *Pool) ListAsOf(asOf time.Time, opts ListOptions) (*ListResult, error) {
+	states, err := p.Replay(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []PoolEntry
+	for _, s := range states {
+		if s.Status == types.PoolStatusArchived {
+			continue // Promoted out of the pool
+		}
+		if opts.Status != "" && opts.Status != s.Status {
+			continue
+		}
+
+		var entry PoolEntry
+		switch {
+		case s.Snapshot != nil:
+			entry.PoolEntry = *s.Snapshot
+		default:
+			if current, gerr := p.Get(s.CandidateID); gerr == nil {
+				entry = *current
+			} else {
+				entry.Candidate = types.Candidate{ID: s.CandidateID, Tier: s.Tier}
+				entry.AddedAt = s.AddedAt
+			}
+		}
+		if opts.Tier != "" && entry.Candidate.Tier != opts.Tier {
+			continue
+		}
+		entry.Status = s.Status
+		entry.UpdatedAt = s.UpdatedAt
+		entry.HumanReview = s.Review
+		if entry.HumanReview == nil && entry.ScoringResult.GateRequired {
+			entry.HumanReview = &types.HumanReview{Reviewed: false}
+		}
+		entry.Age = asOf.Sub(entry.AddedAt)
+		entry.AgeString = formatDuration(entry.Age)
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt.After(entries[j].AddedAt)
+	})
+	total := len(entries)
+	if opts.Offset > 0 {
+		if opts.Offset >= len(entries) {
+			entries = nil
+		} else {
+			entries = entries[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+	return &ListResult{Entries: entries, Total: total}, nil
+}
+
+// Divergence actions taken or proposed by Rebuild.
+const (
+	RebuildMoved       = "moved"
+	RebuildRestored    = "restored"
+	RebuildRemoved     = "removed"
+	RebuildArtifact    = "artifact-restored"
+	RebuildUnrecovered = "unrecoverable"
+	RebuildUntracked   = "untracked"
+)
+
+// Divergence is a difference between the pool directories and the state
+// replayed from the chain.
+type Divergence struct {
+	CandidateID string           `json:"candidate_id"`
+	Expected    types.PoolStatus `json:"expected,omitempty"`
+	Actual      types.PoolStatus `json:"actual,omitempty"`
+	Path        string           `json:"path,omitempty"`
+	Action      string           `json:"action"`
+	Detail      string           `json:"detail"`
+}
+
+// statusDirs maps the pool directories to the status they hold. Validated
+// is the legacy name for staged.
+var statusDirs = []struct {
+	dir    string
+	status types.PoolStatus
+}{
+	{PendingDir, types.PoolStatusPending},
+	{StagedDir, types.PoolStatusStaged},
+	{ValidatedDir, types.PoolStatusStaged},
+	{RejectedDir, types.PoolStatusRejected},
+}
+
+// dirForStatus is the directory a candidate of status belongs in.
+func dirForStatus(status types.PoolStatus) string {
+	switch status {
+	case types.PoolStatusStaged:
+		return StagedDir
+	case types.PoolStatusRejected:
+		return RejectedDir
+	default:
+		return PendingDir
+	}
+}
+
+// Rebuild replays the chain and repairs the pool directories to match:
+// entries in the wrong directory are moved, missing entries are restored
+// from their add snapshot, leftovers of promoted candidates are removed and
+// missing artifacts are re-rendered. Entries with no chain history are
+// reported but left alone. With dryRun, divergences are reported without
+// changing anything. Repairs are one pool operation and each is recorded on
+// the chain.
+func (p *Pool) Rebuild(dryRun bool) ([]Divergence, error) {
+	var divergences []Divergence
+	err := p.update("rebuild", "", func(tx *poolTx) error {
+		events, err := p.GetChain()
+		if err != nil {
+			return err
+		}
+		states := replay(events, time.Time{})
+
+		// Every copy of every entry on disk, by candidate ID
+		found := make(map[string][]*PoolEntry)
+		for _, sd := range statusDirs {
+			dir := filepath.Join(p.PoolPath, sd.dir)
+			files, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, f := range files {
+				if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+					continue
+				}
+				path := filepath.Join(dir, f.Name())
+				entry, err := p.readEntry(path)
+				if err != nil {
+					continue
+				}
+				entry.FilePath = path
+				entry.Status = sd.status
+				id := strings.TrimSuffix(f.Name(), ".json")
+				found[id] = append(found[id], entry)
+			}
+		}
+
+		tracked := make(map[string]bool, len(states))
+		for _, s := range states {
+			tracked[s.CandidateID] = true
+			ds, err := p.reconcile(tx, s, found[s.CandidateID], dryRun)
+			if err != nil {
+				return err
+			}
+			divergences = append(divergences, ds...)
+		}
+
+		var untracked []string
+		for id := range found {
+			if !tracked[id] {
+				untracked = append(untracked, id)
+			}
+		}
+		sort.Strings(untracked)
+		for _, id := range untracked {
+			e := found[id][0]
+			divergences = append(divergences, Divergence{
+				CandidateID: id,
+				Actual:      e.Status,
+				Path:        e.FilePath,
+				Action:      RebuildUntracked,
+				Detail:      "no chain history; left in place",
+			})
+		}
+
+		// Restored entries are not in the near-duplicate index; have the
+		// next Add rebuild it
+		if !dryRun && len(tx.ops) > 0 {
+			return tx.remove(p.lshPath(lshLayoutFile))
+		}
+		return nil
+	})
+	return divergences, err
+}
+
+// reconcile repairs one candidate's files against its replayed state.
+func (p *Pool) reconcile(tx *poolTx, s CandidateState, copies []*PoolEntry, dryRun bool) ([]Divergence, error) {
+	var ds []Divergence
+	record := func(d Divergence) error {
+		ds = append(ds, d)
+		if dryRun || d.Action == RebuildUnrecovered {
+			return nil
+		}
+		return tx.event(ChainEvent{
+			Timestamp:   time.Now(),
+			Operation:   "rebuild",
+			CandidateID: s.CandidateID,
+			ToStatus:    s.Status,
+			Reason:      d.Action + ": " + d.Detail,
+		})
+	}
+
+	if s.Status == types.PoolStatusArchived {
+		for _, c := range copies {
+			if err := record(Divergence{
+				CandidateID: s.CandidateID, Expected: s.Status, Actual: c.Status, Path: c.FilePath,
+				Action: RebuildRemoved, Detail: "promoted, but still in the pool",
+			}); err != nil {
+				return nil, err
+			}
+			if !dryRun {
+				if err := tx.remove(c.FilePath); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return ds, p.reconcileArtifact(tx, s, copies, dryRun, record)
+	}
+
+	want := filepath.Join(p.PoolPath, dirForStatus(s.Status), s.CandidateID+".json")
+	var keep *PoolEntry
+	for _, c := range copies {
+		if c.Status == s.Status && (keep == nil || c.FilePath == want) {
+			keep = c
+		}
+	}
+
+	if keep == nil && len(copies) > 0 {
+		// In the wrong directory: move the most recently updated copy
+		keep = copies[0]
+		for _, c := range copies[1:] {
+			if c.UpdatedAt.After(keep.UpdatedAt) {
+				keep = c
+			}
+		}
+		from := keep.Status
+		keep.Status = s.Status
+		if s.Review != nil {
+			keep.HumanReview = s.Review
+		}
+		if err := record(Divergence{
+			CandidateID: s.CandidateID, Expected: s.Status, Actual: from, Path: keep.FilePath,
+			Action: RebuildMoved, Detail: fmt.Sprintf("moved from %s to %s", from, s.Status),
+		}); err != nil {
+			return nil, err
+		}
+		if !dryRun {
+			if err := tx.writeEntry(want, keep); err != nil {
+				return nil, err
+			}
+			if err := tx.remove(keep.FilePath); err != nil {
+				return nil, err
+			}
+		}
+		keep.FilePath = want
+	}
+
+	if keep == nil {
+		if s.Snapshot == nil {
+			return ds, record(Divergence{
+				CandidateID: s.CandidateID, Expected: s.Status, Path: want,
+				Action: RebuildUnrecovered, Detail: "missing, and its add event has no snapshot",
+			})
+		}
+		restored := p.restoreEntry(s)
+		if err := record(Divergence{
+			CandidateID: s.CandidateID, Expected: s.Status, Path: want,
+			Action: RebuildRestored, Detail: "missing; restored from its add snapshot",
+		}); err != nil {
+			return nil, err
+		}
+		if !dryRun {
+			if err := tx.writeEntry(want, restored); err != nil {
+				return nil, err
+			}
+		}
+		return ds, nil
+	}
+
+	// Stray copies in other directories
+	for _, c := range copies {
+		if c == keep || c.FilePath == keep.FilePath {
+			continue
+		}
+		if err := record(Divergence{
+			CandidateID: s.CandidateID, Expected: s.Status, Actual: c.Status, Path: c.FilePath,
+			Action: RebuildRemoved, Detail: "stray copy; the candidate is " + string(s.Status),
+		}); err != nil {
+			return nil, err
+		}
+		if !dryRun {
+			if err := tx.remove(c.FilePath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ds, nil
+}
+
+// reconcileArtifact re-renders a promoted candidate's artifact if it is
+// missing, from a leftover pool copy or the add snapshot.
+func (p *Pool) reconcileArtifact(tx *poolTx, s CandidateState, copies []*PoolEntry, dryRun bool, record func(Divergence) error) error {
+	if s.ArtifactPath == "" {
+		return nil
+	}
+	if _, err := os.Stat(s.ArtifactPath); err == nil {
+		return nil
+	}
+	if rel, err := p.relPath(s.ArtifactPath); err != nil || strings.HasPrefix(rel, "..") {
+		return record(Divergence{
+			CandidateID: s.CandidateID, Expected: s.Status, Path: s.ArtifactPath,
+			Action: RebuildUnrecovered, Detail: "artifact missing, and its path is outside the repository",
+		})
+	}
+
+	var source *PoolEntry
+	switch {
+	case len(copies) > 0:
+		source = copies[0]
+	case s.Snapshot != nil:
+		source = p.restoreEntry(s)
+	default:
+		return record(Divergence{
+			CandidateID: s.CandidateID, Expected: s.Status, Path: s.ArtifactPath,
+			Action: RebuildUnrecovered, Detail: "artifact missing, and its add event has no snapshot",
+		})
+	}
+	if err := record(Divergence{
+		CandidateID: s.CandidateID, Expected: s.Status, Path: s.ArtifactPath,
+		Action: RebuildArtifact, Detail: "promoted artifact missing; re-rendered",
+	}); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	return tx.write(s.ArtifactPath, []byte(p.renderArtifact(source)))
+}
+
+// restoreEntry rebuilds an entry from its add snapshot and replayed state.
+func (p *Pool) restoreEntry(s CandidateState) *PoolEntry {
+	entry := &PoolEntry{PoolEntry: *s.Snapshot}
+	entry.Status = s.Status
+	entry.UpdatedAt = s.UpdatedAt
+	if s.Review != nil {
+		entry.HumanReview = s.Review
+	}
+	entry.Fingerprint = Fingerprint(entry.Candidate.Content)
+	return entry
+}
@@ -0,0 +1,184 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// historyPool builds a pool where cand-a is promoted, cand-b rejected,
+// cand-c staged and cand-d pending.
+func historyPool(t *testing.T) *Pool {
+	t.Helper()
+	p := NewPool(t.TempDir())
+	for _, id := range []string{"cand-a", "cand-b", "cand-c", "cand-d"} {
+		c := types.Candidate{ID: id, Tier: types.TierSilver, Type: types.KnowledgeTypeLearning, Content: "distinct lesson about " + id}
+		if err := p.Add(c, types.Scoring{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"cand-a", "cand-c"} {
+		if err := p.Stage(id, types.TierBronze); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.Promote("cand-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reject("cand-b", "too vague", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPoolHistory(t *testing.T) {
+	p := historyPool(t)
+	events, err := p.History("cand-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, e := range events {
+		ops = append(ops, e.Operation)
+	}
+	if len(ops) != 3 || ops[0] != "add" || ops[1] != "stage" || ops[2] != "promote" {
+		t.Errorf("history = %v, want add, stage, promote", ops)
+	}
+	if events[0].Entry == nil || events[0].Entry.Candidate.Content != "distinct lesson about cand-a" {
+		t.Error("add event should snapshot the entry")
+	}
+	if _, err := p.History("cand-none"); err == nil {
+		t.Error("expected error for a candidate with no history")
+	}
+}
+
+func TestPoolReplayAndListAsOf(t *testing.T) {
+	p := historyPool(t)
+	states, err := p.Replay(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.PoolStatus{
+		"cand-a": types.PoolStatusArchived,
+		"cand-b": types.PoolStatusRejected,
+		"cand-c": types.PoolStatusStaged,
+		"cand-d": types.PoolStatusPending,
+	}
+	for _, s := range states {
+		if s.Status != want[s.CandidateID] {
+			t.Errorf("%s replayed as %s, want %s", s.CandidateID, s.Status, want[s.CandidateID])
+		}
+	}
+	if states[1].Review == nil || states[1].Review.Reviewer != "alice" {
+		t.Errorf("rejection review not replayed: %+v", states[1].Review)
+	}
+
+	// Before anything was staged, all four were pending
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterAdds := events[3].Timestamp
+	result, err := p.ListAsOf(afterAdds, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 4 {
+		t.Fatalf("as of the last add: %d entries, want 4", result.Total)
+	}
+	for _, e := range result.Entries {
+		if e.Status != types.PoolStatusPending || e.Candidate.Content == "" {
+			t.Errorf("%s as of the last add: status %s", e.Candidate.ID, e.Status)
+		}
+	}
+
+	// Now the promoted candidate has left the pool
+	result, err = p.ListAsOf(time.Now(), ListOptions{Status: types.PoolStatusRejected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Entries[0].Candidate.ID != "cand-b" {
+		t.Errorf("rejected now = %+v", result.Entries)
+	}
+}
+
+func TestPoolRebuild(t *testing.T) {
+	p := historyPool(t)
+	events, err := p.History("cand-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact := events[len(events)-1].ArtifactPath
+
+	// Knock the directories out of sync with the chain
+	pending := filepath.Join(p.PoolPath, PendingDir)
+	staged := filepath.Join(p.PoolPath, StagedDir)
+	if err := os.Rename(filepath.Join(staged, "cand-c.json"), filepath.Join(pending, "cand-c.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(pending, "cand-d.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(artifact); err != nil {
+		t.Fatal(err)
+	}
+	stray := []byte(`{"candidate":{"id":"cand-x"},"status":"pending"}`)
+	if err := os.WriteFile(filepath.Join(pending, "cand-x.json"), stray, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dry, err := p.Rebuild(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dry) != 4 {
+		t.Fatalf("dry-run divergences = %+v, want 4", dry)
+	}
+	if _, err := os.Stat(filepath.Join(pending, "cand-d.json")); !os.IsNotExist(err) {
+		t.Error("dry run should not change the pool")
+	}
+
+	divergences, err := p.Rebuild(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]string)
+	for _, d := range divergences {
+		actions[d.CandidateID] = d.Action
+	}
+	wantActions := map[string]string{
+		"cand-a": RebuildArtifact,
+		"cand-c": RebuildMoved,
+		"cand-d": RebuildRestored,
+		"cand-x": RebuildUntracked,
+	}
+	for id, action := range wantActions {
+		if actions[id] != action {
+			t.Errorf("%s: action %q, want %q", id, actions[id], action)
+		}
+	}
+
+	c, err := p.Get("cand-c")
+	if err != nil || c.Status != types.PoolStatusStaged || filepath.Dir(c.FilePath) != staged {
+		t.Errorf("cand-c not moved back to staged: %+v, %v", c, err)
+	}
+	d, err := p.Get("cand-d")
+	if err != nil || d.Candidate.Content != "distinct lesson about cand-d" {
+		t.Errorf("cand-d not restored: %+v, %v", d, err)
+	}
+	if _, err := os.Stat(artifact); err != nil {
+		t.Errorf("artifact not restored: %v", err)
+	}
+
+	// Rebuilding again finds only the untracked entry
+	again, err := p.Rebuild(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 1 || again[0].Action != RebuildUntracked {
+		t.Errorf("second rebuild = %+v, want only the untracked entry", again)
+	}
+}
@@ -0,0 +1,317 @@
+package pool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// JournalFile is the write-ahead journal of the pool operation in
+	// progress. It exists only between an operation's commit and the end of
+	// its apply, so finding it means an operation was interrupted.
+	JournalFile = "journal.json"
+
+	// tempPrefix marks temporary files written by the pool; any left behind
+	// belong to an operation that never committed.
+	tempPrefix = ".pool-"
+)
+
+// Journal step kinds.
+const (
+	opWrite  = "write"
+	opRemove = "remove"
+	opAppend = "append"
+)
+
+// journalOp is one idempotent file change. Paths are relative to the
+// pool's BaseDir.
+type journalOp struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Data []byte `json:"data,omitempty"`
+
+	// Offset is the file's size before an append; replaying truncates to it
+	// first, so an append applied twice is written once.
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// journal is a committed pool operation: everything it will change.
+type journal struct {
+	ID          string      `json:"id"`
+	Operation   string      `json:"operation"`
+	CandidateID string      `json:"candidate_id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Ops         []journalOp `json:"ops"`
+}
+
+// poolTx collects the changes of one pool operation. Nothing touches disk
+// until the operation returns and the journal is committed.
+type poolTx struct {
+	p           *Pool
+	operation   string
+	candidateID string
+	ops         []journalOp
+	appendSizes map[string]int64
+}
+
+// update runs one pool operation all-or-nothing. Under the pool lock it
+// first replays any journal a crashed writer left behind, then lets fn read
+// the pool and describe its changes on tx. Those are committed by writing
+// the journal atomically, then applied; a crash after the commit is rolled
+// forward by the next writer, and a crash before it leaves no trace.
+func (p *Pool) update(operation, candidateID string, fn func(tx *poolTx) error) (err error) {
+	l, err := p.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := l.unlock(); uerr != nil && err == nil {
+			err = uerr
+		}
+	}()
+
+	if _, err := p.replayJournal(); err != nil {
+		return fmt.Errorf("recover interrupted pool operation: %w", err)
+	}
+
+	tx := &poolTx{p: p, operation: operation, candidateID: candidateID, appendSizes: make(map[string]int64)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	j := &journal{
+		ID:          newJournalID(),
+		Operation:   operation,
+		CandidateID: candidateID,
+		CreatedAt:   time.Now(),
+		Ops:         tx.ops,
+	}
+	if err := p.commitJournal(j); err != nil {
+		return fmt.Errorf("commit %s: %w", operation, err)
+	}
+	if err := p.applyJournal(j); err != nil {
+		return fmt.Errorf("apply %s: %w", operation, err)
+	}
+	return nil
+}
+
+// write schedules an atomic write of data to path.
+func (tx *poolTx) write(path string, data []byte) error {
+	rel, err := tx.p.relPath(path)
+	if err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, journalOp{Kind: opWrite, Path: rel, Data: data})
+	return nil
+}
+
+// writeEntry schedules writing a pool entry as JSON.
+func (tx *poolTx) writeEntry(path string, entry *PoolEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return tx.write(path, data)
+}
+
+// remove schedules removing path.
+func (tx *poolTx) remove(path string) error {
+	rel, err := tx.p.relPath(path)
+	if err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, journalOp{Kind: opRemove, Path: rel})
+	return nil
+}
+
+// event schedules appending a chain event.
+func (tx *poolTx) event(event ChainEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return tx.append(filepath.Join(tx.p.PoolPath, ChainFile), data)
+}
+
+// append schedules appending data as a line to path.
+func (tx *poolTx) append(path string, data []byte) error {
+	rel, err := tx.p.relPath(path)
+	if err != nil {
+		return err
+	}
+
+	offset, ok := tx.appendSizes[rel]
+	if !ok {
+		if info, err := os.Stat(path); err == nil {
+			offset = info.Size()
+		}
+	}
+	data = append(data, '\n')
+	tx.appendSizes[rel] = offset + int64(len(data))
+	tx.ops = append(tx.ops, journalOp{Kind: opAppend, Path: rel, Data: data, Offset: offset})
+	return nil
+}
+
+// relPath makes path relative to BaseDir for the journal.
+func (p *Pool) relPath(path string) (string, error) {
+	rel, err := filepath.Rel(p.BaseDir, path)
+	if err != nil {
+		return "", fmt.Errorf("journal path %s: %w", path, err)
+	}
+	return rel, nil
+}
+
+// commitJournal durably records j. Once the rename lands the operation is
+// decided and will be applied, now or by recovery.
+func (p *Pool) commitJournal(j *journal) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(p.PoolPath, JournalFile), data)
+}
+
+// applyJournal performs j's steps and retires the journal. Every step is
+// idempotent, so a partially applied journal can be applied again.
+func (p *Pool) applyJournal(j *journal) error {
+	for _, op := range j.Ops {
+		path := filepath.Join(p.BaseDir, op.Path)
+		switch op.Kind {
+		case opWrite:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := writeFileAtomic(path, op.Data); err != nil {
+				return err
+			}
+		case opRemove:
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		case opAppend:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := appendAt(path, op.Data, op.Offset); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown journal step %q", op.Kind)
+		}
+	}
+	return os.Remove(filepath.Join(p.PoolPath, JournalFile))
+}
+
+// replayJournal finishes an operation interrupted by a crash. A committed
+// journal is rolled forward; temporary files from an operation that died
+// before committing are discarded, rolling it back. The caller must hold the
+// pool lock. It reports whether a journal was replayed.
+func (p *Pool) replayJournal() (bool, error) {
+	p.removeTempFiles()
+
+	data, err := os.ReadFile(filepath.Join(p.PoolPath, JournalFile))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		// Journals are renamed into place whole, so this is not a torn
+		// write; refuse to guess rather than lose the operation
+		return false, fmt.Errorf("corrupt journal %s: %w", JournalFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "Recovering interrupted pool %s of %s\n", j.Operation, j.CandidateID)
+	return true, p.applyJournal(&j)
+}
+
+// removeTempFiles deletes uncommitted temporary files in the pool.
+func (p *Pool) removeTempFiles() {
+	dirs := []string{
+		p.PoolPath,
+		filepath.Join(p.PoolPath, PendingDir),
+		filepath.Join(p.PoolPath, StagedDir),
+		filepath.Join(p.PoolPath, ValidatedDir),
+		filepath.Join(p.PoolPath, RejectedDir),
+		filepath.Join(p.PoolPath, LSHDir),
+	}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), tempPrefix) {
+				_ = os.Remove(filepath.Join(dir, e.Name())) //nolint:errcheck // best-effort rollback
+			}
+		}
+	}
+}
+
+// writeFileAtomic writes data to a temp file beside path, syncs it and
+// renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()        //nolint:errcheck // cleanup in error path
+		_ = os.Remove(tmpPath) //nolint:errcheck // cleanup in error path
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()        //nolint:errcheck // cleanup in error path
+		_ = os.Remove(tmpPath) //nolint:errcheck // cleanup in error path
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath) //nolint:errcheck // cleanup in error path
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) //nolint:errcheck // cleanup in error path
+		return fmt.Errorf("rename to destination: %w", err)
+	}
+	return nil
+}
+
+// appendAt writes data at offset, truncating anything after it.
+func appendAt(path string, data []byte, offset int64) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, offset); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// newJournalID returns a random journal identifier.
+func newJournalID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) //nolint:errcheck // crypto/rand does not fail on supported platforms
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,321 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func addTestCandidate(t *testing.T, p *Pool, id string, tier types.Tier) {
+	t.Helper()
+	if err := p.Add(types.Candidate{ID: id, Tier: tier, Type: types.KnowledgeTypeLearning, Content: "content of " + id}, types.Scoring{}); err != nil {
+		t.Fatalf("Add %s failed: %v", id, err)
+	}
+}
+
+func TestPoolConcurrentApproveSingleWinner(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addTestCandidate(t, p, "contended", types.TierBronze)
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- p.Approve("contended", "ok", fmt.Sprintf("reviewer-%d", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	approved := 0
+	for err := range errs {
+		if err == nil {
+			approved++
+		}
+	}
+	if approved != 1 {
+		t.Errorf("expected exactly 1 approval to win, got %d", approved)
+	}
+}
+
+func TestPoolConcurrentOperationsLoseNothing(t *testing.T) {
+	p := NewPool(t.TempDir())
+
+	const n = 40
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("cand-%02d", i)
+			if err := p.Add(types.Candidate{ID: id, Tier: types.TierSilver}, types.Scoring{}); err != nil {
+				t.Errorf("Add %s: %v", id, err)
+				return
+			}
+			var err error
+			if i%2 == 0 {
+				err = p.Stage(id, types.TierBronze)
+			} else {
+				err = p.Reject(id, "no", "tester")
+			}
+			if err != nil {
+				t.Errorf("transition %s: %v", id, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	staged, err := p.List(ListOptions{Status: types.PoolStatusStaged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := p.List(ListOptions{Status: types.PoolStatusRejected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := p.List(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staged) != n/2 || len(rejected) != n/2 || len(all) != n {
+		t.Errorf("staged=%d rejected=%d total=%d, want %d/%d/%d", len(staged), len(rejected), len(all), n/2, n/2, n)
+	}
+
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2*n {
+		t.Errorf("expected %d chain events, got %d", 2*n, len(events))
+	}
+}
+
+// TestPoolHelperProcess is run as a subprocess by TestPoolMultiProcess.
+func TestPoolHelperProcess(t *testing.T) {
+	dir := os.Getenv("AO_POOL_HELPER_DIR")
+	if dir == "" {
+		t.Skip("helper process only")
+	}
+	worker := os.Getenv("AO_POOL_HELPER_WORKER")
+	count, _ := strconv.Atoi(os.Getenv("AO_POOL_HELPER_COUNT"))
+
+	p := NewPool(dir)
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("proc-%s-%02d", worker, i)
+		if err := p.Add(types.Candidate{ID: id, Tier: types.TierGold}, types.Scoring{}); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+		if err := p.Stage(id, types.TierBronze); err != nil {
+			t.Fatalf("Stage %s: %v", id, err)
+		}
+	}
+}
+
+func TestPoolMultiProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns subprocesses")
+	}
+	dir := t.TempDir()
+	const procs, count = 4, 15
+
+	var wg sync.WaitGroup
+	for w := 0; w < procs; w++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestPoolHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"AO_POOL_HELPER_DIR="+dir,
+			"AO_POOL_HELPER_WORKER="+strconv.Itoa(w),
+			"AO_POOL_HELPER_COUNT="+strconv.Itoa(count),
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("helper process failed: %v\n%s", err, out)
+			}
+		}()
+	}
+	wg.Wait()
+
+	p := NewPool(dir)
+	staged, err := p.List(ListOptions{Status: types.PoolStatusStaged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staged) != procs*count {
+		t.Errorf("expected %d staged entries, got %d", procs*count, len(staged))
+	}
+	pending, _ := p.List(ListOptions{Status: types.PoolStatusPending})
+	if len(pending) != 0 {
+		t.Errorf("expected no entries left pending, got %d", len(pending))
+	}
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2*procs*count {
+		t.Errorf("expected %d chain events, got %d", 2*procs*count, len(events))
+	}
+}
+
+func TestPoolReplaysCommittedJournal(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addTestCandidate(t, p, "crashed", types.TierSilver)
+	addTestCandidate(t, p, "bystander", types.TierSilver)
+
+	// Simulate a Stage that committed its journal and applied only its
+	// first step (the staged write) before crashing
+	pendingPath := filepath.Join(p.PoolPath, PendingDir, "crashed.json")
+	stagedPath := filepath.Join(p.PoolPath, StagedDir, "crashed.json")
+	entry, err := p.Get("crashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Status = types.PoolStatusStaged
+	tx := &poolTx{p: p, appendSizes: make(map[string]int64)}
+	if err := tx.writeEntry(stagedPath, entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.remove(pendingPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.event(ChainEvent{Operation: "stage", CandidateID: "crashed"}); err != nil {
+		t.Fatal(err)
+	}
+	j := &journal{ID: "test", Operation: "stage", CandidateID: "crashed", Ops: tx.ops}
+	if err := p.commitJournal(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.applyJournal(&journal{Ops: tx.ops[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	// applyJournal retires the journal; put the committed one back
+	if err := p.commitJournal(j); err != nil {
+		t.Fatal(err)
+	}
+
+	// A temp file from an operation that died before committing
+	orphan := filepath.Join(p.PoolPath, PendingDir, tempPrefix+"orphan.tmp")
+	if err := os.WriteFile(orphan, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next operation recovers first
+	if err := p.Approve("bystander", "ok", "tester"); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if _, err := os.Stat(pendingPath); !os.IsNotExist(err) {
+		t.Error("pending copy should be removed by roll-forward")
+	}
+	got, err := p.Get("crashed")
+	if err != nil || got.Status != types.PoolStatusStaged {
+		t.Errorf("crashed candidate = %+v, %v; want staged", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(p.PoolPath, JournalFile)); !os.IsNotExist(err) {
+		t.Error("journal should be removed after replay")
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("uncommitted temp file should be rolled back")
+	}
+
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stages int
+	for _, e := range events {
+		if e.Operation == "stage" {
+			stages++
+		}
+	}
+	if stages != 1 || len(events) != 4 {
+		t.Errorf("chain has %d events (%d stage), want 4 with 1 stage", len(events), stages)
+	}
+}
+
+func TestPoolAppendReplayIsIdempotent(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addTestCandidate(t, p, "once", types.TierSilver)
+
+	tx := &poolTx{p: p, appendSizes: make(map[string]int64)}
+	if err := tx.event(ChainEvent{Operation: "approve", CandidateID: "once"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := p.commitJournal(&journal{Ops: tx.ops}); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.applyJournal(&journal{Ops: tx.ops}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected add + one approve, got %d events", len(events))
+	}
+}
+
+func TestPoolLockTimeoutAndStaleLock(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.LockTimeout = 50 * time.Millisecond
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(p.PoolPath, LockFile)
+
+	// Hold the lock from another descriptor as this (live) process
+	held, err := p.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.lock(); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("second lock = %v, want ErrLockTimeout", err)
+	}
+	if err := p.Approve("missing", "", "tester"); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Approve while locked = %v, want ErrLockTimeout", err)
+	}
+
+	// Record a dead holder: the lock is stale and gets broken
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Skip("cannot spawn a process to get a dead pid")
+	}
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(lockHolder{PID: dead.Process.Pid, Host: host, AcquiredAt: time.Now()})
+	if err := held.f.Truncate(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := held.f.WriteAt(data, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := p.lock()
+	if err != nil {
+		t.Fatalf("lock over stale holder: %v", err)
+	}
+	if !sameFile(l.f, lockPath) || sameFile(held.f, lockPath) {
+		t.Error("stale lock file should have been replaced")
+	}
+	if err := l.unlock(); err != nil {
+		t.Error(err)
+	}
+	_ = syscall.Flock(int(held.f.Fd()), syscall.LOCK_UN) //nolint:errcheck // test cleanup
+	_ = held.f.Close()                                   //nolint:errcheck // test cleanup
+}
@@ -0,0 +1,162 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	// LockFile is the advisory lock serialising pool writers.
+	LockFile = ".lock"
+
+	// DefaultLockTimeout is how long a writer waits for the pool lock.
+	DefaultLockTimeout = 30 * time.Second
+
+	// lockPollInterval is how often a waiting writer retries the lock.
+	lockPollInterval = 10 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when the pool lock cannot be acquired in time.
+var ErrLockTimeout = errors.New("timed out waiting for pool lock")
+
+// lockHolder identifies the process holding the pool lock. It is written
+// into the lock file for diagnostics and stale-lock detection.
+type lockHolder struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// poolLock is a held advisory lock on .agents/pool/.lock.
+type poolLock struct {
+	f *os.File
+}
+
+// lock takes the pool's exclusive lock, waiting up to p.LockTimeout. The
+// lock is an flock on a dedicated file, so it is released by the kernel if
+// the holder dies. If the wait times out and the recorded holder is a
+// process on this host that no longer exists (the lock was left behind by
+// an inherited descriptor, or the filesystem does not release flocks), the
+// lock file is treated as stale, removed, and the lock retried once.
+func (p *Pool) lock() (*poolLock, error) {
+	if err := os.MkdirAll(p.PoolPath, 0700); err != nil {
+		return nil, fmt.Errorf("create pool directory: %w", err)
+	}
+	timeout := p.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	path := filepath.Join(p.PoolPath, LockFile)
+
+	l, err := tryLock(path, timeout)
+	if !errors.Is(err, ErrLockTimeout) {
+		return l, err
+	}
+
+	holder := readLockHolder(path)
+	if holder == nil || !holder.stale() {
+		if holder != nil {
+			return nil, fmt.Errorf("%w (held by pid %d on %s since %s)", ErrLockTimeout, holder.PID, holder.Host, holder.AcquiredAt.Format(time.RFC3339))
+		}
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: removing stale pool lock held by dead pid %d\n", holder.PID)
+	if rerr := os.Remove(path); rerr != nil && !os.IsNotExist(rerr) {
+		return nil, fmt.Errorf("remove stale lock: %w", rerr)
+	}
+	return tryLock(path, timeout)
+}
+
+// tryLock polls for an exclusive flock on path until timeout.
+func tryLock(path string, timeout time.Duration) (*poolLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			// The file may have been replaced (a stale lock removed) between
+			// open and flock; the lock only counts on the current file
+			if sameFile(f, path) {
+				l := &poolLock{f: f}
+				l.record()
+				return l, nil
+			}
+			_ = f.Close() //nolint:errcheck // retrying on the new file
+			continue
+		}
+		_ = f.Close() //nolint:errcheck // not holding the lock
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("lock pool: %w", err)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// sameFile reports whether the open file f is still the file at path.
+func sameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, pi)
+}
+
+// record writes the holder's identity into the lock file.
+func (l *poolLock) record() {
+	host, _ := os.Hostname() //nolint:errcheck // diagnostic only
+	data, err := json.Marshal(lockHolder{PID: os.Getpid(), Host: host, AcquiredAt: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := l.f.Truncate(0); err != nil {
+		return
+	}
+	_, _ = l.f.WriteAt(data, 0) //nolint:errcheck // diagnostic only
+}
+
+// unlock releases the lock.
+func (l *poolLock) unlock() error {
+	_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN) //nolint:errcheck // closing releases it too
+	return l.f.Close()
+}
+
+// readLockHolder returns the holder recorded in the lock file, if any.
+func readLockHolder(path string) *lockHolder {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var h lockHolder
+	if err := json.Unmarshal(data, &h); err != nil || h.PID <= 0 {
+		return nil
+	}
+	return &h
+}
+
+// stale reports whether the holder is a process on this host that has
+// exited.
+func (h *lockHolder) stale() bool {
+	host, err := os.Hostname()
+	if err != nil || host != h.Host {
+		return false
+	}
+	err = syscall.Kill(h.PID, 0)
+	return errors.Is(err, syscall.ESRCH)
+}
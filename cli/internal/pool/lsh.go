@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LSHDir holds the pool's near-duplicate index: one file per fingerprint
+// band bucket listing the candidates whose band hashes into it, so Add
+// compares a new candidate only with those sharing a band instead of
+// reading the whole pool.
+const LSHDir = "lsh"
+
+const (
+	// lshBands splits a fingerprint into bands of lshRows values. Two
+	// candidates at 0.6 similarity share a band with probability 0.9999;
+	// unrelated ones at 0.1 with 0.27.
+	lshBands = 32
+	lshRows  = FingerprintSize / lshBands
+
+	// lshLayoutFile records the band layout the index was built with. An
+	// index without it, or with another layout, is rebuilt on the next Add.
+	lshLayoutFile = "layout"
+)
+
+var lshLayout = fmt.Sprintf("%d bands of %d\n", lshBands, lshRows)
+
+// bandKeys returns the names of the buckets fp falls in, one per band.
+func bandKeys(fp []uint32) []string {
+	if len(fp) != FingerprintSize {
+		return nil
+	}
+	keys := make([]string, 0, lshBands)
+	var buf [4]byte
+	for b := 0; b < lshBands; b++ {
+		h := fnv.New64a()
+		for _, v := range fp[b*lshRows : (b+1)*lshRows] {
+			binary.LittleEndian.PutUint32(buf[:], v)
+			_, _ = h.Write(buf[:]) //nolint:errcheck // hash writes do not fail
+		}
+		keys = append(keys, fmt.Sprintf("%02d-%016x", b, h.Sum64()))
+	}
+	return keys
+}
+
+// lshPath returns the path of an index file.
+func (p *Pool) lshPath(name string) string {
+	return filepath.Join(p.PoolPath, LSHDir, name)
+}
+
+// lshIndexed reports whether the index exists and has the current layout.
+func (p *Pool) lshIndexed() bool {
+	data, err := os.ReadFile(p.lshPath(lshLayoutFile))
+	return err == nil && string(data) == lshLayout
+}
+
+// lshCandidates returns the IDs in fp's buckets. They may include
+// candidates that have since left the pool.
+func (p *Pool) lshCandidates(fp []uint32) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, key := range bandKeys(fp) {
+		data, err := os.ReadFile(p.lshPath(key))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range strings.Fields(string(data)) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// indexEntry schedules adding an entry to the index, building the index
+// from the live entries first if the pool has none yet.
+func (tx *poolTx) indexEntry(entry *PoolEntry) error {
+	if !tx.p.lshIndexed() {
+		live, err := tx.p.liveEntries()
+		if err != nil {
+			return err
+		}
+		return tx.rebuildIndex(append(live, entry))
+	}
+	for _, key := range bandKeys(entry.Fingerprint) {
+		if err := tx.append(tx.p.lshPath(key), []byte(entry.Candidate.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildIndex schedules rewriting the index to hold exactly entries,
+// dropping candidates that have left the pool.
+func (tx *poolTx) rebuildIndex(entries []*PoolEntry) error {
+	buckets := make(map[string][]string)
+	for _, e := range entries {
+		for _, key := range bandKeys(e.Fingerprint) {
+			buckets[key] = append(buckets[key], e.Candidate.ID)
+		}
+	}
+
+	files, err := os.ReadDir(filepath.Join(tx.p.PoolPath, LSHDir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, f := range files {
+		name := f.Name()
+		if _, ok := buckets[name]; ok || name == lshLayoutFile || strings.HasPrefix(name, tempPrefix) {
+			continue
+		}
+		if err := tx.remove(tx.p.lshPath(name)); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := tx.write(tx.p.lshPath(key), []byte(strings.Join(buckets[key], "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	return tx.write(tx.p.lshPath(lshLayoutFile), []byte(lshLayout))
+}
@@ -0,0 +1,402 @@
+package pool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const (
+	// PolicyDir holds repository policy files, relative to the base dir.
+	PolicyDir = ".agents/policy"
+
+	// PromotionPolicyFile is the promotion policy within PolicyDir.
+	PromotionPolicyFile = "promotion.yaml"
+
+	// approachingWindow is how long before a promote rule's min_age a
+	// candidate is flagged as approaching auto-promotion.
+	approachingWindow = 2 * time.Hour
+)
+
+// PolicyAction is what a promotion rule decides.
+type PolicyAction string
+
+const (
+	// PolicyPromote makes a candidate eligible for automatic approval and
+	// promotion.
+	PolicyPromote PolicyAction = "promote"
+
+	// PolicyHold keeps a candidate in the pool for human review.
+	PolicyHold PolicyAction = "hold"
+)
+
+// PromotionPolicy decides which pool candidates may be promoted without a
+// human. Rules are evaluated in order and the first whose conditions all hold
+// decides; if none does, Default applies.
+type PromotionPolicy struct {
+	Version int          `yaml:"version"`
+	Rules   []PolicyRule `yaml:"rules"`
+	Default PolicyAction `yaml:"default,omitempty"`
+
+	// Source is the file the policy was loaded from, or "built-in default".
+	Source string `yaml:"-"`
+}
+
+// PolicyRule is one named rule of a promotion policy.
+type PolicyRule struct {
+	Name   string           `yaml:"name"`
+	Action PolicyAction     `yaml:"action"`
+	When   PolicyConditions `yaml:"when,omitempty"`
+}
+
+// PolicyConditions are the tests a rule applies. Unset conditions always
+// pass, so a rule with none matches every candidate.
+type PolicyConditions struct {
+	// Tiers and Types restrict the rule to candidates of these tiers and
+	// knowledge types.
+	Tiers []types.Tier          `yaml:"tier,omitempty"`
+	Types []types.KnowledgeType `yaml:"type,omitempty"`
+
+	// MinAge is how long the candidate must have been in the pool.
+	MinAge time.Duration `yaml:"min_age,omitempty"`
+
+	// MinCitations is how often the candidate must have been cited.
+	MinCitations int `yaml:"min_citations,omitempty"`
+
+	// MinUtility and MinScore bound the candidate's utility and raw score.
+	MinUtility float64 `yaml:"min_utility,omitempty"`
+	MinScore   float64 `yaml:"min_score,omitempty"`
+
+	// MinSessionReward is the lowest acceptable reward of the session the
+	// candidate was extracted from; candidates whose session has no recorded
+	// outcome fail it.
+	MinSessionReward float64 `yaml:"min_session_reward,omitempty"`
+
+	// AwaitingReview matches candidates that are (true) or are not (false)
+	// gated on a human review that has not approved them yet.
+	AwaitingReview *bool `yaml:"awaiting_review,omitempty"`
+}
+
+// PolicyFacts are what the pool cannot tell about a candidate on its own.
+type PolicyFacts struct {
+	// Citations is how often the candidate has been cited.
+	Citations int
+
+	// SessionReward is the reward of the candidate's source session, nil if
+	// none has been recorded.
+	SessionReward *float64
+}
+
+// PolicyCheck is the outcome of one condition.
+type PolicyCheck struct {
+	Condition string `json:"condition"`
+	Pass      bool   `json:"pass"`
+	Detail    string `json:"detail"`
+}
+
+// RuleResult is how one rule fared against a candidate.
+type RuleResult struct {
+	Rule    string        `json:"rule"`
+	Action  PolicyAction  `json:"action"`
+	Matched bool          `json:"matched"`
+	Checks  []PolicyCheck `json:"checks"`
+}
+
+// PolicyDecision is the verdict of a policy on one candidate.
+type PolicyDecision struct {
+	CandidateID string       `json:"candidate_id"`
+	Action      PolicyAction `json:"action"`
+
+	// Rule is the rule that fired, empty if the default applied.
+	Rule string `json:"rule,omitempty"`
+
+	// Results has every rule's evaluation, in policy order.
+	Results []RuleResult `json:"results"`
+
+	Source string `json:"source"`
+}
+
+// Promote reports whether the candidate may be promoted automatically.
+func (d PolicyDecision) Promote() bool {
+	return d.Action == PolicyPromote
+}
+
+// Reason summarises the decision in one line. When no rule fired it lists
+// what kept each promote rule from firing.
+func (d PolicyDecision) Reason() string {
+	if d.Rule != "" {
+		return fmt.Sprintf("rule %q: %s", d.Rule, d.Action)
+	}
+
+	var misses []string
+	for _, r := range d.Results {
+		if r.Action != PolicyPromote {
+			continue
+		}
+		var fails []string
+		for _, c := range r.Checks {
+			if !c.Pass {
+				fails = append(fails, c.Detail)
+			}
+		}
+		misses = append(misses, fmt.Sprintf("%s: %s", r.Rule, strings.Join(fails, ", ")))
+	}
+	if len(misses) == 0 {
+		return fmt.Sprintf("no rule matched (default %s)", d.Action)
+	}
+	return fmt.Sprintf("no rule matched (default %s); %s", d.Action, strings.Join(misses, "; "))
+}
+
+// DefaultPromotionPolicy returns the policy used when a repository has no
+// promotion.yaml. It holds human-gated candidates, lets silver and gold
+// candidates through after 24h, and any other candidate once it has been
+// cited and has settled for 24h.
+func DefaultPromotionPolicy() *PromotionPolicy {
+	gated := true
+	return &PromotionPolicy{
+		Version: 1,
+		Rules: []PolicyRule{
+			{
+				Name:   "human-gate",
+				Action: PolicyHold,
+				When:   PolicyConditions{AwaitingReview: &gated},
+			},
+			{
+				Name:   "settled-high-tier",
+				Action: PolicyPromote,
+				When: PolicyConditions{
+					Tiers:  []types.Tier{types.TierGold, types.TierSilver},
+					MinAge: 24 * time.Hour,
+				},
+			},
+			{
+				Name:   "cited",
+				Action: PolicyPromote,
+				When:   PolicyConditions{MinAge: 24 * time.Hour, MinCitations: 1},
+			},
+		},
+		Default: PolicyHold,
+		Source:  "built-in default",
+	}
+}
+
+// PromotionPolicyPath returns where a repository's promotion policy lives.
+func PromotionPolicyPath(baseDir string) string {
+	return filepath.Join(baseDir, PolicyDir, PromotionPolicyFile)
+}
+
+// LoadPromotionPolicy reads baseDir's promotion policy, falling back to
+// DefaultPromotionPolicy if the repository has none.
+func LoadPromotionPolicy(baseDir string) (*PromotionPolicy, error) {
+	path := PromotionPolicyPath(baseDir)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultPromotionPolicy(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read promotion policy: %w", err)
+	}
+
+	policy, err := ParsePromotionPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	policy.Source = path
+	return policy, nil
+}
+
+// ParsePromotionPolicy decodes and validates a policy document. Unknown
+// fields are errors so a misspelt condition cannot silently match everything.
+func ParsePromotionPolicy(data []byte) (*PromotionPolicy, error) {
+	var policy PromotionPolicy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse promotion policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks a policy's rules and fills in defaults.
+func (pol *PromotionPolicy) Validate() error {
+	if pol.Version == 0 {
+		pol.Version = 1
+	}
+	if pol.Version != 1 {
+		return fmt.Errorf("unsupported promotion policy version %d", pol.Version)
+	}
+	if pol.Default == "" {
+		pol.Default = PolicyHold
+	}
+	if !validAction(pol.Default) {
+		return fmt.Errorf("invalid default action %q (want promote or hold)", pol.Default)
+	}
+
+	seen := make(map[string]bool, len(pol.Rules))
+	for i, r := range pol.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		seen[r.Name] = true
+		if !validAction(r.Action) {
+			return fmt.Errorf("rule %q: invalid action %q (want promote or hold)", r.Name, r.Action)
+		}
+		for _, t := range r.When.Tiers {
+			if !validTier(t) {
+				return fmt.Errorf("rule %q: unknown tier %q", r.Name, t)
+			}
+		}
+		if r.When.MinAge < 0 || r.When.MinCitations < 0 {
+			return fmt.Errorf("rule %q: min_age and min_citations must not be negative", r.Name)
+		}
+	}
+	return nil
+}
+
+func validAction(a PolicyAction) bool {
+	return a == PolicyPromote || a == PolicyHold
+}
+
+func validTier(t types.Tier) bool {
+	switch t {
+	case types.TierGold, types.TierSilver, types.TierBronze, types.TierDiscard:
+		return true
+	}
+	return false
+}
+
+// Evaluate runs every rule against entry and returns the decision of the
+// first that matches.
+func (pol *PromotionPolicy) Evaluate(entry PoolEntry, facts PolicyFacts) PolicyDecision {
+	d := PolicyDecision{
+		CandidateID: entry.Candidate.ID,
+		Action:      pol.Default,
+		Source:      pol.Source,
+	}
+	for _, r := range pol.Rules {
+		checks := r.When.check(entry, facts)
+		matched := true
+		for _, c := range checks {
+			matched = matched && c.Pass
+		}
+		d.Results = append(d.Results, RuleResult{Rule: r.Name, Action: r.Action, Matched: matched, Checks: checks})
+		if matched && d.Rule == "" {
+			d.Rule, d.Action = r.Name, r.Action
+		}
+	}
+	return d
+}
+
+// check evaluates each set condition.
+func (c PolicyConditions) check(entry PoolEntry, facts PolicyFacts) []PolicyCheck {
+	var checks []PolicyCheck
+	add := func(condition string, pass bool, format string, args ...interface{}) {
+		checks = append(checks, PolicyCheck{Condition: condition, Pass: pass, Detail: fmt.Sprintf(format, args...)})
+	}
+	cand := entry.Candidate
+
+	if len(c.Tiers) > 0 {
+		add("tier", containsTier(c.Tiers, cand.Tier), "tier %s (want %v)", cand.Tier, c.Tiers)
+	}
+	if len(c.Types) > 0 {
+		ok := false
+		for _, t := range c.Types {
+			ok = ok || t == cand.Type
+		}
+		add("type", ok, "type %s (want %v)", cand.Type, c.Types)
+	}
+	if c.MinAge > 0 {
+		add("min_age", entry.Age >= c.MinAge, "age %s (min %s)", formatDuration(entry.Age), policyDuration(c.MinAge))
+	}
+	if c.MinCitations > 0 {
+		add("min_citations", facts.Citations >= c.MinCitations, "citations %d (min %d)", facts.Citations, c.MinCitations)
+	}
+	if c.MinUtility > 0 {
+		add("min_utility", cand.Utility >= c.MinUtility, "utility %.2f (min %.2f)", cand.Utility, c.MinUtility)
+	}
+	if c.MinScore > 0 {
+		add("min_score", entry.ScoringResult.RawScore >= c.MinScore, "score %.2f (min %.2f)", entry.ScoringResult.RawScore, c.MinScore)
+	}
+	if c.MinSessionReward > 0 {
+		if facts.SessionReward == nil {
+			add("min_session_reward", false, "no recorded outcome for session %q", cand.Source.SessionID)
+		} else {
+			add("min_session_reward", *facts.SessionReward >= c.MinSessionReward, "session reward %.2f (min %.2f)", *facts.SessionReward, c.MinSessionReward)
+		}
+	}
+	if c.AwaitingReview != nil {
+		awaiting := awaitingReview(entry)
+		add("awaiting_review", awaiting == *c.AwaitingReview, "awaiting review: %t", awaiting)
+	}
+	return checks
+}
+
+// policyDuration formats a configured duration the way policies write it.
+func policyDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return d.String()
+}
+
+// awaitingReview reports whether entry is gated on a human who has not
+// approved it.
+func awaitingReview(entry PoolEntry) bool {
+	if !entry.ScoringResult.GateRequired {
+		return false
+	}
+	return entry.HumanReview == nil || !entry.HumanReview.Approved
+}
+
+func containsTier(tiers []types.Tier, tier types.Tier) bool {
+	for _, t := range tiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// approaching reports whether entry is within approachingWindow of the
+// min_age of a promote rule that names its tier.
+func (pol *PromotionPolicy) approaching(entry PoolEntry) bool {
+	for _, r := range pol.Rules {
+		if r.Action != PolicyPromote || r.When.MinAge <= 0 || !containsTier(r.When.Tiers, entry.Candidate.Tier) {
+			continue
+		}
+		return entry.Age > r.When.MinAge-approachingWindow
+	}
+	return false
+}
+
+// promotionPolicy returns the pool's policy, loading the repository's on
+// first use. An unreadable policy falls back to the default here; commands
+// that act on the policy load it themselves and report the error.
+func (p *Pool) promotionPolicy() *PromotionPolicy {
+	p.policyOnce.Do(func() {
+		if p.Policy != nil {
+			return
+		}
+		policy, err := LoadPromotionPolicy(p.BaseDir)
+		if err != nil {
+			policy = DefaultPromotionPolicy()
+		}
+		p.Policy = policy
+	})
+	return p.Policy
+}
@@ -0,0 +1,151 @@
+package pool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const strictPolicy = `version: 1
+rules:
+  - name: no-failures
+    action: hold
+    when:
+      type: [failure]
+  - name: proven
+    action: promote
+    when:
+      tier: [gold, silver]
+      min_age: 72h
+      min_citations: 2
+      min_session_reward: 0.7
+default: hold
+`
+
+func policyEntry(tier types.Tier, kt types.KnowledgeType, age time.Duration) PoolEntry {
+	return PoolEntry{
+		PoolEntry: types.PoolEntry{
+			Candidate: types.Candidate{ID: "cand-x", Tier: tier, Type: kt, Source: types.Source{SessionID: "s1"}},
+		},
+		Age: age,
+	}
+}
+
+func TestPromotionPolicyEvaluate(t *testing.T) {
+	policy, err := ParsePromotionPolicy([]byte(strictPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, poor := 0.8, 0.5
+
+	tests := []struct {
+		name     string
+		entry    PoolEntry
+		facts    PolicyFacts
+		wantRule string
+		want     PolicyAction
+	}{
+		{"all conditions hold", policyEntry(types.TierSilver, types.KnowledgeTypeLearning, 80*time.Hour), PolicyFacts{Citations: 2, SessionReward: &good}, "proven", PolicyPromote},
+		{"earlier rule wins", policyEntry(types.TierGold, types.KnowledgeTypeFailure, 80*time.Hour), PolicyFacts{Citations: 2, SessionReward: &good}, "no-failures", PolicyHold},
+		{"poor session", policyEntry(types.TierSilver, types.KnowledgeTypeLearning, 80*time.Hour), PolicyFacts{Citations: 2, SessionReward: &poor}, "", PolicyHold},
+		{"no session outcome", policyEntry(types.TierSilver, types.KnowledgeTypeLearning, 80*time.Hour), PolicyFacts{Citations: 2}, "", PolicyHold},
+		{"too young", policyEntry(types.TierSilver, types.KnowledgeTypeLearning, 24*time.Hour), PolicyFacts{Citations: 2, SessionReward: &good}, "", PolicyHold},
+		{"bronze", policyEntry(types.TierBronze, types.KnowledgeTypeLearning, 80*time.Hour), PolicyFacts{Citations: 2, SessionReward: &good}, "", PolicyHold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := policy.Evaluate(tt.entry, tt.facts)
+			if d.Rule != tt.wantRule || d.Action != tt.want {
+				t.Errorf("decision = %s by %q, want %s by %q (%s)", d.Action, d.Rule, tt.want, tt.wantRule, d.Reason())
+			}
+			if len(d.Results) != len(policy.Rules) {
+				t.Errorf("expected every rule evaluated, got %d results", len(d.Results))
+			}
+		})
+	}
+
+	d := policy.Evaluate(policyEntry(types.TierSilver, types.KnowledgeTypeLearning, 80*time.Hour), PolicyFacts{Citations: 1})
+	if reason := d.Reason(); !strings.Contains(reason, "citations 1 (min 2)") || !strings.Contains(reason, `no recorded outcome for session "s1"`) {
+		t.Errorf("reason %q should name the failed conditions", reason)
+	}
+}
+
+func TestParsePromotionPolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown condition": "rules:\n  - name: r\n    action: promote\n    when:\n      min_citation: 1\n",
+		"bad action":        "rules:\n  - name: r\n    action: approve\n",
+		"unnamed rule":      "rules:\n  - action: hold\n",
+		"duplicate rule":    "rules:\n  - name: r\n    action: hold\n  - name: r\n    action: promote\n",
+		"unknown tier":      "rules:\n  - name: r\n    action: promote\n    when:\n      tier: [platinum]\n",
+		"bad version":       "version: 2\n",
+	}
+	for name, doc := range tests {
+		if _, err := ParsePromotionPolicy([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadPromotionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policy, err := LoadPromotionPolicy(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Source != DefaultPromotionPolicy().Source {
+		t.Errorf("missing file should load the default, got %s", policy.Source)
+	}
+
+	// The default round-trips through YAML, so 'ao pool policy show' output
+	// is a valid starting point
+	data, err := yaml.Marshal(DefaultPromotionPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := PromotionPolicyPath(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPromotionPolicy(dir)
+	if err != nil {
+		t.Fatalf("load written default: %v\n%s", err, data)
+	}
+	if loaded.Source != path || !reflect.DeepEqual(loaded.Rules, DefaultPromotionPolicy().Rules) {
+		t.Errorf("round-tripped policy differs:\n%s", data)
+	}
+}
+
+func TestPoolApproachingUsesPolicy(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.Policy = &PromotionPolicy{
+		Rules:   []PolicyRule{{Name: "slow", Action: PolicyPromote, When: PolicyConditions{Tiers: []types.Tier{types.TierSilver}, MinAge: 48 * time.Hour}}},
+		Default: PolicyHold,
+	}
+	now := time.Now()
+	for id, age := range map[string]time.Duration{"near": 47 * time.Hour, "far": 23 * time.Hour} {
+		c := types.Candidate{ID: id, Tier: types.TierSilver, Content: "content of " + id}
+		if err := p.AddAt(c, types.Scoring{}, now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for id, want := range map[string]bool{"near": true, "far": false} {
+		e, err := p.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.ApproachingAutoPromote != want {
+			t.Errorf("%s approaching = %t, want %t", id, e.ApproachingAutoPromote, want)
+		}
+	}
+}
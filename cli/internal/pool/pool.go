@@ -4,8 +4,6 @@ package pool
 
 import (
 	"bufio"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -13,8 +11,11 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/scorer"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -73,6 +74,24 @@ type PoolEntry struct {
 
 	// ApproachingAutoPromote indicates if nearing 24h threshold.
 	ApproachingAutoPromote bool `json:"approaching_auto_promote,omitempty"`
+
+	// Fingerprint is the MinHash signature of the candidate's content.
+	Fingerprint []uint32 `json:"fingerprint,omitempty"`
+
+	// ClusterID groups near-duplicate candidates under the ID of the
+	// cluster's representative.
+	ClusterID string `json:"cluster_id,omitempty"`
+
+	// DuplicateOf is the representative this candidate near-duplicates.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	// Similarity is the estimated similarity to the nearest member of the
+	// cluster when the candidate joined it.
+	Similarity float64 `json:"similarity,omitempty"`
+
+	// ReviewSampled marks a candidate routed to human review by sampling
+	// rather than because its tier requires it.
+	ReviewSampled bool `json:"review_sampled,omitempty"`
 }
 
 // ChainEvent records a pool operation.
@@ -100,15 +119,67 @@ type ChainEvent struct {
 
 	// ArtifactPath is the destination path for promotions.
 	ArtifactPath string `json:"artifact_path,omitempty"`
+
+	// Tier is the candidate's tier, recorded on add.
+	Tier types.Tier `json:"tier,omitempty"`
+
+	// Review is why an added candidate entered the review queue
+	// (ReviewRequired or ReviewSampled), empty if it did not.
+	Review string `json:"review,omitempty"`
+
+	// SampleRate and SampleDraw record the sampling decision for an added
+	// candidate whose tier does not require review: it was sampled if the
+	// draw is below the rate.
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	SampleDraw float64 `json:"sample_draw,omitempty"`
+
+	// Features are the scorer features of a candidate at approve or reject,
+	// kept so gate decisions remain training data after promotion.
+	Features map[string]float64 `json:"features,omitempty"`
+
+	// Automated marks an approval made by a rule (bulk approval or the
+	// promotion policy) rather than decided by the reviewer.
+	Automated bool `json:"automated,omitempty"`
+
+	// Entry is a snapshot of the entry as added, recorded on add so the
+	// pool can be rebuilt from the chain.
+	Entry *types.PoolEntry `json:"entry,omitempty"`
 }
 
-// Pool manages the candidate pool.
+// Pool manages the candidate pool. Operations that change it (Add, Stage,
+// Promote, Reject, Approve) hold an exclusive lock across processes and are
+// applied through a write-ahead journal, so each is all-or-nothing; see
+// update.
 type Pool struct {
 	// BaseDir is the working directory.
 	BaseDir string
 
 	// PoolPath is the full path to .agents/pool.
 	PoolPath string
+
+	// LockTimeout bounds the wait for the pool lock (DefaultLockTimeout if
+	// zero).
+	LockTimeout time.Duration
+
+	// DuplicateThreshold is the similarity at which Add marks a candidate as
+	// a near-duplicate (DefaultDuplicateThreshold if zero).
+	DuplicateThreshold float64
+
+	// Policy is the promotion policy; if nil, the repository's is loaded on
+	// first use.
+	Policy *PromotionPolicy
+
+	// SampleSeed seeds the review sampler; the same seed samples the same
+	// candidates.
+	SampleSeed string
+
+	// TierConfigs holds each tier's review settings
+	// (taxonomy.DefaultTierConfigs if nil).
+	TierConfigs map[types.Tier]taxonomy.TierConfig
+
+	policyOnce  sync.Once
+	statsOnce   sync.Once
+	reviewStats []ReviewStats
 }
 
 // NewPool creates a new pool manager.
@@ -264,10 +335,8 @@ func (p *Pool) scanDirectory(dir string, status types.PoolStatus) ([]PoolEntry,
 		entry.Age = time.Since(entry.AddedAt)
 		entry.AgeString = formatDuration(entry.Age)
 
-		// Check if approaching 24h auto-promote threshold (warn at 22h, 2h buffer)
-		if entry.Candidate.Tier == types.TierSilver && entry.Age > 22*time.Hour {
-			entry.ApproachingAutoPromote = true
-		}
+		// Warn 2h before a promote rule's age threshold is reached
+		entry.ApproachingAutoPromote = p.promotionPolicy().approaching(*entry)
 
 		entries = append(entries, *entry)
 	}
@@ -317,6 +386,7 @@ func (p *Pool) Get(candidateID string) (*PoolEntry, error) {
 		entry.FilePath = path
 		entry.Age = time.Since(entry.AddedAt)
 		entry.AgeString = formatDuration(entry.Age)
+		entry.ApproachingAutoPromote = p.promotionPolicy().approaching(*entry)
 		return entry, nil
 	}
 
@@ -325,109 +395,101 @@ func (p *Pool) Get(candidateID string) (*PoolEntry, error) {
 
 // Stage moves a candidate from pending to staged.
 func (p *Pool) Stage(candidateID string, minTier types.Tier) error {
-	entry, err := p.Get(candidateID)
-	if err != nil {
-		return err
-	}
-
-	// Prevent staging rejected candidates
-	if entry.Status == types.PoolStatusRejected {
-		return fmt.Errorf("cannot stage rejected candidate")
-	}
-
-	// Validate tier threshold
-	if !isAboveThreshold(entry.Candidate.Tier, minTier) {
-		return fmt.Errorf("candidate tier %s below minimum %s", entry.Candidate.Tier, minTier)
-	}
+	return p.update("stage", candidateID, func(tx *poolTx) error {
+		entry, err := p.Get(candidateID)
+		if err != nil {
+			return err
+		}
 
-	// Move file atomically
-	newPath := filepath.Join(p.PoolPath, StagedDir, filepath.Base(entry.FilePath))
-	if err := atomicMove(entry.FilePath, newPath); err != nil {
-		return fmt.Errorf("move to staged: %w", err)
-	}
+		// Prevent staging rejected candidates
+		if entry.Status == types.PoolStatusRejected {
+			return fmt.Errorf("cannot stage rejected candidate")
+		}
 
-	// Update status
-	entry.Status = types.PoolStatusStaged
-	entry.UpdatedAt = time.Now()
+		// Validate tier threshold
+		if !isAboveThreshold(entry.Candidate.Tier, minTier) {
+			return fmt.Errorf("candidate tier %s below minimum %s", entry.Candidate.Tier, minTier)
+		}
 
-	// Write updated entry
-	if err := p.writeEntry(newPath, entry); err != nil {
-		return fmt.Errorf("write staged entry: %w", err)
-	}
+		// Move to staged with the updated status
+		oldPath := entry.FilePath
+		newPath := filepath.Join(p.PoolPath, StagedDir, filepath.Base(oldPath))
+		entry.Status = types.PoolStatusStaged
+		entry.UpdatedAt = time.Now()
 
-	// Record chain event
-	if err := p.recordEvent(ChainEvent{
-		Timestamp:   time.Now(),
-		Operation:   "stage",
-		CandidateID: candidateID,
-		FromStatus:  types.PoolStatusPending,
-		ToStatus:    types.PoolStatusStaged,
-	}); err != nil {
-		// Non-fatal, continue
-		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
-	}
+		if err := tx.writeEntry(newPath, entry); err != nil {
+			return fmt.Errorf("write staged entry: %w", err)
+		}
+		if newPath != oldPath {
+			if err := tx.remove(oldPath); err != nil {
+				return err
+			}
+		}
 
-	return nil
+		return tx.event(ChainEvent{
+			Timestamp:   time.Now(),
+			Operation:   "stage",
+			CandidateID: candidateID,
+			FromStatus:  types.PoolStatusPending,
+			ToStatus:    types.PoolStatusStaged,
+		})
+	})
 }
 
 // Promote moves a staged candidate to learnings/patterns.
 func (p *Pool) Promote(candidateID string) (string, error) {
-	entry, err := p.Get(candidateID)
-	if err != nil {
-		return "", err
-	}
-
-	// Prevent promoting rejected candidates
-	if entry.Status == types.PoolStatusRejected {
-		return "", fmt.Errorf("cannot promote rejected candidate")
-	}
-
-	// Determine destination based on type
-	var destDir string
-	switch entry.Candidate.Type {
-	case types.KnowledgeTypeLearning, types.KnowledgeTypeSolution:
-		destDir = filepath.Join(p.BaseDir, ".agents", "learnings")
-	case types.KnowledgeTypeDecision:
-		destDir = filepath.Join(p.BaseDir, ".agents", "patterns")
-	default:
-		destDir = filepath.Join(p.BaseDir, ".agents", "learnings")
-	}
+	var artifactPath string
+	err := p.update("promote", candidateID, func(tx *poolTx) error {
+		entry, err := p.Get(candidateID)
+		if err != nil {
+			return err
+		}
 
-	if err := os.MkdirAll(destDir, 0700); err != nil {
-		return "", fmt.Errorf("create destination: %w", err)
-	}
+		// Prevent promoting rejected candidates
+		if entry.Status == types.PoolStatusRejected {
+			return fmt.Errorf("cannot promote rejected candidate")
+		}
 
-	// Generate artifact filename
-	timestamp := time.Now().Format("2006-01-02")
-	shortID := candidateID
-	if len(shortID) > 8 {
-		shortID = shortID[:8]
-	}
-	artifactName := fmt.Sprintf("%s-%s.md", timestamp, shortID)
-	artifactPath := filepath.Join(destDir, artifactName)
+		// Determine destination based on type
+		var destDir string
+		switch entry.Candidate.Type {
+		case types.KnowledgeTypeLearning, types.KnowledgeTypeSolution:
+			destDir = filepath.Join(p.BaseDir, ".agents", "learnings")
+		case types.KnowledgeTypeDecision:
+			destDir = filepath.Join(p.BaseDir, ".agents", "patterns")
+		default:
+			destDir = filepath.Join(p.BaseDir, ".agents", "learnings")
+		}
 
-	// Write artifact as markdown
-	if err := p.writeArtifact(artifactPath, entry); err != nil {
-		return "", fmt.Errorf("write artifact: %w", err)
-	}
+		// Generate artifact filename
+		timestamp := time.Now().Format("2006-01-02")
+		shortID := candidateID
+		if len(shortID) > 8 {
+			shortID = shortID[:8]
+		}
+		artifactName := fmt.Sprintf("%s-%s.md", timestamp, shortID)
+		artifactPath = filepath.Join(destDir, artifactName)
 
-	// Remove from pool
-	if err := os.Remove(entry.FilePath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to remove pool entry: %v\n", err)
-	}
+		// Write artifact as markdown, then remove from pool
+		if err := tx.write(artifactPath, []byte(p.renderArtifact(entry))); err != nil {
+			return fmt.Errorf("write artifact: %w", err)
+		}
+		if err := tx.remove(entry.FilePath); err != nil {
+			return err
+		}
 
-	// Record chain event
-	if err := p.recordEvent(ChainEvent{
-		Timestamp:    time.Now(),
-		Operation:    "promote",
-		CandidateID:  candidateID,
-		FromStatus:   entry.Status,
-		ToStatus:     types.PoolStatusArchived,
-		ArtifactPath: artifactPath,
-	}); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
+		return tx.event(ChainEvent{
+			Timestamp:    time.Now(),
+			Operation:    "promote",
+			CandidateID:  candidateID,
+			FromStatus:   entry.Status,
+			ToStatus:     types.PoolStatusArchived,
+			ArtifactPath: artifactPath,
+		})
+	})
+	if err != nil {
+		return "", err
 	}
-
 	return artifactPath, nil
 }
 
@@ -438,106 +500,117 @@ func (p *Pool) Reject(candidateID, reason, reviewer string) error {
 		return ErrReasonTooLong
 	}
 
-	entry, err := p.Get(candidateID)
-	if err != nil {
-		return err
-	}
-
-	// Move to rejected directory atomically
-	newPath := filepath.Join(p.PoolPath, RejectedDir, filepath.Base(entry.FilePath))
-	if err := atomicMove(entry.FilePath, newPath); err != nil {
-		return fmt.Errorf("move to rejected: %w", err)
-	}
-
-	// Update entry
-	entry.Status = types.PoolStatusRejected
-	entry.UpdatedAt = time.Now()
-	entry.HumanReview = &types.HumanReview{
-		Reviewed:   true,
-		Approved:   false,
-		Reviewer:   reviewer,
-		Notes:      reason,
-		ReviewedAt: time.Now(),
-	}
+	return p.update("reject", candidateID, func(tx *poolTx) error {
+		entry, err := p.Get(candidateID)
+		if err != nil {
+			return err
+		}
 
-	if err := p.writeEntry(newPath, entry); err != nil {
-		return fmt.Errorf("write rejected entry: %w", err)
-	}
+		// Move to rejected directory with the review recorded
+		oldPath := entry.FilePath
+		fromStatus := entry.Status
+		newPath := filepath.Join(p.PoolPath, RejectedDir, filepath.Base(oldPath))
+		entry.Status = types.PoolStatusRejected
+		entry.UpdatedAt = time.Now()
+		entry.HumanReview = &types.HumanReview{
+			Reviewed:   true,
+			Approved:   false,
+			Reviewer:   reviewer,
+			Notes:      reason,
+			ReviewedAt: time.Now(),
+		}
 
-	// Record chain event
-	if err := p.recordEvent(ChainEvent{
-		Timestamp:   time.Now(),
-		Operation:   "reject",
-		CandidateID: candidateID,
-		FromStatus:  entry.Status,
-		ToStatus:    types.PoolStatusRejected,
-		Reason:      reason,
-		Reviewer:    reviewer,
-	}); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
-	}
+		if err := tx.writeEntry(newPath, entry); err != nil {
+			return fmt.Errorf("write rejected entry: %w", err)
+		}
+		if newPath != oldPath {
+			if err := tx.remove(oldPath); err != nil {
+				return err
+			}
+		}
 
-	return nil
+		return tx.event(ChainEvent{
+			Timestamp:   time.Now(),
+			Operation:   "reject",
+			CandidateID: candidateID,
+			FromStatus:  fromStatus,
+			ToStatus:    types.PoolStatusRejected,
+			Reason:      reason,
+			Reviewer:    reviewer,
+			Features:    scorer.Features(entry.Candidate.Content, entry.ScoringResult.Rubric),
+		})
+	})
 }
 
 // Approve records human approval for a bronze candidate.
 func (p *Pool) Approve(candidateID, note, reviewer string) error {
+	return p.approve(candidateID, note, reviewer, false)
+}
+
+// approve records an approval, marking it automated when a rule made it.
+func (p *Pool) approve(candidateID, note, reviewer string, automated bool) error {
 	// Validate note length
 	if len(note) > MaxReasonLength {
 		return ErrReasonTooLong
 	}
 
-	entry, err := p.Get(candidateID)
-	if err != nil {
-		return err
-	}
-
-	// Check if already reviewed
-	if entry.HumanReview != nil && entry.HumanReview.Reviewed {
-		return fmt.Errorf("already reviewed by %s", entry.HumanReview.Reviewer)
-	}
+	return p.update("approve", candidateID, func(tx *poolTx) error {
+		entry, err := p.Get(candidateID)
+		if err != nil {
+			return err
+		}
 
-	// Update entry with review
-	entry.HumanReview = &types.HumanReview{
-		Reviewed:   true,
-		Approved:   true,
-		Reviewer:   reviewer,
-		Notes:      note,
-		ReviewedAt: time.Now(),
-	}
-	entry.UpdatedAt = time.Now()
+		// Check if already reviewed
+		if entry.HumanReview != nil && entry.HumanReview.Reviewed {
+			return fmt.Errorf("already reviewed by %s", entry.HumanReview.Reviewer)
+		}
 
-	if err := p.writeEntry(entry.FilePath, entry); err != nil {
-		return fmt.Errorf("write approved entry: %w", err)
-	}
+		// Update entry with review
+		entry.HumanReview = &types.HumanReview{
+			Reviewed:   true,
+			Approved:   true,
+			Reviewer:   reviewer,
+			Notes:      note,
+			ReviewedAt: time.Now(),
+		}
+		entry.UpdatedAt = time.Now()
 
-	// Record chain event
-	if err := p.recordEvent(ChainEvent{
-		Timestamp:   time.Now(),
-		Operation:   "approve",
-		CandidateID: candidateID,
-		Reason:      note,
-		Reviewer:    reviewer,
-	}); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
-	}
+		if err := tx.writeEntry(entry.FilePath, entry); err != nil {
+			return fmt.Errorf("write approved entry: %w", err)
+		}
 
-	return nil
+		return tx.event(ChainEvent{
+			Timestamp:   time.Now(),
+			Operation:   "approve",
+			CandidateID: candidateID,
+			Reason:      note,
+			Reviewer:    reviewer,
+			Features:    scorer.Features(entry.Candidate.Content, entry.ScoringResult.Rubric),
+			Automated:   automated,
+		})
+	})
 }
 
-// ListPendingReview returns bronze candidates awaiting human review.
+// ListPendingReview returns bronze and sampled candidates awaiting human
+// review, leaving out near-duplicates of another candidate.
 func (p *Pool) ListPendingReview() ([]PoolEntry, error) {
 	entries, err := p.List(ListOptions{
-		Tier:   types.TierBronze,
 		Status: types.PoolStatusPending,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter to only those without review
+	// Filter to only those without review; near-duplicates are reviewed
+	// through their cluster's representative
 	var pending []PoolEntry
 	for _, e := range entries {
+		if e.DuplicateOf != "" {
+			continue
+		}
+		if e.Candidate.Tier != types.TierBronze && !e.ReviewSampled {
+			continue
+		}
 		if e.HumanReview == nil || !e.HumanReview.Reviewed {
 			pending = append(pending, e)
 		}
@@ -572,28 +645,36 @@ func (p *Pool) BulkApprove(olderThan time.Duration, reviewer string, dryRun bool
 		return nil, ErrThresholdTooLow
 	}
 
-	entries, err := p.List(ListOptions{
-		Tier:   types.TierSilver,
-		Status: types.PoolStatusPending,
-	})
+	return p.ApproveWhere(func(entry PoolEntry) bool {
+		return entry.Candidate.Tier == types.TierSilver && entry.Age >= olderThan
+	}, "bulk-approve: auto-promoted after threshold", reviewer, dryRun)
+}
+
+// ApproveWhere approves every pending candidate for which eligible returns
+// true, recording note on each. The approvals are marked automated: the
+// rule decided them, not the reviewer. Candidates that fail to approve are
+// skipped with a warning.
+func (p *Pool) ApproveWhere(eligible func(PoolEntry) bool, note, reviewer string, dryRun bool) ([]string, error) {
+	entries, err := p.List(ListOptions{Status: types.PoolStatusPending})
 	if err != nil {
 		return nil, err
 	}
 
 	var approved []string
 	for _, entry := range entries {
-		if entry.Age >= olderThan {
-			if dryRun {
-				approved = append(approved, entry.Candidate.ID)
-				continue
-			}
-
-			if err := p.Approve(entry.Candidate.ID, "bulk-approve: auto-promoted after threshold", reviewer); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to approve %s: %v\n", entry.Candidate.ID, err)
-				continue
-			}
+		if !eligible(entry) {
+			continue
+		}
+		if dryRun {
 			approved = append(approved, entry.Candidate.ID)
+			continue
+		}
+
+		if err := p.approve(entry.Candidate.ID, note, reviewer, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to approve %s: %v\n", entry.Candidate.ID, err)
+			continue
 		}
+		approved = append(approved, entry.Candidate.ID)
 	}
 
 	return approved, nil
@@ -636,34 +717,42 @@ func (p *Pool) AddAt(candidate types.Candidate, scoring types.Scoring, addedAt t
 	filename := fmt.Sprintf("%s.json", candidate.ID)
 	path := filepath.Join(p.PoolPath, PendingDir, filename)
 
-	if err := p.writeEntry(path, &PoolEntry{PoolEntry: entry}); err != nil {
-		return fmt.Errorf("write entry: %w", err)
-	}
-
-	// Record chain event
-	if err := p.recordEvent(ChainEvent{
-		Timestamp:   time.Now(),
-		Operation:   "add",
-		CandidateID: candidate.ID,
-		ToStatus:    types.PoolStatusPending,
-	}); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
-	}
+	return p.update("add", candidate.ID, func(tx *poolTx) error {
+		added := &PoolEntry{PoolEntry: entry, Fingerprint: Fingerprint(candidate.Content)}
+		event := ChainEvent{
+			Timestamp:   time.Now(),
+			Operation:   "add",
+			CandidateID: candidate.ID,
+			ToStatus:    types.PoolStatusPending,
+		}
+		p.routeReview(added, &event)
+		snapshot := added.PoolEntry
+		event.Entry = &snapshot
 
-	return nil
-}
+		// Join the cluster of the closest existing candidate, if close enough
+		rep, similarity, err := p.nearestDuplicate(candidate.ID, added.Fingerprint)
+		if err != nil {
+			return err
+		}
+		if rep != nil {
+			if err := tx.joinCluster(added, rep, similarity); err != nil {
+				return err
+			}
+			event.Reason = fmt.Sprintf("duplicate of %s (similarity %.2f)", added.DuplicateOf, similarity)
+		}
 
-// writeEntry writes a pool entry to JSON file.
-func (p *Pool) writeEntry(path string, entry *PoolEntry) error {
-	data, err := json.MarshalIndent(entry, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0600)
+		if err := tx.writeEntry(path, added); err != nil {
+			return fmt.Errorf("write entry: %w", err)
+		}
+		if err := tx.indexEntry(added); err != nil {
+			return fmt.Errorf("index entry: %w", err)
+		}
+		return tx.event(event)
+	})
 }
 
-// writeArtifact writes a promoted candidate as markdown.
-func (p *Pool) writeArtifact(path string, entry *PoolEntry) error {
+// renderArtifact renders a promoted candidate as markdown.
+func (p *Pool) renderArtifact(entry *PoolEntry) string {
 	var content strings.Builder
 
 	// Title
@@ -722,30 +811,7 @@ func (p *Pool) writeArtifact(path string, entry *PoolEntry) error {
 	content.WriteString(fmt.Sprintf("- **Message**: %d\n", entry.Candidate.Source.MessageIndex))
 	content.WriteString("\n")
 
-	return os.WriteFile(path, []byte(content.String()), 0600)
-}
-
-// recordEvent appends an event to the chain file.
-func (p *Pool) recordEvent(event ChainEvent) (err error) {
-	chainPath := filepath.Join(p.PoolPath, ChainFile)
-
-	data, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
-
-	f, err := os.OpenFile(chainPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	_, err = f.Write(append(data, '\n'))
-	return err
+	return content.String()
 }
 
 // GetChain returns all chain events.
@@ -814,61 +880,3 @@ func truncateAtWordBoundary(s string, limit int) string {
 	}
 	return s[:lastSpace]
 }
-
-// atomicMove moves a file atomically using the pattern:
-// write-to-temp → sync → chmod 0600 → rename
-// This prevents partial writes and data corruption during moves.
-func atomicMove(srcPath, destPath string) error {
-	// Generate random suffix for temp file
-	randBytes := make([]byte, 4)
-	if _, err := rand.Read(randBytes); err != nil {
-		return fmt.Errorf("generate random suffix: %w", err)
-	}
-	tempPath := destPath + ".tmp." + hex.EncodeToString(randBytes)
-
-	// Read source file
-	data, err := os.ReadFile(srcPath)
-	if err != nil {
-		return fmt.Errorf("read source: %w", err)
-	}
-
-	// Write to temp file
-	tempFile, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-
-	// Write data
-	if _, err := tempFile.Write(data); err != nil {
-		_ = tempFile.Close()    //nolint:errcheck // cleanup in error path
-		_ = os.Remove(tempPath) //nolint:errcheck // cleanup in error path
-		return fmt.Errorf("write temp file: %w", err)
-	}
-
-	// Sync to disk
-	if err := tempFile.Sync(); err != nil {
-		_ = tempFile.Close()    //nolint:errcheck // cleanup in error path
-		_ = os.Remove(tempPath) //nolint:errcheck // cleanup in error path
-		return fmt.Errorf("sync temp file: %w", err)
-	}
-
-	// Close before rename
-	if err := tempFile.Close(); err != nil {
-		_ = os.Remove(tempPath) //nolint:errcheck // cleanup in error path
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	// Atomic rename
-	if err := os.Rename(tempPath, destPath); err != nil {
-		_ = os.Remove(tempPath) //nolint:errcheck // cleanup in error path
-		return fmt.Errorf("rename to destination: %w", err)
-	}
-
-	// Remove source file
-	if err := os.Remove(srcPath); err != nil {
-		// Non-fatal: the move succeeded, just cleanup failed
-		fmt.Fprintf(os.Stderr, "Warning: failed to remove source file %s: %v\n", srcPath, err)
-	}
-
-	return nil
-}
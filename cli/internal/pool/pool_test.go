@@ -1288,45 +1288,6 @@ func TestFormatDurationEdgeCases(t *testing.T) {
 	}
 }
 
-func TestAtomicMove(t *testing.T) {
-	t.Run("successful move", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		srcPath := filepath.Join(tmpDir, "source.json")
-		destPath := filepath.Join(tmpDir, "dest.json")
-
-		content := []byte(`{"test": true}`)
-		if err := os.WriteFile(srcPath, content, 0600); err != nil {
-			t.Fatalf("WriteFile failed: %v", err)
-		}
-
-		if err := atomicMove(srcPath, destPath); err != nil {
-			t.Fatalf("atomicMove failed: %v", err)
-		}
-
-		// Source should be gone
-		if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
-			t.Error("source file should be removed after move")
-		}
-
-		// Dest should have the content
-		data, err := os.ReadFile(destPath)
-		if err != nil {
-			t.Fatalf("ReadFile dest failed: %v", err)
-		}
-		if string(data) != string(content) {
-			t.Errorf("expected content %q, got %q", string(content), string(data))
-		}
-	})
-
-	t.Run("source does not exist", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		err := atomicMove(filepath.Join(tmpDir, "nonexistent"), filepath.Join(tmpDir, "dest"))
-		if err == nil {
-			t.Error("expected error when source does not exist")
-		}
-	})
-}
-
 func TestPoolListPendingReviewFiltersReviewed(t *testing.T) {
 	tmpDir := t.TempDir()
 	p := NewPool(tmpDir)
@@ -0,0 +1,210 @@
+package pool
+
+import (
+	"sort"
+
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Review routes recorded on add events.
+const (
+	// ReviewRequired marks a candidate whose tier requires human review.
+	ReviewRequired = "required"
+
+	// ReviewSampled marks a candidate sampled into review for quality control.
+	ReviewSampled = "sampled"
+)
+
+// ReviewStats summarises human review for one tier: how candidates reached
+// the review queue and how reviews of each kind went.
+type ReviewStats struct {
+	Tier types.Tier `json:"tier"`
+
+	// Added is how many candidates of the tier were added to the pool.
+	Added int `json:"added"`
+
+	// Required and Sampled count candidates routed to review.
+	Required int `json:"required"`
+	Sampled  int `json:"sampled"`
+
+	RequiredReviewed int `json:"required_reviewed"`
+	RequiredRejected int `json:"required_rejected"`
+	SampledReviewed  int `json:"sampled_reviewed"`
+	SampledRejected  int `json:"sampled_rejected"`
+
+	// BaseRate is the configured sample rate; Rate is the one in effect,
+	// raised when sampled rejects climb.
+	BaseRate float64 `json:"base_rate"`
+	Rate     float64 `json:"rate"`
+}
+
+// RequiredRejectRate is the fraction of required reviews that rejected.
+func (s ReviewStats) RequiredRejectRate() float64 {
+	return ratio(s.RequiredRejected, s.RequiredReviewed)
+}
+
+// SampledRejectRate is the fraction of sampled reviews that rejected.
+func (s ReviewStats) SampledRejectRate() float64 {
+	return ratio(s.SampledRejected, s.SampledReviewed)
+}
+
+// Escalated reports whether sampled rejects have raised the sample rate.
+func (s ReviewStats) Escalated() bool {
+	return s.Rate > s.BaseRate
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// ReviewStats tallies review routing and outcomes per tier from the pool
+// chain, so candidates that have since been promoted still count. Only the
+// first approve or reject of a candidate counts as its review.
+func (p *Pool) ReviewStats() ([]ReviewStats, error) {
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, err
+	}
+	return reviewStats(events, p.tierConfigs()), nil
+}
+
+// tierConfigs returns the pool's tier review settings.
+func (p *Pool) tierConfigs() map[types.Tier]taxonomy.TierConfig {
+	if p.TierConfigs != nil {
+		return p.TierConfigs
+	}
+	return taxonomy.DefaultTierConfigs
+}
+
+func reviewStats(events []ChainEvent, configs map[types.Tier]taxonomy.TierConfig) []ReviewStats {
+	type route struct {
+		tier   types.Tier
+		review string
+	}
+	routes := make(map[string]route)
+	reviewed := make(map[string]bool)
+	byTier := make(map[types.Tier]*ReviewStats)
+
+	stats := func(tier types.Tier) *ReviewStats {
+		s, ok := byTier[tier]
+		if !ok {
+			s = &ReviewStats{Tier: tier}
+			byTier[tier] = s
+		}
+		return s
+	}
+
+	for _, e := range events {
+		switch e.Operation {
+		case "add":
+			if e.Tier == "" {
+				continue // Recorded before tiers were logged
+			}
+			routes[e.CandidateID] = route{e.Tier, e.Review}
+			s := stats(e.Tier)
+			s.Added++
+			switch e.Review {
+			case ReviewRequired:
+				s.Required++
+			case ReviewSampled:
+				s.Sampled++
+			}
+		case "approve", "reject":
+			r, ok := routes[e.CandidateID]
+			if !ok || r.review == "" || reviewed[e.CandidateID] {
+				continue
+			}
+			reviewed[e.CandidateID] = true
+			s := stats(r.tier)
+			rejected := e.Operation == "reject"
+			if r.review == ReviewRequired {
+				s.RequiredReviewed++
+				if rejected {
+					s.RequiredRejected++
+				}
+			} else {
+				s.SampledReviewed++
+				if rejected {
+					s.SampledRejected++
+				}
+			}
+		}
+	}
+
+	out := make([]ReviewStats, 0, len(byTier))
+	for _, s := range byTier {
+		s.BaseRate = taxonomy.SampleRate(s.Tier, configs, 0, 0)
+		s.Rate = taxonomy.SampleRate(s.Tier, configs, s.SampledReviewed, s.SampledRejected)
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return tierIndex(out[i].Tier) < tierIndex(out[j].Tier)
+	})
+	return out
+}
+
+// tierIndex orders tiers from highest to lowest quality.
+func tierIndex(tier types.Tier) int {
+	for i, t := range taxonomy.TierOrder {
+		if t == tier {
+			return i
+		}
+	}
+	return len(taxonomy.TierOrder)
+}
+
+// sampleRate returns the review sample rate in effect for tier, using the
+// review history as of the pool's first add in this process.
+func (p *Pool) sampleRate(tier types.Tier) float64 {
+	p.statsOnce.Do(func() {
+		events, err := p.GetChain()
+		if err != nil {
+			return // No history: base rates apply
+		}
+		p.reviewStats = reviewStats(events, p.tierConfigs())
+	})
+	for _, s := range p.reviewStats {
+		if s.Tier == tier {
+			return s.Rate
+		}
+	}
+	return taxonomy.SampleRate(tier, p.tierConfigs(), 0, 0)
+}
+
+// routeReview decides whether an added candidate enters the review queue and
+// records the decision on its add event. Candidates whose tier does not
+// require review are sampled by their deterministic draw.
+func (p *Pool) routeReview(entry *PoolEntry, event *ChainEvent) {
+	tier := entry.Candidate.Tier
+	if tier == "" {
+		tier = entry.ScoringResult.TierAssignment
+	}
+	event.Tier = tier
+
+	if entry.ScoringResult.GateRequired || taxonomy.RequiresHumanGate(tier, p.tierConfigs()) {
+		event.Review = ReviewRequired
+		if !entry.ScoringResult.GateRequired {
+			entry.ScoringResult.GateRequired = true
+			entry.HumanReview = &types.HumanReview{Reviewed: false}
+		}
+		return
+	}
+	rate := p.sampleRate(tier)
+	if rate <= 0 {
+		return
+	}
+	draw := taxonomy.SampleDraw(p.SampleSeed, entry.Candidate.ID)
+	event.SampleRate, event.SampleDraw = rate, draw
+	if draw >= rate {
+		return
+	}
+
+	event.Review = ReviewSampled
+	entry.ReviewSampled = true
+	entry.ScoringResult.GateRequired = true
+	entry.HumanReview = &types.HumanReview{Reviewed: false}
+}
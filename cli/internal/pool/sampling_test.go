@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// addTier adds n candidates of tier with unrelated content, so none are
+// near-duplicates of each other.
+func addTier(t *testing.T, p *Pool, tier types.Tier, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("cand-%s-%d", tier, i)
+		c := types.Candidate{ID: id, Tier: tier, Content: fmt.Sprintf("%s%dalpha %s%dbeta %s%dgamma", tier, i, tier, i, tier, i)}
+		if err := p.Add(c, types.Scoring{TierAssignment: tier}); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// sampledTiers are the default tier configs with 5% of silver sampled.
+func sampledTiers() map[types.Tier]taxonomy.TierConfig {
+	configs := make(map[types.Tier]taxonomy.TierConfig)
+	for tier, c := range taxonomy.DefaultTierConfigs {
+		configs[tier] = c
+	}
+	silver := configs[types.TierSilver]
+	silver.HumanGateSampleRate = 0.05
+	configs[types.TierSilver] = silver
+	return configs
+}
+
+func TestPoolSamplesIntoReview(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.SampleSeed = "test-seed"
+	p.TierConfigs = sampledTiers()
+	ids := addTier(t, p, types.TierSilver, 200)
+	addTier(t, p, types.TierBronze, 2)
+
+	var want []string
+	for _, id := range ids {
+		if taxonomy.SampleDraw(p.SampleSeed, id) < 0.05 {
+			want = append(want, id)
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("seed sampled no silver candidates; pick another")
+	}
+
+	pending, err := p.ListPendingReview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(want)+2 {
+		t.Fatalf("pending review = %d, want %d sampled + 2 bronze", len(pending), len(want))
+	}
+	for _, id := range want {
+		e, err := p.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.ReviewSampled || !e.ScoringResult.GateRequired {
+			t.Errorf("%s should be sampled into review", id)
+		}
+	}
+
+	// The chain records each draw, so the sample can be audited
+	events, err := p.GetChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := map[string]int{}
+	for _, e := range events {
+		if e.Operation != "add" {
+			continue
+		}
+		routes[e.Review]++
+		if e.Tier == types.TierSilver && (e.SampleRate != 0.05 || e.SampleDraw != taxonomy.SampleDraw(p.SampleSeed, e.CandidateID)) {
+			t.Errorf("%s: recorded rate %f draw %f", e.CandidateID, e.SampleRate, e.SampleDraw)
+		}
+	}
+	if routes[ReviewSampled] != len(want) || routes[ReviewRequired] != 2 {
+		t.Errorf("routes = %v, want %d sampled and 2 required", routes, len(want))
+	}
+}
+
+func TestPoolDefaultRatesSampleNothing(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.SampleSeed = "test-seed"
+	addTier(t, p, types.TierGold, 50)
+	addTier(t, p, types.TierSilver, 50)
+	addTier(t, p, types.TierBronze, 2)
+
+	pending, err := p.ListPendingReview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Errorf("pending review = %d, want only the 2 bronze", len(pending))
+	}
+	for _, e := range pending {
+		if e.ReviewSampled {
+			t.Errorf("%s sampled at a zero rate", e.Candidate.ID)
+		}
+	}
+}
+
+func TestPoolReviewStatsEscalates(t *testing.T) {
+	p := NewPool(t.TempDir())
+	p.SampleSeed = "test-seed"
+	p.TierConfigs = sampledTiers()
+	addTier(t, p, types.TierSilver, 400)
+	bronze := addTier(t, p, types.TierBronze, 2)
+
+	pending, err := p.ListPendingReview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sampled []string
+	for _, e := range pending {
+		if e.ReviewSampled {
+			sampled = append(sampled, e.Candidate.ID)
+		}
+	}
+	if len(sampled) < taxonomy.SampleEscalationMinReviews {
+		t.Fatalf("only %d sampled; need %d to escalate", len(sampled), taxonomy.SampleEscalationMinReviews)
+	}
+
+	// Reject half of the sampled reviews and approve the rest
+	for i, id := range sampled {
+		if i%2 == 0 {
+			err = p.Reject(id, "not reusable", "reviewer")
+		} else {
+			err = p.Approve(id, "fine", "reviewer")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Reject(bronze[0], "vague", "reviewer"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := p.ReviewStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Tier != types.TierSilver || stats[1].Tier != types.TierBronze {
+		t.Fatalf("stats = %+v, want silver then bronze", stats)
+	}
+	silver, br := stats[0], stats[1]
+	if silver.Added != 400 || silver.Sampled != len(sampled) || silver.SampledReviewed != len(sampled) {
+		t.Errorf("silver stats = %+v", silver)
+	}
+	if !silver.Escalated() || silver.Rate <= silver.BaseRate {
+		t.Errorf("silver at %.0f%% rejects should escalate, rate %f", silver.SampledRejectRate()*100, silver.Rate)
+	}
+	if br.Required != 2 || br.RequiredReviewed != 1 || br.RequiredRejectRate() != 1 {
+		t.Errorf("bronze stats = %+v", br)
+	}
+
+	// A new pool picks up the escalated rate from the chain
+	next := NewPool(p.BaseDir)
+	next.TierConfigs = p.TierConfigs
+	if rate := next.sampleRate(types.TierSilver); rate != silver.Rate {
+		t.Errorf("sample rate = %f, want escalated %f", rate, silver.Rate)
+	}
+}
@@ -0,0 +1,115 @@
+package pool
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// FingerprintSize is the number of MinHash permutations in a fingerprint.
+	FingerprintSize = 64
+
+	// DefaultDuplicateThreshold is the estimated Jaccard similarity at or
+	// above which a new candidate is treated as a near-duplicate.
+	DefaultDuplicateThreshold = 0.6
+)
+
+// stopwords are dropped before shingling so paraphrases that differ only in
+// filler words still match.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "but": true,
+	"to": true, "of": true, "in": true, "on": true, "for": true, "with": true,
+	"is": true, "are": true, "was": true, "were": true, "be": true, "been": true,
+	"it": true, "its": true, "this": true, "that": true, "these": true, "those": true,
+	"you": true, "we": true, "i": true, "our": true, "your": true, "by": true,
+	"as": true, "at": true, "so": true, "then": true, "when": true, "if": true,
+	"always": true, "should": true, "must": true, "can": true, "will": true,
+}
+
+// minhashSeeds are the per-permutation seeds, derived deterministically so
+// fingerprints are stable across runs and binaries.
+var minhashSeeds = func() [FingerprintSize]uint64 {
+	var seeds [FingerprintSize]uint64
+	var x uint64
+	for i := range seeds {
+		x += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(x)
+	}
+	return seeds
+}()
+
+// shingles returns the set of word unigrams and bigrams of the normalised
+// content: lowercased, punctuation stripped, stopwords removed.
+func shingles(content string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if !stopwords[w] {
+			kept = append(kept, w)
+		}
+	}
+
+	set := make(map[string]bool, 2*len(kept))
+	for i, w := range kept {
+		set[w] = true
+		if i > 0 {
+			set[kept[i-1]+" "+w] = true
+		}
+	}
+	return set
+}
+
+// Fingerprint computes the MinHash signature of content's shingles. Two
+// fingerprints agree in a fraction of positions that estimates the Jaccard
+// similarity of the shingle sets. Content with no words has a nil
+// fingerprint.
+func Fingerprint(content string) []uint32 {
+	set := shingles(content)
+	if len(set) == 0 {
+		return nil
+	}
+
+	sig := make([]uint32, FingerprintSize)
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	for s := range set {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s)) //nolint:errcheck // hash writes do not fail
+		base := h.Sum64()
+		for i, seed := range minhashSeeds {
+			v := mix64(base ^ seed)
+			if uint32(v) < sig[i] {
+				sig[i] = uint32(v)
+			}
+		}
+	}
+	return sig
+}
+
+// Similarity estimates the Jaccard similarity of the content behind two
+// fingerprints, in [0, 1]. Mismatched or missing fingerprints score 0.
+func Similarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// mix64 is the splitmix64 finaliser, used to derive independent hash
+// permutations from one base hash.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -28,7 +29,14 @@ const (
 
 // LoadChain loads the ratchet chain from the nearest .agents directory.
 // It first tries the new JSONL format, then falls back to legacy YAML.
+// The chain follows the workflow defined in .agents/ao/workflow.yaml, or
+// the built-in workflow when there is none.
 func LoadChain(startDir string) (*Chain, error) {
+	return loadChain(startDir, nil)
+}
+
+// loadChain loads the chain with its workflow's gates resolved in gates.
+func loadChain(startDir string, gates *GateRegistry) (*Chain, error) {
 	// Find the .agents directory
 	agentsDir, err := findAgentsDir(startDir)
 	if err != nil {
@@ -40,10 +48,16 @@ func LoadChain(startDir string) (*Chain, error) {
 		}, nil
 	}
 
+	workflow, err := loadWorkflowFrom(agentsDir, gates)
+	if err != nil {
+		return nil, err
+	}
+
 	// Try new location first
 	chainPath := filepath.Join(agentsDir, "ao", ChainFile)
 	if chain, err := loadJSONLChain(chainPath); err == nil {
 		chain.path = chainPath
+		chain.workflow = workflow
 		return chain, nil
 	}
 
@@ -51,16 +65,18 @@ func LoadChain(startDir string) (*Chain, error) {
 	legacyPath := filepath.Join(agentsDir, "provenance", LegacyChainFile)
 	if chain, err := loadLegacyYAMLChain(legacyPath); err == nil {
 		chain.path = chainPath // Will write to new location
+		chain.workflow = workflow
 		fmt.Fprintf(os.Stderr, "Note: Migrating chain from %s to %s\n", legacyPath, chainPath)
 		return chain, nil
 	}
 
 	// No existing chain - create new
 	return &Chain{
-		ID:      generateChainID(),
-		Started: time.Now(),
-		Entries: []ChainEntry{},
-		path:    chainPath,
+		ID:       generateChainID(),
+		Started:  time.Now(),
+		Entries:  []ChainEntry{},
+		path:     chainPath,
+		workflow: workflow,
 	}, nil
 }
 
@@ -206,16 +222,7 @@ func (c *Chain) Save() error {
 	}()
 
 	// Write chain metadata on first line
-	meta := struct {
-		ID      string    `json:"id"`
-		Started time.Time `json:"started"`
-		EpicID  string    `json:"epic_id,omitempty"`
-	}{
-		ID:      c.ID,
-		Started: c.Started,
-		EpicID:  c.EpicID,
-	}
-	metaLine, _ := json.Marshal(meta)
+	metaLine, _ := json.Marshal(c.meta())
 	if _, err := f.Write(append(metaLine, '\n')); err != nil {
 		return fmt.Errorf("write chain metadata: %w", err)
 	}
@@ -235,7 +242,10 @@ func (c *Chain) Save() error {
 }
 
 // Append adds a new entry to the chain with file locking.
-// This is atomic and safe for concurrent access.
+// This is atomic and safe for concurrent access. The entry is sealed before
+// it is written: linked to the hash of the entry before it on disk, given a
+// hash of its output artifact and of itself, and signed when a local signing
+// key exists.
 func (c *Chain) Append(entry ChainEntry) error {
 	if c.path == "" {
 		return fmt.Errorf("chain has no path set")
@@ -247,8 +257,9 @@ func (c *Chain) Append(entry ChainEntry) error {
 		return fmt.Errorf("create chain directory: %w", err)
 	}
 
-	// Open file for append with exclusive lock
-	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	// Open file for append with exclusive lock; it is also read to find the
+	// entry to link to
+	f, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
 		return fmt.Errorf("open chain file: %w", err)
 	}
@@ -264,22 +275,27 @@ func (c *Chain) Append(entry ChainEntry) error {
 		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
 	}()
 
-	// Check if file is empty (needs metadata)
-	stat, _ := f.Stat()
-	if stat.Size() == 0 {
-		meta := struct {
-			ID      string    `json:"id"`
-			Started time.Time `json:"started"`
-			EpicID  string    `json:"epic_id,omitempty"`
-		}{
-			ID:      c.ID,
-			Started: c.Started,
-			EpicID:  c.EpicID,
-		}
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat chain file: %w", err)
+	}
+	prev, err := headHash(io.NewSectionReader(f, 0, stat.Size()))
+	if err != nil {
+		return err
+	}
+
+	// An empty file needs metadata first
+	if prev == "" {
+		meta := c.meta()
 		metaLine, _ := json.Marshal(meta)
 		if _, err := f.Write(append(metaLine, '\n')); err != nil {
 			return fmt.Errorf("write chain metadata: %w", err)
 		}
+		prev = meta.genesisHash()
+	}
+
+	if err := c.seal(&entry, prev); err != nil {
+		return fmt.Errorf("seal entry: %w", err)
 	}
 
 	// Write entry
@@ -337,15 +353,23 @@ func (c *Chain) GetStatus(step Step) StepStatus {
 	return StatusInProgress
 }
 
-// GetAllStatus returns status for all steps.
+// GetAllStatus returns status for all steps of the chain's workflow.
 func (c *Chain) GetAllStatus() map[Step]StepStatus {
 	status := make(map[Step]StepStatus)
-	for _, step := range AllSteps() {
+	for _, step := range c.Workflow().StepNames() {
 		status[step] = c.GetStatus(step)
 	}
 	return status
 }
 
+// Workflow returns the workflow the chain follows.
+func (c *Chain) Workflow() *Workflow {
+	if c.workflow == nil {
+		return defaultWorkflow()
+	}
+	return c.workflow
+}
+
 // Path returns the file path where the chain is stored.
 func (c *Chain) Path() string {
 	return c.path
@@ -1,10 +1,16 @@
 package ratchet
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,182 +20,438 @@ const BdCLITimeout = 5 * time.Second
 // ErrBdCLITimeout is returned when bd CLI command times out.
 var ErrBdCLITimeout = fmt.Errorf("bd CLI timeout after %s", BdCLITimeout)
 
+// GateCommandTimeout is the maximum duration to wait for a gate command or
+// gate executable.
+const GateCommandTimeout = 2 * time.Minute
+
+// GateStatus is the outcome of a gate check.
+type GateStatus string
+
+const (
+	GatePass GateStatus = "pass"
+	GateWarn GateStatus = "warn"
+	GateFail GateStatus = "fail"
+)
+
+// Built-in gates.
+const (
+	// GateArtifact passes when an input artifact pattern matches.
+	GateArtifact = "artifact"
+
+	// GateEpic passes when bd has an epic in one of the input statuses.
+	GateEpic = "epic"
+
+	// GateCommand passes when its command exits zero.
+	GateCommand = "command"
+)
+
+// GateRequest is what a gate is asked to check. External gates receive it
+// as JSON on stdin.
+type GateRequest struct {
+	// Gate is the check's display name.
+	Gate string `json:"gate"`
+
+	// Step is the step being checked.
+	Step Step `json:"step"`
+
+	// Root is the repo root; external gates run there.
+	Root string `json:"root"`
+
+	// Input and Output are the step's artifacts from the workflow.
+	Input  StepArtifacts `json:"input"`
+	Output StepArtifacts `json:"output"`
+
+	// With is the gate's configuration from the workflow.
+	With map[string]string `json:"with,omitempty"`
+
+	// Chain is the current ratchet chain.
+	Chain *Chain `json:"chain"`
+}
+
+// GateResponse is a gate's verdict. External gates write it as JSON on
+// stdout.
+type GateResponse struct {
+	Status  GateStatus `json:"status"`
+	Message string     `json:"message"`
+
+	// Input and Location identify the artifact that satisfied the gate.
+	Input    string `json:"input,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// Gate checks whether a step may proceed.
+type Gate interface {
+	Check(ctx context.Context, req *GateRequest) (*GateResponse, error)
+}
+
+// GateFunc adapts a function to the Gate interface.
+type GateFunc func(ctx context.Context, req *GateRequest) (*GateResponse, error)
+
+// Check calls f.
+func (f GateFunc) Check(ctx context.Context, req *GateRequest) (*GateResponse, error) {
+	return f(ctx, req)
+}
+
+// GateRegistry maps the names workflows refer to with "uses" to gates.
+type GateRegistry struct {
+	mu    sync.RWMutex
+	gates map[string]Gate
+}
+
+// NewGateRegistry returns a registry holding the built-in gates.
+func NewGateRegistry() *GateRegistry {
+	return &GateRegistry{gates: map[string]Gate{
+		GateArtifact: GateFunc(artifactGate),
+		GateEpic:     GateFunc(epicGate),
+		GateCommand:  GateFunc(commandGate),
+	}}
+}
+
+// Register adds a gate. It panics if the name is empty or already
+// registered.
+func (r *GateRegistry) Register(name string, g Gate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" || g == nil {
+		panic("ratchet: RegisterGate with empty name or nil gate")
+	}
+	if _, dup := r.gates[name]; dup {
+		panic("ratchet: RegisterGate called twice for gate " + name)
+	}
+	r.gates[name] = g
+}
+
+// Lookup returns the gate with the given name.
+func (r *GateRegistry) Lookup(name string) (Gate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	g, ok := r.gates[name]
+	return g, ok
+}
+
+// Names returns the registered gate names, sorted.
+func (r *GateRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.gates))
+	for name := range r.gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultGates is the registry workflows use unless given another.
+var defaultGates = NewGateRegistry()
+
+// RegisterGate makes a Go gate available to workflows as "uses: name".
+// It panics if the name is empty or already registered.
+func RegisterGate(name string, g Gate) {
+	defaultGates.Register(name, g)
+}
+
+// LookupGate returns the registered gate with the given name.
+func LookupGate(name string) (Gate, bool) {
+	return defaultGates.Lookup(name)
+}
+
+// RegisteredGates returns the registered gate names, sorted.
+func RegisteredGates() []string {
+	return defaultGates.Names()
+}
+
 // GateChecker validates that prerequisites are met before a step can proceed.
 type GateChecker struct {
-	locator *Locator
+	workflow *Workflow
+
+	// startDir is where the chain is loaded from; root is the repo root
+	// gates run in.
+	startDir string
+	root     string
 }
 
-// NewGateChecker creates a new gate checker.
+// NewGateChecker creates a gate checker for the workflow found from startDir.
 func NewGateChecker(startDir string) (*GateChecker, error) {
-	locator, err := NewLocator(startDir)
+	return NewGateCheckerWithGates(startDir, nil)
+}
+
+// NewGateCheckerWithGates creates a gate checker that resolves "uses" gates
+// in gates instead of the default registry.
+func NewGateCheckerWithGates(startDir string, gates *GateRegistry) (*GateChecker, error) {
+	workflow, err := LoadWorkflowWithGates(startDir, gates)
 	if err != nil {
 		return nil, err
 	}
-	return &GateChecker{locator: locator}, nil
+	root := startDir
+	if agentsDir, err := findAgentsDir(startDir); err == nil {
+		root = filepath.Dir(agentsDir)
+	}
+	return &GateChecker{workflow: workflow, startDir: startDir, root: root}, nil
 }
 
-// Check validates that the gate for a step is satisfied.
+// Workflow returns the workflow the checker evaluates gates from.
+func (g *GateChecker) Workflow() *Workflow {
+	return g.workflow
+}
+
+// Check runs every gate of a step. The step fails if any gate fails, unless
+// the gate is soft, in which case it only warns.
 func (g *GateChecker) Check(step Step) (*GateResult, error) {
-	switch step {
-	case StepResearch:
-		return g.checkResearchGate()
-	case StepPreMortem:
-		return g.checkPreMortemGate()
-	case StepPlan:
-		return g.checkPlanGate()
-	case StepImplement, StepCrank:
-		return g.checkImplementGate()
-	case StepVibe:
-		return g.checkVibeGate()
-	case StepPostMortem:
-		return g.checkPostMortemGate()
-	default:
+	def := g.workflow.Step(step)
+	if def == nil {
 		return &GateResult{
 			Step:    step,
 			Passed:  false,
+			Status:  GateFail,
 			Message: fmt.Sprintf("Unknown step: %s", step),
 		}, nil
 	}
+	specs := def.GateSpecs()
+	if len(specs) == 0 {
+		return &GateResult{
+			Step:    step,
+			Passed:  true,
+			Status:  GatePass,
+			Message: fmt.Sprintf("%s has no gates", step),
+		}, nil
+	}
+
+	chain, err := loadChain(g.startDir, g.workflow.gates)
+	if err != nil {
+		return nil, fmt.Errorf("load chain: %w", err)
+	}
+
+	result := &GateResult{Step: step, Status: GatePass}
+	var failed, warned, passed []string
+	for _, spec := range specs {
+		check := g.runGate(spec, &GateRequest{
+			Gate:   spec.DisplayName(),
+			Step:   step,
+			Root:   g.root,
+			Input:  def.Input,
+			Output: def.Output,
+			With:   spec.With,
+			Chain:  chain,
+		})
+		switch check.Status {
+		case GateFail:
+			failed = append(failed, check.Message)
+			result.Status = GateFail
+		case GateWarn:
+			warned = append(warned, check.Message)
+			if result.Status == GatePass {
+				result.Status = GateWarn
+			}
+		default:
+			passed = append(passed, check.Message)
+		}
+		if result.Input == "" && check.Input != "" {
+			result.Input, result.Location = check.Input, check.Location
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	result.Passed = result.Status != GateFail
+	switch result.Status {
+	case GateFail:
+		result.Message = strings.Join(failed, "; ")
+	case GateWarn:
+		result.Message = fmt.Sprintf("Soft gate: passes with warnings (%s)", strings.Join(warned, "; "))
+	default:
+		result.Message = strings.Join(passed, "; ")
+	}
+	return result, nil
 }
 
-// checkResearchGate - No gate (chaos phase, always passes).
-func (g *GateChecker) checkResearchGate() (*GateResult, error) {
-	return &GateResult{
-		Step:    StepResearch,
-		Passed:  true,
-		Message: "Research has no prerequisites (chaos phase)",
-	}, nil
+// runGate runs one gate and applies its spec: soft failures become
+// warnings, and the spec's message replaces the gate's when it does not
+// pass. Gate errors and malformed responses count as failures.
+func (g *GateChecker) runGate(spec GateSpec, req *GateRequest) GateCheckResult {
+	check := GateCheckResult{Gate: req.Gate, Soft: spec.Soft}
+
+	ctx, cancel := context.WithTimeout(context.Background(), GateCommandTimeout)
+	defer cancel()
+
+	var gate Gate
+	if spec.Exec != "" {
+		gate = execGate{path: spec.Exec, args: spec.Args}
+	} else if registered, ok := g.workflow.registry().Lookup(spec.Uses); ok {
+		gate = registered
+	} else {
+		check.Status, check.Message = GateFail, fmt.Sprintf("unknown gate %q", spec.Uses)
+		return check
+	}
+
+	resp, err := gate.Check(ctx, req)
+	switch {
+	case err != nil:
+		check.Status, check.Message = GateFail, fmt.Sprintf("gate error: %v", err)
+	case resp == nil:
+		check.Status, check.Message = GateFail, "gate returned no result"
+	default:
+		check.GateResponse = *resp
+		if check.Status != GatePass && check.Status != GateWarn && check.Status != GateFail {
+			check.Status, check.Message = GateFail, fmt.Sprintf("gate returned invalid status %q", resp.Status)
+		}
+	}
+
+	if check.Status == GateFail && spec.Soft {
+		check.Status = GateWarn
+	}
+	if check.Status != GatePass && spec.Message != "" {
+		check.Message = spec.Message
+	}
+	return check
 }
 
-// checkPreMortemGate - Requires research artifact to exist.
-func (g *GateChecker) checkPreMortemGate() (*GateResult, error) {
-	// Look for any research artifact
-	patterns := []string{
-		"research/*.md",
-		"research/**/*.md",
+// artifactGate passes when one of the step's input patterns (or the
+// comma-separated "patterns" setting) matches an artifact.
+func artifactGate(_ context.Context, req *GateRequest) (*GateResponse, error) {
+	patterns := req.Input.Patterns
+	if v := req.With["patterns"]; v != "" {
+		patterns = splitList(v)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no input patterns configured")
 	}
 
+	locator, err := NewLocator(req.Root)
+	if err != nil {
+		return nil, err
+	}
 	for _, pattern := range patterns {
-		path, loc, err := g.locator.FindFirst(pattern)
+		path, loc, err := locator.FindFirst(pattern)
 		if err == nil {
-			return &GateResult{
-				Step:     StepPreMortem,
-				Passed:   true,
-				Message:  fmt.Sprintf("Research artifact found: %s", path),
+			return &GateResponse{
+				Status:   GatePass,
+				Message:  fmt.Sprintf("Input artifact found: %s", path),
 				Input:    path,
 				Location: string(loc),
 			}, nil
 		}
 	}
 
-	return &GateResult{
-		Step:    StepPreMortem,
-		Passed:  false,
-		Message: "No research artifact found. Run /research first.",
-	}, nil
+	desc := req.Input.Description
+	if desc == "" {
+		desc = strings.Join(patterns, " or ")
+	}
+	return &GateResponse{Status: GateFail, Message: fmt.Sprintf("No input artifact found (%s)", desc)}, nil
 }
 
-// checkPlanGate - Requires synthesis or spec v2+ artifact.
-func (g *GateChecker) checkPlanGate() (*GateResult, error) {
-	// Look for synthesis or spec artifacts
-	patterns := []string{
-		"synthesis/*.md",
-		"specs/*-v2.md",
-		"specs/*-v*.md", // Any versioned spec
+// epicGate passes when bd lists an epic in one of the step's input epic
+// statuses (or the comma-separated "status" setting).
+func epicGate(_ context.Context, req *GateRequest) (*GateResponse, error) {
+	statuses := req.Input.Epic
+	if v := req.With["status"]; v != "" {
+		statuses = splitList(v)
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no epic statuses configured")
 	}
 
-	for _, pattern := range patterns {
-		path, loc, err := g.locator.FindFirst(pattern)
-		if err == nil {
-			return &GateResult{
-				Step:     StepPlan,
-				Passed:   true,
-				Message:  fmt.Sprintf("Spec/synthesis artifact found: %s", path),
-				Input:    path,
-				Location: string(loc),
+	for _, status := range statuses {
+		epicID, err := findEpic(status)
+		if err == nil && epicID != "" {
+			return &GateResponse{
+				Status:   GatePass,
+				Message:  fmt.Sprintf("Epic %s is %s", epicID, status),
+				Input:    epicID,
+				Location: "beads",
 			}, nil
 		}
 	}
-
-	return &GateResult{
-		Step:    StepPlan,
-		Passed:  false,
-		Message: "No spec or synthesis artifact found. Run /pre-mortem first.",
+	return &GateResponse{
+		Status:  GateFail,
+		Message: fmt.Sprintf("No epic with status %s found", strings.Join(statuses, " or ")),
 	}, nil
 }
 
-// checkImplementGate - Requires open or in_progress epic via bd CLI.
-func (g *GateChecker) checkImplementGate() (*GateResult, error) {
-	// Try to find an open epic
-	epicID, err := g.findEpic("open")
-	if err != nil || epicID == "" {
-		// Also try in_progress
-		epicID, _ = g.findEpic("in_progress")
+// commandGate passes when the "command" setting exits zero, run with sh -c
+// from the repo root.
+func commandGate(ctx context.Context, req *GateRequest) (*GateResponse, error) {
+	command := req.With["command"]
+	if command == "" {
+		return nil, fmt.Errorf("no command configured")
 	}
 
-	if epicID != "" {
-		return &GateResult{
-			Step:     StepImplement,
-			Passed:   true,
-			Message:  fmt.Sprintf("Epic %s exists", epicID),
-			Input:    epicID,
-			Location: "beads",
-		}, nil
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = req.Root
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("command timed out after %s", GateCommandTimeout)
+		}
+		return &GateResponse{Status: GateFail, Message: fmt.Sprintf("Gate command failed: %v", err)}, nil
 	}
+	return &GateResponse{Status: GatePass, Message: fmt.Sprintf("Gate command passed: %s", command)}, nil
+}
 
-	return &GateResult{
-		Step:    StepImplement,
-		Passed:  false,
-		Message: "No open epic found. Run /plan first.",
-	}, nil
+// execGate runs an external gate. It writes the request as JSON to the
+// executable's stdin and reads a GateResponse from its stdout. An
+// executable that prints nothing is judged by its exit code, with stderr as
+// the message.
+type execGate struct {
+	path string
+	args []string
 }
 
-// checkVibeGate - Soft gate (always passes, but warns if no code).
-func (g *GateChecker) checkVibeGate() (*GateResult, error) {
-	// This is a soft gate - always passes
-	// But we check for code changes to provide a meaningful message
-	hasChanges := g.checkGitChanges()
+func (e execGate) Check(ctx context.Context, req *GateRequest) (*GateResponse, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode gate request: %w", err)
+	}
 
-	if hasChanges {
-		return &GateResult{
-			Step:    StepVibe,
-			Passed:  true,
-			Message: "Code changes detected, ready for validation",
-		}, nil
+	path := e.path
+	if strings.ContainsRune(path, filepath.Separator) && !filepath.IsAbs(path) {
+		path = filepath.Join(req.Root, path)
 	}
+	cmd := exec.CommandContext(ctx, path, e.args...)
+	cmd.Dir = req.Root
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
 
-	return &GateResult{
-		Step:    StepVibe,
-		Passed:  true,
-		Message: "Soft gate: always passes (no code changes detected)",
-	}, nil
-}
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", e.path, GateCommandTimeout)
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, fmt.Errorf("run %s: %w", e.path, runErr)
+	}
 
-// checkPostMortemGate - Requires recently closed epic.
-func (g *GateChecker) checkPostMortemGate() (*GateResult, error) {
-	// Look for a closed epic
-	epicID, err := g.findEpic("closed")
-	if err == nil && epicID != "" {
-		return &GateResult{
-			Step:     StepPostMortem,
-			Passed:   true,
-			Message:  fmt.Sprintf("Closed epic %s found", epicID),
-			Input:    epicID,
-			Location: "beads",
-		}, nil
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		var resp GateResponse
+		if err := json.Unmarshal(out, &resp); err != nil {
+			return nil, fmt.Errorf("%s: invalid response: %w", e.path, err)
+		}
+		return &resp, nil
 	}
 
-	// Also pass if there are completed entries in the chain
-	// (user may be running post-mortem on informal work)
-	return &GateResult{
-		Step:    StepPostMortem,
-		Passed:  true,
-		Message: "Soft gate: always passes (no closed epic found, informal review OK)",
-	}, nil
+	msg := strings.TrimSpace(stderr.String())
+	if runErr != nil {
+		if msg == "" {
+			msg = fmt.Sprintf("%s: %v", e.path, runErr)
+		}
+		return &GateResponse{Status: GateFail, Message: msg}, nil
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("%s passed", e.path)
+	}
+	return &GateResponse{Status: GatePass, Message: msg}, nil
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // findEpic uses bd CLI to find an epic with the given status.
-func (g *GateChecker) findEpic(status string) (string, error) {
+func findEpic(status string) (string, error) {
 	// Create context with 5s timeout
 	ctx, cancel := context.WithTimeout(context.Background(), BdCLITimeout)
 	defer cancel()
@@ -222,52 +484,14 @@ func (g *GateChecker) findEpic(status string) (string, error) {
 	return "", fmt.Errorf("no epic found with status %s", status)
 }
 
-// checkGitChanges returns true if there are uncommitted changes.
-func (g *GateChecker) checkGitChanges() bool {
-	cmd := exec.Command("git", "status", "--porcelain")
-	out, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-	return strings.TrimSpace(string(out)) != ""
-}
-
-// GetRequiredInput returns the expected input artifact for a step.
+// GetRequiredInput returns the expected input artifact for a step of the
+// built-in workflow.
 func GetRequiredInput(step Step) string {
-	switch step {
-	case StepResearch:
-		return "" // No input required
-	case StepPreMortem:
-		return ".agents/research/*.md"
-	case StepPlan:
-		return ".agents/specs/*-v2.md OR .agents/synthesis/*.md"
-	case StepImplement, StepCrank:
-		return "epic:<epic-id>"
-	case StepVibe:
-		return "code changes (optional)"
-	case StepPostMortem:
-		return "closed epic (optional)"
-	default:
-		return "unknown"
-	}
+	return defaultWorkflow().RequiredInput(step)
 }
 
-// GetExpectedOutput returns the expected output artifact for a step.
+// GetExpectedOutput returns the expected output artifact for a step of the
+// built-in workflow.
 func GetExpectedOutput(step Step) string {
-	switch step {
-	case StepResearch:
-		return ".agents/research/<topic>.md"
-	case StepPreMortem:
-		return ".agents/specs/<topic>-v2.md"
-	case StepPlan:
-		return "epic:<epic-id>"
-	case StepImplement, StepCrank:
-		return "issue:<issue-id> (closed)"
-	case StepVibe:
-		return "validation report"
-	case StepPostMortem:
-		return ".agents/retros/<date>-<topic>.md"
-	default:
-		return "unknown"
-	}
+	return defaultWorkflow().ExpectedOutput(step)
 }
@@ -0,0 +1,472 @@
+package ratchet
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// TrustedKeysFile lists the public keys allowed to sign chain entries,
+	// one per line, relative to the repo root.
+	TrustedKeysFile = ".agents/ao/ratchet-keys"
+
+	// SigningKeyEnv overrides the path of the local signing key.
+	SigningKeyEnv = "AGENTOPS_RATCHET_KEY"
+
+	// hashPrefix marks the hash algorithm of entry and artifact hashes.
+	hashPrefix = "sha256:"
+
+	// keyPrefix marks the algorithm of signer public keys.
+	keyPrefix = "ed25519:"
+)
+
+// chainMeta is the first line of a JSONL chain file.
+type chainMeta struct {
+	ID      string    `json:"id"`
+	Started time.Time `json:"started"`
+	EpicID  string    `json:"epic_id,omitempty"`
+}
+
+func (c *Chain) meta() chainMeta {
+	return chainMeta{ID: c.ID, Started: c.Started, EpicID: c.EpicID}
+}
+
+// genesisHash is the PrevHash of a chain's first entry.
+func (m chainMeta) genesisHash() string {
+	data, _ := json.Marshal(m) //nolint:errcheck // plain struct always marshals
+	return hashBytes(data)
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// ComputeHash returns the entry's hash: its JSON encoding without Hash,
+// Signer and Signature.
+func (e ChainEntry) ComputeHash() string {
+	e.Hash, e.Signer, e.Signature = "", "", ""
+	data, _ := json.Marshal(e) //nolint:errcheck // plain struct always marshals
+	return hashBytes(data)
+}
+
+// linkHash is the hash the entry after e links to. Legacy entries written
+// before hashing carry no links, so the running hash is folded into theirs
+// and an edit anywhere in the legacy prefix breaks the first hashed link.
+func linkHash(e ChainEntry, prev string) string {
+	if e.Hash != "" {
+		return e.Hash
+	}
+	e.PrevHash = prev
+	return e.ComputeHash()
+}
+
+// HashFile returns the content hash of a file.
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(data), nil
+}
+
+// chainRoot is the repo root for a chain stored at .agents/ao/chain.jsonl.
+func chainRoot(chainPath string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(chainPath)))
+}
+
+// resolveOutput returns the file an entry output refers to, relative paths
+// being taken from root, or empty when the output is not a regular file
+// (an epic or issue ID, a description).
+func resolveOutput(root, output string) string {
+	if output == "" || strings.Contains(output, "://") {
+		return ""
+	}
+	path := output
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// seal links entry to prev, hashes its output artifact and the entry, and
+// signs it when a local signing key exists.
+func (c *Chain) seal(entry *ChainEntry, prev string) error {
+	entry.PrevHash = prev
+	if entry.OutputHash == "" {
+		if path := resolveOutput(chainRoot(c.path), entry.Output); path != "" {
+			h, err := HashFile(path)
+			if err != nil {
+				return fmt.Errorf("hash output artifact: %w", err)
+			}
+			entry.OutputHash = h
+		}
+	}
+	entry.Hash = entry.ComputeHash()
+
+	key, err := LoadSigningKey()
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		entry.Signer = EncodePublicKey(key.Public().(ed25519.PublicKey))
+		entry.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(entry.Hash)))
+	}
+	return nil
+}
+
+// headHash reads a chain file and returns the hash the next entry must link
+// to: the last entry's hash, or the genesis hash of an empty chain. Returns
+// empty string for an empty file.
+func headHash(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	head := ""
+	first := true
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if first {
+			first = false
+			var meta chainMeta
+			if err := json.Unmarshal(line, &meta); err != nil {
+				return "", fmt.Errorf("parse chain metadata: %w", err)
+			}
+			head = meta.genesisHash()
+			continue
+		}
+		var entry ChainEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue // Skipped by LoadChain too
+		}
+		head = linkHash(entry, head)
+	}
+	return head, scanner.Err()
+}
+
+// DefaultSigningKeyPath returns where the local signing key is kept:
+// $AGENTOPS_RATCHET_KEY, or ~/.agentops/ratchet.key.
+func DefaultSigningKeyPath() (string, error) {
+	if v := os.Getenv(SigningKeyEnv); v != "" {
+		return v, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agentops", "ratchet.key"), nil
+}
+
+// LoadSigningKey loads the local signing key. Returns nil without error when
+// there is no key, so signing stays optional.
+func LoadSigningKey() (ed25519.PrivateKey, error) {
+	path, err := DefaultSigningKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key %s", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GenerateSigningKey creates a signing key at path and returns its public
+// key. It refuses to overwrite an existing key.
+func GenerateSigningKey(path string) (ed25519.PublicKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create key directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("signing key already exists at %s", path)
+		}
+		return nil, fmt.Errorf("create signing key: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, base64.StdEncoding.EncodeToString(priv.Seed())); err != nil {
+		_ = f.Close() //nolint:errcheck // write error takes precedence
+		return nil, fmt.Errorf("write signing key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("write signing key: %w", err)
+	}
+	return pub, nil
+}
+
+// EncodePublicKey formats a public key as it appears in entries and in the
+// trusted keys file.
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return keyPrefix + base64.StdEncoding.EncodeToString(pub)
+}
+
+func decodePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, ok := strings.CutPrefix(s, keyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type")
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("malformed public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// LoadTrustedKeys reads the trusted keys file under root. Blank lines and
+// # comments are ignored; a missing file yields no keys.
+func LoadTrustedKeys(root string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(root, TrustedKeysFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trusted keys: %w", err)
+	}
+	keys := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Allow a trailing comment naming the key's owner
+		key := strings.Fields(line)[0]
+		if _, err := decodePublicKey(key); err != nil {
+			return nil, fmt.Errorf("trusted key %q: %w", key, err)
+		}
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// Verification issue kinds.
+const (
+	IssueMalformed        = "malformed"
+	IssueUnhashed         = "unhashed"
+	IssueHashMismatch     = "hash-mismatch"
+	IssueBrokenLink       = "broken-link"
+	IssueArtifactModified = "artifact-modified"
+	IssueArtifactMissing  = "artifact-missing"
+	IssueBadSignature     = "bad-signature"
+	IssueUntrustedSigner  = "untrusted-signer"
+	IssueUnsigned         = "unsigned"
+	IssueAnchorMissing    = "anchor-missing"
+)
+
+// VerifyOptions configures VerifyChainFile.
+type VerifyOptions struct {
+	// RequireSigned fails hashed entries without a trusted signature.
+	RequireSigned bool
+
+	// Anchor is an entry hash recorded earlier, e.g. by CI; verification
+	// fails unless the chain still contains it.
+	Anchor string
+}
+
+// VerifyIssue is one integrity failure.
+type VerifyIssue struct {
+	// Line is the chain file line, counting the metadata line as 1.
+	Line   int    `json:"line,omitempty"`
+	Step   Step   `json:"step,omitempty"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// VerifyReport is the result of verifying a chain file.
+type VerifyReport struct {
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+
+	// Legacy counts entries written before hashing, which can only appear
+	// before the first hashed entry.
+	Legacy int `json:"legacy"`
+
+	Hashed    int `json:"hashed"`
+	Signed    int `json:"signed"`
+	Artifacts int `json:"artifacts_checked"`
+
+	// Head is the hash of the last entry; record it to anchor a later run.
+	Head string `json:"head,omitempty"`
+
+	Issues []VerifyIssue `json:"issues,omitempty"`
+}
+
+// OK reports whether verification found no issues.
+func (r *VerifyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *VerifyReport) add(line int, step Step, kind, format string, args ...any) {
+	r.Issues = append(r.Issues, VerifyIssue{Line: line, Step: step, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+}
+
+// VerifyChainFile checks a JSONL chain file: every hashed entry's hash and
+// link to its predecessor, signatures, and the content of the latest locked
+// artifact at each output path. Trusted signers come from TrustedKeysFile;
+// without one, signatures are checked against the key that made them.
+func VerifyChainFile(path string, opts VerifyOptions) (*VerifyReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open chain: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only
+	}()
+
+	root := chainRoot(path)
+	trusted, err := LoadTrustedKeys(root)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{Path: path}
+	type lockedArtifact struct {
+		line  int
+		entry ChainEntry
+	}
+	artifacts := make(map[string]lockedArtifact)
+	var outputs []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	lineNum := 0
+	prev := ""
+	sawMeta, sawHashed, anchorFound := false, false, opts.Anchor == ""
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !sawMeta {
+			sawMeta = true
+			var meta chainMeta
+			if err := json.Unmarshal(line, &meta); err != nil {
+				return nil, fmt.Errorf("parse chain metadata: %w", err)
+			}
+			prev = meta.genesisHash()
+			continue
+		}
+
+		var entry ChainEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			report.add(lineNum, "", IssueMalformed, "unparseable entry: %v", err)
+			continue
+		}
+		report.Entries++
+
+		switch {
+		case entry.Hash == "" && !sawHashed:
+			report.Legacy++
+		case entry.Hash == "":
+			report.add(lineNum, entry.Step, IssueUnhashed, "unhashed entry after hashed history")
+		default:
+			sawHashed = true
+			report.Hashed++
+			if entry.Hash != entry.ComputeHash() {
+				report.add(lineNum, entry.Step, IssueHashMismatch, "entry was modified after it was recorded")
+			}
+			if entry.PrevHash != prev {
+				report.add(lineNum, entry.Step, IssueBrokenLink, "previous entry was modified, removed or reordered")
+			}
+			verifySignature(report, lineNum, entry, trusted, opts.RequireSigned)
+			if entry.Hash == opts.Anchor {
+				anchorFound = true
+			}
+		}
+		prev = linkHash(entry, prev)
+
+		if entry.Locked && entry.Output != "" {
+			if _, seen := artifacts[entry.Output]; !seen {
+				outputs = append(outputs, entry.Output)
+			}
+			artifacts[entry.Output] = lockedArtifact{line: lineNum, entry: entry}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read chain: %w", err)
+	}
+	if report.Entries > 0 {
+		report.Head = prev
+	}
+
+	for _, output := range outputs {
+		a := artifacts[output]
+		if a.entry.OutputHash == "" {
+			continue
+		}
+		report.Artifacts++
+		artifactPath := output
+		if !filepath.IsAbs(artifactPath) {
+			artifactPath = filepath.Join(root, artifactPath)
+		}
+		h, err := HashFile(artifactPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			report.add(a.line, a.entry.Step, IssueArtifactMissing, "locked artifact %s no longer exists", output)
+		case err != nil:
+			report.add(a.line, a.entry.Step, IssueArtifactMissing, "read locked artifact %s: %v", output, err)
+		case h != a.entry.OutputHash:
+			report.add(a.line, a.entry.Step, IssueArtifactModified, "locked artifact %s changed since it was recorded", output)
+		}
+	}
+
+	if !anchorFound {
+		report.add(0, "", IssueAnchorMissing, "anchor %s is not in the chain; history was rewritten", opts.Anchor)
+	}
+	return report, nil
+}
+
+// verifySignature checks a hashed entry's signature and signer.
+func verifySignature(report *VerifyReport, line int, entry ChainEntry, trusted map[string]bool, requireSigned bool) {
+	if entry.Signature == "" {
+		if requireSigned {
+			report.add(line, entry.Step, IssueUnsigned, "entry is not signed")
+		}
+		return
+	}
+
+	pub, err := decodePublicKey(entry.Signer)
+	if err != nil {
+		report.add(line, entry.Step, IssueBadSignature, "signer: %v", err)
+		return
+	}
+	sig, err := base64.StdEncoding.DecodeString(entry.Signature)
+	if err != nil || !ed25519.Verify(pub, []byte(entry.Hash), sig) {
+		report.add(line, entry.Step, IssueBadSignature, "signature does not match the entry")
+		return
+	}
+	if (len(trusted) > 0 || requireSigned) && !trusted[entry.Signer] {
+		report.add(line, entry.Step, IssueUntrustedSigner, "signer %s is not in %s", entry.Signer, TrustedKeysFile)
+		return
+	}
+	report.Signed++
+}
@@ -0,0 +1,288 @@
+package ratchet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sealedChain returns a chain in a temp repo, with signing disabled unless
+// the test creates a key at the returned key path.
+func sealedChain(t *testing.T) (chain *Chain, root, keyPath string) {
+	t.Helper()
+	root = t.TempDir()
+	keyPath = filepath.Join(t.TempDir(), "ratchet.key")
+	t.Setenv(SigningKeyEnv, keyPath)
+	if err := os.MkdirAll(filepath.Join(root, ".agents", "ao"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := LoadChain(root)
+	if err != nil {
+		t.Fatalf("LoadChain: %v", err)
+	}
+	return chain, root, keyPath
+}
+
+func appendEntries(t *testing.T, chain *Chain, steps ...Step) {
+	t.Helper()
+	for _, step := range steps {
+		entry := ChainEntry{Step: step, Timestamp: time.Now(), Output: "epic:" + string(step), Locked: true}
+		if err := chain.Append(entry); err != nil {
+			t.Fatalf("Append(%s): %v", step, err)
+		}
+	}
+}
+
+func verifyKinds(t *testing.T, path string, opts VerifyOptions) []string {
+	t.Helper()
+	report, err := VerifyChainFile(path, opts)
+	if err != nil {
+		t.Fatalf("VerifyChainFile: %v", err)
+	}
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+func rewriteChainLines(t *testing.T, path string, edit func(lines []string) []string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendSealsEntries(t *testing.T) {
+	chain, _, _ := sealedChain(t)
+	appendEntries(t, chain, StepResearch, StepPreMortem, StepPlan)
+
+	for i, e := range chain.Entries {
+		if e.Hash == "" || e.Hash != e.ComputeHash() {
+			t.Errorf("entry %d hash = %q, want %q", i, e.Hash, e.ComputeHash())
+		}
+		if i > 0 && e.PrevHash != chain.Entries[i-1].Hash {
+			t.Errorf("entry %d not linked to entry %d", i, i-1)
+		}
+	}
+
+	report, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyChainFile: %v", err)
+	}
+	if !report.OK() || report.Hashed != 3 || report.Head != chain.Entries[2].Hash {
+		t.Errorf("report = %+v, want 3 hashed entries and no issues", report)
+	}
+
+	// A second process appending links to what is on disk, not to its
+	// stale in-memory copy
+	stale, err := LoadChain(filepath.Dir(filepath.Dir(filepath.Dir(chain.Path()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendEntries(t, chain, StepImplement)
+	appendEntries(t, stale, StepVibe)
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 0 {
+		t.Errorf("concurrent appends: issues %v", kinds)
+	}
+}
+
+func TestVerifyDetectsRewrittenHistory(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(lines []string) []string
+		want string
+	}{
+		{"edited entry", func(l []string) []string {
+			l[2] = strings.Replace(l[2], `"locked":true`, `"locked":false`, 1)
+			return l
+		}, IssueHashMismatch},
+		{"removed entry", func(l []string) []string {
+			return append(l[:2], l[3:]...)
+		}, IssueBrokenLink},
+		{"reordered entries", func(l []string) []string {
+			l[1], l[2] = l[2], l[1]
+			return l
+		}, IssueBrokenLink},
+		{"inserted unhashed entry", func(l []string) []string {
+			return append(l, `{"step":"vibe","timestamp":"2026-01-01T00:00:00Z","output":"x","locked":true}`)
+		}, IssueUnhashed},
+		{"malformed entry", func(l []string) []string {
+			return append(l, `{"step":`)
+		}, IssueMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, _, _ := sealedChain(t)
+			appendEntries(t, chain, StepResearch, StepPreMortem, StepPlan)
+			rewriteChainLines(t, chain.Path(), tt.edit)
+
+			kinds := verifyKinds(t, chain.Path(), VerifyOptions{})
+			found := false
+			for _, k := range kinds {
+				found = found || k == tt.want
+			}
+			if !found {
+				t.Errorf("issues = %v, want %s", kinds, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacyPrefix(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(SigningKeyEnv, filepath.Join(root, "no-key"))
+	writeJSONLChain(t, root, "legacy", "", []ChainEntry{
+		{Step: StepResearch, Timestamp: time.Now(), Output: "r.md", Locked: true},
+		{Step: StepPreMortem, Timestamp: time.Now(), Output: "s.md", Locked: true},
+	})
+	chain, err := LoadChain(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendEntries(t, chain, StepPlan)
+
+	report, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Legacy != 2 || report.Hashed != 1 {
+		t.Errorf("report = %+v, want 2 legacy and 1 hashed entry without issues", report)
+	}
+
+	// Editing a legacy entry breaks the first hashed entry's link
+	rewriteChainLines(t, chain.Path(), func(l []string) []string {
+		l[1] = strings.Replace(l[1], "r.md", "other.md", 1)
+		return l
+	})
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 1 || kinds[0] != IssueBrokenLink {
+		t.Errorf("issues = %v, want [%s]", kinds, IssueBrokenLink)
+	}
+}
+
+func TestVerifyLockedArtifacts(t *testing.T) {
+	chain, root, _ := sealedChain(t)
+	artifact := filepath.Join(".agents", "research", "topic.md")
+	if err := os.MkdirAll(filepath.Join(root, ".agents", "research"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, artifact), []byte("v1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.Append(ChainEntry{Step: StepResearch, Timestamp: time.Now(), Output: artifact, Locked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if chain.Entries[0].OutputHash == "" {
+		t.Fatal("OutputHash not recorded for a file output")
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 0 {
+		t.Fatalf("issues = %v, want none", kinds)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, artifact), []byte("v2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 1 || kinds[0] != IssueArtifactModified {
+		t.Errorf("issues = %v, want [%s]", kinds, IssueArtifactModified)
+	}
+
+	// Recording the step again locks the new content
+	if err := chain.Append(ChainEntry{Step: StepResearch, Timestamp: time.Now(), Output: artifact, Locked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 0 {
+		t.Errorf("issues after re-recording = %v, want none", kinds)
+	}
+
+	if err := os.Remove(filepath.Join(root, artifact)); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 1 || kinds[0] != IssueArtifactMissing {
+		t.Errorf("issues = %v, want [%s]", kinds, IssueArtifactMissing)
+	}
+}
+
+func TestVerifySignatures(t *testing.T) {
+	chain, root, keyPath := sealedChain(t)
+	appendEntries(t, chain, StepResearch)
+
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{RequireSigned: true}); len(kinds) != 1 || kinds[0] != IssueUnsigned {
+		t.Errorf("unsigned entry: issues = %v, want [%s]", kinds, IssueUnsigned)
+	}
+
+	pub, err := GenerateSigningKey(keyPath)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	if _, err := GenerateSigningKey(keyPath); err == nil {
+		t.Error("GenerateSigningKey overwrote an existing key")
+	}
+	appendEntries(t, chain, StepPreMortem)
+	if got := chain.Entries[1].Signer; got != EncodePublicKey(pub) {
+		t.Errorf("Signer = %q, want %q", got, EncodePublicKey(pub))
+	}
+
+	// Without a trusted keys file, valid signatures are accepted
+	report, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Signed != 1 {
+		t.Errorf("report = %+v, want 1 signed entry and no issues", report)
+	}
+
+	keysFile := filepath.Join(root, TrustedKeysFile)
+	if err := os.WriteFile(keysFile, []byte("# team keys\ned25519:"+strings.Repeat("A", 43)+"= someone-else\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 1 || kinds[0] != IssueUntrustedSigner {
+		t.Errorf("untrusted signer: issues = %v, want [%s]", kinds, IssueUntrustedSigner)
+	}
+
+	if err := os.WriteFile(keysFile, []byte(EncodePublicKey(pub)+" me\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 0 {
+		t.Errorf("trusted signer: issues = %v, want none", kinds)
+	}
+
+	// A forged signature is rejected even though the hash still matches
+	rewriteChainLines(t, chain.Path(), func(l []string) []string {
+		l[2] = strings.Replace(l[2], `"signature":"`, `"signature":"AAAA`, 1)
+		return l
+	})
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{}); len(kinds) != 1 || kinds[0] != IssueBadSignature {
+		t.Errorf("forged signature: issues = %v, want [%s]", kinds, IssueBadSignature)
+	}
+}
+
+func TestVerifyAnchor(t *testing.T) {
+	chain, _, _ := sealedChain(t)
+	appendEntries(t, chain, StepResearch, StepPreMortem)
+	anchor := chain.Entries[1].Hash
+	appendEntries(t, chain, StepPlan)
+
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{Anchor: anchor}); len(kinds) != 0 {
+		t.Errorf("appended chain: issues = %v, want none", kinds)
+	}
+
+	// Rewriting everything from the anchor on, with fresh hashes, passes
+	// the link checks but loses the anchor
+	rewriteChainLines(t, chain.Path(), func(l []string) []string { return l[:2] })
+	rebuilt, err := LoadChain(filepath.Dir(filepath.Dir(filepath.Dir(chain.Path()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendEntries(t, rebuilt, StepPlan)
+	if kinds := verifyKinds(t, chain.Path(), VerifyOptions{Anchor: anchor}); len(kinds) != 1 || kinds[0] != IssueAnchorMissing {
+		t.Errorf("rewritten chain: issues = %v, want [%s]", kinds, IssueAnchorMissing)
+	}
+}
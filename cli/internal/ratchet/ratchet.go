@@ -3,7 +3,6 @@
 package ratchet
 
 import (
-	"strings"
 	"time"
 )
 
@@ -20,54 +19,15 @@ const (
 	StepPostMortem Step = "post-mortem"
 )
 
-// AllSteps returns all valid steps in workflow order.
+// AllSteps returns all valid steps of the built-in workflow in order.
 func AllSteps() []Step {
-	return []Step{
-		StepResearch,
-		StepPreMortem,
-		StepPlan,
-		StepImplement,
-		StepCrank,
-		StepVibe,
-		StepPostMortem,
-	}
-}
-
-// stepAliases maps alternative names to canonical step names.
-var stepAliases = map[string]Step{
-	// Canonical names
-	"research":    StepResearch,
-	"pre-mortem":  StepPreMortem,
-	"plan":        StepPlan,
-	"implement":   StepImplement,
-	"crank":       StepCrank,
-	"vibe":        StepVibe,
-	"post-mortem": StepPostMortem,
-
-	// Aliases without hyphen
-	"premortem":  StepPreMortem,
-	"postmortem": StepPostMortem,
-
-	// Aliases with underscore
-	"pre_mortem":  StepPreMortem,
-	"post_mortem": StepPostMortem,
-
-	// Semantic aliases
-	"formulate": StepPlan, // Legacy alias - formulate is now plan
-	"autopilot": StepCrank,
-	"validate":  StepVibe,
-	"review":    StepPostMortem,
-	"execute":   StepCrank,
+	return defaultWorkflow().StepNames()
 }
 
-// ParseStep normalizes a step name to its canonical form.
-// Returns empty string if the step is not recognized.
+// ParseStep normalizes a step name of the built-in workflow to its canonical
+// form. Returns empty string if the step is not recognized.
 func ParseStep(name string) Step {
-	normalized := strings.ToLower(strings.TrimSpace(name))
-	if step, ok := stepAliases[normalized]; ok {
-		return step
-	}
-	return ""
+	return defaultWorkflow().ParseStep(name)
 }
 
 // IsValid returns true if the step is a recognized step name.
@@ -156,6 +116,23 @@ type ChainEntry struct {
 
 	// ParentEpic is the epic ID from the prior RPI cycle (empty for first cycle).
 	ParentEpic string `json:"parent_epic,omitempty"`
+
+	// OutputHash is the content hash of the output artifact when it is a
+	// file, so later edits to a locked artifact can be detected.
+	OutputHash string `json:"output_hash,omitempty"`
+
+	// PrevHash is the hash of the previous entry, or of the chain metadata
+	// for the first entry.
+	PrevHash string `json:"prev_hash,omitempty"`
+
+	// Hash is the hash of this entry, excluding Hash and the signature.
+	Hash string `json:"hash,omitempty"`
+
+	// Signer is the public key that signed Hash, if the entry is signed.
+	Signer string `json:"signer,omitempty"`
+
+	// Signature is the signer's signature over Hash.
+	Signature string `json:"signature,omitempty"`
 }
 
 // Chain represents the full ratchet chain state for a workflow.
@@ -174,6 +151,9 @@ type Chain struct {
 
 	// path is the file path where the chain is stored.
 	path string
+
+	// workflow defines the chain's steps; nil means the built-in workflow.
+	workflow *Workflow
 }
 
 // GateResult contains the result of a gate check.
@@ -181,9 +161,12 @@ type GateResult struct {
 	// Step is the step being checked.
 	Step Step `json:"step"`
 
-	// Passed indicates if the gate is satisfied.
+	// Passed indicates if the gate is satisfied (pass or warn).
 	Passed bool `json:"passed"`
 
+	// Status is the combined status of the step's checks.
+	Status GateStatus `json:"status"`
+
 	// Message describes the gate result.
 	Message string `json:"message"`
 
@@ -192,6 +175,20 @@ type GateResult struct {
 
 	// Location is where the input artifact was found.
 	Location string `json:"location,omitempty"`
+
+	// Checks are the results of the step's individual gates.
+	Checks []GateCheckResult `json:"checks,omitempty"`
+}
+
+// GateCheckResult is the result of one gate of a step.
+type GateCheckResult struct {
+	// Gate is the gate's display name.
+	Gate string `json:"gate"`
+
+	// Soft is set when a failure only warns.
+	Soft bool `json:"soft,omitempty"`
+
+	GateResponse
 }
 
 // ValidationResult contains the result of step validation.
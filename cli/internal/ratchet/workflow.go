@@ -0,0 +1,554 @@
+package ratchet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// WorkflowFile is the workflow definition file, relative to the repo root.
+	WorkflowFile = ".agents/ao/workflow.yaml"
+
+	// WorkflowVersion is the workflow definition format version.
+	WorkflowVersion = 1
+)
+
+// Workflow defines the ratchet steps, the order they run in and the gates
+// that guard them. Without a workflow file the built-in RPI flow is used.
+type Workflow struct {
+	// Version is the definition format version.
+	Version int `yaml:"version" json:"version"`
+
+	// Spec is the step whose output 'ao ratchet spec' reports.
+	Spec Step `yaml:"spec,omitempty" json:"spec,omitempty"`
+
+	// Steps are the workflow steps in order.
+	Steps []WorkflowStep `yaml:"steps" json:"steps"`
+
+	// Source is the file the workflow was loaded from, or empty for the
+	// built-in default.
+	Source string `yaml:"-" json:"source,omitempty"`
+
+	// gates resolves "uses" gates; nil means the default registry.
+	gates *GateRegistry
+}
+
+// registry returns the registry the workflow's gates are resolved in.
+func (w *Workflow) registry() *GateRegistry {
+	if w.gates != nil {
+		return w.gates
+	}
+	return defaultGates
+}
+
+// WorkflowStep defines one step of a workflow.
+type WorkflowStep struct {
+	// Name is the canonical step name recorded in the chain.
+	Name Step `yaml:"name" json:"name"`
+
+	// Aliases are alternative names accepted on the command line.
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+
+	// Skill is the command suggested when the step is next.
+	Skill string `yaml:"skill,omitempty" json:"skill,omitempty"`
+
+	// After lists the steps that must be locked or skipped first.
+	After []Step `yaml:"after,omitempty" json:"after,omitempty"`
+
+	// Satisfies names another step this one can stand in for; completing
+	// either completes both.
+	Satisfies Step `yaml:"satisfies,omitempty" json:"satisfies,omitempty"`
+
+	// Input is what the step consumes; the artifact and epic gates look
+	// for it.
+	Input StepArtifacts `yaml:"input,omitempty" json:"input,omitempty"`
+
+	// Output is what the step produces.
+	Output StepArtifacts `yaml:"output,omitempty" json:"output,omitempty"`
+
+	// Gate configures the step's input check. It is the short form of
+	// Gates; set one or the other.
+	Gate StepGate `yaml:"gate,omitempty" json:"gate,omitempty"`
+
+	// Gates are the checks 'ao ratchet check' runs before the step.
+	Gates []GateSpec `yaml:"gates,omitempty" json:"gates,omitempty"`
+}
+
+// StepGate configures a step's gate beyond its required input.
+type StepGate struct {
+	// Command runs with sh -c from the repo root; the gate fails on a
+	// non-zero exit.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Soft gates always pass; a failed check only changes the message.
+	Soft bool `yaml:"soft,omitempty" json:"soft,omitempty"`
+
+	// Message explains a failed check.
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// IsZero reports whether the gate has no settings.
+func (g StepGate) IsZero() bool {
+	return g.Command == "" && !g.Soft && g.Message == ""
+}
+
+// GateSpecs returns the checks run before the step: its gates, or for a
+// step without them, an artifact or epic check of its input followed by
+// the gate command.
+func (s *WorkflowStep) GateSpecs() []GateSpec {
+	if len(s.Gates) > 0 {
+		return s.Gates
+	}
+	var specs []GateSpec
+	switch {
+	case len(s.Input.Patterns) > 0:
+		specs = append(specs, GateSpec{Uses: GateArtifact})
+	case len(s.Input.Epic) > 0:
+		specs = append(specs, GateSpec{Uses: GateEpic})
+	}
+	if s.Gate.Command != "" {
+		specs = append(specs, GateSpec{Uses: GateCommand, With: map[string]string{"command": s.Gate.Command}})
+	}
+	for i := range specs {
+		specs[i].Soft = s.Gate.Soft
+		specs[i].Message = s.Gate.Message
+	}
+	return specs
+}
+
+// StepArtifacts describes the artifacts a step consumes or produces.
+type StepArtifacts struct {
+	// Description is shown to users, e.g. ".agents/research/<topic>.md".
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Patterns are globs relative to .agents, searched in order.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// Epic lists bd epic statuses, searched in order.
+	Epic []string `yaml:"epic,omitempty" json:"epic,omitempty"`
+}
+
+// IsZero reports whether no artifacts are described.
+func (a StepArtifacts) IsZero() bool {
+	return a.Description == "" && len(a.Patterns) == 0 && len(a.Epic) == 0
+}
+
+// GateSpec configures one gate check of a step: a registered gate (uses)
+// or an external executable (exec).
+type GateSpec struct {
+	// Name labels the check in output; defaults to Uses or the executable.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Uses names a registered gate: artifact, epic, command, or one
+	// registered with RegisterGate.
+	Uses string `yaml:"uses,omitempty" json:"uses,omitempty"`
+
+	// Exec is an executable implementing the gate protocol, relative to
+	// the repo root or on PATH.
+	Exec string `yaml:"exec,omitempty" json:"exec,omitempty"`
+
+	// Args are passed to Exec.
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// With is gate-specific configuration.
+	With map[string]string `yaml:"with,omitempty" json:"with,omitempty"`
+
+	// Soft turns a failure into a warning that does not block the step.
+	Soft bool `yaml:"soft,omitempty" json:"soft,omitempty"`
+
+	// Message replaces the gate's message when the check does not pass.
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// DisplayName labels the check in output.
+func (g GateSpec) DisplayName() string {
+	switch {
+	case g.Name != "":
+		return g.Name
+	case g.Uses != "":
+		return g.Uses
+	default:
+		return filepath.Base(g.Exec)
+	}
+}
+
+// DefaultWorkflow returns the built-in RPI workflow.
+func DefaultWorkflow() *Workflow {
+	epicInput := StepArtifacts{Description: "epic:<epic-id>", Epic: []string{"open", "in_progress"}}
+	epicOutput := StepArtifacts{Description: "issue:<issue-id> (closed)"}
+	epicGates := []GateSpec{{Uses: GateEpic, Message: "No open epic found. Run /plan first."}}
+
+	return &Workflow{
+		Version: WorkflowVersion,
+		Spec:    StepPreMortem,
+		Steps: []WorkflowStep{
+			{
+				Name:   StepResearch,
+				Skill:  "/research",
+				Output: StepArtifacts{Description: ".agents/research/<topic>.md", Patterns: []string{"research/*.md"}},
+			},
+			{
+				Name:    StepPreMortem,
+				Aliases: []string{"premortem", "pre_mortem"},
+				Skill:   "/pre-mortem",
+				After:   []Step{StepResearch},
+				Input: StepArtifacts{
+					Description: ".agents/research/*.md",
+					Patterns:    []string{"research/*.md", "research/**/*.md"},
+				},
+				Output: StepArtifacts{
+					Description: ".agents/specs/<topic>-v2.md",
+					Patterns:    []string{"specs/*-v*.md", "synthesis/*.md"},
+				},
+				Gates: []GateSpec{{Uses: GateArtifact, Message: "No research artifact found. Run /research first."}},
+			},
+			{
+				Name:    StepPlan,
+				Aliases: []string{"formulate"}, // Legacy alias - formulate is now plan
+				Skill:   "/plan",
+				After:   []Step{StepPreMortem},
+				Input: StepArtifacts{
+					Description: ".agents/specs/*-v2.md OR .agents/synthesis/*.md",
+					Patterns:    []string{"synthesis/*.md", "specs/*-v2.md", "specs/*-v*.md"},
+				},
+				Output: StepArtifacts{Description: "epic:<epic-id>"},
+				Gates:  []GateSpec{{Uses: GateArtifact, Message: "No spec or synthesis artifact found. Run /pre-mortem first."}},
+			},
+			{
+				Name:   StepImplement,
+				Skill:  "/implement or /crank",
+				After:  []Step{StepPlan},
+				Input:  epicInput,
+				Output: epicOutput,
+				Gates:  epicGates,
+			},
+			{
+				Name:      StepCrank,
+				Aliases:   []string{"autopilot", "execute"},
+				Skill:     "/implement or /crank",
+				After:     []Step{StepPlan},
+				Satisfies: StepImplement,
+				Input:     epicInput,
+				Output:    epicOutput,
+				Gates:     epicGates,
+			},
+			{
+				Name:    StepVibe,
+				Aliases: []string{"validate"},
+				Skill:   "/vibe",
+				After:   []Step{StepImplement},
+				Input:   StepArtifacts{Description: "code changes (optional)"},
+				Output:  StepArtifacts{Description: "validation report"},
+				Gates: []GateSpec{{
+					Uses:    GateCommand,
+					With:    map[string]string{"command": "test -n \"$(git status --porcelain)\""},
+					Soft:    true,
+					Message: "no code changes detected",
+				}},
+			},
+			{
+				Name:    StepPostMortem,
+				Aliases: []string{"postmortem", "post_mortem", "review"},
+				Skill:   "/post-mortem",
+				After:   []Step{StepVibe},
+				Input:   StepArtifacts{Description: "closed epic (optional)", Epic: []string{"closed"}},
+				Output: StepArtifacts{
+					Description: ".agents/retros/<date>-<topic>.md",
+					Patterns:    []string{"retros/*.md"},
+				},
+				Gates: []GateSpec{{Uses: GateEpic, Soft: true, Message: "no closed epic found, informal review OK"}},
+			},
+		},
+	}
+}
+
+// defaultWorkflow is the shared built-in workflow behind the package-level
+// helpers. Callers must not modify it.
+var defaultWorkflow = sync.OnceValue(DefaultWorkflow)
+
+// LoadWorkflow loads the workflow file from the nearest .agents directory,
+// falling back to DefaultWorkflow when there is none.
+func LoadWorkflow(startDir string) (*Workflow, error) {
+	return LoadWorkflowWithGates(startDir, nil)
+}
+
+// LoadWorkflowWithGates is LoadWorkflow resolving "uses" gates in gates
+// instead of the default registry.
+func LoadWorkflowWithGates(startDir string, gates *GateRegistry) (*Workflow, error) {
+	agentsDir, err := findAgentsDir(startDir)
+	if err != nil {
+		return withGates(DefaultWorkflow(), gates), nil
+	}
+	return loadWorkflowFrom(agentsDir, gates)
+}
+
+// loadWorkflowFrom loads ao/workflow.yaml under agentsDir, or the default.
+func loadWorkflowFrom(agentsDir string, gates *GateRegistry) (*Workflow, error) {
+	path := filepath.Join(agentsDir, "ao", filepath.Base(WorkflowFile))
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return withGates(DefaultWorkflow(), gates), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read workflow: %w", err)
+	}
+	w, err := ParseWorkflowWithGates(data, gates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	w.Source = path
+	return w, nil
+}
+
+func withGates(w *Workflow, gates *GateRegistry) *Workflow {
+	w.gates = gates
+	return w
+}
+
+// ParseWorkflow parses and validates a YAML workflow definition.
+func ParseWorkflow(data []byte) (*Workflow, error) {
+	return ParseWorkflowWithGates(data, nil)
+}
+
+// ParseWorkflowWithGates is ParseWorkflow resolving "uses" gates in gates
+// instead of the default registry.
+func ParseWorkflowWithGates(data []byte, gates *GateRegistry) (*Workflow, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	w := Workflow{gates: gates}
+	if err := dec.Decode(&w); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("workflow file is empty")
+		}
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Validate checks that step names and aliases are unique and that every
+// reference names a known step. Prerequisites must come earlier in the
+// step list, which keeps the step graph acyclic.
+func (w *Workflow) Validate() error {
+	if w.Version != WorkflowVersion {
+		return fmt.Errorf("unsupported workflow version %d (want %d)", w.Version, WorkflowVersion)
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow has no steps")
+	}
+
+	names := make(map[string]Step)
+	index := make(map[Step]int)
+	for i, s := range w.Steps {
+		if s.Name == "" {
+			return fmt.Errorf("step %d has no name", i+1)
+		}
+		if normalizeStepName(string(s.Name)) != string(s.Name) {
+			return fmt.Errorf("step %q: names must be lower-case without surrounding spaces", s.Name)
+		}
+		for _, name := range append([]string{string(s.Name)}, s.Aliases...) {
+			key := normalizeStepName(name)
+			if other, ok := names[key]; ok {
+				return fmt.Errorf("step %q: name %q is already used by step %q", s.Name, name, other)
+			}
+			names[key] = s.Name
+		}
+		index[s.Name] = i
+	}
+
+	for i, s := range w.Steps {
+		for _, dep := range s.After {
+			j, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("step %q: unknown prerequisite %q", s.Name, dep)
+			}
+			if j >= i {
+				return fmt.Errorf("step %q: prerequisite %q must come earlier in the workflow", s.Name, dep)
+			}
+		}
+		if len(s.Gates) > 0 && !s.Gate.IsZero() {
+			return fmt.Errorf("step %q: set gate or gates, not both", s.Name)
+		}
+		for j, g := range s.Gates {
+			if err := g.validate(w.registry()); err != nil {
+				return fmt.Errorf("step %q: gate %d: %w", s.Name, j+1, err)
+			}
+		}
+		if s.Satisfies != "" {
+			if _, ok := index[s.Satisfies]; !ok {
+				return fmt.Errorf("step %q: satisfies unknown step %q", s.Name, s.Satisfies)
+			}
+			if s.Satisfies == s.Name {
+				return fmt.Errorf("step %q: cannot satisfy itself", s.Name)
+			}
+		}
+	}
+
+	if w.Spec != "" {
+		spec := w.Step(w.Spec)
+		if spec == nil {
+			return fmt.Errorf("spec names unknown step %q", w.Spec)
+		}
+		if len(spec.Output.Patterns) == 0 {
+			return fmt.Errorf("spec step %q has no output patterns", w.Spec)
+		}
+	}
+	return nil
+}
+
+func normalizeStepName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// StepNames returns the workflow's step names in order.
+func (w *Workflow) StepNames() []Step {
+	steps := make([]Step, len(w.Steps))
+	for i, s := range w.Steps {
+		steps[i] = s.Name
+	}
+	return steps
+}
+
+// Step returns the definition of a step, or nil if it is not in the workflow.
+func (w *Workflow) Step(name Step) *WorkflowStep {
+	for i := range w.Steps {
+		if w.Steps[i].Name == name {
+			return &w.Steps[i]
+		}
+	}
+	return nil
+}
+
+// ParseStep resolves a step name or alias to its canonical name.
+// Returns empty string if the step is not recognized.
+func (w *Workflow) ParseStep(name string) Step {
+	normalized := normalizeStepName(name)
+	for _, s := range w.Steps {
+		if string(s.Name) == normalized {
+			return s.Name
+		}
+		for _, alias := range s.Aliases {
+			if normalizeStepName(alias) == normalized {
+				return s.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Skill returns the suggested command for a step.
+func (w *Workflow) Skill(name Step) string {
+	if s := w.Step(name); s != nil {
+		return s.Skill
+	}
+	return ""
+}
+
+// group is the step a step is interchangeable with: its Satisfies target,
+// or itself.
+func (s *WorkflowStep) group() Step {
+	if s.Satisfies != "" {
+		return s.Satisfies
+	}
+	return s.Name
+}
+
+// Completed returns the steps the chain has completed. A step is complete
+// when its latest entry is locked or skipped, when an interchangeable step
+// is complete, or when a step that depends on it is complete.
+func (w *Workflow) Completed(chain *Chain) map[Step]bool {
+	done := make(map[Step]bool)
+	groups := make(map[Step]bool)
+	for _, s := range w.Steps {
+		status := chain.GetStatus(s.Name)
+		if status == StatusLocked || status == StatusSkipped {
+			done[s.Name] = true
+			groups[s.group()] = true
+		}
+	}
+
+	// Propagate until nothing changes; each pass only adds steps
+	for changed := true; changed; {
+		changed = false
+		for i := range w.Steps {
+			s := &w.Steps[i]
+			if !done[s.Name] && groups[s.group()] {
+				done[s.Name] = true
+				changed = true
+			}
+			if !done[s.Name] {
+				continue
+			}
+			for _, dep := range s.After {
+				if !done[dep] {
+					done[dep] = true
+					changed = true
+				}
+				if d := w.Step(dep); d != nil && !groups[d.group()] {
+					groups[d.group()] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return done
+}
+
+// Next returns the first step the chain has not completed, or empty string
+// when every step is complete. Prerequisites come earlier in the workflow,
+// so the first incomplete step is always ready to run.
+func (w *Workflow) Next(chain *Chain) Step {
+	done := w.Completed(chain)
+	for _, s := range w.Steps {
+		if !done[s.Name] {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// validate checks that the gate names exactly one registered gate or
+// executable.
+func (g GateSpec) validate(gates *GateRegistry) error {
+	switch {
+	case g.Uses != "" && g.Exec != "":
+		return fmt.Errorf("set uses or exec, not both")
+	case g.Exec != "":
+		return nil
+	case g.Uses == "":
+		return fmt.Errorf("set uses or exec")
+	}
+	if _, ok := gates.Lookup(g.Uses); !ok {
+		return fmt.Errorf("unknown gate %q (registered: %s)", g.Uses, strings.Join(gates.Names(), ", "))
+	}
+	return nil
+}
+
+// RequiredInput describes the input artifact a step's gate expects.
+func (w *Workflow) RequiredInput(name Step) string {
+	s := w.Step(name)
+	if s == nil {
+		return "unknown"
+	}
+	return s.Input.Description
+}
+
+// ExpectedOutput describes the output artifact a step produces.
+func (w *Workflow) ExpectedOutput(name Step) string {
+	s := w.Step(name)
+	if s == nil {
+		return "unknown"
+	}
+	return s.Output.Description
+}
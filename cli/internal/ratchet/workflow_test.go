@@ -0,0 +1,226 @@
+package ratchet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reviewWorkflow has two parallel branches joined by a release step, and a
+// step that stands in for another.
+const reviewWorkflow = `version: 1
+spec: design
+steps:
+  - name: design
+    aliases: [draft]
+    skill: /design
+    output:
+      patterns: [designs/*.md]
+  - name: build
+    after: [design]
+    input:
+      patterns: [designs/*.md]
+    gate:
+      message: Write a design first.
+  - name: docs
+    after: [design]
+  - name: quick-build
+    after: [design]
+    satisfies: build
+  - name: release
+    after: [build, docs]
+    gate:
+      command: test -f RELEASE_OK
+`
+
+func writeWorkflow(t *testing.T, dir, content string) {
+	t.Helper()
+	aoDir := filepath.Join(dir, ".agents", "ao")
+	if err := os.MkdirAll(aoDir, 0700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(aoDir, "workflow.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestDefaultWorkflowMatchesBuiltins(t *testing.T) {
+	w := DefaultWorkflow()
+	if err := w.Validate(); err != nil {
+		t.Fatalf("default workflow invalid: %v", err)
+	}
+	if got := len(w.Steps); got != len(AllSteps()) {
+		t.Errorf("default workflow has %d steps, AllSteps has %d", got, len(AllSteps()))
+	}
+
+	aliases := map[string]Step{
+		"premortem": StepPreMortem,
+		"formulate": StepPlan,
+		"autopilot": StepCrank,
+		"validate":  StepVibe,
+		"review":    StepPostMortem,
+	}
+	for alias, want := range aliases {
+		if got := w.ParseStep(alias); got != want {
+			t.Errorf("ParseStep(%q) = %q, want %q", alias, got, want)
+		}
+	}
+	if got := GetExpectedOutput(StepPreMortem); got != ".agents/specs/<topic>-v2.md" {
+		t.Errorf("GetExpectedOutput(pre-mortem) = %q", got)
+	}
+	if got := GetRequiredInput("nope"); got != "unknown" {
+		t.Errorf("GetRequiredInput(unknown) = %q, want unknown", got)
+	}
+}
+
+func TestParseWorkflowErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"empty", "", "empty"},
+		{"version", "version: 2\nsteps: [{name: a}]\n", "unsupported workflow version"},
+		{"no steps", "version: 1\n", "no steps"},
+		{"unknown field", "version: 1\nsteps: [{name: a, gate: {cmd: x}}]\n", "field cmd not found"},
+		{"gate and gates", "version: 1\nsteps: [{name: a, gate: {soft: true}, gates: [{uses: command}]}]\n", "not both"},
+		{"upper case", "version: 1\nsteps: [{name: A}]\n", "lower-case"},
+		{"duplicate alias", "version: 1\nsteps: [{name: a}, {name: b, aliases: [A]}]\n", `name "A" is already used by step "a"`},
+		{"unknown prerequisite", "version: 1\nsteps: [{name: a, after: [z]}]\n", `unknown prerequisite "z"`},
+		{"later prerequisite", "version: 1\nsteps: [{name: a, after: [b]}, {name: b}]\n", "must come earlier"},
+		{"self prerequisite", "version: 1\nsteps: [{name: a, after: [a]}]\n", "must come earlier"},
+		{"unknown satisfies", "version: 1\nsteps: [{name: a, satisfies: z}]\n", `satisfies unknown step "z"`},
+		{"unknown spec", "version: 1\nspec: z\nsteps: [{name: a}]\n", `spec names unknown step "z"`},
+		{"spec without patterns", "version: 1\nspec: a\nsteps: [{name: a}]\n", "no output patterns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWorkflow([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseWorkflow() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowNext(t *testing.T) {
+	w, err := ParseWorkflow([]byte(reviewWorkflow))
+	if err != nil {
+		t.Fatalf("ParseWorkflow: %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		entries []ChainEntry
+		want    Step
+	}{
+		{"empty", nil, "design"},
+		{"in progress is not done", []ChainEntry{{Step: "design"}}, "design"},
+		{"parallel branch", []ChainEntry{{Step: "design", Locked: true}, {Step: "build", Locked: true}}, "docs"},
+		{"satisfies", []ChainEntry{
+			{Step: "design", Locked: true},
+			{Step: "quick-build", Locked: true},
+			{Step: "docs", Skipped: true},
+		}, "release"},
+		{"later step implies prerequisites", []ChainEntry{{Step: "release", Locked: true}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.entries {
+				tt.entries[i].Timestamp = now
+			}
+			chain := &Chain{Entries: tt.entries, workflow: w}
+			if got := w.Next(chain); got != tt.want {
+				t.Errorf("Next() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Completing the stand-in completes the step it satisfies
+	chain := &Chain{Entries: []ChainEntry{{Step: "quick-build", Locked: true}}, workflow: w}
+	done := w.Completed(chain)
+	for _, step := range []Step{"design", "build", "quick-build"} {
+		if !done[step] {
+			t.Errorf("Completed() missing %q", step)
+		}
+	}
+	if done["docs"] {
+		t.Error("Completed() includes docs, which nothing completed")
+	}
+}
+
+func TestLoadChainUsesWorkflow(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, reviewWorkflow)
+	writeJSONLChain(t, tmp, "wf", "", []ChainEntry{{Step: "design", Timestamp: time.Now(), Output: "d.md", Locked: true}})
+
+	chain, err := LoadChain(tmp)
+	if err != nil {
+		t.Fatalf("LoadChain: %v", err)
+	}
+	if got := chain.Workflow().Source; !strings.HasSuffix(got, filepath.Join(".agents", "ao", "workflow.yaml")) {
+		t.Errorf("Workflow().Source = %q", got)
+	}
+	status := chain.GetAllStatus()
+	if len(status) != 5 || status["design"] != StatusLocked || status["release"] != StatusPending {
+		t.Errorf("GetAllStatus() = %v", status)
+	}
+
+	writeWorkflow(t, tmp, "version: 1\nsteps: [{name: a, after: [b]}, {name: b}]\n")
+	if _, err := LoadChain(tmp); err == nil {
+		t.Error("LoadChain() with an invalid workflow succeeded")
+	}
+}
+
+func TestGateCheckerWorkflowGates(t *testing.T) {
+	tmp := t.TempDir()
+	writeWorkflow(t, tmp, reviewWorkflow)
+
+	checker, err := NewGateChecker(tmp)
+	if err != nil {
+		t.Fatalf("NewGateChecker: %v", err)
+	}
+
+	check := func(step Step) *GateResult {
+		t.Helper()
+		result, err := checker.Check(step)
+		if err != nil {
+			t.Fatalf("Check(%s): %v", step, err)
+		}
+		return result
+	}
+
+	if r := check("design"); !r.Passed {
+		t.Errorf("design gate failed: %s", r.Message)
+	}
+	if r := check("build"); r.Passed || r.Message != "Write a design first." {
+		t.Errorf("build gate = %+v, want failure with the configured message", r)
+	}
+	if r := check("release"); r.Passed {
+		t.Errorf("release gate passed without RELEASE_OK: %s", r.Message)
+	}
+	if r := check("nope"); r.Passed {
+		t.Error("unknown step passed")
+	}
+
+	designs := filepath.Join(tmp, ".agents", "designs")
+	if err := os.MkdirAll(designs, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(designs, "api.md"), []byte("# API\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "RELEASE_OK"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := check("build"); !r.Passed || !strings.HasSuffix(r.Input, "api.md") {
+		t.Errorf("build gate = %+v, want pass with the design as input", r)
+	}
+	if r := check("release"); !r.Passed {
+		t.Errorf("release gate failed with RELEASE_OK: %s", r.Message)
+	}
+}
@@ -0,0 +1,215 @@
+// Package scorer learns candidate scoring from human gate decisions.
+//
+// The extraction rubric (see taxonomy.DefaultRubricWeights) is a fixed
+// guess at what makes knowledge worth keeping. Every 'ao gate approve' and
+// 'ao gate reject' is a labelled example of what reviewers actually keep, so
+// a Model fitted to those decisions can replace the fixed weights at ingest.
+//
+// Two kinds of model are supported:
+//   - weights: non-negative rubric weights summing to 1, a drop-in for the
+//     default weights
+//   - logistic: a logistic model over the rubric and a few content features,
+//     scoring the probability a reviewer approves
+package scorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// ModelFile is the learned model's path relative to the repository root.
+const ModelFile = ".agents/ao/scorer.json"
+
+// ModelVersion is the current model file format.
+const ModelVersion = 1
+
+// Model kinds.
+const (
+	KindWeights  = "weights"
+	KindLogistic = "logistic"
+)
+
+// Feature names. The first five are the rubric dimensions.
+const (
+	FeatureSpecificity   = "specificity"
+	FeatureActionability = "actionability"
+	FeatureNovelty       = "novelty"
+	FeatureContext       = "context"
+	FeatureConfidence    = "confidence"
+	FeatureLength        = "length"
+	FeatureCodeBlock     = "code_block"
+	FeatureList          = "list"
+	FeatureSource        = "source_section"
+)
+
+// RubricFeatures are the features a weights model is fitted over.
+var RubricFeatures = []string{
+	FeatureSpecificity, FeatureActionability, FeatureNovelty, FeatureContext, FeatureConfidence,
+}
+
+// AllFeatures are the features a logistic model is fitted over.
+var AllFeatures = append(append([]string{}, RubricFeatures...),
+	FeatureLength, FeatureCodeBlock, FeatureList, FeatureSource)
+
+var reListItem = regexp.MustCompile(`(?m)^\s*[-*]\s+`)
+
+// lengthScale is the content length, in bytes, that maps to a length
+// feature of 1.
+const lengthScale = 4000
+
+// Features extracts the scorer's features from a candidate's content and
+// rubric scores. All features are in [0, 1].
+func Features(content string, rubric types.RubricScores) map[string]float64 {
+	lower := strings.ToLower(content)
+	length := math.Min(1, math.Log1p(float64(len(content)))/math.Log1p(lengthScale))
+	return map[string]float64{
+		FeatureSpecificity:   rubric.Specificity,
+		FeatureActionability: rubric.Actionability,
+		FeatureNovelty:       rubric.Novelty,
+		FeatureContext:       rubric.Context,
+		FeatureConfidence:    rubric.Confidence,
+		FeatureLength:        length,
+		FeatureCodeBlock:     indicator(strings.Contains(content, "```")),
+		FeatureList:          indicator(reListItem.MatchString(content)),
+		FeatureSource:        indicator(strings.Contains(lower, "## source") || strings.Contains(lower, "**source**")),
+	}
+}
+
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Model is a scorer fitted to gate decisions.
+type Model struct {
+	Version int    `json:"version"`
+	Kind    string `json:"kind"`
+
+	// Weights are the rubric weights of a weights model.
+	Weights taxonomy.RubricWeights `json:"weights"`
+
+	// Coefficients and Intercept define a logistic model.
+	Coefficients map[string]float64 `json:"coefficients,omitempty"`
+	Intercept    float64            `json:"intercept,omitempty"`
+
+	// Threshold is the score at or above which the model predicts approval,
+	// chosen on the training split.
+	Threshold float64 `json:"threshold"`
+
+	TrainedAt time.Time `json:"trained_at"`
+	Report    Report    `json:"report"`
+}
+
+// DefaultModel scores with the fixed default rubric weights.
+func DefaultModel() *Model {
+	return &Model{Version: ModelVersion, Kind: KindWeights, Weights: taxonomy.DefaultRubricWeights}
+}
+
+// Score returns the model's score in [0, 1] for a candidate. Ingest uses it
+// in place of the default rubric weighted sum.
+func (m *Model) Score(content string, rubric types.RubricScores) float64 {
+	if m.Kind == KindLogistic {
+		return m.scoreFeatures(Features(content, rubric))
+	}
+	return m.Weights.Score(rubric)
+}
+
+// scoreFeatures scores an extracted feature vector.
+func (m *Model) scoreFeatures(f map[string]float64) float64 {
+	if m.Kind == KindLogistic {
+		z := m.Intercept
+		for name, c := range m.Coefficients {
+			z += c * f[name]
+		}
+		return sigmoid(z)
+	}
+	return m.Weights.Specificity*f[FeatureSpecificity] +
+		m.Weights.Actionability*f[FeatureActionability] +
+		m.Weights.Novelty*f[FeatureNovelty] +
+		m.Weights.Context*f[FeatureContext] +
+		m.Weights.Confidence*f[FeatureConfidence]
+}
+
+// Validate checks the model is usable.
+func (m *Model) Validate() error {
+	if m.Version != ModelVersion {
+		return fmt.Errorf("unsupported scorer version %d (want %d)", m.Version, ModelVersion)
+	}
+	switch m.Kind {
+	case KindWeights:
+		if !m.Weights.ValidateWeights() {
+			return fmt.Errorf("scorer weights must sum to 1")
+		}
+	case KindLogistic:
+		if len(m.Coefficients) == 0 {
+			return fmt.Errorf("logistic scorer has no coefficients")
+		}
+	default:
+		return fmt.Errorf("unknown scorer kind %q", m.Kind)
+	}
+	return nil
+}
+
+// Path returns the model file path under baseDir.
+func Path(baseDir string) string {
+	return filepath.Join(baseDir, filepath.FromSlash(ModelFile))
+}
+
+// ErrNoModel is returned by Load when no model has been trained.
+var ErrNoModel = errors.New("no learned scorer; run 'ao gate train' first")
+
+// Load reads the learned model under baseDir.
+func Load(baseDir string) (*Model, error) {
+	data, err := os.ReadFile(Path(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoModel
+		}
+		return nil, fmt.Errorf("read scorer: %w", err)
+	}
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse scorer: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", Path(baseDir), err)
+	}
+	return &m, nil
+}
+
+// Save writes m under baseDir, replacing any previous model.
+func Save(baseDir string, m *Model) error {
+	path := Path(baseDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create scorer directory: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal scorer: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write scorer: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp) //nolint:errcheck // cleanup in error path
+		return fmt.Errorf("write scorer: %w", err)
+	}
+	return nil
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,130 @@
+package scorer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// decisions builds n examples where reviewers approve exactly the
+// candidates with high context, a dimension the default weights barely use.
+func decisions(n int) []Example {
+	var examples []Example
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("cand-%d", i)
+		approved := i%3 != 0
+		rubric := types.RubricScores{
+			Specificity:   taxonomy.SampleDraw("specificity", id),
+			Actionability: taxonomy.SampleDraw("actionability", id),
+			Novelty:       0.5,
+			Context:       0.3,
+			Confidence:    0.6,
+		}
+		if approved {
+			rubric.Context = 0.8
+		}
+		examples = append(examples, Example{
+			CandidateID: id,
+			Features:    Features("body", rubric),
+			Approved:    approved,
+		})
+	}
+	return examples
+}
+
+func TestTrainWeights(t *testing.T) {
+	m, err := Train(decisions(150), TrainOptions{Kind: KindWeights, Seed: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("trained model invalid: %v", err)
+	}
+	if m.Weights.Context <= m.Weights.Specificity {
+		t.Errorf("context should dominate the learned weights: %+v", m.Weights)
+	}
+	r := m.Report
+	if r.Train+r.Holdout != 150 || r.Holdout == 0 || r.Approved != 100 {
+		t.Errorf("report split = %+v", r)
+	}
+	if r.LearnedAccuracy < 0.95 || r.LearnedAccuracy <= r.DefaultAccuracy {
+		t.Errorf("learned accuracy %.2f should beat default %.2f", r.LearnedAccuracy, r.DefaultAccuracy)
+	}
+
+	// The split is reproducible
+	again, err := Train(decisions(150), TrainOptions{Kind: KindWeights, Seed: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Report.Holdout != r.Holdout || again.Weights != m.Weights {
+		t.Error("training with the same seed should give the same model")
+	}
+}
+
+func TestTrainLogistic(t *testing.T) {
+	m, err := Train(decisions(150), TrainOptions{Kind: KindLogistic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Coefficients[FeatureContext] <= 0 {
+		t.Errorf("context coefficient = %f, want positive", m.Coefficients[FeatureContext])
+	}
+	if m.Report.LearnedAccuracy < 0.95 {
+		t.Errorf("learned accuracy = %.2f", m.Report.LearnedAccuracy)
+	}
+	high := m.Score("body", types.RubricScores{Context: 0.8, Novelty: 0.5, Confidence: 0.6})
+	low := m.Score("body", types.RubricScores{Context: 0.3, Novelty: 0.5, Confidence: 0.6})
+	if high < m.Threshold || low >= m.Threshold {
+		t.Errorf("scores %f/%f should straddle threshold %f", high, low, m.Threshold)
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	if _, err := Train(decisions(MinExamples-1), TrainOptions{}); err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Errorf("too few decisions: err = %v", err)
+	}
+	allApproved := decisions(60)
+	for i := range allApproved {
+		allApproved[i].Approved = true
+	}
+	if _, err := Train(allApproved, TrainOptions{}); err == nil {
+		t.Error("one-sided decisions should fail")
+	}
+	if _, err := Train(decisions(60), TrainOptions{Kind: "forest"}); err == nil {
+		t.Error("unknown kind should fail")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); !errors.Is(err, ErrNoModel) {
+		t.Fatalf("Load without model: err = %v, want ErrNoModel", err)
+	}
+
+	m, err := Train(decisions(60), TrainOptions{Kind: KindLogistic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(dir, m); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rubric := types.RubricScores{Specificity: 0.6, Context: 0.7}
+	if loaded.Score("some `code`", rubric) != m.Score("some `code`", rubric) {
+		t.Error("loaded model scores differently")
+	}
+}
+
+func TestDefaultModelMatchesRubric(t *testing.T) {
+	rubric := types.RubricScores{Specificity: 1, Actionability: 1, Novelty: 1, Context: 1, Confidence: 1}
+	if got := DefaultModel().Score("", rubric); got < 0.999 || got > 1.001 {
+		t.Errorf("default score of a perfect rubric = %f, want 1", got)
+	}
+}
@@ -0,0 +1,256 @@
+package scorer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+)
+
+// MinExamples is the fewest gate decisions Train will fit a model to.
+const MinExamples = 20
+
+// Example is one gate decision: a candidate's features and whether a
+// reviewer approved it.
+type Example struct {
+	CandidateID string             `json:"candidate_id"`
+	Features    map[string]float64 `json:"features"`
+	Approved    bool               `json:"approved"`
+}
+
+// TrainOptions configures Train.
+type TrainOptions struct {
+	// Kind is KindWeights or KindLogistic.
+	Kind string
+
+	// Holdout is the fraction of examples held out for evaluation.
+	Holdout float64
+
+	// Seed selects the holdout split; the split for a candidate depends only
+	// on the seed and its ID, so reruns evaluate on the same examples.
+	Seed string
+}
+
+// DefaultHoldout is the fraction of examples held out when unset.
+const DefaultHoldout = 0.2
+
+// Gradient descent settings. Features are in [0, 1], so a fixed step size
+// converges without scaling.
+const (
+	trainEpochs       = 3000
+	trainLearningRate = 0.5
+	trainL2           = 0.001
+)
+
+// Report compares a trained model with the default weights on held-out
+// decisions.
+type Report struct {
+	Examples int `json:"examples"`
+	Approved int `json:"approved"`
+	Rejected int `json:"rejected"`
+	Train    int `json:"train"`
+	Holdout  int `json:"holdout"`
+
+	// InSample is set when no examples fell in the holdout split and the
+	// accuracies are measured on the training examples.
+	InSample bool `json:"in_sample,omitempty"`
+
+	DefaultAccuracy  float64 `json:"default_accuracy"`
+	DefaultThreshold float64 `json:"default_threshold"`
+	LearnedAccuracy  float64 `json:"learned_accuracy"`
+}
+
+// Train fits a model of opts.Kind to examples and reports its held-out
+// accuracy against the default rubric weights. Both scorers predict approval
+// at a threshold chosen on the training split.
+func Train(examples []Example, opts TrainOptions) (*Model, error) {
+	if opts.Kind == "" {
+		opts.Kind = KindWeights
+	}
+	if opts.Kind != KindWeights && opts.Kind != KindLogistic {
+		return nil, fmt.Errorf("unknown scorer kind %q (want %s or %s)", opts.Kind, KindWeights, KindLogistic)
+	}
+	if opts.Holdout <= 0 || opts.Holdout >= 1 {
+		opts.Holdout = DefaultHoldout
+	}
+	if len(examples) < MinExamples {
+		return nil, fmt.Errorf("need at least %d gate decisions to train, have %d", MinExamples, len(examples))
+	}
+
+	var train, holdout []Example
+	report := Report{Examples: len(examples)}
+	for _, e := range examples {
+		if e.Approved {
+			report.Approved++
+		} else {
+			report.Rejected++
+		}
+		if taxonomy.SampleDraw(opts.Seed, e.CandidateID) < opts.Holdout {
+			holdout = append(holdout, e)
+		} else {
+			train = append(train, e)
+		}
+	}
+	if !hasBothLabels(train) {
+		return nil, fmt.Errorf("training decisions must include both approvals and rejections")
+	}
+	report.Train, report.Holdout = len(train), len(holdout)
+	if len(holdout) == 0 {
+		holdout = train
+		report.InSample = true
+	}
+
+	features := RubricFeatures
+	if opts.Kind == KindLogistic {
+		features = AllFeatures
+	}
+	coef, intercept := fitLogistic(train, features, opts.Kind == KindWeights)
+
+	m := &Model{Version: ModelVersion, Kind: opts.Kind, TrainedAt: time.Now().UTC()}
+	if opts.Kind == KindLogistic {
+		m.Coefficients = make(map[string]float64, len(features))
+		for i, name := range features {
+			m.Coefficients[name] = coef[i]
+		}
+		m.Intercept = intercept
+	} else {
+		m.Weights = normalizedWeights(coef)
+	}
+
+	def := DefaultModel()
+	def.Threshold = bestThreshold(def, train)
+	m.Threshold = bestThreshold(m, train)
+
+	report.DefaultThreshold = def.Threshold
+	report.DefaultAccuracy = accuracy(def, holdout)
+	report.LearnedAccuracy = accuracy(m, holdout)
+	m.Report = report
+	return m, nil
+}
+
+func hasBothLabels(examples []Example) bool {
+	var approved, rejected bool
+	for _, e := range examples {
+		approved = approved || e.Approved
+		rejected = rejected || !e.Approved
+	}
+	return approved && rejected
+}
+
+// fitLogistic fits a class-balanced, L2-regularised logistic regression by
+// batch gradient descent. With nonNegative, coefficients are clamped at zero
+// after each step so they can serve as rubric weights.
+func fitLogistic(examples []Example, features []string, nonNegative bool) ([]float64, float64) {
+	var positives int
+	for _, e := range examples {
+		if e.Approved {
+			positives++
+		}
+	}
+	// Weight each class equally so a queue of mostly approvals does not
+	// teach the model to approve everything
+	n := float64(len(examples))
+	posWeight := n / (2 * float64(positives))
+	negWeight := n / (2 * float64(len(examples)-positives))
+
+	xs := make([][]float64, len(examples))
+	for i, e := range examples {
+		xs[i] = make([]float64, len(features))
+		for j, name := range features {
+			xs[i][j] = e.Features[name]
+		}
+	}
+
+	coef := make([]float64, len(features))
+	var intercept float64
+	grad := make([]float64, len(features))
+	for epoch := 0; epoch < trainEpochs; epoch++ {
+		for j := range grad {
+			grad[j] = 0
+		}
+		var gradIntercept float64
+		for i, e := range examples {
+			z := intercept
+			for j, x := range xs[i] {
+				z += coef[j] * x
+			}
+			label, weight := 0.0, negWeight
+			if e.Approved {
+				label, weight = 1, posWeight
+			}
+			diff := weight * (sigmoid(z) - label)
+			for j, x := range xs[i] {
+				grad[j] += diff * x
+			}
+			gradIntercept += diff
+		}
+		for j := range coef {
+			coef[j] -= trainLearningRate * (grad[j]/n + trainL2*coef[j])
+			if nonNegative && coef[j] < 0 {
+				coef[j] = 0
+			}
+		}
+		intercept -= trainLearningRate * gradIntercept / n
+	}
+	return coef, intercept
+}
+
+// normalizedWeights turns non-negative rubric coefficients into weights
+// summing to 1. If no dimension predicts approval the defaults are kept.
+func normalizedWeights(coef []float64) taxonomy.RubricWeights {
+	var sum float64
+	for _, c := range coef {
+		sum += c
+	}
+	if sum <= 0 {
+		return taxonomy.DefaultRubricWeights
+	}
+	return taxonomy.RubricWeights{
+		Specificity:   coef[0] / sum,
+		Actionability: coef[1] / sum,
+		Novelty:       coef[2] / sum,
+		Context:       coef[3] / sum,
+		Confidence:    coef[4] / sum,
+	}
+}
+
+// bestThreshold returns the score threshold that classifies examples most
+// accurately, preferring the lowest on ties.
+func bestThreshold(m *Model, examples []Example) float64 {
+	scores := make([]float64, len(examples))
+	for i, e := range examples {
+		scores[i] = m.scoreFeatures(e.Features)
+	}
+	candidates := append([]float64{}, scores...)
+	sort.Float64s(candidates)
+
+	best, bestCorrect := 0.0, -1
+	for _, t := range candidates {
+		correct := 0
+		for i, e := range examples {
+			if (scores[i] >= t) == e.Approved {
+				correct++
+			}
+		}
+		if correct > bestCorrect {
+			best, bestCorrect = t, correct
+		}
+	}
+	return best
+}
+
+// accuracy is the fraction of examples m classifies correctly at its
+// threshold.
+func accuracy(m *Model, examples []Example) float64 {
+	if len(examples) == 0 {
+		return 0
+	}
+	correct := 0
+	for _, e := range examples {
+		if (m.scoreFeatures(e.Features) >= m.Threshold) == e.Approved {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(examples))
+}
@@ -0,0 +1,195 @@
+package search
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dirTypes maps knowledge directory names to document types.
+var dirTypes = map[string]string{
+	"learnings": "learning",
+	"patterns":  "pattern",
+	"sessions":  "session",
+	"research":  "research",
+	"retros":    "retro",
+	"decisions": "decision",
+}
+
+// ParseFields splits file content into title, tags and body fields and
+// extracts the type, maturity and date metadata used by query filters.
+//
+// Markdown files contribute their frontmatter title/tags, the first H1
+// heading (as title when frontmatter has none) and any "**Tags**:" lines.
+// Everything else, including JSONL content, is indexed as body.
+func ParseFields(path, content string) Fields {
+	var f Fields
+	if filepath.Ext(path) == ".md" {
+		f = parseMarkdownFields(content)
+	} else {
+		f = parseJSONLFields(content)
+	}
+	if f.Type == "" {
+		f.Type = typeFromPath(path)
+	}
+	return f
+}
+
+// parseMarkdownFields handles frontmatter, headings and metadata lines.
+func parseMarkdownFields(content string) Fields {
+	var f Fields
+	lines := strings.Split(content, "\n")
+	body := make([]string, 0, len(lines))
+
+	start := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				f = parseFrontmatterFields(strings.Join(lines[1:i], "\n"))
+				body = append(body, f.Body)
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	for _, line := range lines[start:] {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		switch {
+		case f.Title == "" && strings.HasPrefix(trimmed, "# "):
+			f.Title = strings.TrimPrefix(trimmed, "# ")
+		case strings.HasPrefix(trimmed, "**Tags**:"):
+			for _, t := range strings.Split(strings.TrimPrefix(trimmed, "**Tags**:"), ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					f.Tags = append(f.Tags, t)
+				}
+			}
+		default:
+			if f.Maturity == "" && strings.HasPrefix(trimmed, "**Maturity**:") {
+				f.Maturity = strings.TrimSpace(strings.TrimPrefix(trimmed, "**Maturity**:"))
+			}
+			body = append(body, line)
+		}
+	}
+
+	f.Body = strings.Join(body, "\n")
+	return f
+}
+
+// parseJSONLFields indexes JSONL verbatim as body, taking type/maturity/date
+// metadata from the first record when present.
+func parseJSONLFields(content string) Fields {
+	f := Fields{Body: content}
+
+	first := content
+	if i := strings.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	var meta struct {
+		Type      string `json:"type"`
+		Maturity  string `json:"maturity"`
+		Date      string `json:"date"`
+		CreatedAt string `json:"created_at"`
+	}
+	if err := json.Unmarshal([]byte(first), &meta); err != nil {
+		return f
+	}
+	f.Type = meta.Type
+	f.Maturity = meta.Maturity
+	f.Date = parseDate(meta.Date)
+	if f.Date.IsZero() {
+		f.Date = parseDate(meta.CreatedAt)
+	}
+	return f
+}
+
+// parseFrontmatterFields decodes YAML frontmatter. The title and tags keys
+// become their own fields and type/maturity/date become metadata; remaining
+// scalar values are returned as body text so they stay searchable.
+func parseFrontmatterFields(text string) Fields {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(text), &raw); err != nil {
+		// Malformed frontmatter: index it verbatim as body text
+		return Fields{Body: text}
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var f Fields
+	var rest []string
+	for _, key := range keys {
+		val := raw[key]
+		switch key {
+		case "title":
+			if s, ok := val.(string); ok {
+				f.Title = s
+			}
+		case "tags":
+			switch v := val.(type) {
+			case []interface{}:
+				for _, t := range v {
+					if s, ok := t.(string); ok && s != "" {
+						f.Tags = append(f.Tags, s)
+					}
+				}
+			case string:
+				for _, t := range strings.Split(v, ",") {
+					if t = strings.TrimSpace(t); t != "" {
+						f.Tags = append(f.Tags, t)
+					}
+				}
+			}
+		case "date", "created", "created_at":
+			switch v := val.(type) {
+			case time.Time:
+				f.Date = v
+			case string:
+				f.Date = parseDate(v)
+			}
+		default:
+			s, ok := val.(string)
+			if !ok {
+				continue
+			}
+			switch key {
+			case "type":
+				f.Type = s
+			case "maturity":
+				f.Maturity = s
+			}
+			rest = append(rest, s)
+		}
+	}
+	f.Body = strings.Join(rest, "\n")
+	return f
+}
+
+// parseDate accepts RFC 3339 timestamps and YYYY-MM-DD dates.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// typeFromPath derives a document type from its nearest knowledge directory.
+func typeFromPath(path string) string {
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if t, ok := dirTypes[filepath.Base(dir)]; ok {
+			return t
+		}
+	}
+	return "knowledge"
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	t.Run("frontmatter title and tags", func(t *testing.T) {
+		content := "---\ntitle: Token Bucket\ntags: [rate-limit, redis]\nmaturity: established\n---\n# Heading\nBody text"
+		f := ParseFields("learning.md", content)
+		if f.Title != "Token Bucket" {
+			t.Errorf("Title = %q, want %q", f.Title, "Token Bucket")
+		}
+		if len(f.Tags) != 2 || f.Tags[0] != "rate-limit" || f.Tags[1] != "redis" {
+			t.Errorf("Tags = %v, want [rate-limit redis]", f.Tags)
+		}
+		if !strings.Contains(f.Body, "established") {
+			t.Errorf("expected remaining frontmatter values in body, got %q", f.Body)
+		}
+		if !strings.Contains(f.Body, "# Heading") {
+			t.Errorf("expected H1 to stay in body when frontmatter has a title, got %q", f.Body)
+		}
+	})
+
+	t.Run("heading and tags line", func(t *testing.T) {
+		content := "# Mutex Pattern\n\n**Tags**: go, concurrency\n\nUse a mutex."
+		f := ParseFields("pattern.md", content)
+		if f.Title != "Mutex Pattern" {
+			t.Errorf("Title = %q, want %q", f.Title, "Mutex Pattern")
+		}
+		if len(f.Tags) != 2 || f.Tags[1] != "concurrency" {
+			t.Errorf("Tags = %v, want [go concurrency]", f.Tags)
+		}
+		if strings.Contains(f.Body, "Mutex Pattern") || strings.Contains(f.Body, "**Tags**") {
+			t.Errorf("title and tags should not be repeated in body, got %q", f.Body)
+		}
+	})
+
+	t.Run("jsonl is body only", func(t *testing.T) {
+		content := `{"title":"x","summary":"database migration"}`
+		f := ParseFields("session.jsonl", content)
+		if f.Title != "" || len(f.Tags) != 0 || f.Body != content {
+			t.Errorf("unexpected fields for jsonl: %+v", f)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		f := ParseFields("/repo/.agents/learnings/l.md", "# L\n- **Maturity**: candidate\nbody")
+		if f.Type != "learning" || f.Maturity != "candidate" {
+			t.Errorf("type/maturity = %q/%q, want learning/candidate", f.Type, f.Maturity)
+		}
+
+		f = ParseFields("/repo/.agents/learnings/l.jsonl", `{"maturity":"established","created_at":"2026-01-02T03:04:05Z"}`)
+		if f.Type != "learning" || f.Maturity != "established" || f.Date.Year() != 2026 {
+			t.Errorf("jsonl metadata = %q/%q/%v", f.Type, f.Maturity, f.Date)
+		}
+
+		f = ParseFields("/repo/notes/x.md", "---\ntype: decision\ndate: 2026-01-31\n---\nbody")
+		if f.Type != "decision" || f.Date.Format("2006-01-02") != "2026-01-31" {
+			t.Errorf("frontmatter metadata = %q/%v", f.Type, f.Date)
+		}
+	})
+}
@@ -1,4 +1,4 @@
-// Package search provides an inverted index for fast keyword-based search
+// Package search provides an inverted index for ranked keyword search
 // across AgentOps session and knowledge files.
 package search
 
@@ -10,130 +10,310 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 )
 
+// IndexVersion is the on-disk format version written by SaveIndex.
+// Version 1 (unversioned) stored only term → paths; version 2 adds term
+// frequencies, document lengths and field provenance for BM25 ranking;
+// version 3 adds the type/maturity/date metadata used by query filters and
+// the modification times used by Refresh.
+const IndexVersion = 3
+
+// ErrIndexVersion is returned by LoadIndex when the file was written in a
+// different format version. Callers should rebuild the index.
+var ErrIndexVersion = fmt.Errorf("search index format is not version %d; rebuild required", IndexVersion)
+
+// Posting records how often a term occurs in one document, per field.
+type Posting struct {
+	Title int `json:"title,omitempty"`
+	Tags  int `json:"tags,omitempty"`
+	Body  int `json:"body,omitempty"`
+}
+
+// Document holds the per-document statistics needed for ranking and
+// filtering.
+type Document struct {
+	Path     string    `json:"path"`
+	Title    string    `json:"title,omitempty"`
+	Length   int       `json:"length"` // total tokens across all fields
+	Type     string    `json:"type,omitempty"`
+	Maturity string    `json:"maturity,omitempty"`
+	Date     time.Time `json:"date"`     // authored date, else ModTime
+	ModTime  time.Time `json:"mod_time"` // source mtime when indexed
+}
+
+// Fields is the field-separated content of a single document, plus the
+// metadata used by query filters.
+type Fields struct {
+	Title string
+	Tags  []string
+	Body  string
+
+	Type     string
+	Maturity string
+	Date     time.Time
+}
+
 // Index is an in-memory inverted index mapping lowercase terms to the
-// set of document paths that contain them.
+// documents that contain them, with per-field term frequencies.
 type Index struct {
-	// Terms maps each lowercase term to a set of document paths.
-	Terms map[string]map[string]bool `json:"-"`
-}
+	// Terms maps each lowercase term to its postings keyed by document path.
+	Terms map[string]map[string]Posting `json:"-"`
 
-// IndexEntry is the JSONL-serialised form: one line per term.
-type IndexEntry struct {
-	Term  string   `json:"term"`
-	Paths []string `json:"paths"`
+	// Docs maps each document path to its statistics.
+	Docs map[string]*Document `json:"-"`
+
+	// docTerms is the reverse of Terms: the distinct terms of each
+	// document, so removal touches only that document's postings.
+	docTerms map[string][]string
 }
 
-// IndexResult is returned by Search.
-type IndexResult struct {
-	Path  string
-	Score int // number of query terms matched
+// IndexEntry is the JSONL-serialised form. The first line carries only
+// Version; it is followed by one line per document and one line per term.
+type IndexEntry struct {
+	Version  int                `json:"version,omitempty"`
+	Doc      *Document          `json:"doc,omitempty"`
+	Term     string             `json:"term,omitempty"`
+	Postings map[string]Posting `json:"postings,omitempty"`
 }
 
 // NewIndex creates an empty index.
 func NewIndex() *Index {
-	return &Index{Terms: make(map[string]map[string]bool)}
+	return &Index{
+		Terms:    make(map[string]map[string]Posting),
+		Docs:     make(map[string]*Document),
+		docTerms: make(map[string][]string),
+	}
+}
+
+// RefreshStats reports what Refresh changed.
+type RefreshStats struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// Changed reports whether Refresh modified the index.
+func (s RefreshStats) Changed() bool {
+	return s.Added+s.Updated+s.Removed > 0
 }
 
 // BuildIndex scans all .md and .jsonl files under dir (recursively) and
 // builds an inverted index from their content.
 func BuildIndex(dir string) (*Index, error) {
 	idx := NewIndex()
+	if _, err := Refresh(idx, dir); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // skip unreadable entries
-		}
-		if info.IsDir() {
-			return nil
-		}
-		ext := filepath.Ext(path)
-		if ext != ".md" && ext != ".jsonl" {
+// Refresh brings the index up to date with the .md and .jsonl files under
+// dirs: new files are added, files whose modification time changed are
+// re-indexed, and documents under dirs whose files no longer exist are
+// removed. Documents outside dirs are left untouched. Missing dirs are
+// treated as empty.
+func Refresh(idx *Index, dirs ...string) (RefreshStats, error) {
+	var stats RefreshStats
+	seen := make(map[string]bool)
+
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // skip unreadable entries
+			}
+			if info.IsDir() || !isIndexable(path) {
+				return nil
+			}
+			seen[path] = true
+
+			doc, exists := idx.Docs[path]
+			if exists && doc.ModTime.Equal(info.ModTime()) {
+				return nil
+			}
+			if err := indexFile(idx, path); err != nil {
+				// Non-fatal: skip files we cannot read
+				return nil
+			}
+			if exists {
+				stats.Updated++
+			} else {
+				stats.Added++
+			}
 			return nil
+		})
+		if err != nil {
+			return stats, fmt.Errorf("walk %s: %w", dir, err)
 		}
-		if err := indexFile(idx, path); err != nil {
-			// Non-fatal: skip files we cannot read
-			return nil
+	}
+
+	for path := range idx.Docs {
+		if seen[path] || !underAny(path, dirs) {
+			continue
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("walk %s: %w", dir, err)
+		RemoveDocument(idx, path)
+		stats.Removed++
 	}
 
-	return idx, nil
+	return stats, nil
 }
 
 // UpdateIndex adds or re-indexes a single file in the index.
 // It first removes any existing entries for the path, then re-scans.
 func UpdateIndex(idx *Index, path string) error {
-	// Remove old entries for this path
-	for _, docs := range idx.Terms {
-		delete(docs, path)
-	}
+	RemoveDocument(idx, path)
 	return indexFile(idx, path)
 }
 
-// Search finds documents matching the query and returns up to limit results
-// sorted by descending score (number of matching query terms).
-func Search(idx *Index, query string, limit int) []IndexResult {
-	queryTerms := tokenize(query)
-	if len(queryTerms) == 0 {
-		return nil
-	}
+// AddDocument indexes already-loaded content under path, replacing any
+// previous entry for the same path.
+func AddDocument(idx *Index, path string, f Fields) {
+	RemoveDocument(idx, path)
 
-	// Count how many query terms each document matches
-	scores := make(map[string]int)
-	for _, term := range queryTerms {
-		if docs, ok := idx.Terms[term]; ok {
-			for doc := range docs {
-				scores[doc]++
-			}
+	postings := make(map[string]Posting)
+	length := 0
+
+	for _, term := range terms(f.Title) {
+		p := postings[term]
+		p.Title++
+		postings[term] = p
+		length++
+	}
+	for _, tag := range f.Tags {
+		for _, term := range terms(tag) {
+			p := postings[term]
+			p.Tags++
+			postings[term] = p
+			length++
 		}
 	}
-
-	if len(scores) == 0 {
-		return nil
+	for _, term := range terms(f.Body) {
+		p := postings[term]
+		p.Body++
+		postings[term] = p
+		length++
 	}
 
-	results := make([]IndexResult, 0, len(scores))
-	for path, score := range scores {
-		results = append(results, IndexResult{Path: path, Score: score})
+	docTerms := make([]string, 0, len(postings))
+	for term, p := range postings {
+		if idx.Terms[term] == nil {
+			idx.Terms[term] = make(map[string]Posting)
+		}
+		idx.Terms[term][path] = p
+		docTerms = append(docTerms, term)
+	}
+	idx.docTerms[path] = docTerms
+
+	idx.Docs[path] = &Document{
+		Path:     path,
+		Title:    strings.TrimSpace(f.Title),
+		Length:   length,
+		Type:     f.Type,
+		Maturity: f.Maturity,
+		Date:     f.Date,
 	}
+}
 
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Score != results[j].Score {
-			return results[i].Score > results[j].Score
+// RemoveDocument deletes every posting for path from the index. The cost
+// is proportional to the number of distinct terms in the document.
+func RemoveDocument(idx *Index, path string) {
+	for _, term := range idx.docTerms[path] {
+		docs := idx.Terms[term]
+		delete(docs, path)
+		if len(docs) == 0 {
+			delete(idx.Terms, term)
 		}
-		return results[i].Path < results[j].Path
-	})
-
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
 	}
+	delete(idx.docTerms, path)
+	delete(idx.Docs, path)
+}
 
-	return results
+// RemoveTree deletes every document at or below dir and returns how many
+// were removed. It is used when a whole directory is deleted or renamed.
+func RemoveTree(idx *Index, dir string) int {
+	removed := 0
+	for path := range idx.Docs {
+		if underAny(path, []string{dir}) {
+			RemoveDocument(idx, path)
+			removed++
+		}
+	}
+	return removed
 }
 
-// SaveIndex writes the index to a JSONL file (one line per term).
+// SaveIndex writes the index to a JSONL file: a version header, then one
+// line per document, then one line per term. The file is written to a
+// temporary sibling and renamed into place, so concurrent readers and
+// crashes never observe a partially written index.
 func SaveIndex(idx *Index, path string) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("create index dir: %w", err)
 	}
 
-	f, err := os.Create(path)
+	f, err := os.CreateTemp(dir, ".index-*.tmp")
 	if err != nil {
 		return fmt.Errorf("create index file: %w", err)
 	}
+	tmpPath := f.Name()
+	committed := false
 	defer func() {
-		_ = f.Close() //nolint:errcheck // best-effort close
+		if !committed {
+			_ = f.Close()          //nolint:errcheck // cleanup after failure
+			_ = os.Remove(tmpPath) //nolint:errcheck // cleanup after failure
+		}
 	}()
 
+	if err := writeIndex(idx, f); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync index file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close index file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename index file: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// writeIndex serialises idx to f in SaveIndex's format.
+func writeIndex(idx *Index, f *os.File) error {
 	w := bufio.NewWriter(f)
+	writeLine := func(entry IndexEntry) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		_, err = w.WriteString("\n")
+		return err
+	}
+
+	if err := writeLine(IndexEntry{Version: IndexVersion}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	// Sort paths and terms for deterministic output
+	paths := make([]string, 0, len(idx.Docs))
+	for p := range idx.Docs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := writeLine(IndexEntry{Doc: idx.Docs[p]}); err != nil {
+			return fmt.Errorf("write doc %q: %w", p, err)
+		}
+	}
 
-	// Sort terms for deterministic output
 	terms := make([]string, 0, len(idx.Terms))
 	for term := range idx.Terms {
 		terms = append(terms, term)
@@ -145,29 +325,16 @@ func SaveIndex(idx *Index, path string) error {
 		if len(docs) == 0 {
 			continue
 		}
-		paths := make([]string, 0, len(docs))
-		for p := range docs {
-			paths = append(paths, p)
-		}
-		sort.Strings(paths)
-
-		entry := IndexEntry{Term: term, Paths: paths}
-		data, err := json.Marshal(entry)
-		if err != nil {
-			return fmt.Errorf("marshal term %q: %w", term, err)
-		}
-		if _, err := w.Write(data); err != nil {
-			return err
-		}
-		if _, err := w.WriteString("\n"); err != nil {
-			return err
+		if err := writeLine(IndexEntry{Term: term, Postings: docs}); err != nil {
+			return fmt.Errorf("write term %q: %w", term, err)
 		}
 	}
 
 	return w.Flush()
 }
 
-// LoadIndex reads an index from a JSONL file.
+// LoadIndex reads an index from a JSONL file. It returns ErrIndexVersion
+// if the file was not written by the current format version.
 func LoadIndex(path string) (*Index, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -180,78 +347,113 @@ func LoadIndex(path string) (*Index, error) {
 	idx := NewIndex()
 	scanner := bufio.NewScanner(f)
 	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	scanner.Buffer(buf, 16*1024*1024)
 
+	sawHeader := false
 	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+		line := scanner.Bytes()
+		if len(line) == 0 {
 			continue
 		}
 		var entry IndexEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if !sawHeader {
+				return nil, ErrIndexVersion
+			}
 			continue // skip malformed lines
 		}
-		docs := make(map[string]bool, len(entry.Paths))
-		for _, p := range entry.Paths {
-			docs[p] = true
+		if !sawHeader {
+			if entry.Version != IndexVersion {
+				return nil, ErrIndexVersion
+			}
+			sawHeader = true
+			continue
+		}
+		switch {
+		case entry.Doc != nil:
+			idx.Docs[entry.Doc.Path] = entry.Doc
+		case entry.Term != "" && len(entry.Postings) > 0:
+			idx.Terms[entry.Term] = entry.Postings
+			for p := range entry.Postings {
+				idx.docTerms[p] = append(idx.docTerms[p], entry.Term)
+			}
 		}
-		idx.Terms[entry.Term] = docs
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("read index: %w", err)
 	}
+	if !sawHeader {
+		return nil, ErrIndexVersion
+	}
 
 	return idx, nil
 }
 
 // indexFile reads a file and adds its terms to the index.
 func indexFile(idx *Index, path string) error {
-	f, err := os.Open(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only, close best-effort
-	}()
 
-	scanner := bufio.NewScanner(f)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	f := ParseFields(path, string(data))
+	if f.Date.IsZero() {
+		f.Date = info.ModTime()
+	}
+	AddDocument(idx, path, f)
+	idx.Docs[path].ModTime = info.ModTime()
+	return nil
+}
 
-	seen := make(map[string]bool) // dedupe terms per file
+// isIndexable reports whether path has an extension the index covers.
+func isIndexable(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".md" || ext == ".jsonl"
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		terms := tokenize(line)
-		for _, term := range terms {
-			if seen[term] {
-				continue
-			}
-			seen[term] = true
-			if idx.Terms[term] == nil {
-				idx.Terms[term] = make(map[string]bool)
-			}
-			idx.Terms[term][path] = true
+// underAny reports whether path lies inside one of dirs.
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
 		}
 	}
-
-	return scanner.Err()
+	return false
 }
 
-// tokenize splits text into lowercase word tokens.
-// Strips punctuation and filters out very short (< 2 char) tokens.
-func tokenize(text string) []string {
+// terms splits text into lowercase word tokens, keeping repeats so that
+// term frequencies can be counted. Strips punctuation and filters out
+// very short (< 2 char) tokens.
+func terms(text string) []string {
 	lower := strings.ToLower(text)
 	words := strings.FieldsFunc(lower, func(r rune) bool {
 		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
 	})
 
-	// Filter short tokens and deduplicate within this call
+	result := make([]string, 0, len(words))
+	for _, w := range words {
+		if len(w) < 2 {
+			continue
+		}
+		result = append(result, w)
+	}
+	return result
+}
+
+// tokenize splits text into unique lowercase word tokens in order of
+// first appearance.
+func tokenize(text string) []string {
+	words := terms(text)
 	result := make([]string, 0, len(words))
 	seen := make(map[string]bool, len(words))
 	for _, w := range words {
-		if len(w) < 2 || seen[w] {
+		if seen[w] {
 			continue
 		}
 		seen[w] = true
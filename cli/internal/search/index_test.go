@@ -1,6 +1,7 @@
 package search
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -169,12 +170,12 @@ func TestUpdateIndex(t *testing.T) {
 	}
 
 	// "alpha" should be gone (or at least not point to docPath)
-	if docs, ok := idx.Terms["alpha"]; ok && docs[docPath] {
+	if _, ok := idx.Terms["alpha"][docPath]; ok {
 		t.Error("expected 'alpha' to be removed for docPath after update")
 	}
 
 	// "beta" should be present
-	if docs, ok := idx.Terms["beta"]; !ok || !docs[docPath] {
+	if _, ok := idx.Terms["beta"][docPath]; !ok {
 		t.Error("expected 'beta' to be present after update")
 	}
 }
@@ -211,6 +212,143 @@ func TestTokenize(t *testing.T) {
 	}
 }
 
+func TestSaveAndLoadIndexPreservesStats(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "doc.md"), "# Mutex Guide\n\nmutex mutex lock")
+
+	idx, err := BuildIndex(dir)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	indexPath := filepath.Join(dir, "index.jsonl")
+	if err := SaveIndex(idx, indexPath); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	loaded, err := LoadIndex(indexPath)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	docPath := filepath.Join(dir, "doc.md")
+	got := loaded.Terms["mutex"][docPath]
+	want := Posting{Title: 1, Body: 2}
+	if got != want {
+		t.Errorf("mutex posting = %+v, want %+v", got, want)
+	}
+	doc, ok := loaded.Docs[docPath]
+	if !ok {
+		t.Fatal("loaded index missing document stats")
+	}
+	if doc.Length != idx.Docs[docPath].Length || doc.Title != "Mutex Guide" {
+		t.Errorf("doc stats = %+v, want length %d and title %q", doc, idx.Docs[docPath].Length, "Mutex Guide")
+	}
+}
+
+func TestLoadIndexDetectsOldFormat(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.jsonl")
+	writeFile(t, indexPath, `{"term":"mutex","paths":["/tmp/a.md"]}`+"\n")
+
+	_, err := LoadIndex(indexPath)
+	if !errors.Is(err, ErrIndexVersion) {
+		t.Errorf("expected ErrIndexVersion for v1 index, got %v", err)
+	}
+
+	writeFile(t, indexPath, `{"version":99}`+"\n")
+	_, err = LoadIndex(indexPath)
+	if !errors.Is(err, ErrIndexVersion) {
+		t.Errorf("expected ErrIndexVersion for unknown version, got %v", err)
+	}
+}
+
+func TestRemoveDocument(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "a.md", Fields{Body: "shared unique"})
+	AddDocument(idx, "b.md", Fields{Body: "shared"})
+
+	RemoveDocument(idx, "a.md")
+
+	if _, ok := idx.Terms["unique"]; ok {
+		t.Error("expected term only in removed doc to be dropped")
+	}
+	if _, ok := idx.Terms["shared"]["b.md"]; !ok {
+		t.Error("expected other doc postings to survive")
+	}
+	if _, ok := idx.Docs["a.md"]; ok {
+		t.Error("expected doc stats to be removed")
+	}
+}
+
+func TestRemoveDocumentAfterLoad(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "a.md", Fields{Title: "unique", Body: "shared"})
+	AddDocument(idx, "b.md", Fields{Body: "shared"})
+
+	path := filepath.Join(t.TempDir(), "index.jsonl")
+	if err := SaveIndex(idx, path); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	// The reverse path->terms map is rebuilt on load
+	RemoveDocument(loaded, "a.md")
+	if _, ok := loaded.Terms["unique"]; ok {
+		t.Error("expected term only in removed doc to be dropped after load")
+	}
+	if _, ok := loaded.Terms["shared"]["a.md"]; ok {
+		t.Error("expected removed doc's shared postings to be dropped after load")
+	}
+	if _, ok := loaded.Terms["shared"]["b.md"]; !ok {
+		t.Error("expected other doc postings to survive")
+	}
+}
+
+func TestRemoveTree(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, filepath.Join("root", "sub", "a.md"), Fields{Body: "alpha"})
+	AddDocument(idx, filepath.Join("root", "sub", "deeper", "b.md"), Fields{Body: "beta"})
+	AddDocument(idx, filepath.Join("root", "subway.md"), Fields{Body: "gamma"})
+
+	if n := RemoveTree(idx, filepath.Join("root", "sub")); n != 2 {
+		t.Errorf("RemoveTree removed %d docs, want 2", n)
+	}
+	if len(idx.Docs) != 1 {
+		t.Errorf("expected sibling with shared prefix to survive, got %d docs", len(idx.Docs))
+	}
+	if _, ok := idx.Terms["beta"]; ok {
+		t.Error("expected nested doc terms to be dropped")
+	}
+}
+
+func TestSaveIndexLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	idx := NewIndex()
+	AddDocument(idx, "a.md", Fields{Body: "alpha"})
+
+	path := filepath.Join(dir, "index.jsonl")
+	for i := 0; i < 2; i++ {
+		if err := SaveIndex(idx, path); err != nil {
+			t.Fatalf("SaveIndex: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "index.jsonl" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only index.jsonl, got %v", names)
+	}
+}
+
 func TestLoadIndexMissing(t *testing.T) {
 	_, err := LoadIndex("/nonexistent/path/index.jsonl")
 	if err == nil {
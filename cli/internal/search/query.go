@@ -0,0 +1,783 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter restricts results by document metadata.
+type Filter struct {
+	// Field is one of "type", "maturity", "tag", "category" or "since",
+	// or a numeric field such as "utility".
+	Field string `json:"field"`
+
+	// Op is the comparison of a numeric filter (> >= < <= =), empty for
+	// field:value filters.
+	Op string `json:"op,omitempty"`
+
+	// Value is the raw filter value as written in the query.
+	Value string `json:"value"`
+
+	// Time is the resolved cutoff for "since" filters.
+	Time time.Time `json:"time,omitempty"`
+
+	// Number is the parsed value of a numeric filter.
+	Number float64 `json:"number,omitempty"`
+}
+
+// String renders the filter as written, e.g. "tag:auth" or "utility>0.6".
+func (f Filter) String() string {
+	if f.Op != "" {
+		return f.Field + f.Op + strconv.FormatFloat(f.Number, 'g', -1, 64)
+	}
+	return f.Field + ":" + f.Value
+}
+
+// Query is a parsed search query. Clauses must all match (implicit AND).
+//
+// Syntax:
+//
+//	mutex pattern      terms, ranked by BM25
+//	"token bucket"     required phrase (or single term) that must appear
+//	-redis  -"a b"     negation of any clause, e.g. -tag:legacy
+//	type:learning      document type (learning, pattern, session, ...)
+//	maturity:stable    maturity level
+//	tag:auth           tag
+//	category:security  category
+//	utility>0.6        numeric comparison: > >= < <= = (or utility:0.6)
+//	since:7d           modified/authored within 7 days (also 2w, 36h, 2026-01-31)
+//	a OR b             either side matches; binds looser than AND
+//	( ... )            grouping
+//
+// A "word:value" whose field is not listed above is plain text. Terms,
+// Required, Excluded and Filters list the clauses by kind; Root combines
+// them and decides what matches.
+type Query struct {
+	Terms    []string   `json:"terms,omitempty"`
+	Required [][]string `json:"required,omitempty"`
+	Excluded [][]string `json:"excluded,omitempty"`
+	Filters  []Filter   `json:"filters,omitempty"`
+
+	// Root is the query's clause tree, nil for an empty query.
+	Root Node `json:"-"`
+
+	// constraints is Root without its top-level terms, which in ranked
+	// search (SearchQuery) only rank.
+	constraints Node
+}
+
+// filterFields lists the field prefixes recognised as filters. Any other
+// "word:value" token is treated as plain text.
+var filterFields = map[string]bool{
+	"type":     true,
+	"maturity": true,
+	"tag":      true,
+	"category": true,
+	"since":    true,
+}
+
+// numericFields lists the fields accepted in comparisons.
+var numericFields = map[string]bool{
+	"utility": true,
+}
+
+// Doc is a document a query is matched against.
+type Doc interface {
+	// HasTerm reports whether the document contains the term in any field.
+	HasTerm(term string) bool
+
+	// Text returns the document's title, tags and body, for phrases.
+	Text() string
+
+	// Field returns the document's values of a metadata field: "type",
+	// "maturity", "tag" or "category".
+	Field(name string) []string
+
+	// Date is when the document was authored or last modified.
+	Date() time.Time
+
+	// Number returns a numeric field such as "utility", if the document
+	// has it.
+	Number(name string) (float64, bool)
+}
+
+// Node is a clause of a parsed query.
+type Node interface {
+	// Match reports whether the document satisfies the clause.
+	Match(d Doc) bool
+
+	// String renders the clause in a canonical, fully parenthesised form.
+	String() string
+}
+
+type andNode struct{ children []Node }
+
+func (n *andNode) Match(d Doc) bool {
+	for _, c := range n.children {
+		if !c.Match(d) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *andNode) String() string { return "AND(" + joinNodes(n.children) + ")" }
+
+type orNode struct{ children []Node }
+
+func (n *orNode) Match(d Doc) bool {
+	for _, c := range n.children {
+		if c.Match(d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *orNode) String() string { return "OR(" + joinNodes(n.children) + ")" }
+
+type notNode struct{ child Node }
+
+func (n *notNode) Match(d Doc) bool { return !n.child.Match(d) }
+
+func (n *notNode) String() string { return "NOT(" + n.child.String() + ")" }
+
+// termsNode matches documents containing any of its terms.
+type termsNode struct{ terms []string }
+
+func (n *termsNode) Match(d Doc) bool {
+	for _, t := range n.terms {
+		if d.HasTerm(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *termsNode) String() string { return "ANY(" + strings.Join(n.terms, ", ") + ")" }
+
+// phraseNode matches documents containing its terms contiguously.
+type phraseNode struct{ terms []string }
+
+func (n *phraseNode) Match(d Doc) bool {
+	for _, t := range n.terms {
+		if !d.HasTerm(t) {
+			return false
+		}
+	}
+	return len(n.terms) == 1 || containsSequence(terms(d.Text()), n.terms)
+}
+
+func (n *phraseNode) String() string { return strconv.Quote(strings.Join(n.terms, " ")) }
+
+type filterNode struct{ filter Filter }
+
+func (n *filterNode) Match(d Doc) bool { return matchFilter(d, n.filter) }
+
+func (n *filterNode) String() string { return n.filter.String() }
+
+func joinNodes(nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseQuery parses the query syntax described on Query, resolving relative
+// "since" values against now.
+func ParseQuery(s string, now time.Time) (Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+	p := &queryParser{toks: toks, now: now}
+	root, err := p.parseOr()
+	if err != nil {
+		return Query{}, err
+	}
+	if p.pos < len(p.toks) {
+		return Query{}, fmt.Errorf("unexpected %q at position %d", p.toks[p.pos].text, p.pos+1)
+	}
+
+	q := Query{Root: root, constraints: withoutTerms(root)}
+	if root != nil {
+		q.collect(root, false)
+	}
+	return q, nil
+}
+
+// collect lists the clauses under n by kind.
+func (q *Query) collect(n Node, negated bool) {
+	switch n := n.(type) {
+	case *andNode:
+		for _, c := range n.children {
+			q.collect(c, negated)
+		}
+	case *orNode:
+		for _, c := range n.children {
+			q.collect(c, negated)
+		}
+	case *notNode:
+		q.collect(n.child, !negated)
+	case *termsNode:
+		for _, term := range n.terms {
+			if negated {
+				q.Excluded = append(q.Excluded, []string{term})
+			} else {
+				q.Terms = append(q.Terms, term)
+			}
+		}
+	case *phraseNode:
+		if negated {
+			q.Excluded = append(q.Excluded, n.terms)
+		} else {
+			q.Required = append(q.Required, n.terms)
+		}
+	case *filterNode:
+		if !negated {
+			q.Filters = append(q.Filters, n.filter)
+		}
+	}
+}
+
+// withoutTerms drops the top-level terms from root.
+func withoutTerms(root Node) Node {
+	switch n := root.(type) {
+	case *termsNode:
+		return nil
+	case *andNode:
+		var kept []Node
+		for _, c := range n.children {
+			if _, ok := c.(*termsNode); !ok {
+				kept = append(kept, c)
+			}
+		}
+		switch len(kept) {
+		case 0:
+			return nil
+		case 1:
+			return kept[0]
+		}
+		return &andNode{children: kept}
+	}
+	return root
+}
+
+// Empty reports whether the query has no clauses.
+func (q Query) Empty() bool {
+	return q.Root == nil
+}
+
+// Match reports whether the document satisfies every clause, terms
+// included: a document must contain at least one of a group's terms.
+func (q Query) Match(d Doc) bool {
+	return q.Root == nil || q.Root.Match(d)
+}
+
+// RankTerms returns the distinct terms and phrase terms, not negated, that
+// contribute to the BM25 score.
+func (q Query) RankTerms() []string {
+	all := append([]string{}, q.Terms...)
+	for _, seq := range q.Required {
+		all = append(all, seq...)
+	}
+	return tokenize(strings.Join(all, " "))
+}
+
+// PlainQuery turns free text into a query of its terms, so text such as a
+// prompt is never read as query syntax.
+func PlainQuery(text string) string {
+	var words []string
+	for _, term := range terms(text) {
+		if term = strings.TrimLeft(term, "-"); term != "" {
+			words = append(words, term)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokNot
+	tokOr
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lexQuery splits a query into words, quoted phrases, "-", "OR" and
+// parentheses. A quote directly after "field:" quotes the field value.
+func lexQuery(s string) ([]token, error) {
+	var toks []token
+	runes := []rune(s)
+
+	// quoted returns the end of the quote opening at i.
+	quoted := func(i int) (int, error) {
+		end := i + 1
+		for end < len(runes) && runes[end] != '"' {
+			end++
+		}
+		if end >= len(runes) {
+			return 0, fmt.Errorf("unterminated quote")
+		}
+		return end, nil
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			toks = append(toks, token{kind: tokLParen, text: "("})
+			i++
+		case r == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")"})
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] != ' ':
+			toks = append(toks, token{kind: tokNot, text: "-"})
+			i++
+		case r == '"':
+			end, err := quoted(i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokPhrase, text: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			var word strings.Builder
+			for i < len(runes) && !strings.ContainsRune(" \t\n()", runes[i]) {
+				if runes[i] == '"' {
+					if !strings.HasSuffix(word.String(), ":") {
+						break
+					}
+					end, err := quoted(i)
+					if err != nil {
+						return nil, err
+					}
+					word.WriteString(string(runes[i+1 : end]))
+					i = end + 1
+					continue
+				}
+				word.WriteRune(runes[i])
+				i++
+			}
+			text := word.String()
+			if text == "OR" {
+				toks = append(toks, token{kind: tokOr, text: text})
+			} else if text != "AND" {
+				toks = append(toks, token{kind: tokWord, text: text})
+			}
+		}
+	}
+	return toks, nil
+}
+
+// queryParser is a recursive-descent parser over lexed tokens:
+//
+//	or    := and ("OR" and)*
+//	and   := unary+
+//	unary := "-" unary | "(" or ")" | phrase | word
+type queryParser struct {
+	toks []token
+	pos  int
+	now  time.Time
+}
+
+func (p *queryParser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *queryParser) parseOr() (Node, error) {
+	var children []Node
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			if len(children) > 0 {
+				return nil, fmt.Errorf("OR needs a clause on both sides")
+			}
+		} else {
+			children = append(children, n)
+		}
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			break
+		}
+		if len(children) == 0 {
+			return nil, fmt.Errorf("OR needs a clause on both sides")
+		}
+		p.pos++
+	}
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return &orNode{children: children}, nil
+}
+
+// parseAnd parses clauses up to OR, ")" or the end. Bare terms in the
+// group are merged into a single any-of node.
+func (p *queryParser) parseAnd() (Node, error) {
+	var children []Node
+	var group *termsNode
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			break
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := n.(*termsNode); ok && tok.kind == tokWord {
+			if group == nil {
+				group = &termsNode{}
+				children = append(children, group)
+			}
+			group.terms = append(group.terms, t.terms...)
+			continue
+		}
+		if n != nil {
+			children = append(children, n)
+		}
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	return &andNode{children: children}, nil
+}
+
+func (p *queryParser) parseUnary() (Node, error) {
+	tok := p.toks[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokNot:
+		if next, ok := p.peek(); !ok || next.kind == tokOr || next.kind == tokRParen {
+			return nil, fmt.Errorf("nothing to negate after '-'")
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, nil
+		}
+		return &notNode{child: child}, nil
+
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != tokRParen {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return n, nil
+
+	case tokPhrase:
+		seq := terms(tok.text)
+		if len(seq) == 0 {
+			return nil, nil
+		}
+		return &phraseNode{terms: seq}, nil
+
+	case tokWord:
+		return p.parseWord(tok.text)
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+// parseWord turns a word into a filter, comparison or terms.
+func (p *queryParser) parseWord(word string) (Node, error) {
+	if i := strings.IndexAny(word, "<>=:"); i > 0 {
+		field := strings.ToLower(word[:i])
+		rest := word[i:]
+		op := rest[:1]
+		if len(rest) > 1 && rest[1] == '=' && (op == "<" || op == ">") {
+			op = rest[:2]
+		}
+		value := strings.TrimSpace(rest[len(op):])
+
+		switch {
+		case numericFields[field]:
+			if op == ":" {
+				op = "="
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q for %s", value, field)
+			}
+			return &filterNode{filter: Filter{Field: field, Op: op, Value: value, Number: v}}, nil
+
+		case filterFields[field]:
+			if op != ":" {
+				return nil, fmt.Errorf("operator %s is not supported for %s (use %s:value)", op, field, field)
+			}
+			f, err := parseFilter(field, value, p.now)
+			if err != nil {
+				return nil, err
+			}
+			return &filterNode{filter: f}, nil
+		}
+	}
+
+	seq := terms(word)
+	if len(seq) == 0 {
+		return nil, nil
+	}
+	return &termsNode{terms: seq}, nil
+}
+
+// parseFilter validates a filter value.
+func parseFilter(field, value string, now time.Time) (Filter, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Filter{}, fmt.Errorf("empty value for %s: filter", field)
+	}
+	f := Filter{Field: field, Value: value}
+	if field == "since" {
+		t, err := parseSince(value, now)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.Time = t
+	}
+	return f, nil
+}
+
+// parseSince resolves "7d", "2w", Go durations, or absolute dates.
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if t := parseDate(v); !t.IsZero() {
+		return t, nil
+	}
+	unit := v[len(v)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(v[:len(v)-1])
+		if err == nil && n >= 0 {
+			days := n
+			if unit == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since: value %q (use 7d, 2w, 36h or YYYY-MM-DD)", v)
+}
+
+// SearchQuery ranks documents against a parsed query. Documents must
+// satisfy every clause, except that top-level terms only affect ranking.
+// Documents matching without any term, as for a filter-only query, follow
+// the ranked ones newest first. A limit <= 0 returns all.
+func SearchQuery(idx *Index, q Query, limit int, opts Options) []IndexResult {
+	var candidates []IndexResult
+	if rank := q.RankTerms(); len(rank) > 0 {
+		candidates = SearchWithOptions(idx, strings.Join(rank, " "), 0, opts)
+	}
+	// Documents without any term can match, e.g. one side of an OR; they
+	// follow the ranked ones, newest first
+	if q.Root != nil && !requiresTerm(q.Root) {
+		ranked := make(map[string]bool, len(candidates))
+		for _, r := range candidates {
+			ranked[r.Path] = true
+		}
+		var rest []IndexResult
+		for path := range idx.Docs {
+			if !ranked[path] {
+				rest = append(rest, IndexResult{Path: path})
+			}
+		}
+		sort.Slice(rest, func(i, j int) bool {
+			di, dj := idx.Docs[rest[i].Path], idx.Docs[rest[j].Path]
+			if !di.Date.Equal(dj.Date) {
+				return di.Date.After(dj.Date)
+			}
+			return di.Path < dj.Path
+		})
+		candidates = append(candidates, rest...)
+	}
+
+	results := make([]IndexResult, 0, len(candidates))
+	for _, r := range candidates {
+		if !matchQuery(idx, r.Path, q) {
+			continue
+		}
+		results = append(results, r)
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+// requiresTerm reports whether every document matching n contains one of
+// the query's terms.
+func requiresTerm(n Node) bool {
+	switch n := n.(type) {
+	case *termsNode, *phraseNode:
+		return true
+	case *andNode:
+		for _, c := range n.children {
+			if requiresTerm(c) {
+				return true
+			}
+		}
+	case *orNode:
+		for _, c := range n.children {
+			if !requiresTerm(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// matchQuery applies the query's constraints to one indexed document.
+func matchQuery(idx *Index, path string, q Query) bool {
+	doc := idx.Docs[path]
+	if doc == nil {
+		return false
+	}
+	return q.constraints == nil || q.constraints.Match(&indexDoc{idx: idx, doc: doc})
+}
+
+// matchFilter evaluates a single metadata filter.
+func matchFilter(d Doc, f Filter) bool {
+	if f.Op != "" {
+		v, ok := d.Number(f.Field)
+		if !ok {
+			return false
+		}
+		switch f.Op {
+		case ">":
+			return v > f.Number
+		case ">=":
+			return v >= f.Number
+		case "<":
+			return v < f.Number
+		case "<=":
+			return v <= f.Number
+		default:
+			return v == f.Number
+		}
+	}
+
+	switch f.Field {
+	case "since":
+		return !d.Date().Before(f.Time)
+	case "maturity":
+		values := d.Field("maturity")
+		if len(values) == 0 || values[0] == "" {
+			values = []string{"provisional"}
+		}
+		return anyEqualFold(values, f.Value)
+	case "type":
+		want := strings.TrimSuffix(strings.ToLower(f.Value), "s")
+		for _, v := range d.Field("type") {
+			if strings.TrimSuffix(strings.ToLower(v), "s") == want {
+				return true
+			}
+		}
+		return false
+	}
+	return anyEqualFold(d.Field(f.Field), f.Value)
+}
+
+func anyEqualFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexDoc is an indexed document as a Doc. The index does not hold tags,
+// categories or utilities, so those filters match nothing.
+type indexDoc struct {
+	idx  *Index
+	doc  *Document
+	text *string
+}
+
+func (d *indexDoc) HasTerm(term string) bool {
+	_, ok := d.idx.Terms[term][d.doc.Path]
+	return ok
+}
+
+// Text re-reads the document's file.
+func (d *indexDoc) Text() string {
+	if d.text == nil {
+		var text string
+		if data, err := os.ReadFile(d.doc.Path); err == nil {
+			f := ParseFields(d.doc.Path, string(data))
+			text = f.Title + "\n" + strings.Join(f.Tags, "\n") + "\n" + f.Body
+		}
+		d.text = &text
+	}
+	return *d.text
+}
+
+func (d *indexDoc) Field(name string) []string {
+	switch name {
+	case "type":
+		return []string{d.doc.Type}
+	case "maturity":
+		return []string{d.doc.Maturity}
+	}
+	return nil
+}
+
+func (d *indexDoc) Date() time.Time { return d.doc.Date }
+
+func (d *indexDoc) Number(string) (float64, bool) { return 0, false }
+
+// containsSequence reports whether phrase occurs contiguously in seq.
+func containsSequence(seq, phrase []string) bool {
+	if len(phrase) == 0 {
+		return true
+	}
+	for i := 0; i+len(phrase) <= len(seq); i++ {
+		match := true
+		for j, term := range phrase {
+			if seq[i+j] != term {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
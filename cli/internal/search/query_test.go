@@ -0,0 +1,235 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	q, err := ParseQuery(`mutex "token bucket" -redis -"global lock" type:learning maturity:established since:7d error:nil`, now)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+
+	if want := []string{"mutex", "error", "nil"}; !reflect.DeepEqual(q.Terms, want) {
+		t.Errorf("Terms = %v, want %v", q.Terms, want)
+	}
+	if want := [][]string{{"token", "bucket"}}; !reflect.DeepEqual(q.Required, want) {
+		t.Errorf("Required = %v, want %v", q.Required, want)
+	}
+	if want := [][]string{{"redis"}, {"global", "lock"}}; !reflect.DeepEqual(q.Excluded, want) {
+		t.Errorf("Excluded = %v, want %v", q.Excluded, want)
+	}
+	if len(q.Filters) != 3 {
+		t.Fatalf("expected 3 filters, got %+v", q.Filters)
+	}
+	if since := q.Filters[2]; since.Field != "since" || !since.Time.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("since filter = %+v, want 7 days before now", since)
+	}
+}
+
+func TestParseQueryRegexMetacharacters(t *testing.T) {
+	q, err := ParseQuery(`mutex.Lock() (foo|bar) [x]*`, time.Now())
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	if want := []string{"mutex", "lock", "foo", "bar"}; !reflect.DeepEqual(q.Terms, want) {
+		t.Errorf("Terms = %v, want %v", q.Terms, want)
+	}
+}
+
+func TestParseQueryInvalidSince(t *testing.T) {
+	for _, q := range []string{"since:yesterday", "since:", "since:-3d"} {
+		if _, err := ParseQuery(q, time.Now()); err == nil {
+			t.Errorf("ParseQuery(%q): expected error", q)
+		}
+	}
+	if _, err := ParseQuery("since:2026-01-31", time.Now()); err != nil {
+		t.Errorf("absolute date should parse: %v", err)
+	}
+}
+
+func TestParseQueryClauses(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query string
+		want  string
+		rank  string
+	}{
+		{"mutex pattern", "ANY(mutex, pattern)", "mutex pattern"},
+		{
+			`tag:auth maturity:established utility>0.6 "token bucket" -redis`,
+			`AND(tag:auth, maturity:established, utility>0.6, "token bucket", NOT(ANY(redis)))`,
+			"token bucket",
+		},
+		{"retry tag:go backoff", "AND(ANY(retry, backoff), tag:go)", "retry backoff"},
+		{"tag:auth OR tag:oauth utility<=0.3", "OR(tag:auth, AND(tag:oauth, utility<=0.3))", ""},
+		{
+			"-(tag:legacy OR maturity:anti-pattern) cache",
+			"AND(NOT(OR(tag:legacy, maturity:anti-pattern)), ANY(cache))",
+			"cache",
+		},
+		{`category:"error handling" utility:0.5`, "AND(category:error handling, utility=0.5)", ""},
+		// Unknown fields are plain text
+		{"http:retry", "ANY(http, retry)", "http retry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, now)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			if got := q.Root.String(); got != tt.want {
+				t.Errorf("Root = %s, want %s", got, tt.want)
+			}
+			if got := strings.Join(q.RankTerms(), " "); got != tt.rank {
+				t.Errorf("RankTerms = %q, want %q", got, tt.rank)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		`"unterminated`,
+		"(tag:auth",
+		"tag:auth)",
+		"utility>high",
+		"tag>auth",
+		"since:soon",
+		"OR tag:auth",
+		"tag:auth OR",
+	} {
+		if _, err := ParseQuery(query, time.Now()); err == nil {
+			t.Errorf("ParseQuery(%q): expected error", query)
+		}
+	}
+}
+
+func TestPlainQuery(t *testing.T) {
+	text := `Why does -redis OR "since:soon" fail (tag:x)?`
+	plain := PlainQuery(text)
+	q, err := ParseQuery(plain, time.Now())
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", plain, err)
+	}
+	if len(q.Excluded) != 0 || len(q.Required) != 0 || len(q.Filters) != 0 || len(q.Terms) != 8 {
+		t.Errorf("PlainQuery(%q) = %q, parsed as %+v; want terms only", text, plain, q)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	dir := t.TempDir()
+	learnings := filepath.Join(dir, "learnings")
+	sessions := filepath.Join(dir, "sessions")
+	for _, d := range []string{learnings, sessions} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, filepath.Join(learnings, "bucket.md"),
+		"---\nmaturity: established\ndate: 2026-02-18\n---\n# Rate limiting\nUse a token bucket per tenant.")
+	writeFile(t, filepath.Join(learnings, "redis.md"),
+		"---\ndate: 2026-02-18\n---\n# Redis limiter\nA token bucket in redis.")
+	writeFile(t, filepath.Join(learnings, "scrambled.md"),
+		"---\ndate: 2026-02-18\n---\n# Buckets\nThe bucket holds a token.")
+	writeFile(t, filepath.Join(sessions, "old.md"),
+		"---\ndate: 2025-06-01\n---\n# Session\nDiscussed token bucket limits.")
+
+	idx, err := BuildIndex(dir)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	now := time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)
+
+	search := func(s string) []string {
+		t.Helper()
+		q, err := ParseQuery(s, now)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", s, err)
+		}
+		var paths []string
+		for _, r := range SearchQuery(idx, q, 0, DefaultOptions()) {
+			paths = append(paths, filepath.Base(r.Path))
+		}
+		return paths
+	}
+
+	if got := search(`"token bucket"`); len(got) != 3 {
+		t.Errorf("phrase should match 3 docs (not scrambled.md), got %v", got)
+	}
+	if got := search(`"token bucket" -redis`); len(got) != 2 {
+		t.Errorf("exclusion should drop redis.md, got %v", got)
+	}
+	if got := search(`"token bucket" type:learnings`); len(got) != 2 {
+		t.Errorf("type filter should keep 2 learnings, got %v", got)
+	}
+	if got := search(`token maturity:established`); !reflect.DeepEqual(got, []string{"bucket.md"}) {
+		t.Errorf("maturity filter = %v, want [bucket.md]", got)
+	}
+	if got := search(`token since:30d`); len(got) != 3 {
+		t.Errorf("since filter should drop old session, got %v", got)
+	}
+	if got := search(`type:session`); !reflect.DeepEqual(got, []string{"old.md"}) {
+		t.Errorf("filter-only query = %v, want [old.md]", got)
+	}
+	// Terms only rank, but count inside OR
+	if got := search(`limiter OR maturity:established`); len(got) != 2 {
+		t.Errorf("OR query = %v, want redis.md and bucket.md", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	writeFile(t, a, "alpha")
+	writeFile(t, b, "bravo")
+
+	idx := NewIndex()
+	AddDocument(idx, "/elsewhere/x.md", Fields{Body: "outside"})
+
+	stats, err := Refresh(idx, dir)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if stats.Added != 2 || stats.Updated != 0 || stats.Removed != 0 {
+		t.Errorf("first refresh stats = %+v, want 2 added", stats)
+	}
+
+	stats, _ = Refresh(idx, dir)
+	if stats.Changed() {
+		t.Errorf("second refresh should be a no-op, got %+v", stats)
+	}
+
+	writeFile(t, a, "alpha charlie")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(a, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, _ = Refresh(idx, dir)
+	if stats.Updated != 1 || stats.Removed != 1 {
+		t.Errorf("refresh after edit/delete = %+v, want 1 updated, 1 removed", stats)
+	}
+	if _, ok := idx.Terms["charlie"][a]; !ok {
+		t.Error("expected modified file to be re-indexed")
+	}
+	if _, ok := idx.Docs[b]; ok {
+		t.Error("expected deleted file to be removed")
+	}
+	if _, ok := idx.Docs["/elsewhere/x.md"]; !ok {
+		t.Error("documents outside refreshed dirs should be kept")
+	}
+}
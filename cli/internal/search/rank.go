@@ -0,0 +1,151 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// FieldBoosts weights term frequencies by the field they occur in.
+type FieldBoosts struct {
+	Title float64 `json:"title"`
+	Tags  float64 `json:"tags"`
+	Body  float64 `json:"body"`
+}
+
+// Options configures BM25 ranking.
+type Options struct {
+	// K1 controls term-frequency saturation.
+	K1 float64 `json:"k1"`
+
+	// B controls document-length normalisation (0 = none, 1 = full).
+	B float64 `json:"b"`
+
+	// Boosts weights title, tag and body occurrences.
+	Boosts FieldBoosts `json:"boosts"`
+}
+
+// DefaultOptions returns standard BM25 parameters with title matches
+// worth three body matches and tag matches worth two.
+func DefaultOptions() Options {
+	return Options{
+		K1:     1.2,
+		B:      0.75,
+		Boosts: FieldBoosts{Title: 3.0, Tags: 2.0, Body: 1.0},
+	}
+}
+
+// TermScore is one query term's contribution to a document score.
+type TermScore struct {
+	Term string  `json:"term"`
+	IDF  float64 `json:"idf"`
+	Posting
+	Score float64 `json:"score"`
+}
+
+// IndexResult is returned by Search.
+type IndexResult struct {
+	Path      string      `json:"path"`
+	Score     float64     `json:"score"`
+	Matched   int         `json:"matched"` // number of query terms matched
+	Breakdown []TermScore `json:"breakdown,omitempty"`
+}
+
+// Search finds documents matching the query and returns up to limit results
+// ranked by BM25 with the default field boosts.
+func Search(idx *Index, query string, limit int) []IndexResult {
+	return SearchWithOptions(idx, query, limit, DefaultOptions())
+}
+
+// SearchWithOptions is Search with explicit ranking parameters.
+// A limit <= 0 returns every matching document.
+func SearchWithOptions(idx *Index, query string, limit int, opts Options) []IndexResult {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 || len(idx.Docs) == 0 {
+		return nil
+	}
+
+	avgLen := averageLength(idx)
+	n := float64(len(idx.Docs))
+
+	byPath := make(map[string]*IndexResult)
+	for _, term := range queryTerms {
+		docs, ok := idx.Terms[term]
+		if !ok {
+			continue
+		}
+		idf := bm25IDF(n, float64(len(docs)))
+
+		for path, p := range docs {
+			doc, ok := idx.Docs[path]
+			if !ok {
+				continue
+			}
+			s := idf * bm25TF(weightedTF(p, opts.Boosts), float64(doc.Length), avgLen, opts)
+
+			r := byPath[path]
+			if r == nil {
+				r = &IndexResult{Path: path}
+				byPath[path] = r
+			}
+			r.Score += s
+			r.Matched++
+			r.Breakdown = append(r.Breakdown, TermScore{Term: term, IDF: idf, Posting: p, Score: s})
+		}
+	}
+
+	if len(byPath) == 0 {
+		return nil
+	}
+
+	results := make([]IndexResult, 0, len(byPath))
+	for _, r := range byPath {
+		results = append(results, *r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// weightedTF folds per-field frequencies into a single boosted frequency.
+func weightedTF(p Posting, b FieldBoosts) float64 {
+	return b.Title*float64(p.Title) + b.Tags*float64(p.Tags) + b.Body*float64(p.Body)
+}
+
+// bm25IDF is the Robertson-Sparck Jones IDF, shifted to stay positive.
+func bm25IDF(n, df float64) float64 {
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// bm25TF applies saturation and length normalisation to a term frequency.
+func bm25TF(tf, docLen, avgLen float64, opts Options) float64 {
+	if tf <= 0 {
+		return 0
+	}
+	norm := 1.0
+	if avgLen > 0 {
+		norm = 1 - opts.B + opts.B*docLen/avgLen
+	}
+	return tf * (opts.K1 + 1) / (tf + opts.K1*norm)
+}
+
+// averageLength returns the mean document length in tokens.
+func averageLength(idx *Index) float64 {
+	if len(idx.Docs) == 0 {
+		return 0
+	}
+	total := 0
+	for _, d := range idx.Docs {
+		total += d.Length
+	}
+	return float64(total) / float64(len(idx.Docs))
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSearchPrefersShortFocusedDocs(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "short.md", Fields{Body: "mutex guards shared state"})
+	AddDocument(idx, "dump.jsonl", Fields{Body: "mutex " + strings.Repeat("unrelated session chatter ", 1000)})
+	AddDocument(idx, "other.md", Fields{Body: "oauth tokens"})
+
+	results := Search(idx, "mutex", 10)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Path != "short.md" {
+		t.Errorf("expected short.md to outrank long dump, got %s first", results[0].Path)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("expected strictly higher score for short doc: %.4f vs %.4f", results[0].Score, results[1].Score)
+	}
+}
+
+func TestSearchFieldBoosts(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "title.md", Fields{Title: "Mutex", Body: "guards shared state"})
+	AddDocument(idx, "body.md", Fields{Title: "Locks", Body: "mutex guards state"})
+
+	results := Search(idx, "mutex", 10)
+	if len(results) != 2 || results[0].Path != "title.md" {
+		t.Fatalf("expected title match first, got %+v", results)
+	}
+
+	// With boosts flattened, equal-length docs tie and fall back to path order.
+	opts := DefaultOptions()
+	opts.Boosts = FieldBoosts{Title: 1, Tags: 1, Body: 1}
+	results = SearchWithOptions(idx, "mutex", 10, opts)
+	if math.Abs(results[0].Score-results[1].Score) > 1e-9 {
+		t.Errorf("expected tie with flat boosts, got %.4f vs %.4f", results[0].Score, results[1].Score)
+	}
+	if results[0].Path != "body.md" {
+		t.Errorf("expected path tiebreak, got %s first", results[0].Path)
+	}
+}
+
+func TestSearchBreakdown(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "a.md", Fields{Title: "Mutex", Tags: []string{"go"}, Body: "mutex pattern"})
+	AddDocument(idx, "b.md", Fields{Body: "pattern"})
+
+	results := Search(idx, "mutex pattern", 10)
+	if len(results) == 0 || results[0].Path != "a.md" {
+		t.Fatalf("expected a.md first, got %+v", results)
+	}
+
+	top := results[0]
+	if top.Matched != 2 || len(top.Breakdown) != 2 {
+		t.Fatalf("expected 2 matched terms with breakdown, got %d/%d", top.Matched, len(top.Breakdown))
+	}
+
+	var sum float64
+	for _, ts := range top.Breakdown {
+		sum += ts.Score
+		if ts.Term == "mutex" && (ts.Title != 1 || ts.Body != 1) {
+			t.Errorf("mutex posting = %+v, want title=1 body=1", ts.Posting)
+		}
+		if ts.IDF <= 0 {
+			t.Errorf("expected positive IDF for %q, got %f", ts.Term, ts.IDF)
+		}
+	}
+	if math.Abs(sum-top.Score) > 1e-9 {
+		t.Errorf("breakdown sum %.6f != score %.6f", sum, top.Score)
+	}
+}
+
+func TestSearchLimitZeroReturnsAll(t *testing.T) {
+	idx := NewIndex()
+	for _, p := range []string{"a.md", "b.md", "c.md"} {
+		AddDocument(idx, p, Fields{Body: "mutex"})
+	}
+	if got := len(Search(idx, "mutex", 0)); got != 3 {
+		t.Errorf("expected 3 results with limit 0, got %d", got)
+	}
+}
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRRFK is the reciprocal rank fusion constant from Cormack et al.
+const DefaultRRFK = 60
+
+// Hit is one document returned by a Retriever.
+type Hit struct {
+	Path    string  `json:"path"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
+
+	// Ranks records the 1-based rank each fused retriever gave this hit.
+	Ranks map[string]int `json:"ranks,omitempty"`
+}
+
+// Retriever finds documents related to a query. Implementations interpret
+// the query syntax described on Query as far as they are able.
+type Retriever interface {
+	// Name identifies the retriever in fused rankings.
+	Name() string
+
+	// Retrieve returns up to limit hits, best first. A limit <= 0 means
+	// no limit.
+	Retrieve(query string, limit int) ([]Hit, error)
+}
+
+// KeywordRetriever ranks documents in an Index with BM25.
+type KeywordRetriever struct {
+	Index   *Index
+	Options Options
+}
+
+// NewKeywordRetriever returns a BM25 retriever with default options.
+func NewKeywordRetriever(idx *Index) *KeywordRetriever {
+	return &KeywordRetriever{Index: idx, Options: DefaultOptions()}
+}
+
+// Name implements Retriever.
+func (k *KeywordRetriever) Name() string { return "keyword" }
+
+// Retrieve implements Retriever.
+func (k *KeywordRetriever) Retrieve(query string, limit int) ([]Hit, error) {
+	q, err := ParseQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	results := SearchQuery(k.Index, q, limit, k.Options)
+	hits := make([]Hit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, Hit{Path: r.Path, Score: r.Score})
+	}
+	return hits, nil
+}
+
+// HybridRetriever fuses several retrievers with reciprocal rank fusion:
+// each document scores Σ 1/(K + rank) over the lists it appears in.
+type HybridRetriever struct {
+	Retrievers []Retriever
+	K          float64
+}
+
+// NewHybridRetriever fuses retrievers with the default RRF constant.
+func NewHybridRetriever(retrievers ...Retriever) *HybridRetriever {
+	return &HybridRetriever{Retrievers: retrievers, K: DefaultRRFK}
+}
+
+// Name implements Retriever.
+func (h *HybridRetriever) Name() string { return "hybrid" }
+
+// Retrieve implements Retriever. A failing retriever is skipped as long as
+// at least one other succeeds.
+func (h *HybridRetriever) Retrieve(query string, limit int) ([]Hit, error) {
+	var lists [][]Hit
+	var names []string
+	var errs []string
+
+	for _, r := range h.Retrievers {
+		hits, err := r.Retrieve(query, 0)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Name(), err))
+			continue
+		}
+		lists = append(lists, hits)
+		names = append(names, r.Name())
+	}
+	if len(lists) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all retrievers failed: %s", strings.Join(errs, "; "))
+	}
+
+	fused := FuseRRF(h.K, names, lists)
+	if limit > 0 && len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused, nil
+}
+
+// FuseRRF merges ranked hit lists with reciprocal rank fusion. names[i]
+// labels lists[i] in each hit's Ranks. Snippets are kept from the first
+// list that has one.
+func FuseRRF(k float64, names []string, lists [][]Hit) []Hit {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	byPath := make(map[string]*Hit)
+	for i, list := range lists {
+		for rank, hit := range list {
+			h := byPath[hit.Path]
+			if h == nil {
+				h = &Hit{Path: hit.Path, Ranks: make(map[string]int)}
+				byPath[hit.Path] = h
+			}
+			h.Score += 1 / (k + float64(rank+1))
+			h.Ranks[names[i]] = rank + 1
+			if h.Snippet == "" {
+				h.Snippet = hit.Snippet
+			}
+		}
+	}
+
+	fused := make([]Hit, 0, len(byPath))
+	for _, h := range byPath {
+		fused = append(fused, *h)
+	}
+	sort.Slice(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].Path < fused[j].Path
+	})
+	return fused
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+type stubRetriever struct {
+	name string
+	hits []Hit
+	err  error
+}
+
+func (s stubRetriever) Name() string { return s.name }
+
+func (s stubRetriever) Retrieve(string, int) ([]Hit, error) { return s.hits, s.err }
+
+func TestFuseRRF(t *testing.T) {
+	keyword := []Hit{{Path: "a"}, {Path: "b"}, {Path: "c"}}
+	vector := []Hit{{Path: "c", Snippet: "from vector"}, {Path: "a"}, {Path: "d"}}
+
+	fused := FuseRRF(60, []string{"keyword", "vector"}, [][]Hit{keyword, vector})
+	if len(fused) != 4 {
+		t.Fatalf("expected 4 fused hits, got %d", len(fused))
+	}
+
+	// a: 1/61 + 1/62, c: 1/63 + 1/61, b: 1/62, d: 1/63
+	if fused[0].Path != "a" || fused[1].Path != "c" {
+		t.Errorf("fused order = %s,%s; want a,c first", fused[0].Path, fused[1].Path)
+	}
+	if want := 1.0/61 + 1.0/62; math.Abs(fused[0].Score-want) > 1e-12 {
+		t.Errorf("a score = %v, want %v", fused[0].Score, want)
+	}
+	if fused[0].Ranks["keyword"] != 1 || fused[0].Ranks["vector"] != 2 {
+		t.Errorf("a ranks = %v, want keyword:1 vector:2", fused[0].Ranks)
+	}
+	if fused[1].Snippet != "from vector" {
+		t.Errorf("expected snippet carried through fusion, got %q", fused[1].Snippet)
+	}
+}
+
+func TestHybridRetrieverSkipsFailures(t *testing.T) {
+	ok := stubRetriever{name: "keyword", hits: []Hit{{Path: "a"}, {Path: "b"}}}
+	down := stubRetriever{name: "smart-connections", err: errors.New("connection refused")}
+
+	hits, err := NewHybridRetriever(ok, down).Retrieve("q", 1)
+	if err != nil {
+		t.Fatalf("expected partial failure to be tolerated: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Path != "a" {
+		t.Errorf("hits = %+v, want [a]", hits)
+	}
+
+	if _, err := NewHybridRetriever(down).Retrieve("q", 0); err == nil {
+		t.Error("expected error when every retriever fails")
+	}
+}
+
+func TestKeywordRetriever(t *testing.T) {
+	idx := NewIndex()
+	AddDocument(idx, "a.md", Fields{Title: "Mutex", Body: "locks"})
+	AddDocument(idx, "b.md", Fields{Body: "mutex redis"})
+
+	hits, err := NewKeywordRetriever(idx).Retrieve("mutex -redis", 0)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Path != "a.md" {
+		t.Errorf("hits = %+v, want [a.md]", hits)
+	}
+}
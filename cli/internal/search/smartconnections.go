@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultSmartConnectionsURL is where the Obsidian Smart Connections plugin
+// serves its HTTP API while Obsidian is running.
+const DefaultSmartConnectionsURL = "http://localhost:37042"
+
+// SmartConnectionsRetriever queries the Obsidian Smart Connections plugin.
+// The query is sent verbatim; filters and exclusions are not applied.
+type SmartConnectionsRetriever struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewSmartConnectionsRetriever returns a client for the default endpoint.
+func NewSmartConnectionsRetriever() *SmartConnectionsRetriever {
+	return &SmartConnectionsRetriever{
+		BaseURL: DefaultSmartConnectionsURL,
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name implements Retriever.
+func (s *SmartConnectionsRetriever) Name() string { return "smart-connections" }
+
+// Retrieve implements Retriever.
+func (s *SmartConnectionsRetriever) Retrieve(query string, limit int) ([]Hit, error) {
+	searchURL := fmt.Sprintf("%s/search?query=%s", s.BaseURL, url.QueryEscape(query))
+	if limit > 0 {
+		searchURL += fmt.Sprintf("&limit=%d", limit)
+	}
+
+	resp, err := s.Client.Get(searchURL)
+	if err != nil {
+		return nil, fmt.Errorf("smart connections not running: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck // HTTP response body close best-effort
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("smart connections API error: %s", resp.Status)
+	}
+
+	var scResponse struct {
+		Results []struct {
+			Path    string  `json:"path"`
+			Score   float64 `json:"score"`
+			Content string  `json:"content,omitempty"`
+			Title   string  `json:"title,omitempty"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&scResponse); err != nil {
+		return nil, fmt.Errorf("parse Smart Connections response: %w", err)
+	}
+
+	hits := make([]Hit, 0, len(scResponse.Results))
+	for _, r := range scResponse.Results {
+		snippet := r.Content
+		if snippet == "" {
+			snippet = r.Title
+		}
+		hits = append(hits, Hit{Path: r.Path, Score: r.Score, Snippet: snippet})
+	}
+	return hits, nil
+}
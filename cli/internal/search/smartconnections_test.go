@@ -0,0 +1,38 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSmartConnectionsRetriever(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("query") != "token bucket" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"path":"a.md","score":0.9,"title":"Rate limits"},{"path":"b.md","score":0.4,"content":"bucket"}]}`))
+	}))
+	defer srv.Close()
+
+	sc := NewSmartConnectionsRetriever()
+	sc.BaseURL = srv.URL
+
+	hits, err := sc.Retrieve("token bucket", 5)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Path != "a.md" || hits[0].Snippet != "Rate limits" || hits[1].Snippet != "bucket" {
+		t.Errorf("unexpected hits: %+v", hits)
+	}
+
+	if _, err := sc.Retrieve("other", 5); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+
+	sc.BaseURL = "http://127.0.0.1:1"
+	if _, err := sc.Retrieve("x", 5); err == nil {
+		t.Error("expected error when Smart Connections is not running")
+	}
+}
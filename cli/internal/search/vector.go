@@ -0,0 +1,335 @@
+package search
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// VectorVersion is the on-disk format version written by SaveVectors.
+	VectorVersion = 1
+
+	// DefaultVectorDim is the number of hashed feature buckets per vector.
+	DefaultVectorDim = 512
+
+	// DefaultMinSimilarity is the cosine similarity below which vector
+	// hits are discarded as unrelated.
+	DefaultMinSimilarity = 0.15
+)
+
+// VectorIndex holds dense, L2-normalised document vectors built from
+// hashed TF-IDF features: every term contributes to a bucket for itself
+// and to buckets for its character trigrams, so related word forms
+// ("authenticate", "authentication") land near each other without any
+// external embedding model.
+type VectorIndex struct {
+	Dim     int                  `json:"dim"`
+	BuiltAt time.Time            `json:"built_at"`
+	Vectors map[string][]float32 `json:"-"`
+}
+
+// vectorLine is the JSONL-serialised form: a header line, then one line
+// per document with its vector as little-endian float32 bytes.
+type vectorLine struct {
+	Version int       `json:"version,omitempty"`
+	Dim     int       `json:"dim,omitempty"`
+	BuiltAt time.Time `json:"built_at,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Vector  []byte    `json:"vector,omitempty"`
+}
+
+// BuildVectors computes a vector for every document in idx in a single
+// pass over the postings.
+func BuildVectors(idx *Index, dim int) *VectorIndex {
+	if dim <= 0 {
+		dim = DefaultVectorDim
+	}
+	vi := &VectorIndex{Dim: dim, BuiltAt: time.Now(), Vectors: make(map[string][]float32, len(idx.Docs))}
+
+	n := float64(len(idx.Docs))
+	boosts := DefaultOptions().Boosts
+	acc := make(map[string][]float64, len(idx.Docs))
+
+	for term, docs := range idx.Terms {
+		idf := bm25IDF(n, float64(len(docs)))
+		for path, p := range docs {
+			tf := weightedTF(p, boosts)
+			if tf <= 0 {
+				continue
+			}
+			v := acc[path]
+			if v == nil {
+				v = make([]float64, dim)
+				acc[path] = v
+			}
+			addTermFeatures(v, term, (1+math.Log(tf))*idf)
+		}
+	}
+
+	for path := range idx.Docs {
+		vi.Vectors[path] = normalize(acc[path], dim)
+	}
+	return vi
+}
+
+// Stale reports whether the vectors no longer cover exactly the documents
+// in idx, or any document was re-indexed after the vectors were built.
+func (vi *VectorIndex) Stale(idx *Index) bool {
+	if len(vi.Vectors) != len(idx.Docs) {
+		return true
+	}
+	for path, doc := range idx.Docs {
+		if _, ok := vi.Vectors[path]; !ok {
+			return true
+		}
+		if doc.ModTime.After(vi.BuiltAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Embed returns the normalised query vector for terms, weighting each by
+// its IDF in idx. Terms unknown to the index get the maximum IDF.
+func (vi *VectorIndex) Embed(idx *Index, terms []string) []float32 {
+	n := float64(len(idx.Docs))
+	v := make([]float64, vi.Dim)
+	for _, term := range terms {
+		addTermFeatures(v, term, bm25IDF(n, float64(len(idx.Terms[term]))))
+	}
+	return normalize(v, vi.Dim)
+}
+
+// Similar returns documents ordered by cosine similarity to vec, keeping
+// only those at or above minScore.
+func (vi *VectorIndex) Similar(vec []float32, minScore float64) []Hit {
+	var hits []Hit
+	for path, dv := range vi.Vectors {
+		s := dot(vec, dv)
+		if s >= minScore && s > 0 {
+			hits = append(hits, Hit{Path: path, Score: s})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Path < hits[j].Path
+	})
+	return hits
+}
+
+// SaveVectors writes vectors to a JSONL file.
+func SaveVectors(vi *VectorIndex, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create vector dir: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create vector file: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // best-effort close
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(vectorLine{Version: VectorVersion, Dim: vi.Dim, BuiltAt: vi.BuiltAt}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	paths := make([]string, 0, len(vi.Vectors))
+	for p := range vi.Vectors {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := enc.Encode(vectorLine{Path: p, Vector: encodeVector(vi.Vectors[p])}); err != nil {
+			return fmt.Errorf("write vector %q: %w", p, err)
+		}
+	}
+	return w.Flush()
+}
+
+// LoadVectors reads vectors from a JSONL file. It returns ErrIndexVersion
+// if the file was written in a different format version.
+func LoadVectors(path string) (*VectorIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open vectors: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close best-effort
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var vi *VectorIndex
+	for scanner.Scan() {
+		var line vectorLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			if vi == nil {
+				return nil, ErrIndexVersion
+			}
+			continue // skip malformed lines
+		}
+		if vi == nil {
+			if line.Version != VectorVersion || line.Dim <= 0 {
+				return nil, ErrIndexVersion
+			}
+			vi = &VectorIndex{Dim: line.Dim, BuiltAt: line.BuiltAt, Vectors: make(map[string][]float32)}
+			continue
+		}
+		if v := decodeVector(line.Vector); len(v) == vi.Dim {
+			vi.Vectors[line.Path] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read vectors: %w", err)
+	}
+	if vi == nil {
+		return nil, ErrIndexVersion
+	}
+	return vi, nil
+}
+
+// VectorRetriever ranks documents by cosine similarity of hashed TF-IDF
+// vectors. Required/excluded phrases and filters in the query are applied
+// to the candidates; optional terms and phrase words form the query vector.
+type VectorRetriever struct {
+	Index    *Index
+	Vectors  *VectorIndex
+	MinScore float64
+}
+
+// NewVectorRetriever returns a vector retriever with the default cutoff.
+func NewVectorRetriever(idx *Index, vi *VectorIndex) *VectorRetriever {
+	return &VectorRetriever{Index: idx, Vectors: vi, MinScore: DefaultMinSimilarity}
+}
+
+// Name implements Retriever.
+func (r *VectorRetriever) Name() string { return "vector" }
+
+// Retrieve implements Retriever.
+func (r *VectorRetriever) Retrieve(query string, limit int) ([]Hit, error) {
+	q, err := ParseQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rank := q.RankTerms()
+	if len(rank) == 0 {
+		// Nothing to embed: defer to filter-only keyword behaviour
+		return (&KeywordRetriever{Index: r.Index, Options: DefaultOptions()}).Retrieve(query, limit)
+	}
+
+	var hits []Hit
+	for _, h := range r.Vectors.Similar(r.Vectors.Embed(r.Index, rank), r.MinScore) {
+		if !matchQuery(r.Index, h.Path, q) {
+			continue
+		}
+		hits = append(hits, h)
+		if limit > 0 && len(hits) >= limit {
+			break
+		}
+	}
+	return hits, nil
+}
+
+// addTermFeatures adds a term's whole-word feature and its character
+// trigram features to v. The trigrams together carry the same L2 mass as
+// the word feature, so exact and partial matches count about equally.
+func addTermFeatures(v []float64, term string, weight float64) {
+	addFeature(v, "w:"+term, weight)
+
+	grams := trigrams(term)
+	if len(grams) == 0 {
+		return
+	}
+	share := weight / math.Sqrt(float64(len(grams)))
+	for _, g := range grams {
+		addFeature(v, "g:"+g, share)
+	}
+}
+
+// addFeature hashes a feature to a signed bucket.
+func addFeature(v []float64, feature string, weight float64) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(feature)) //nolint:errcheck // hash writes never fail
+	sum := h.Sum32()
+	bucket := int(sum % uint32(len(v)))
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	v[bucket] += weight
+}
+
+// trigrams returns the character trigrams of "^term$".
+func trigrams(term string) []string {
+	runes := []rune("^" + strings.ToLower(term) + "$")
+	if len(runes) < 3 {
+		return nil
+	}
+	grams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+3]))
+	}
+	return grams
+}
+
+// normalize converts v to an L2-normalised float32 vector. A nil or zero
+// vector yields all zeros.
+func normalize(v []float64, dim int) []float32 {
+	out := make([]float32, dim)
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(x / norm)
+	}
+	return out
+}
+
+// dot returns the dot product of two equal-length vectors.
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func vectorFixture() *Index {
+	idx := NewIndex()
+	AddDocument(idx, "auth.md", Fields{Title: "Authentication", Body: "Rotate authentication tokens before expiry."})
+	AddDocument(idx, "db.md", Fields{Title: "Migrations", Body: "Run database migrations in a transaction."})
+	AddDocument(idx, "cache.md", Fields{Title: "Caching", Body: "Invalidate cached entries on write."})
+	return idx
+}
+
+func TestVectorRetrieverFindsRelatedWordForms(t *testing.T) {
+	idx := vectorFixture()
+	r := NewVectorRetriever(idx, BuildVectors(idx, DefaultVectorDim))
+
+	// No document contains "authenticate" or "token" verbatim as a term
+	// match for keyword search on "authenticate", but trigrams overlap.
+	if kw := Search(idx, "authenticate", 10); len(kw) != 0 {
+		t.Fatalf("precondition: keyword search should miss, got %+v", kw)
+	}
+
+	hits, err := r.Retrieve("authenticate", 10)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(hits) == 0 || hits[0].Path != "auth.md" {
+		t.Fatalf("expected auth.md as top semantic hit, got %+v", hits)
+	}
+	for _, h := range hits {
+		if h.Path == "cache.md" {
+			t.Errorf("unrelated doc should fall below similarity cutoff: %+v", h)
+		}
+	}
+
+	hits, _ = r.Retrieve("authenticate -expiry", 10)
+	for _, h := range hits {
+		if h.Path == "auth.md" {
+			t.Error("exclusion should apply to vector hits")
+		}
+	}
+}
+
+func TestSaveLoadVectors(t *testing.T) {
+	idx := vectorFixture()
+	vi := BuildVectors(idx, 64)
+
+	path := filepath.Join(t.TempDir(), "vectors.jsonl")
+	if err := SaveVectors(vi, path); err != nil {
+		t.Fatalf("SaveVectors: %v", err)
+	}
+	loaded, err := LoadVectors(path)
+	if err != nil {
+		t.Fatalf("LoadVectors: %v", err)
+	}
+	if loaded.Dim != 64 || len(loaded.Vectors) != 3 {
+		t.Fatalf("loaded dim=%d vectors=%d, want 64/3", loaded.Dim, len(loaded.Vectors))
+	}
+	for p, v := range vi.Vectors {
+		lv := loaded.Vectors[p]
+		for i := range v {
+			if v[i] != lv[i] {
+				t.Fatalf("vector %s[%d] = %v, want %v", p, i, lv[i], v[i])
+			}
+		}
+	}
+
+	writeFile(t, path, `{"version":99,"dim":64}`+"\n")
+	if _, err := LoadVectors(path); !errors.Is(err, ErrIndexVersion) {
+		t.Errorf("expected ErrIndexVersion, got %v", err)
+	}
+}
+
+func TestVectorsStale(t *testing.T) {
+	idx := vectorFixture()
+	vi := BuildVectors(idx, 32)
+	if vi.Stale(idx) {
+		t.Error("fresh vectors should not be stale")
+	}
+
+	AddDocument(idx, "new.md", Fields{Body: "new"})
+	if !vi.Stale(idx) {
+		t.Error("vectors missing a document should be stale")
+	}
+
+	vi = BuildVectors(idx, 32)
+	idx.Docs["db.md"].ModTime = time.Now().Add(time.Hour)
+	if !vi.Stale(idx) {
+		t.Error("vectors older than a re-indexed document should be stale")
+	}
+}
@@ -0,0 +1,243 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultWatchDebounce is how long the watcher waits after the last
+	// event before applying pending changes, so editors that write a file
+	// in several steps cause a single re-index.
+	DefaultWatchDebounce = 250 * time.Millisecond
+
+	// DefaultCheckpointInterval is how often a changed index is saved.
+	DefaultCheckpointInterval = 5 * time.Second
+)
+
+// WatchStats counts what a Watcher has done since it started.
+type WatchStats struct {
+	Updated     int `json:"updated"`
+	Removed     int `json:"removed"`
+	Checkpoints int `json:"checkpoints"`
+}
+
+// Watcher keeps an Index current from filesystem notifications instead of
+// rescanning the corpus. Created and modified files are re-indexed, deleted
+// or renamed-away files and directories are removed, and the index is
+// checkpointed to Path with SaveIndex whenever it has changed.
+type Watcher struct {
+	Index *Index
+	Dirs  []string
+	Path  string
+
+	Debounce           time.Duration
+	CheckpointInterval time.Duration
+
+	// Logf, when set, receives one line per applied change and checkpoint.
+	Logf func(format string, args ...interface{})
+
+	fsw     *fsnotify.Watcher
+	pending map[string]bool
+	missing map[string]bool
+	dirty   bool
+	stats   WatchStats
+}
+
+// NewWatcher returns a watcher over dirs that checkpoints idx to path.
+func NewWatcher(idx *Index, path string, dirs ...string) *Watcher {
+	return &Watcher{
+		Index:              idx,
+		Dirs:               dirs,
+		Path:               path,
+		Debounce:           DefaultWatchDebounce,
+		CheckpointInterval: DefaultCheckpointInterval,
+	}
+}
+
+// Run brings the index up to date with a Refresh, then applies changes as
+// they are reported until ctx is cancelled. Pending changes are applied and
+// checkpointed before it returns. Dirs that do not exist yet are picked up
+// once they are created.
+func (w *Watcher) Run(ctx context.Context) (WatchStats, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return w.stats, fmt.Errorf("start watcher: %w", err)
+	}
+	defer func() {
+		_ = fsw.Close() //nolint:errcheck // shutting down
+	}()
+	w.fsw = fsw
+	w.pending = make(map[string]bool)
+	w.missing = make(map[string]bool)
+
+	for _, dir := range w.Dirs {
+		w.missing[dir] = true
+	}
+	w.watchMissing()
+
+	stats, err := Refresh(w.Index, w.Dirs...)
+	if err != nil {
+		return w.stats, err
+	}
+	w.pending = make(map[string]bool) // the Refresh covered them
+	w.stats.Updated += stats.Added + stats.Updated
+	w.stats.Removed += stats.Removed
+	if stats.Changed() || len(w.Index.Docs) == 0 {
+		w.dirty = true
+	}
+	if err := w.checkpoint(); err != nil {
+		return w.stats, err
+	}
+
+	debounce := time.NewTimer(w.Debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	ticker := time.NewTicker(w.CheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.apply()
+			return w.stats, w.checkpoint()
+
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return w.stats, w.checkpoint()
+			}
+			w.pending[ev.Name] = true
+			if ev.Has(fsnotify.Rename) || ev.Has(fsnotify.Remove) {
+				// A renamed directory keeps its inotify watch under the old
+				// name; drop it so a new directory there is watched afresh.
+				_ = fsw.Remove(ev.Name) //nolint:errcheck // usually not a watched dir
+			}
+			debounce.Reset(w.Debounce)
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return w.stats, w.checkpoint()
+			}
+			w.logf("watch error: %v", err)
+
+		case <-debounce.C:
+			w.apply()
+
+		case <-ticker.C:
+			w.watchMissing()
+			w.apply()
+			if err := w.checkpoint(); err != nil {
+				w.logf("checkpoint failed: %v", err)
+			}
+		}
+	}
+}
+
+// apply re-indexes or removes every pending path according to what is on
+// disk now, which makes the outcome independent of event ordering.
+func (w *Watcher) apply() {
+	for path := range w.pending {
+		delete(w.pending, path)
+
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			n := RemoveTree(w.Index, path)
+			if n > 0 {
+				w.stats.Removed += n
+				w.dirty = true
+				w.logf("removed %s (%d docs)", path, n)
+			}
+			for _, dir := range w.Dirs {
+				if dir == path {
+					w.missing[dir] = true // watch it again if recreated
+				}
+			}
+		case err != nil:
+			w.logf("stat %s: %v", path, err)
+		case info.IsDir():
+			// New or moved-in directory: watch it and index what it holds
+			if err := w.addTree(path); err != nil {
+				w.logf("watch %s: %v", path, err)
+			}
+			stats, err := Refresh(w.Index, path)
+			if err != nil {
+				w.logf("index %s: %v", path, err)
+			}
+			if stats.Changed() {
+				w.stats.Updated += stats.Added + stats.Updated
+				w.stats.Removed += stats.Removed
+				w.dirty = true
+				w.logf("indexed %s (%d docs)", path, stats.Added+stats.Updated)
+			}
+		case isIndexable(path) && underAny(path, w.Dirs):
+			if doc := w.Index.Docs[path]; doc != nil && doc.ModTime.Equal(info.ModTime()) {
+				continue
+			}
+			if err := UpdateIndex(w.Index, path); err != nil {
+				w.logf("index %s: %v", path, err)
+				continue
+			}
+			w.stats.Updated++
+			w.dirty = true
+			w.logf("indexed %s", path)
+		}
+	}
+}
+
+// checkpoint saves the index if it changed since the last save.
+func (w *Watcher) checkpoint() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := SaveIndex(w.Index, w.Path); err != nil {
+		return err
+	}
+	w.dirty = false
+	w.stats.Checkpoints++
+	w.logf("checkpoint: %d docs, %d terms", len(w.Index.Docs), len(w.Index.Terms))
+	return nil
+}
+
+// watchMissing starts watching dirs that have appeared since the last
+// attempt and queues them for indexing.
+func (w *Watcher) watchMissing() {
+	for dir := range w.missing {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := w.addTree(dir); err != nil {
+			w.logf("watch %s: %v", dir, err)
+			continue
+		}
+		delete(w.missing, dir)
+		w.pending[dir] = true
+	}
+}
+
+// addTree watches dir and every directory below it. inotify watches are
+// not recursive.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.fsw.Add(path)
+	})
+}
+
+func (w *Watcher) logf(format string, args ...interface{}) {
+	if w.Logf != nil {
+		w.Logf(format, args...)
+	}
+}
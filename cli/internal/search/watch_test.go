@@ -0,0 +1,108 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForIndex polls the checkpoint file until cond holds or times out.
+func waitForIndex(t *testing.T, path string, cond func(*Index) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if idx, err := LoadIndex(path); err == nil && cond(idx) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("checkpoint %s never reached the expected state", path)
+}
+
+func TestWatcherTracksChanges(t *testing.T) {
+	root := t.TempDir()
+	learnings := filepath.Join(root, "learnings")
+	patterns := filepath.Join(root, "patterns") // created while watching
+	if err := os.MkdirAll(learnings, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(learnings, "existing.md")
+	if err := os.WriteFile(existing, []byte("# Existing\n\nmutex contention"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idxPath := filepath.Join(root, "ao", "index.jsonl")
+	w := NewWatcher(NewIndex(), idxPath, learnings, patterns)
+	w.Debounce = 10 * time.Millisecond
+	w.CheckpointInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Run(ctx)
+		done <- err
+	}()
+
+	waitForIndex(t, idxPath, func(idx *Index) bool { return idx.Docs[existing] != nil })
+
+	created := filepath.Join(learnings, "created.md")
+	if err := os.WriteFile(created, []byte("# Created\n\ntoken bucket"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForIndex(t, idxPath, func(idx *Index) bool { return len(idx.Terms["bucket"]) == 1 })
+
+	renamed := filepath.Join(learnings, "renamed.md")
+	if err := os.Rename(created, renamed); err != nil {
+		t.Fatal(err)
+	}
+	waitForIndex(t, idxPath, func(idx *Index) bool {
+		return idx.Docs[created] == nil && idx.Docs[renamed] != nil
+	})
+
+	if err := os.Remove(existing); err != nil {
+		t.Fatal(err)
+	}
+	waitForIndex(t, idxPath, func(idx *Index) bool {
+		_, ok := idx.Terms["mutex"]
+		return idx.Docs[existing] == nil && !ok
+	})
+
+	// A root that did not exist at startup is picked up once created
+	if err := os.MkdirAll(patterns, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pattern := filepath.Join(patterns, "retry.md")
+	if err := os.WriteFile(pattern, []byte("# Retry\n\nexponential backoff"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForIndex(t, idxPath, func(idx *Index) bool { return idx.Docs[pattern] != nil })
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop after cancel")
+	}
+}
+
+func TestWatcherApplyIgnoresUnindexable(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWatcher(NewIndex(), filepath.Join(dir, "index.jsonl"), dir)
+	w.pending = map[string]bool{other: true}
+	w.missing = map[string]bool{}
+	w.apply()
+
+	if len(w.Index.Docs) != 0 || w.dirty {
+		t.Errorf("expected non-indexable file to be ignored, got %d docs", len(w.Index.Docs))
+	}
+}
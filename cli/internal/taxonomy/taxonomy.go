@@ -14,9 +14,13 @@
 // Candidates are assigned to tiers based on their composite score:
 //   - Gold (0.85-1.0): Highest quality, auto-promoted
 //   - Silver (0.70-0.84): High quality, auto-promoted
-//   - Bronze (0.50-0.69): Acceptable, 5% sampled for human review
+//   - Bronze (0.50-0.69): Acceptable, always gated for human review
 //   - Discard (<0.50): Below threshold, not stored
 //
+// Only tiers that are not gated are sampled for human review, at their
+// HumanGateSampleRate (zero by default; ao sets gold's and silver's from
+// gate.sample_rates in config).
+//
 // # Scoring Rubric
 //
 // The composite score is calculated from five dimensions:
@@ -27,7 +31,13 @@
 //   - Confidence (10%): Assertion strength
 package taxonomy
 
-import "github.com/boshu2/agentops/cli/internal/types"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
 
 // KnowledgeTypeInfo describes a knowledge type and its base scoring.
 type KnowledgeTypeInfo struct {
@@ -133,23 +143,23 @@ var DefaultTierConfigs = map[types.Tier]TierConfig{
 type RubricWeights struct {
 	// Specificity measures named entities, concrete values, code snippets.
 	// Weight: 0.30 (30%)
-	Specificity float64
+	Specificity float64 `json:"specificity"`
 
 	// Actionability measures imperative verbs, clear steps, before/after.
 	// Weight: 0.25 (25%)
-	Actionability float64
+	Actionability float64 `json:"actionability"`
 
 	// Novelty measures uniqueness vs. common knowledge.
 	// Weight: 0.20 (20%)
-	Novelty float64
+	Novelty float64 `json:"novelty"`
 
 	// Context measures quality of surrounding context.
 	// Weight: 0.15 (15%)
-	Context float64
+	Context float64 `json:"context"`
 
 	// Confidence measures assertion strength.
 	// Weight: 0.10 (10%)
-	Confidence float64
+	Confidence float64 `json:"confidence"`
 }
 
 // DefaultRubricWeights provides the standard scoring weights.
@@ -168,6 +178,15 @@ func (w RubricWeights) ValidateWeights() bool {
 	return sum >= 0.99 && sum <= 1.01
 }
 
+// Score returns the weighted sum of rubric scores.
+func (w RubricWeights) Score(r types.RubricScores) float64 {
+	return r.Specificity*w.Specificity +
+		r.Actionability*w.Actionability +
+		r.Novelty*w.Novelty +
+		r.Context*w.Context +
+		r.Confidence*w.Confidence
+}
+
 // TierOrder provides the tier ordering from highest to lowest quality.
 var TierOrder = []types.Tier{
 	types.TierGold,
@@ -213,3 +232,40 @@ func RequiresHumanGate(tier types.Tier, configs map[types.Tier]TierConfig) bool
 	}
 	return false
 }
+
+// Sample escalation: once a tier has at least SampleEscalationMinReviews
+// sampled reviews and more than SampleEscalationRejectRate of them were
+// rejected, its sample rate is raised.
+const (
+	SampleEscalationMinReviews = 10
+	SampleEscalationRejectRate = 0.20
+)
+
+// SampleDraw maps a candidate to a uniform value in [0, 1). The draw depends
+// only on seed and candidateID, so a sample can be reproduced and audited; a
+// candidate is sampled when its draw is below the tier's sample rate.
+func SampleDraw(seed, candidateID string) float64 {
+	h := sha256.Sum256([]byte(seed + "\x00" + candidateID))
+	return float64(binary.BigEndian.Uint64(h[:8])>>11) / (1 << 53)
+}
+
+// SampleRate returns a tier's HumanGateSampleRate given how many of its
+// sampled reviews were done and rejected. When sampled rejects climb past
+// SampleEscalationRejectRate the rate becomes the larger of double the
+// configured rate and the observed reject rate, capped at 1. A tier whose
+// configured rate is zero is never sampled.
+func SampleRate(tier types.Tier, configs map[types.Tier]TierConfig, reviewed, rejected int) float64 {
+	config, ok := configs[tier]
+	if !ok {
+		return 0
+	}
+	rate := config.HumanGateSampleRate
+	if reviewed < SampleEscalationMinReviews || rate <= 0 {
+		return rate
+	}
+	rejectRate := float64(rejected) / float64(reviewed)
+	if rejectRate <= SampleEscalationRejectRate {
+		return rate
+	}
+	return math.Min(1, math.Max(2*rate, rejectRate))
+}
@@ -1,6 +1,8 @@
 package taxonomy
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/boshu2/agentops/cli/internal/types"
@@ -162,6 +164,64 @@ func TestRequiresHumanGate(t *testing.T) {
 	}
 }
 
+func TestSampleDraw(t *testing.T) {
+	if SampleDraw("seed", "cand-1") != SampleDraw("seed", "cand-1") {
+		t.Error("draw should be deterministic for a seed and ID")
+	}
+	if SampleDraw("seed", "cand-1") == SampleDraw("other", "cand-1") {
+		t.Error("draw should depend on the seed")
+	}
+
+	// Over many candidates the fraction sampled approaches the rate
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		d := SampleDraw("seed", fmt.Sprintf("cand-%d", i))
+		if d < 0 || d >= 1 {
+			t.Fatalf("draw %f out of [0, 1)", d)
+		}
+		if d < 0.05 {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("sampled %d of 10000 at 5%%, want about 500", sampled)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	sampled := map[types.Tier]TierConfig{
+		types.TierGold:   {Tier: types.TierGold, HumanGateSampleRate: 0.02},
+		types.TierSilver: {Tier: types.TierSilver, HumanGateSampleRate: 0.05},
+	}
+	tests := []struct {
+		name               string
+		configs            map[types.Tier]TierConfig
+		tier               types.Tier
+		reviewed, rejected int
+		expected           float64
+	}{
+		{"gold default", DefaultTierConfigs, types.TierGold, 0, 0, 0},
+		{"silver default", DefaultTierConfigs, types.TierSilver, 0, 0, 0},
+		{"bronze default", DefaultTierConfigs, types.TierBronze, 0, 0, 0.05},
+		{"discard never sampled", DefaultTierConfigs, types.TierDiscard, 0, 0, 0},
+		{"zero rate never escalates", DefaultTierConfigs, types.TierSilver, 10, 5, 0},
+		{"configured rate", sampled, types.TierGold, 0, 0, 0.02},
+		{"too few reviews to escalate", sampled, types.TierSilver, 5, 5, 0.05},
+		{"rejects at threshold", sampled, types.TierSilver, 10, 2, 0.05},
+		{"escalated doubles", sampled, types.TierGold, 20, 5, 0.25},
+		{"escalated to reject rate", sampled, types.TierSilver, 10, 5, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SampleRate(tt.tier, tt.configs, tt.reviewed, tt.rejected)
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("SampleRate(%q, %d, %d) = %f, want %f", tt.tier, tt.reviewed, tt.rejected, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestKnowledgeTypesCompleteness(t *testing.T) {
 	expectedTypes := []types.KnowledgeType{
 		types.KnowledgeTypeDecision,
@@ -37,6 +37,10 @@ type ToolCall struct {
 	// Name is the tool identifier (e.g., "Read", "Bash", "Edit").
 	Name string `json:"name"`
 
+	// ID links a call to its result: a "tool_result" carries the ID of the
+	// call it answers. Empty when the transcript does not record one.
+	ID string `json:"id,omitempty"`
+
 	// Input contains the parameters passed to the tool.
 	Input map[string]interface{} `json:"input,omitempty"`
 
@@ -598,13 +602,23 @@ type CitationEvent struct {
 	CitedAt time.Time `json:"cited_at"`
 
 	// CitationType indicates how the artifact was used.
-	// Values: "retrieved" (surfaced via ol inject), "applied" (used in implementation),
-	// "reference" (manual citation).
+	// Values: "injected" (surfaced via ao inject), "retrieved" (read or found
+	// by search in the transcript), "applied" (quoted or reused in the
+	// transcript), "reference" (manual citation).
 	CitationType string `json:"citation_type,omitempty"`
 
 	// Query is the search query that surfaced this artifact (if applicable).
 	Query string `json:"query,omitempty"`
 
+	// Exploratory marks an artifact injected by exploration (a Thompson
+	// sample of its utility) rather than by greedy ranking.
+	Exploratory bool `json:"exploratory,omitempty"`
+
+	// Source is what recorded the citation: "transcript" for citations
+	// detected in the session's transcript, empty for ones recorded by
+	// ao inject or by hand.
+	Source string `json:"source,omitempty"`
+
 	// --- MemRL Feedback Tracking (Phase 5) ---
 
 	// FeedbackGiven indicates whether feedback has been recorded for this citation.
@@ -627,6 +641,25 @@ type CitationEvent struct {
 	FeedbackAt time.Time `json:"feedback_at,omitempty"`
 }
 
+// Citation types recorded for learnings, from the weakest evidence of use
+// to the strongest.
+const (
+	// CitationTypeInjected means the artifact was put in the session's context.
+	CitationTypeInjected = "injected"
+
+	// CitationTypeRetrieved means the session read the artifact or found it
+	// by search.
+	CitationTypeRetrieved = "retrieved"
+
+	// CitationTypeApplied means the session quoted the artifact's ID or
+	// reused its content.
+	CitationTypeApplied = "applied"
+)
+
+// CitationSourceTranscript marks a citation detected in the session's
+// transcript.
+const CitationSourceTranscript = "transcript"
+
 // --- Knowledge Flywheel Metrics (ol-a46 Phase 0) ---
 
 // FlywheelMetrics captures the state of the knowledge flywheel equation:
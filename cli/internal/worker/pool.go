@@ -1,9 +1,11 @@
 // Package worker provides a generic concurrent worker pool for fan-out/fan-in
 // file processing. Used by forge, search, and inject commands to parallelize
-// file I/O across available CPUs.
+// file I/O across available CPUs. Stream and Budget support long-running
+// batches that consume results as they complete under a memory ceiling.
 package worker
 
 import (
+	"context"
 	"runtime"
 	"sync"
 )
@@ -80,3 +82,113 @@ func (p *Pool[T]) Process(items []string, fn func(string) (T, error)) []Result[T
 
 	return results
 }
+
+// Stream distributes items across workers like Process, but sends each
+// result on the returned channel as soon as it is ready (in completion
+// order), so a single consumer can handle results while work continues.
+// Once ctx is cancelled no further items are started; each of them is
+// reported with ctx.Err() instead. Every item yields exactly one result, and
+// the channel is closed after the last.
+func (p *Pool[T]) Stream(ctx context.Context, items []string, fn func(context.Context, string) (T, error)) <-chan Result[T] {
+	out := make(chan Result[T])
+	if len(items) == 0 {
+		close(out)
+		return out
+	}
+
+	workers := p.concurrency
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var r Result[T]
+				if err := ctx.Err(); err != nil {
+					r = Result[T]{Index: i, Err: err}
+				} else {
+					val, err := fn(ctx, items[i])
+					r = Result[T]{Index: i, Value: val, Err: err}
+				}
+				out <- r
+			}
+		}()
+	}
+
+	go func() {
+		for i := range items {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Budget is a weighted semaphore bounding the total size of work in flight,
+// e.g. the bytes of files being processed at once.
+type Budget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+// NewBudget returns a budget of limit units. A limit <= 0 is unlimited.
+func NewBudget(limit int64) *Budget {
+	b := &Budget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Acquire blocks until n units are free or ctx is done, and returns the
+// number of units taken, which must be passed to Release. Requests larger
+// than the whole budget are clamped to it, so they run alone rather than
+// never.
+func (b *Budget) Acquire(ctx context.Context, n int64) (int64, error) {
+	if b.limit <= 0 {
+		return 0, ctx.Err()
+	}
+	if n > b.limit {
+		n = b.limit
+	}
+
+	// Wake waiters on cancellation so they can give up
+	stop := context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	})
+	defer stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used+n > b.limit {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		b.cond.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.used += n
+	return n, nil
+}
+
+// Release returns n units taken by Acquire.
+func (b *Budget) Release(n int64) {
+	if n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"sort"
@@ -184,3 +186,108 @@ func TestProcessResultsAreSortable(t *testing.T) {
 		t.Error("sorting by value failed")
 	}
 }
+
+func TestStreamYieldsEveryItem(t *testing.T) {
+	p := NewPool[string](3)
+	items := []string{"a", "b", "c", "d", "e"}
+
+	seen := make(map[int]string)
+	for r := range p.Stream(context.Background(), items, func(_ context.Context, s string) (string, error) {
+		return s + "!", nil
+	}) {
+		if r.Err != nil {
+			t.Errorf("result[%d] unexpected error: %v", r.Index, r.Err)
+		}
+		seen[r.Index] = r.Value
+	}
+
+	if len(seen) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(seen))
+	}
+	for i, item := range items {
+		if seen[i] != item+"!" {
+			t.Errorf("result[%d] = %q, expected %q", i, seen[i], item+"!")
+		}
+	}
+}
+
+func TestStreamStopsOnCancel(t *testing.T) {
+	p := NewPool[int](1)
+	items := make([]string, 10)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started int64
+	var cancelled int
+	for r := range p.Stream(ctx, items, func(_ context.Context, s string) (int, error) {
+		if atomic.AddInt64(&started, 1) == 2 {
+			cancel()
+		}
+		return 1, nil
+	}) {
+		if errors.Is(r.Err, context.Canceled) {
+			cancelled++
+		}
+	}
+
+	if started != 2 {
+		t.Errorf("expected 2 items started before cancel, got %d", started)
+	}
+	if cancelled != len(items)-2 {
+		t.Errorf("expected %d cancelled results, got %d", len(items)-2, cancelled)
+	}
+}
+
+func TestBudgetBoundsInFlight(t *testing.T) {
+	b := NewBudget(10)
+	ctx := context.Background()
+
+	var inFlight, peak int64
+	p := NewPool[int](8)
+	items := make([]string, 16)
+	for range p.Stream(ctx, items, func(ctx context.Context, _ string) (int, error) {
+		n, err := b.Acquire(ctx, 4)
+		if err != nil {
+			return 0, err
+		}
+		defer b.Release(n)
+		c := atomic.AddInt64(&inFlight, n)
+		for {
+			old := atomic.LoadInt64(&peak)
+			if c <= old || atomic.CompareAndSwapInt64(&peak, old, c) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt64(&inFlight, -n)
+		return 0, nil
+	}) {
+	}
+
+	if peak > 10 {
+		t.Errorf("budget exceeded: peak %d > 10", peak)
+	}
+}
+
+func TestBudgetClampsOversizedAndCancels(t *testing.T) {
+	b := NewBudget(10)
+	n, err := b.Acquire(context.Background(), 100)
+	if err != nil || n != 10 {
+		t.Fatalf("Acquire(100) = %d, %v; want 10, nil", n, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire on a full budget = %v, want deadline exceeded", err)
+	}
+
+	b.Release(n)
+	if _, err := b.Acquire(context.Background(), 1); err != nil {
+		t.Errorf("Acquire after release: %v", err)
+	}
+}